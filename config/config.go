@@ -5,8 +5,11 @@ import (
 	"log"
 	"math"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/DrumPatiphon/go-rest-api-service/pkg/locale"
+	"github.com/DrumPatiphon/go-rest-api-service/pkg/money"
 	"github.com/joho/godotenv"
 )
 
@@ -56,6 +59,36 @@ func LoadConfig(path string) Iconfig {
 				return f
 			}(),
 			gcpbucket: envMap["APP_GCP_BUCKET"],
+			trashRetention: func() time.Duration {
+				if envMap["APP_TRASH_RETENTION"] == "" {
+					return 30 * 24 * time.Hour
+				}
+				t, err := strconv.Atoi(envMap["APP_TRASH_RETENTION"])
+				if err != nil {
+					log.Fatalf("load trash retention failed: %v", err)
+				}
+				return time.Duration(int64(t) * int64(math.Pow10(9))) //ยกกำลัง 9 เพื่อเปลงหน่วยจาก nano sec to sec
+			}(),
+			currency: func() string {
+				c := envMap["APP_CURRENCY"]
+				if c == "" {
+					return "THB"
+				}
+				if !money.IsCurrency(c) {
+					log.Fatalf("load currency failed: %s is not supported", c)
+				}
+				return c
+			}(),
+			locale: func() string {
+				l := envMap["APP_LOCALE"]
+				if l == "" {
+					return "th"
+				}
+				if !locale.IsLocale(l) {
+					log.Fatalf("load locale failed: %s is not supported", l)
+				}
+				return l
+			}(),
 		},
 		db: &db{
 			host: envMap["DB_HOST"],
@@ -98,6 +131,56 @@ func LoadConfig(path string) Iconfig {
 				return t
 			}(),
 		},
+		payment: &payment{
+			// fake provider approve payments without money, it must be allowed on purpose
+			provider: func() string {
+				p := envMap["PAYMENT_PROVIDER"]
+				if p == "" {
+					log.Fatalf("load payment provider failed: PAYMENT_PROVIDER is required")
+				}
+				if p == "fake" && envMap["PAYMENT_ALLOW_FAKE"] != "true" {
+					log.Fatalf("load payment provider failed: fake provider needs PAYMENT_ALLOW_FAKE=true")
+				}
+				return p
+			}(),
+			webhookSecret: func() string {
+				s := envMap["PAYMENT_WEBHOOK_SECRET"]
+				if s == "" {
+					log.Fatalf("load payment webhook secret failed: PAYMENT_WEBHOOK_SECRET is required")
+				}
+				return s
+			}(),
+		},
+		notify: &notify{
+			notifier: envMap["NOTIFIER"],
+		},
+		document: &document{
+			companyName:    envMap["COMPANY_NAME"],
+			companyAddress: envMap["COMPANY_ADDRESS"],
+			companyTaxId:   envMap["COMPANY_TAX_ID"],
+			companyPhone:   envMap["COMPANY_PHONE"],
+			companyEmail:   envMap["COMPANY_EMAIL"],
+			fontPath:       envMap["DOCUMENT_FONT"],
+		},
+		tax: &tax{
+			country: func() string {
+				c := strings.ToUpper(envMap["TAX_COUNTRY"])
+				if c == "" {
+					return "TH"
+				}
+				return c
+			}(),
+			pricesIncludeTax: func() bool {
+				if envMap["TAX_PRICES_INCLUDE_TAX"] == "" {
+					return true
+				}
+				b, err := strconv.ParseBool(envMap["TAX_PRICES_INCLUDE_TAX"])
+				if err != nil {
+					log.Fatalf("load prices include tax failed: %v", err)
+				}
+				return b
+			}(),
+		},
 	}
 }
 
@@ -105,12 +188,20 @@ type Iconfig interface {
 	App() IAppConfig
 	Db() IDbConfig
 	Jwt() IJwtConfig
+	Payment() IPaymentConfig
+	Tax() ITaxConfig
+	Notify() INotifyConfig
+	Document() IDocumentConfig
 }
 
 type config struct {
-	app *app
-	db  *db
-	jwt *jwt
+	app      *app
+	db       *db
+	jwt      *jwt
+	payment  *payment
+	tax      *tax
+	notify   *notify
+	document *document
 }
 
 type IAppConfig interface {
@@ -122,31 +213,40 @@ type IAppConfig interface {
 	BodyLimit() int
 	FileLimit() int
 	Gcpbucket() string
+	TrashRetention() time.Duration
+	Currency() string
+	Locale() string
 }
 type app struct {
-	host         string
-	port         int
-	name         string
-	version      string
-	readTimeOut  time.Duration
-	writeTimeOut time.Duration
-	bodyLimit    int //bytes
-	filelimit    int //bytes
-	gcpbucket    string
+	host           string
+	port           int
+	name           string
+	version        string
+	readTimeOut    time.Duration
+	writeTimeOut   time.Duration
+	bodyLimit      int //bytes
+	filelimit      int //bytes
+	gcpbucket      string
+	trashRetention time.Duration //sec, deleted products are purged after this, 30 days when empty
+	currency       string        //ISO 4217, every product must have a price in this currency, THB when empty
+	locale         string        //language of products and categories columns, others are in translations, th when empty
 }
 
 func (c *config) App() IAppConfig {
 	return c.app
 }
 
-func (a *app) Url() string                 { return fmt.Sprintf("%s:%d", a.host, a.port) } // host:port
-func (a *app) Name() string                { return a.name }
-func (a *app) Version() string             { return a.version }
-func (a *app) ReadTimeOut() time.Duration  { return a.readTimeOut }
-func (a *app) WriteTimeOut() time.Duration { return a.writeTimeOut }
-func (a *app) BodyLimit() int              { return a.bodyLimit }
-func (a *app) FileLimit() int              { return a.filelimit }
-func (a *app) Gcpbucket() string           { return a.gcpbucket }
+func (a *app) Url() string                   { return fmt.Sprintf("%s:%d", a.host, a.port) } // host:port
+func (a *app) Name() string                  { return a.name }
+func (a *app) Version() string               { return a.version }
+func (a *app) ReadTimeOut() time.Duration    { return a.readTimeOut }
+func (a *app) WriteTimeOut() time.Duration   { return a.writeTimeOut }
+func (a *app) BodyLimit() int                { return a.bodyLimit }
+func (a *app) FileLimit() int                { return a.filelimit }
+func (a *app) Gcpbucket() string             { return a.gcpbucket }
+func (a *app) TrashRetention() time.Duration { return a.trashRetention }
+func (a *app) Currency() string              { return a.currency }
+func (a *app) Locale() string                { return a.locale }
 
 type IDbConfig interface {
 	Url() string
@@ -202,3 +302,76 @@ func (j *jwt) AcessExpriresAt() int       { return j.accessExpriresAt }
 func (j *jwt) RefreshExpiresAt() int      { return j.refreshExpiresAt }
 func (j *jwt) SetJwtAcessExpires(t int)   { j.accessExpriresAt = t }
 func (j *jwt) SetJwtRefreshExpires(t int) { j.refreshExpiresAt = t }
+
+type IPaymentConfig interface {
+	Provider() string
+	WebhookSecret() string
+}
+type payment struct {
+	provider      string //fake only with PAYMENT_ALLOW_FAKE=true
+	webhookSecret string //sign and verify webhook of provider, required
+}
+
+func (c *config) Payment() IPaymentConfig {
+	return c.payment
+}
+
+func (p *payment) Provider() string      { return p.provider }
+func (p *payment) WebhookSecret() string { return p.webhookSecret }
+
+type ITaxConfig interface {
+	Country() string
+	PricesIncludeTax() bool
+}
+type tax struct {
+	country          string //ISO 3166-1 alpha-2, zone of cart without shipping address
+	pricesIncludeTax bool   //product prices include tax, like Thai VAT
+}
+
+func (c *config) Tax() ITaxConfig {
+	return c.tax
+}
+
+func (t *tax) Country() string        { return t.country }
+func (t *tax) PricesIncludeTax() bool { return t.pricesIncludeTax }
+
+type INotifyConfig interface {
+	Notifier() string
+}
+type notify struct {
+	notifier string //log when empty
+}
+
+func (c *config) Notify() INotifyConfig {
+	return c.notify
+}
+
+func (n *notify) Notifier() string { return n.notifier }
+
+type IDocumentConfig interface {
+	CompanyName() string
+	CompanyAddress() string
+	CompanyTaxId() string
+	CompanyPhone() string
+	CompanyEmail() string
+	FontPath() string
+}
+type document struct {
+	companyName    string
+	companyAddress string //lines are split by |
+	companyTaxId   string
+	companyPhone   string
+	companyEmail   string
+	fontPath       string //TrueType font with Thai glyphs, documents are not rendered without it
+}
+
+func (c *config) Document() IDocumentConfig {
+	return c.document
+}
+
+func (d *document) CompanyName() string    { return d.companyName }
+func (d *document) CompanyAddress() string { return d.companyAddress }
+func (d *document) CompanyTaxId() string   { return d.companyTaxId }
+func (d *document) CompanyPhone() string   { return d.companyPhone }
+func (d *document) CompanyEmail() string   { return d.companyEmail }
+func (d *document) FontPath() string       { return d.fontPath }
@@ -0,0 +1,116 @@
+package addresses
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Kind of default address
+const (
+	Shipping = "shipping"
+	Billing  = "billing"
+)
+
+type Address struct {
+	Id                string `db:"id" json:"id"`
+	UserId            string `db:"user_id" json:"user_id"`
+	Label             string `db:"label" json:"label"` // like home, office
+	Name              string `db:"name" json:"name"`   // recipient
+	Phone             string `db:"phone" json:"phone"`
+	Company           string `db:"company" json:"company"`
+	Line1             string `db:"line1" json:"line1"`
+	Line2             string `db:"line2" json:"line2"`
+	District          string `db:"district" json:"district"` // sub district, khwaeng or tambon in TH
+	City              string `db:"city" json:"city"`         // district, khet or amphoe in TH
+	Region            string `db:"region" json:"region"`     // state or province
+	Postcode          string `db:"postcode" json:"postcode"`
+	Country           string `db:"country" json:"country"` // ISO 3166-1 alpha-2
+	IsDefaultShipping bool   `db:"is_default_shipping" json:"is_default_shipping"`
+	IsDefaultBilling  bool   `db:"is_default_billing" json:"is_default_billing"`
+	CreatedAt         string `db:"created_at" json:"created_at,omitempty"`
+	UpdatedAt         string `db:"updated_at" json:"updated_at,omitempty"`
+}
+
+// countryRule is fields required in country on top of name, line1 and country, and format of postcode
+type countryRule struct {
+	required []string
+	postcode *regexp.Regexp
+}
+
+// defaultRule is used by country without rule
+var defaultRule = &countryRule{required: []string{"city"}}
+
+var countryRules = map[string]*countryRule{
+	"TH": {required: []string{"phone", "district", "city", "region", "postcode"}, postcode: regexp.MustCompile(`^[0-9]{5}$`)},
+	"US": {required: []string{"city", "region", "postcode"}, postcode: regexp.MustCompile(`^[0-9]{5}(-[0-9]{4})?$`)},
+	"GB": {required: []string{"city", "postcode"}, postcode: regexp.MustCompile(`^[A-Z]{1,2}[0-9][A-Z0-9]? ?[0-9][A-Z]{2}$`)},
+	"JP": {required: []string{"city", "region", "postcode"}, postcode: regexp.MustCompile(`^[0-9]{3}-?[0-9]{4}$`)},
+	"SG": {required: []string{"postcode"}, postcode: regexp.MustCompile(`^[0-9]{6}$`)},
+}
+
+var countryRegexp = regexp.MustCompile(`^[A-Z]{2}$`)
+
+// fields is value of every field which can be required
+func (obj *Address) fields() map[string]string {
+	return map[string]string{
+		"phone":    obj.Phone,
+		"district": obj.District,
+		"city":     obj.City,
+		"region":   obj.Region,
+		"postcode": obj.Postcode,
+	}
+}
+
+// Validate trim the address then check required fields of its country
+func (obj *Address) Validate() error {
+	for _, f := range []*string{&obj.Label, &obj.Name, &obj.Phone, &obj.Company, &obj.Line1, &obj.Line2, &obj.District, &obj.City, &obj.Region} {
+		*f = strings.TrimSpace(*f)
+	}
+	obj.Postcode = strings.ToUpper(strings.TrimSpace(obj.Postcode))
+	obj.Country = strings.ToUpper(strings.TrimSpace(obj.Country))
+
+	if obj.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if obj.Line1 == "" {
+		return fmt.Errorf("line1 is required")
+	}
+	if !countryRegexp.MatchString(obj.Country) {
+		return fmt.Errorf("country must be ISO 3166-1 alpha-2 code")
+	}
+
+	rule, ok := countryRules[obj.Country]
+	if !ok {
+		rule = defaultRule
+	}
+	fields := obj.fields()
+	for _, name := range rule.required {
+		if fields[name] == "" {
+			return fmt.Errorf("%s is required in %s", name, obj.Country)
+		}
+	}
+	if rule.postcode != nil && obj.Postcode != "" && !rule.postcode.MatchString(obj.Postcode) {
+		return fmt.Errorf("postcode is invalid in %s", obj.Country)
+	}
+	return nil
+}
+
+// Contact is name and phone of recipient
+func (obj *Address) Contact() string {
+	if obj.Phone == "" {
+		return obj.Name
+	}
+	return obj.Name + " " + obj.Phone
+}
+
+// Format return the address in one line, without the recipient
+func (obj *Address) Format() string {
+	lines := make([]string, 0)
+	for _, line := range []string{obj.Company, obj.Line1, obj.Line2, obj.District, obj.City, obj.Region, obj.Postcode, obj.Country} {
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, ", ")
+}
@@ -0,0 +1,151 @@
+package addressesHandlers
+
+import (
+	"strings"
+
+	"github.com/DrumPatiphon/go-rest-api-service/modules/addresses"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/addresses/addressesUsecases"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/entities"
+	"github.com/gofiber/fiber/v2"
+)
+
+type addressesHandlerErrCode string
+
+const (
+	findAddressesErr  addressesHandlerErrCode = "addresses-001"
+	findOneAddressErr addressesHandlerErrCode = "addresses-002"
+	insertAddressErr  addressesHandlerErrCode = "addresses-003"
+	updateAddressErr  addressesHandlerErrCode = "addresses-004"
+	deleteAddressErr  addressesHandlerErrCode = "addresses-005"
+)
+
+type IAddressesHandler interface {
+	FindAddresses(c *fiber.Ctx) error
+	FindOneAddress(c *fiber.Ctx) error
+	InsertAddress(c *fiber.Ctx) error
+	UpdateAddress(c *fiber.Ctx) error
+	DeleteAddress(c *fiber.Ctx) error
+}
+
+type addressesHandler struct {
+	addressesUsecase addressesUsecases.IAddressesUsecase
+}
+
+func AddressesHandler(addressesUsecase addressesUsecases.IAddressesUsecase) IAddressesHandler {
+	return &addressesHandler{
+		addressesUsecase: addressesUsecase,
+	}
+}
+
+// errStatus return status of known errors of addresses usecase
+func errStatus(err error) int {
+	if err.Error() == "address not found" {
+		return fiber.ErrNotFound.Code
+	}
+	return fiber.ErrInternalServerError.Code
+}
+
+// user_id param is checked against token by ParamsCheck
+func (h *addressesHandler) FindAddresses(c *fiber.Ctx) error {
+	userId := strings.TrimSpace(c.Params("user_id"))
+
+	addressesData, err := h.addressesUsecase.FindAddresses(userId)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(findAddressesErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, addressesData).Res()
+}
+
+func (h *addressesHandler) FindOneAddress(c *fiber.Ctx) error {
+	userId := strings.TrimSpace(c.Params("user_id"))
+	addressId := strings.TrimSpace(c.Params("address_id"))
+
+	address, err := h.addressesUsecase.FindOneAddress(userId, addressId)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			errStatus(err),
+			string(findOneAddressErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, address).Res()
+}
+
+func (h *addressesHandler) InsertAddress(c *fiber.Ctx) error {
+	req := new(addresses.Address)
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(insertAddressErr),
+			err.Error(),
+		).Res()
+	}
+	req.Id = ""
+	req.UserId = strings.TrimSpace(c.Params("user_id"))
+	if err := req.Validate(); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(insertAddressErr),
+			err.Error(),
+		).Res()
+	}
+
+	address, err := h.addressesUsecase.InsertAddress(req)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(insertAddressErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Success(fiber.StatusCreated, address).Res()
+}
+
+// UpdateAddress replace the whole address, default flags too
+func (h *addressesHandler) UpdateAddress(c *fiber.Ctx) error {
+	req := new(addresses.Address)
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(updateAddressErr),
+			err.Error(),
+		).Res()
+	}
+	req.Id = strings.TrimSpace(c.Params("address_id"))
+	req.UserId = strings.TrimSpace(c.Params("user_id"))
+	if err := req.Validate(); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(updateAddressErr),
+			err.Error(),
+		).Res()
+	}
+
+	address, err := h.addressesUsecase.UpdateAddress(req)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			errStatus(err),
+			string(updateAddressErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, address).Res()
+}
+
+func (h *addressesHandler) DeleteAddress(c *fiber.Ctx) error {
+	userId := strings.TrimSpace(c.Params("user_id"))
+	addressId := strings.TrimSpace(c.Params("address_id"))
+
+	if err := h.addressesUsecase.DeleteAddress(userId, addressId); err != nil {
+		return entities.NewResponse(c).Error(
+			errStatus(err),
+			string(deleteAddressErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Success(fiber.StatusNoContent, nil).Res()
+}
@@ -0,0 +1,278 @@
+package addressesRepositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/DrumPatiphon/go-rest-api-service/modules/addresses"
+	"github.com/jmoiron/sqlx"
+)
+
+type IAddressesRepository interface {
+	FindAddresses(userId string) ([]*addresses.Address, error)
+	FindOneAddress(userId, addressId string) (*addresses.Address, error)
+	FindDefaultAddress(userId, kind string) (*addresses.Address, error)
+	InsertAddress(req *addresses.Address) error
+	UpdateAddress(req *addresses.Address) error
+	DeleteAddress(userId, addressId string) error
+}
+
+type addressesRepository struct {
+	db *sqlx.DB
+}
+
+func AddressesRepository(db *sqlx.DB) IAddressesRepository {
+	return &addressesRepository{
+		db: db,
+	}
+}
+
+const addressColumns = `
+		"id",
+		"user_id",
+		"label",
+		"name",
+		"phone",
+		"company",
+		"line1",
+		"line2",
+		"district",
+		"city",
+		"region",
+		"postcode",
+		"country",
+		"is_default_shipping",
+		"is_default_billing",
+		"created_at"::TEXT AS "created_at",
+		"updated_at"::TEXT AS "updated_at"`
+
+// defaultColumns is flag column of every kind of default address
+var defaultColumns = map[string]string{
+	addresses.Shipping: "is_default_shipping",
+	addresses.Billing:  "is_default_billing",
+}
+
+// FindAddresses return address book of user, defaults first
+func (r *addressesRepository) FindAddresses(userId string) ([]*addresses.Address, error) {
+	query := fmt.Sprintf(`
+	SELECT %s
+	FROM "addresses"
+	WHERE "user_id" = $1
+	ORDER BY "is_default_shipping" DESC, "is_default_billing" DESC, "created_at";`, addressColumns)
+
+	addressesData := make([]*addresses.Address, 0)
+	if err := r.db.Select(&addressesData, query, userId); err != nil {
+		return nil, fmt.Errorf("get addresses failed: %v", err)
+	}
+	return addressesData, nil
+}
+
+// FindOneAddress only find address of the user, so address of others is not found
+func (r *addressesRepository) FindOneAddress(userId, addressId string) (*addresses.Address, error) {
+	query := fmt.Sprintf(`
+	SELECT %s
+	FROM "addresses"
+	WHERE "user_id" = $1
+	AND "id"::TEXT = $2;`, addressColumns)
+
+	address := new(addresses.Address)
+	if err := r.db.Get(address, query, userId, addressId); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("address not found")
+		}
+		return nil, fmt.Errorf("get address failed: %v", err)
+	}
+	return address, nil
+}
+
+// FindDefaultAddress return nil when user has no default address of the kind
+func (r *addressesRepository) FindDefaultAddress(userId, kind string) (*addresses.Address, error) {
+	column, ok := defaultColumns[kind]
+	if !ok {
+		return nil, fmt.Errorf("kind of address must be shipping or billing")
+	}
+	query := fmt.Sprintf(`
+	SELECT %s
+	FROM "addresses"
+	WHERE "user_id" = $1
+	AND "%s";`, addressColumns, column)
+
+	address := new(addresses.Address)
+	if err := r.db.Get(address, query, userId); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("get default address failed: %v", err)
+	}
+	return address, nil
+}
+
+// clearDefaults unset default flags of other addresses of user which req take over
+func clearDefaults(ctx context.Context, tx *sqlx.Tx, req *addresses.Address) error {
+	query := `
+	UPDATE "addresses" SET
+		"is_default_shipping" = "is_default_shipping" AND NOT $3,
+		"is_default_billing" = "is_default_billing" AND NOT $4
+	WHERE "user_id" = $1
+	AND "id"::TEXT <> $2
+	AND (("is_default_shipping" AND $3) OR ("is_default_billing" AND $4));`
+
+	if _, err := tx.ExecContext(ctx, query, req.UserId, req.Id, req.IsDefaultShipping, req.IsDefaultBilling); err != nil {
+		return fmt.Errorf("clear default addresses failed: %v", err)
+	}
+	return nil
+}
+
+// InsertAddress make the first address of user the default of both kinds
+func (r *addressesRepository) InsertAddress(req *addresses.Address) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*15)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	isFirst := false
+	if err := tx.QueryRowContext(ctx, `SELECT NOT EXISTS (SELECT 1 FROM "addresses" WHERE "user_id" = $1);`, req.UserId).Scan(&isFirst); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("count addresses failed: %v", err)
+	}
+	if isFirst {
+		req.IsDefaultShipping = true
+		req.IsDefaultBilling = true
+	}
+	if err := clearDefaults(ctx, tx, req); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	query := fmt.Sprintf(`
+	INSERT INTO "addresses" (
+		"user_id",
+		"label",
+		"name",
+		"phone",
+		"company",
+		"line1",
+		"line2",
+		"district",
+		"city",
+		"region",
+		"postcode",
+		"country",
+		"is_default_shipping",
+		"is_default_billing"
+	)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		RETURNING %s;`, addressColumns)
+
+	if err := tx.QueryRowxContext(
+		ctx,
+		query,
+		req.UserId,
+		req.Label,
+		req.Name,
+		req.Phone,
+		req.Company,
+		req.Line1,
+		req.Line2,
+		req.District,
+		req.City,
+		req.Region,
+		req.Postcode,
+		req.Country,
+		req.IsDefaultShipping,
+		req.IsDefaultBilling,
+	).StructScan(req); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("insert address failed: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return nil
+}
+
+// UpdateAddress replace the whole address of user
+func (r *addressesRepository) UpdateAddress(req *addresses.Address) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*15)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := clearDefaults(ctx, tx, req); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	query := fmt.Sprintf(`
+	UPDATE "addresses" SET
+		"label" = $3,
+		"name" = $4,
+		"phone" = $5,
+		"company" = $6,
+		"line1" = $7,
+		"line2" = $8,
+		"district" = $9,
+		"city" = $10,
+		"region" = $11,
+		"postcode" = $12,
+		"country" = $13,
+		"is_default_shipping" = $14,
+		"is_default_billing" = $15
+	WHERE "user_id" = $1
+	AND "id"::TEXT = $2
+		RETURNING %s;`, addressColumns)
+
+	if err := tx.QueryRowxContext(
+		ctx,
+		query,
+		req.UserId,
+		req.Id,
+		req.Label,
+		req.Name,
+		req.Phone,
+		req.Company,
+		req.Line1,
+		req.Line2,
+		req.District,
+		req.City,
+		req.Region,
+		req.Postcode,
+		req.Country,
+		req.IsDefaultShipping,
+		req.IsDefaultBilling,
+	).StructScan(req); err != nil {
+		tx.Rollback()
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("address not found")
+		}
+		return fmt.Errorf("update address failed: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return nil
+}
+
+// DeleteAddress keep orders of the address, they have the snapshot
+func (r *addressesRepository) DeleteAddress(userId, addressId string) error {
+	result, err := r.db.ExecContext(context.Background(), `DELETE FROM "addresses" WHERE "user_id" = $1 AND "id"::TEXT = $2;`, userId, addressId)
+	if err != nil {
+		return fmt.Errorf("delete address failed: %v", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("address not found")
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package addressesUsecases
+
+import (
+	"github.com/DrumPatiphon/go-rest-api-service/modules/addresses"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/addresses/addressesRepositories"
+)
+
+type IAddressesUsecase interface {
+	FindAddresses(userId string) ([]*addresses.Address, error)
+	FindOneAddress(userId, addressId string) (*addresses.Address, error)
+	InsertAddress(req *addresses.Address) (*addresses.Address, error)
+	UpdateAddress(req *addresses.Address) (*addresses.Address, error)
+	DeleteAddress(userId, addressId string) error
+	PickAddress(userId, addressId, kind string) (*addresses.Address, error)
+}
+
+type addressesUsecase struct {
+	addressesRepository addressesRepositories.IAddressesRepository
+}
+
+func AddressesUsecase(addressesRepository addressesRepositories.IAddressesRepository) IAddressesUsecase {
+	return &addressesUsecase{
+		addressesRepository: addressesRepository,
+	}
+}
+
+func (u *addressesUsecase) FindAddresses(userId string) ([]*addresses.Address, error) {
+	return u.addressesRepository.FindAddresses(userId)
+}
+
+func (u *addressesUsecase) FindOneAddress(userId, addressId string) (*addresses.Address, error) {
+	return u.addressesRepository.FindOneAddress(userId, addressId)
+}
+
+func (u *addressesUsecase) InsertAddress(req *addresses.Address) (*addresses.Address, error) {
+	if err := u.addressesRepository.InsertAddress(req); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+func (u *addressesUsecase) UpdateAddress(req *addresses.Address) (*addresses.Address, error) {
+	if err := u.addressesRepository.UpdateAddress(req); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+func (u *addressesUsecase) DeleteAddress(userId, addressId string) error {
+	return u.addressesRepository.DeleteAddress(userId, addressId)
+}
+
+// PickAddress return address of user by id, or default address of kind when id is empty.
+// It is nil when user has no default address
+func (u *addressesUsecase) PickAddress(userId, addressId, kind string) (*addresses.Address, error) {
+	if addressId != "" {
+		return u.addressesRepository.FindOneAddress(userId, addressId)
+	}
+	return u.addressesRepository.FindDefaultAddress(userId, kind)
+}
@@ -1,10 +1,86 @@
 package appInfo
 
+import "sort"
+
 type CategoryFilter struct {
-	Title string `query:"title"`
+	Title  string `query:"title"`
+	Locale string `query:"lang"` // empty is the default locale
 }
 
 type Category struct {
-	Id    int    `db:"id" json:"id"`
-	Title string `db:"title" json:"title"`
+	Id        int         `db:"id" json:"id"`
+	Title     string      `db:"title" json:"title"`
+	ParentId  int         `db:"parent_id" json:"parent_id,omitempty"` // 0 is root
+	SortOrder int         `db:"sort_order" json:"sort_order,omitempty"`
+	ImageUrl  string      `db:"image_url" json:"image_url,omitempty"`
+	Children  []*Category `db:"-" json:"children,omitempty"`
+}
+
+// UpdateCategoryReq update only fields which are sent
+type UpdateCategoryReq struct {
+	Id        int     `json:"-"`
+	Title     *string `json:"title"`
+	SortOrder *int    `json:"sort_order"`
+	ImageUrl  *string `json:"image_url"` // "" remove image
+}
+
+// DeleteCategoryReq products of the category are moved to ReassignTo, required when there are products
+type DeleteCategoryReq struct {
+	Id         int `json:"-"`
+	ReassignTo int `query:"reassign_to"`
+}
+
+// MoveCategoryReq set parent of category, parent_id 0 move it to root
+type MoveCategoryReq struct {
+	Id       int `json:"-"`
+	ParentId int `json:"parent_id"`
+}
+
+type CategoryTranslation struct {
+	CategoryId int    `db:"category_id" json:"category_id"`
+	Locale     string `db:"locale" json:"locale"`
+	Title      string `db:"title" json:"title"`
+}
+
+// Tree nest flat categories under their parent, category with unknown parent is a root
+func Tree(categories []*Category) []*Category {
+	byId := make(map[int]*Category)
+	for _, c := range categories {
+		byId[c.Id] = c
+		c.Children = nil
+	}
+
+	roots := make([]*Category, 0)
+	for _, c := range categories {
+		if parent, ok := byId[c.ParentId]; ok && c.ParentId != c.Id {
+			parent.Children = append(parent.Children, c)
+			continue
+		}
+		roots = append(roots, c)
+	}
+	sortTree(roots)
+	return roots
+}
+
+func sortTree(categories []*Category) {
+	sort.SliceStable(categories, func(i, j int) bool {
+		if categories[i].SortOrder != categories[j].SortOrder {
+			return categories[i].SortOrder < categories[j].SortOrder
+		}
+		return categories[i].Id < categories[j].Id
+	})
+	for _, c := range categories {
+		sortTree(c.Children)
+	}
+}
+
+// Breadcrumb return path from root to category id, categories is by id
+func Breadcrumb(categories map[int]*Category, id int) []*Category {
+	path := make([]*Category, 0)
+	visited := make(map[int]bool)
+	for c, ok := categories[id]; ok && !visited[c.Id]; c, ok = categories[c.ParentId] {
+		visited[c.Id] = true
+		path = append([]*Category{{Id: c.Id, Title: c.Title}}, path...)
+	}
+	return path
 }
@@ -1,6 +1,7 @@
 package appinfoHandlers
 
 import (
+	"fmt"
 	"strconv"
 	"strings"
 
@@ -8,6 +9,7 @@ import (
 	"github.com/DrumPatiphon/go-rest-api-service/modules/appInfo"
 	appinfoUsecases "github.com/DrumPatiphon/go-rest-api-service/modules/appInfo/appInfoUsecases"
 	"github.com/DrumPatiphon/go-rest-api-service/modules/entities"
+	"github.com/DrumPatiphon/go-rest-api-service/pkg/locale"
 	"github.com/DrumPatiphon/go-rest-api-service/pkg/serviceauth"
 	"github.com/gofiber/fiber/v2"
 )
@@ -15,17 +17,27 @@ import (
 type appInfoHandlerErrcode string
 
 const (
-	generrateApiKeyErr appInfoHandlerErrcode = "appinfo-001"
-	findCategoryErr    appInfoHandlerErrcode = "appinfo-002"
-	addCategoryErr     appInfoHandlerErrcode = "appinfo-003"
-	RemoveCategoryErr  appInfoHandlerErrcode = "appinfo-004"
+	generrateApiKeyErr           appInfoHandlerErrcode = "appinfo-001"
+	findCategoryErr              appInfoHandlerErrcode = "appinfo-002"
+	addCategoryErr               appInfoHandlerErrcode = "appinfo-003"
+	RemoveCategoryErr            appInfoHandlerErrcode = "appinfo-004"
+	upsertCategoryTranslationErr appInfoHandlerErrcode = "appinfo-005"
+	deleteCategoryTranslationErr appInfoHandlerErrcode = "appinfo-006"
+	findCategoryTreeErr          appInfoHandlerErrcode = "appinfo-007"
+	moveCategoryErr              appInfoHandlerErrcode = "appinfo-008"
+	updateCategoryErr            appInfoHandlerErrcode = "appinfo-009"
 )
 
 type IAppInfoHandler interface {
 	GenerateApiKey(c *fiber.Ctx) error
 	FindCategory(c *fiber.Ctx) error
+	FindCategoryTree(c *fiber.Ctx) error
+	MoveCategory(c *fiber.Ctx) error
 	AddCategory(c *fiber.Ctx) error
+	UpdateCategory(c *fiber.Ctx) error
 	RemoveCategory(c *fiber.Ctx) error
+	UpsertCategoryTranslation(c *fiber.Ctx) error
+	DeleteCategoryTranslation(c *fiber.Ctx) error
 }
 
 type appinfoHandler struct {
@@ -73,6 +85,12 @@ func (h *appinfoHandler) FindCategory(c *fiber.Ctx) error {
 		).Res()
 	}
 
+	// title in lang or Accept-Language, the default locale is in categories itself
+	req.Locale = locale.Parse(req.Locale, c.Get(fiber.HeaderAcceptLanguage), h.cfg.App().Locale())
+	if req.Locale == h.cfg.App().Locale() {
+		req.Locale = ""
+	}
+
 	category, err := h.appInfousecase.FindCategory(req)
 	if err != nil {
 		return entities.NewResponse(c).Error(
@@ -87,6 +105,33 @@ func (h *appinfoHandler) FindCategory(c *fiber.Ctx) error {
 	).Res()
 }
 
+// FindCategoryTree return categories nested as children of their parent
+func (h *appinfoHandler) FindCategoryTree(c *fiber.Ctx) error {
+	req := new(appInfo.CategoryFilter)
+	if err := c.QueryParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(findCategoryTreeErr),
+			err.Error(),
+		).Res()
+	}
+
+	req.Locale = locale.Parse(req.Locale, c.Get(fiber.HeaderAcceptLanguage), h.cfg.App().Locale())
+	if req.Locale == h.cfg.App().Locale() {
+		req.Locale = ""
+	}
+
+	tree, err := h.appInfousecase.FindCategoryTree(req)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(findCategoryTreeErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, tree).Res()
+}
+
 func (h *appinfoHandler) AddCategory(c *fiber.Ctx) error {
 	req := make([]*appInfo.Category, 0)
 	if err := c.BodyParser(&req); err != nil {
@@ -103,16 +148,112 @@ func (h *appinfoHandler) AddCategory(c *fiber.Ctx) error {
 			"categories request are emty",
 		).Res()
 	}
+	// duplicate title in the same request, the ones in database are checked by repository
+	titles := make(map[string]bool)
+	for _, category := range req {
+		category.Title = strings.TrimSpace(category.Title)
+		if category.Title == "" {
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(addCategoryErr),
+				"title is required",
+			).Res()
+		}
+		if titles[strings.ToLower(category.Title)] {
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(addCategoryErr),
+				fmt.Sprintf("title %s is duplicated", category.Title),
+			).Res()
+		}
+		titles[strings.ToLower(category.Title)] = true
+
+		if category.ParentId < 0 {
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(addCategoryErr),
+				"parent_id is invalid",
+			).Res()
+		}
+	}
 
 	if err := h.appInfousecase.InsertCagetory(req); err != nil {
+		switch {
+		case err.Error() == "parent category not found", strings.HasPrefix(err.Error(), "title has been used"):
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(addCategoryErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(addCategoryErr),
+				err.Error(),
+			).Res()
+		}
+	}
+
+	return entities.NewResponse(c).Success(fiber.StatusCreated, req).Res()
+}
+
+// UpdateCategory rename, reorder or change image of category
+func (h *appinfoHandler) UpdateCategory(c *fiber.Ctx) error {
+	req := new(appInfo.UpdateCategoryReq)
+	if err := c.BodyParser(req); err != nil {
 		return entities.NewResponse(c).Error(
-			fiber.ErrInternalServerError.Code,
-			string(addCategoryErr),
+			fiber.ErrBadRequest.Code,
+			string(updateCategoryErr),
 			err.Error(),
 		).Res()
 	}
 
-	return entities.NewResponse(c).Success(fiber.StatusCreated, req).Res()
+	categoryId, err := strconv.Atoi(strings.Trim(c.Params("category_id"), " "))
+	if err != nil || categoryId <= 0 {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(updateCategoryErr),
+			"id type is invalid",
+		).Res()
+	}
+	req.Id = categoryId
+
+	if req.Title != nil {
+		title := strings.TrimSpace(*req.Title)
+		if title == "" {
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(updateCategoryErr),
+				"title is required",
+			).Res()
+		}
+		req.Title = &title
+	}
+
+	category, err := h.appInfousecase.UpdateCategory(req)
+	if err != nil {
+		switch err.Error() {
+		case "category not found":
+			return entities.NewResponse(c).Error(
+				fiber.ErrNotFound.Code,
+				string(updateCategoryErr),
+				err.Error(),
+			).Res()
+		case "title has been used":
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(updateCategoryErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(updateCategoryErr),
+				err.Error(),
+			).Res()
+		}
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, category).Res()
 }
 
 func (h *appinfoHandler) RemoveCategory(c *fiber.Ctx) error {
@@ -134,20 +275,197 @@ func (h *appinfoHandler) RemoveCategory(c *fiber.Ctx) error {
 		).Res()
 	}
 
-	if err := h.appInfousecase.DeleteCategory(categoryIdInt); err != nil {
+	// products of the category must be moved to reassign_to
+	req := &appInfo.DeleteCategoryReq{Id: categoryIdInt}
+	if err := c.QueryParser(req); err != nil {
 		return entities.NewResponse(c).Error(
-			fiber.ErrInternalServerError.Code,
+			fiber.ErrBadRequest.Code,
 			string(RemoveCategoryErr),
 			err.Error(),
 		).Res()
 	}
+	if req.ReassignTo < 0 || req.ReassignTo == req.Id {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(RemoveCategoryErr),
+			"reassign_to is invalid",
+		).Res()
+	}
+
+	products, err := h.appInfousecase.DeleteCategory(req)
+	if err != nil {
+		switch {
+		case err.Error() == "category not found":
+			return entities.NewResponse(c).Error(
+				fiber.ErrNotFound.Code,
+				string(RemoveCategoryErr),
+				err.Error(),
+			).Res()
+		case err.Error() == "reassign category not found", strings.HasSuffix(err.Error(), "reassign_to is required"):
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(RemoveCategoryErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(RemoveCategoryErr),
+				err.Error(),
+			).Res()
+		}
+	}
 
 	return entities.NewResponse(c).Success(
 		fiber.StatusOK,
 		&struct {
 			CategoryId int `json:"category_id"`
+			ReassignTo int `json:"reassign_to,omitempty"`
+			Products   int `json:"reassigned_products"`
 		}{
 			CategoryId: categoryIdInt,
+			ReassignTo: req.ReassignTo,
+			Products:   products,
 		},
 	).Res()
 }
+
+func (h *appinfoHandler) UpsertCategoryTranslation(c *fiber.Ctx) error {
+	req := new(appInfo.CategoryTranslation)
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(upsertCategoryTranslationErr),
+			err.Error(),
+		).Res()
+	}
+
+	categoryId, err := strconv.Atoi(strings.Trim(c.Params("category_id"), " "))
+	if err != nil || categoryId <= 0 {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(upsertCategoryTranslationErr),
+			"id type is invalid",
+		).Res()
+	}
+	req.CategoryId = categoryId
+	req.Locale = strings.ToLower(strings.Trim(c.Params("locale"), " "))
+	req.Title = strings.TrimSpace(req.Title)
+
+	if !locale.IsLocale(req.Locale) || req.Locale == h.cfg.App().Locale() {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(upsertCategoryTranslationErr),
+			"locale is invalid",
+		).Res()
+	}
+	if req.Title == "" {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(upsertCategoryTranslationErr),
+			"title is required",
+		).Res()
+	}
+
+	if err := h.appInfousecase.UpsertCategoryTranslation(req); err != nil {
+		switch err.Error() {
+		case "category not found":
+			return entities.NewResponse(c).Error(
+				fiber.ErrNotFound.Code,
+				string(upsertCategoryTranslationErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(upsertCategoryTranslationErr),
+				err.Error(),
+			).Res()
+		}
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, req).Res()
+}
+
+func (h *appinfoHandler) DeleteCategoryTranslation(c *fiber.Ctx) error {
+	categoryId, err := strconv.Atoi(strings.Trim(c.Params("category_id"), " "))
+	if err != nil || categoryId <= 0 {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(deleteCategoryTranslationErr),
+			"id type is invalid",
+		).Res()
+	}
+	lang := strings.ToLower(strings.Trim(c.Params("locale"), " "))
+
+	if err := h.appInfousecase.DeleteCategoryTranslation(categoryId, lang); err != nil {
+		switch err.Error() {
+		case "translation not found":
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(deleteCategoryTranslationErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(deleteCategoryTranslationErr),
+				err.Error(),
+			).Res()
+		}
+	}
+	return entities.NewResponse(c).Success(fiber.StatusNoContent, nil).Res()
+}
+
+// MoveCategory set parent of category, cycle is rejected
+func (h *appinfoHandler) MoveCategory(c *fiber.Ctx) error {
+	req := new(appInfo.MoveCategoryReq)
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(moveCategoryErr),
+			err.Error(),
+		).Res()
+	}
+
+	categoryId, err := strconv.Atoi(strings.Trim(c.Params("category_id"), " "))
+	if err != nil || categoryId <= 0 {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(moveCategoryErr),
+			"id type is invalid",
+		).Res()
+	}
+	req.Id = categoryId
+
+	if req.ParentId < 0 {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(moveCategoryErr),
+			"parent_id is invalid",
+		).Res()
+	}
+
+	if err := h.appInfousecase.MoveCategory(req); err != nil {
+		switch err.Error() {
+		case "category not found":
+			return entities.NewResponse(c).Error(
+				fiber.ErrNotFound.Code,
+				string(moveCategoryErr),
+				err.Error(),
+			).Res()
+		case "parent category not found", "parent category is the category or its descendant":
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(moveCategoryErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(moveCategoryErr),
+				err.Error(),
+			).Res()
+		}
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, req).Res()
+}
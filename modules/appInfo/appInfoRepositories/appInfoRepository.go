@@ -2,7 +2,9 @@ package appinfoRepositories
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
+	"strings"
 
 	"github.com/DrumPatiphon/go-rest-api-service/modules/appInfo"
 	"github.com/jmoiron/sqlx"
@@ -11,7 +13,11 @@ import (
 type IAppInfoRepository interface {
 	FindCategory(req *appInfo.CategoryFilter) ([]*appInfo.Category, error)
 	InsertCategory(req []*appInfo.Category) error
-	DeleteCategory(categoryId int) error
+	UpdateCategory(req *appInfo.UpdateCategoryReq) (*appInfo.Category, error)
+	DeleteCategory(req *appInfo.DeleteCategoryReq) (int, error)
+	MoveCategory(req *appInfo.MoveCategoryReq) error
+	UpsertCategoryTranslation(req *appInfo.CategoryTranslation) error
+	DeleteCategoryTranslation(categoryId int, locale string) error
 }
 
 type appInfoRepository struct {
@@ -23,49 +29,81 @@ func AppInfoRepository(db *sqlx.DB) IAppInfoRepository {
 }
 
 func (r *appInfoRepository) FindCategory(req *appInfo.CategoryFilter) ([]*appInfo.Category, error) {
+	// title of locale, category without translation keep the default one
 	query := `
 	SELECT 
-		id,
-		title
-	FROM categories`
+		c.id,
+		COALESCE(ct.title, c.title) AS title,
+		COALESCE(c.parent_id, 0) AS parent_id,
+		c.sort_order,
+		c.image_url
+	FROM categories c
+		LEFT JOIN categories_translations ct ON ct.category_id = c.id AND ct.locale = $1`
 
-	filterValues := make([]any, 0)
+	filterValues := []any{req.Locale}
 	if req.Title != "" {
 		query += `
-		WHERE (LOWER(title) ILIKE $1)`
+		WHERE (LOWER(c.title) ILIKE $2 OR LOWER(ct.title) ILIKE $2)`
 
 		filterValues = append(filterValues, "%"+req.Title+"%")
 	}
 
+	query += `
+	ORDER BY c.sort_order, c.id`
+
 	category := make([]*appInfo.Category, 0)
 	if err := r.db.Select(&category, query, filterValues...); err != nil {
-		return nil, fmt.Errorf("select categories failed : &v", err)
+		return nil, fmt.Errorf("select categories failed : %v", err)
 	}
 	return category, nil
 }
 
+// InsertCategory insert every category or nothing, title used by other category is rejected
 func (r *appInfoRepository) InsertCategory(req []*appInfo.Category) error {
 	ctx := context.Background()
 
-	query := `
-	INSERT INTO categories (
-		title
-	)
-	VALUES`
-
 	tx, err := r.db.BeginTxx(ctx, nil)
 	if err != nil {
 		return err
 	}
 
+	titles := make([]string, 0)
+	for _, category := range req {
+		titles = append(titles, strings.ToLower(category.Title))
+	}
+
+	duplicates := make([]string, 0)
+	if err := tx.SelectContext(
+		ctx,
+		&duplicates,
+		`SELECT title FROM categories WHERE LOWER(title) = ANY($1::VARCHAR[]) ORDER BY title;`,
+		titles,
+	); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("select duplicate categories failed: %v", err)
+	}
+	if len(duplicates) > 0 {
+		tx.Rollback()
+		return fmt.Errorf("title has been used: %s", strings.Join(duplicates, ", "))
+	}
+
+	query := `
+	INSERT INTO categories (
+		title,
+		parent_id,
+		sort_order,
+		image_url
+	)
+	VALUES`
+
 	valuesStack := make([]any, 0)
 	for i, category := range req {
-		valuesStack = append(valuesStack, category.Title)
+		valuesStack = append(valuesStack, category.Title, category.ParentId, category.SortOrder, category.ImageUrl)
 
 		if i != len(req)-1 {
-			query += fmt.Sprintf(`($%d),`, i+1)
+			query += fmt.Sprintf(`($%d, NULLIF($%d, 0), $%d, $%d),`, i*4+1, i*4+2, i*4+3, i*4+4)
 		} else {
-			query += fmt.Sprintf(`($%d)`, i+1)
+			query += fmt.Sprintf(`($%d, NULLIF($%d, 0), $%d, $%d)`, i*4+1, i*4+2, i*4+3, i*4+4)
 		}
 	}
 
@@ -74,16 +112,24 @@ func (r *appInfoRepository) InsertCategory(req []*appInfo.Category) error {
 	rows, err := tx.QueryxContext(ctx, query, valuesStack...)
 	if err != nil {
 		tx.Rollback()
+		switch {
+		case strings.Contains(err.Error(), "categories_parent_id_fkey"):
+			return fmt.Errorf("parent category not found")
+		case strings.Contains(err.Error(), "categories_title_key"):
+			return fmt.Errorf("title has been used")
+		}
 		return fmt.Errorf("insert categories failed: %v", err)
 	}
 
 	var i int
 	for rows.Next() { //วนลูปจนกว่า array จะไม่มีให้วนแล้ว
 		if err := rows.Scan(&req[i].Id); err != nil {
+			tx.Rollback()
 			return fmt.Errorf("scan cagetories id failed: %v", err)
 		}
 		i++
 	}
+	rows.Close()
 
 	if err := tx.Commit(); err != nil {
 		tx.Rollback()
@@ -92,14 +138,194 @@ func (r *appInfoRepository) InsertCategory(req []*appInfo.Category) error {
 	return nil
 }
 
-func (r *appInfoRepository) DeleteCategory(categoryId int) error {
+func (r *appInfoRepository) UpdateCategory(req *appInfo.UpdateCategoryReq) (*appInfo.Category, error) {
+	ctx := context.Background()
+
+	// NULL keep the old value
+	query := `
+	UPDATE categories SET
+		title = COALESCE($1, title),
+		sort_order = COALESCE($2, sort_order),
+		image_url = COALESCE($3, image_url)
+	WHERE id = $4
+	RETURNING
+		id,
+		title,
+		COALESCE(parent_id, 0) AS parent_id,
+		sort_order,
+		image_url;`
+
+	category := new(appInfo.Category)
+	if err := r.db.GetContext(ctx, category, query, req.Title, req.SortOrder, req.ImageUrl, req.Id); err != nil {
+		switch {
+		case err == sql.ErrNoRows:
+			return nil, fmt.Errorf("category not found")
+		case strings.Contains(err.Error(), "categories_title_key"):
+			return nil, fmt.Errorf("title has been used")
+		}
+		return nil, fmt.Errorf("update category failed: %v", err)
+	}
+	return category, nil
+}
+
+// DeleteCategory move products to req.ReassignTo and children to the parent of category, then delete it.
+// Return number of products reassigned
+func (r *appInfoRepository) DeleteCategory(req *appInfo.DeleteCategoryReq) (int, error) {
+	ctx := context.Background()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	var parentId sql.NullInt64
+	if err := tx.GetContext(ctx, &parentId, `SELECT parent_id FROM categories WHERE id = $1 FOR UPDATE;`, req.Id); err != nil {
+		tx.Rollback()
+		if err == sql.ErrNoRows {
+			return 0, fmt.Errorf("category not found")
+		}
+		return 0, fmt.Errorf("select category failed: %v", err)
+	}
+
+	var products int
+	if err := tx.GetContext(ctx, &products, `SELECT COUNT(*) FROM products_categories WHERE category_id = $1;`, req.Id); err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("count category products failed: %v", err)
+	}
+
+	if products > 0 {
+		if req.ReassignTo == 0 {
+			tx.Rollback()
+			return 0, fmt.Errorf("category has %d products, reassign_to is required", products)
+		}
+
+		reassignQuery := `
+		INSERT INTO products_categories (
+			product_id,
+			category_id
+		)
+		SELECT product_id, $2
+		FROM products_categories
+		WHERE category_id = $1
+		ON CONFLICT (product_id, category_id) DO NOTHING;`
+
+		if _, err := tx.ExecContext(ctx, reassignQuery, req.Id, req.ReassignTo); err != nil {
+			tx.Rollback()
+			if strings.Contains(err.Error(), "products_categories_category_id_fkey") {
+				return 0, fmt.Errorf("reassign category not found")
+			}
+			return 0, fmt.Errorf("reassign category products failed: %v", err)
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE categories SET parent_id = $1 WHERE parent_id = $2;`, parentId, req.Id); err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("move category children failed: %v", err)
+	}
+
+	// products_categories and translations are removed by ON DELETE CASCADE
+	if _, err := tx.ExecContext(ctx, `DELETE FROM categories WHERE id = $1;`, req.Id); err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("delete category failed: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+	return products, nil
+}
+
+func (r *appInfoRepository) UpsertCategoryTranslation(req *appInfo.CategoryTranslation) error {
+	ctx := context.Background()
+
+	query := `
+	INSERT INTO categories_translations (
+		category_id,
+		locale,
+		title
+	)
+	VALUES ($1, $2, $3)
+	ON CONFLICT (category_id, locale) DO UPDATE SET
+		title = EXCLUDED.title;`
+
+	if _, err := r.db.ExecContext(ctx, query, req.CategoryId, req.Locale, req.Title); err != nil {
+		if strings.Contains(err.Error(), "categories_translations_category_id_fkey") {
+			return fmt.Errorf("category not found")
+		}
+		return fmt.Errorf("upsert category translation failed: %v", err)
+	}
+	return nil
+}
+
+func (r *appInfoRepository) DeleteCategoryTranslation(categoryId int, locale string) error {
+	ctx := context.Background()
+
+	query := `DELETE FROM categories_translations WHERE category_id = $1 AND locale = $2`
+
+	result, err := r.db.ExecContext(ctx, query, categoryId, locale)
+	if err != nil {
+		return fmt.Errorf("delete category translation failed: %v", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("translation not found")
+	}
+	return nil
+}
 
+// MoveCategory set parent of category, parent can't be the category itself or its descendant
+func (r *appInfoRepository) MoveCategory(req *appInfo.MoveCategoryReq) error {
 	ctx := context.Background()
 
-	query := `DELETE FROM categories WHERE id = $1`
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	// other moves wait, so two moves can't make a cycle together
+	if _, err := tx.ExecContext(ctx, `LOCK TABLE categories IN SHARE ROW EXCLUSIVE MODE;`); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("lock categories failed: %v", err)
+	}
+
+	if req.ParentId != 0 {
+		cycleQuery := `
+		WITH RECURSIVE ancestors AS (
+			SELECT id, parent_id FROM categories WHERE id = $1
+			UNION
+			SELECT c.id, c.parent_id FROM categories c JOIN ancestors a ON c.id = a.parent_id
+		)
+		SELECT EXISTS (SELECT 1 FROM ancestors WHERE id = $2);`
+
+		var isCycle bool
+		if err := tx.GetContext(ctx, &isCycle, cycleQuery, req.ParentId, req.Id); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("check category cycle failed: %v", err)
+		}
+		if isCycle {
+			tx.Rollback()
+			return fmt.Errorf("parent category is the category or its descendant")
+		}
+	}
+
+	query := `UPDATE categories SET parent_id = NULLIF($1, 0) WHERE id = $2`
 
-	if _, err := r.db.ExecContext(ctx, query, categoryId); err != nil {
-		return fmt.Errorf("delete category failed: %v", err)
+	result, err := tx.ExecContext(ctx, query, req.ParentId, req.Id)
+	if err != nil {
+		tx.Rollback()
+		if strings.Contains(err.Error(), "categories_parent_id_fkey") {
+			return fmt.Errorf("parent category not found")
+		}
+		return fmt.Errorf("move category failed: %v", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		tx.Rollback()
+		return fmt.Errorf("category not found")
+	}
+
+	if err := tx.Commit(); err != nil {
+		tx.Rollback()
+		return err
 	}
 	return nil
 }
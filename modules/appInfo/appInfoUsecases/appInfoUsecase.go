@@ -7,8 +7,13 @@ import (
 
 type IAppInfoUsecase interface {
 	FindCategory(req *appInfo.CategoryFilter) ([]*appInfo.Category, error)
+	FindCategoryTree(req *appInfo.CategoryFilter) ([]*appInfo.Category, error)
 	InsertCagetory(req []*appInfo.Category) error
-	DeleteCategory(categoryId int) error
+	UpdateCategory(req *appInfo.UpdateCategoryReq) (*appInfo.Category, error)
+	DeleteCategory(req *appInfo.DeleteCategoryReq) (int, error)
+	MoveCategory(req *appInfo.MoveCategoryReq) error
+	UpsertCategoryTranslation(req *appInfo.CategoryTranslation) error
+	DeleteCategoryTranslation(categoryId int, locale string) error
 }
 
 type appinfoUsecase struct {
@@ -29,6 +34,15 @@ func (u *appinfoUsecase) FindCategory(req *appInfo.CategoryFilter) ([]*appInfo.C
 	return category, nil
 }
 
+// FindCategoryTree return every category nested under its parent, title filter is ignored
+func (u *appinfoUsecase) FindCategoryTree(req *appInfo.CategoryFilter) ([]*appInfo.Category, error) {
+	category, err := u.appInfoRepository.FindCategory(&appInfo.CategoryFilter{Locale: req.Locale})
+	if err != nil {
+		return nil, err
+	}
+	return appInfo.Tree(category), nil
+}
+
 func (u *appinfoUsecase) InsertCagetory(req []*appInfo.Category) error {
 	if err := u.appInfoRepository.InsertCategory(req); err != nil {
 		return err
@@ -36,8 +50,38 @@ func (u *appinfoUsecase) InsertCagetory(req []*appInfo.Category) error {
 	return nil
 }
 
-func (u *appinfoUsecase) DeleteCategory(categoryId int) error {
-	if err := u.appInfoRepository.DeleteCategory(categoryId); err != nil {
+func (u *appinfoUsecase) UpdateCategory(req *appInfo.UpdateCategoryReq) (*appInfo.Category, error) {
+	category, err := u.appInfoRepository.UpdateCategory(req)
+	if err != nil {
+		return nil, err
+	}
+	return category, nil
+}
+
+func (u *appinfoUsecase) DeleteCategory(req *appInfo.DeleteCategoryReq) (int, error) {
+	products, err := u.appInfoRepository.DeleteCategory(req)
+	if err != nil {
+		return 0, err
+	}
+	return products, nil
+}
+
+func (u *appinfoUsecase) UpsertCategoryTranslation(req *appInfo.CategoryTranslation) error {
+	if err := u.appInfoRepository.UpsertCategoryTranslation(req); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (u *appinfoUsecase) DeleteCategoryTranslation(categoryId int, locale string) error {
+	if err := u.appInfoRepository.DeleteCategoryTranslation(categoryId, locale); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (u *appinfoUsecase) MoveCategory(req *appInfo.MoveCategoryReq) error {
+	if err := u.appInfoRepository.MoveCategory(req); err != nil {
 		return err
 	}
 	return nil
@@ -0,0 +1,190 @@
+package carts
+
+import (
+	"strings"
+
+	"github.com/DrumPatiphon/go-rest-api-service/modules/taxes"
+	"github.com/DrumPatiphon/go-rest-api-service/pkg/money"
+	"github.com/DrumPatiphon/go-rest-api-service/pkg/shipping"
+)
+
+// CartTokenHeader is the header of guest cart token
+const CartTokenHeader = "X-Cart-Token"
+
+type Cart struct {
+	Id           string         `json:"id"`
+	UserId       string         `json:"user_id,omitempty"`
+	Token        string         `json:"token,omitempty"` // guest cart only
+	Currency     string         `json:"currency"`
+	Items        []*CartItem    `json:"items"`
+	ItemCount    int            `json:"item_count"`
+	Subtotal     *money.Money   `json:"subtotal"` // of items which can be bought
+	Discount     *money.Money   `json:"discount"`
+	Tax          *money.Money   `json:"tax"`
+	TaxInclusive bool           `json:"tax_inclusive"` // tax is already in prices
+	TaxAddress   *taxes.Address `json:"tax_address"`
+	Total        *money.Money   `json:"total"` // subtotal - discount, + tax when it is not inclusive
+	CouponId     string         `json:"coupon_id,omitempty"`
+	Coupon       *CartCoupon    `json:"coupon,omitempty"`
+	HasStale     bool           `json:"has_stale"`
+	CreatedAt    string         `json:"created_at"`
+	UpdatedAt    string         `json:"updated_at"`
+}
+
+// CartItem price is computed from current product data, AddedPrice is the unit price when it was added
+type CartItem struct {
+	Id          string       `json:"id"`
+	ProductId   string       `json:"product_id"`
+	Title       string       `json:"title"`
+	Slug        string       `json:"slug"`
+	ImageUrl    string       `json:"image_url"`
+	Quantity    int          `json:"quantity"`
+	AddedPrice  *money.Money `json:"added_price"`
+	UnitPrice   *money.Money `json:"unit_price"` // nil when product has no price in cart currency
+	LineTotal   *money.Money `json:"line_total"`
+	Discount    *money.Money `json:"discount"` // share of coupon discount
+	TaxClassId  string       `json:"tax_class_id"`
+	TaxRate     string       `json:"tax_rate"` // percent
+	Tax         *money.Money `json:"tax"`
+	Weight      int          `json:"weight"` // grams of one
+	Length      int          `json:"length"` // millimetres
+	Width       int          `json:"width"`
+	Height      int          `json:"height"`
+	Available   bool         `json:"available"` // published and not deleted
+	Stale       bool         `json:"stale"`
+	StaleReason string       `json:"stale_reason,omitempty"` // unavailable, no_price, price_changed
+	CreatedAt   string       `json:"created_at"`
+	UpdatedAt   string       `json:"updated_at"`
+}
+
+// CartCoupon is the applied coupon, it is checked again on every read and not applied when Reason is set
+type CartCoupon struct {
+	Code    string       `json:"code"`
+	Type    string       `json:"type"`
+	Percent int          `json:"percent,omitempty"`
+	Amount  *money.Money `json:"amount,omitempty"`
+	Applied bool         `json:"applied"`
+	Reason  string       `json:"reason,omitempty"`
+	Message string       `json:"message,omitempty"`
+}
+
+// CartOwner is user of the cart or token of guest cart
+type CartOwner struct {
+	UserId string
+	Token  string
+}
+
+// NewOwner return the signed in user, or the guest token when nobody signed in
+func NewOwner(userId, token string) *CartOwner {
+	if userId != "" {
+		return &CartOwner{UserId: userId}
+	}
+	return &CartOwner{Token: strings.TrimSpace(token)}
+}
+
+type CartItemReq struct {
+	ProductId string `json:"product_id" form:"product_id"`
+	Quantity  int    `json:"quantity" form:"quantity"`
+}
+
+// MaxQuantity of an item in cart
+const MaxQuantity = 999
+
+func (obj *CartItemReq) IsQuantity() bool {
+	return obj.Quantity >= 1 && obj.Quantity <= MaxQuantity
+}
+
+// Compute flag stale items and sum line totals, unavailable item and item without price are not counted
+func (obj *Cart) Compute() {
+	obj.ItemCount = 0
+	obj.HasStale = false
+	obj.Subtotal = money.New(0, obj.Currency)
+	obj.Discount = money.New(0, obj.Currency)
+	obj.Tax = money.New(0, obj.Currency)
+
+	for _, item := range obj.Items {
+		item.Stale = true
+		switch {
+		case !item.Available:
+			item.StaleReason = "unavailable"
+		case item.UnitPrice == nil:
+			item.StaleReason = "no_price"
+		case item.AddedPrice == nil || item.AddedPrice.Amount != item.UnitPrice.Amount:
+			item.StaleReason = "price_changed"
+		default:
+			item.Stale = false
+			item.StaleReason = ""
+		}
+		if item.Stale {
+			obj.HasStale = true
+		}
+
+		item.Discount = nil
+		item.Tax = nil
+		if !item.Available || item.UnitPrice == nil {
+			item.LineTotal = nil
+			continue
+		}
+		item.LineTotal = money.New(item.UnitPrice.Amount*int64(item.Quantity), obj.Currency)
+		obj.Subtotal.Amount += item.LineTotal.Amount
+		obj.ItemCount += item.Quantity
+	}
+	obj.Total = money.New(obj.Subtotal.Amount, obj.Currency)
+}
+
+// SetDiscount set discount of every item from allocations of line id and total of cart
+func (obj *Cart) SetDiscount(discount int64, allocations map[string]int64) {
+	obj.Discount = money.New(discount, obj.Currency)
+	obj.Total = money.New(obj.Subtotal.Amount-discount, obj.Currency)
+	for _, item := range obj.Items {
+		item.Discount = money.New(allocations[item.Id], obj.Currency)
+	}
+}
+
+// TaxLines return line total after discount of items which can be bought
+func (obj *Cart) TaxLines() []*taxes.Line {
+	lines := make([]*taxes.Line, 0)
+	for _, item := range obj.Items {
+		if item.LineTotal == nil {
+			continue
+		}
+		amount := item.LineTotal.Amount
+		if item.Discount != nil {
+			amount -= item.Discount.Amount
+		}
+		lines = append(lines, &taxes.Line{
+			Id:      item.Id,
+			ClassId: item.TaxClassId,
+			Amount:  amount,
+		})
+	}
+	return lines
+}
+
+// SetTax set tax of every item, exclusive tax is added to total
+func (obj *Cart) SetTax(result *taxes.Result) {
+	obj.Tax = money.New(result.Total, obj.Currency)
+	obj.TaxInclusive = result.Inclusive
+	if !result.Inclusive {
+		obj.Total = money.New(obj.Total.Amount+result.Total, obj.Currency)
+	}
+	for _, item := range obj.Items {
+		if lineTax, ok := result.Lines[item.Id]; ok {
+			item.TaxRate = lineTax.Rate
+			item.Tax = money.New(lineTax.Amount, obj.Currency)
+		}
+	}
+}
+
+// Parcel of items which can be bought
+func (obj *Cart) Parcel() *shipping.Parcel {
+	parcel := new(shipping.Parcel)
+	for _, item := range obj.Items {
+		if item.LineTotal == nil {
+			continue
+		}
+		parcel.Weight += item.Weight * item.Quantity
+		parcel.Volume += int64(item.Length) * int64(item.Width) * int64(item.Height) * int64(item.Quantity)
+	}
+	return parcel
+}
@@ -0,0 +1,262 @@
+package cartsHandlers
+
+import (
+	"strings"
+
+	"github.com/DrumPatiphon/go-rest-api-service/config"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/carts"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/carts/cartsUsecases"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/coupons"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/entities"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/taxes"
+	"github.com/gofiber/fiber/v2"
+)
+
+type cartsHandlerErrCode string
+
+const (
+	findCartErr     cartsHandlerErrCode = "carts-001"
+	addItemErr      cartsHandlerErrCode = "carts-002"
+	updateItemErr   cartsHandlerErrCode = "carts-003"
+	deleteItemErr   cartsHandlerErrCode = "carts-004"
+	applyCouponErr  cartsHandlerErrCode = "carts-005"
+	removeCouponErr cartsHandlerErrCode = "carts-006"
+)
+
+type ICartsHandler interface {
+	FindCart(c *fiber.Ctx) error
+	AddItem(c *fiber.Ctx) error
+	UpdateItem(c *fiber.Ctx) error
+	DeleteItem(c *fiber.Ctx) error
+	ApplyCoupon(c *fiber.Ctx) error
+	RemoveCoupon(c *fiber.Ctx) error
+}
+
+type cartsHandler struct {
+	cfg          config.Iconfig
+	cartsUsecase cartsUsecases.ICartsUsecase
+}
+
+func CartsHandler(cfg config.Iconfig, cartsUsecase cartsUsecases.ICartsUsecase) ICartsHandler {
+	return &cartsHandler{
+		cfg:          cfg,
+		cartsUsecase: cartsUsecase,
+	}
+}
+
+// cartOwner is the signed in user, or guest cart token from header
+func cartOwner(c *fiber.Ctx) *carts.CartOwner {
+	userId, _ := c.Locals("userId").(string)
+	return carts.NewOwner(userId, c.Get(carts.CartTokenHeader))
+}
+
+// setToken send token back so guest can keep using the cart
+func setToken(c *fiber.Ctx, owner *carts.CartOwner) {
+	if owner.UserId == "" && owner.Token != "" {
+		c.Set(carts.CartTokenHeader, owner.Token)
+	}
+}
+
+// FindCart estimate tax with ?country=&region= of the shipping address
+func (h *cartsHandler) FindCart(c *fiber.Ctx) error {
+	address := new(taxes.Address)
+	if err := c.QueryParser(address); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(findCartErr),
+			err.Error(),
+		).Res()
+	}
+	if err := address.Normalize(); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(findCartErr),
+			err.Error(),
+		).Res()
+	}
+
+	owner := cartOwner(c)
+	cart, err := h.cartsUsecase.FindCart(owner, address)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(findCartErr),
+			err.Error(),
+		).Res()
+	}
+	setToken(c, owner)
+	return entities.NewResponse(c).Success(fiber.StatusOK, cart).Res()
+}
+
+func (h *cartsHandler) AddItem(c *fiber.Ctx) error {
+	req := new(carts.CartItemReq)
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(addItemErr),
+			err.Error(),
+		).Res()
+	}
+	req.ProductId = strings.TrimSpace(req.ProductId)
+	if req.ProductId == "" {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(addItemErr),
+			"product id is required",
+		).Res()
+	}
+	if !req.IsQuantity() {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(addItemErr),
+			"quantity is invalid",
+		).Res()
+	}
+
+	owner := cartOwner(c)
+	cart, err := h.cartsUsecase.AddItem(owner, req)
+	if err != nil {
+		switch {
+		case err.Error() == "product not found":
+			return entities.NewResponse(c).Error(
+				fiber.ErrNotFound.Code,
+				string(addItemErr),
+				err.Error(),
+			).Res()
+		case strings.HasPrefix(err.Error(), "product has no price"):
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(addItemErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(addItemErr),
+				err.Error(),
+			).Res()
+		}
+	}
+	setToken(c, owner)
+	return entities.NewResponse(c).Success(fiber.StatusCreated, cart).Res()
+}
+
+func (h *cartsHandler) UpdateItem(c *fiber.Ctx) error {
+	itemId := strings.TrimSpace(c.Params("item_id"))
+	req := new(carts.CartItemReq)
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(updateItemErr),
+			err.Error(),
+		).Res()
+	}
+	if !req.IsQuantity() {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(updateItemErr),
+			"quantity is invalid",
+		).Res()
+	}
+
+	owner := cartOwner(c)
+	cart, err := h.cartsUsecase.UpdateItem(owner, itemId, req.Quantity)
+	if err != nil {
+		switch err.Error() {
+		case "item not found":
+			return entities.NewResponse(c).Error(
+				fiber.ErrNotFound.Code,
+				string(updateItemErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(updateItemErr),
+				err.Error(),
+			).Res()
+		}
+	}
+	setToken(c, owner)
+	return entities.NewResponse(c).Success(fiber.StatusOK, cart).Res()
+}
+
+func (h *cartsHandler) DeleteItem(c *fiber.Ctx) error {
+	itemId := strings.TrimSpace(c.Params("item_id"))
+
+	owner := cartOwner(c)
+	cart, err := h.cartsUsecase.DeleteItem(owner, itemId)
+	if err != nil {
+		switch err.Error() {
+		case "item not found":
+			return entities.NewResponse(c).Error(
+				fiber.ErrNotFound.Code,
+				string(deleteItemErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(deleteItemErr),
+				err.Error(),
+			).Res()
+		}
+	}
+	setToken(c, owner)
+	return entities.NewResponse(c).Success(fiber.StatusOK, cart).Res()
+}
+
+// ApplyCoupon reply the reason and message when the coupon is rejected
+func (h *cartsHandler) ApplyCoupon(c *fiber.Ctx) error {
+	req := new(coupons.ApplyCouponReq)
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(applyCouponErr),
+			err.Error(),
+		).Res()
+	}
+	req.Code = strings.TrimSpace(req.Code)
+	if req.Code == "" {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(applyCouponErr),
+			"code is required",
+		).Res()
+	}
+
+	owner := cartOwner(c)
+	cart, err := h.cartsUsecase.ApplyCoupon(owner, req.Code)
+	if err != nil {
+		switch {
+		case strings.HasPrefix(err.Error(), "coupon rejected"):
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(applyCouponErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(applyCouponErr),
+				err.Error(),
+			).Res()
+		}
+	}
+	setToken(c, owner)
+	return entities.NewResponse(c).Success(fiber.StatusOK, cart).Res()
+}
+
+func (h *cartsHandler) RemoveCoupon(c *fiber.Ctx) error {
+	owner := cartOwner(c)
+	cart, err := h.cartsUsecase.RemoveCoupon(owner)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(removeCouponErr),
+			err.Error(),
+		).Res()
+	}
+	setToken(c, owner)
+	return entities.NewResponse(c).Success(fiber.StatusOK, cart).Res()
+}
@@ -0,0 +1,319 @@
+package cartsRepositories
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/DrumPatiphon/go-rest-api-service/modules/carts"
+	"github.com/jmoiron/sqlx"
+)
+
+type ICartsRepository interface {
+	FindCart(owner *carts.CartOwner) (*carts.Cart, error)
+	InsertCart(owner *carts.CartOwner, currency string) (string, error)
+	UpsertItem(cartId string, req *carts.CartItemReq, userId string) error
+	UpdateItem(cartId, itemId string, quantity int, userId string) error
+	DeleteItem(cartId, itemId string) error
+	MergeCart(token, userId, currency string) error
+	UpdateCoupon(cartId, couponId string) error
+}
+
+type cartsRepository struct {
+	db *sqlx.DB
+}
+
+func CartsRepository(db *sqlx.DB) ICartsRepository {
+	return &cartsRepository{
+		db: db,
+	}
+}
+
+// FindCart return cart of user or guest token with current price of every item
+func (r *cartsRepository) FindCart(owner *carts.CartOwner) (*carts.Cart, error) {
+	query := `
+	SELECT
+		to_jsonb("t")
+	FROM (
+		SELECT
+			"c"."id",
+			COALESCE("c"."user_id", '') AS "user_id",
+			COALESCE("c"."token", '') AS "token",
+			"c"."currency",
+			COALESCE("c"."coupon_id"::TEXT, '') AS "coupon_id",
+			"c"."created_at",
+			"c"."updated_at",
+			(
+				SELECT
+					COALESCE(array_to_json(array_agg("it")), '[]'::json)
+				FROM (
+					SELECT
+						"ci"."id",
+						"ci"."product_id",
+						"p"."title",
+						"p"."slug",
+						COALESCE((
+							SELECT
+								"i"."url"
+							FROM "images" "i"
+							WHERE "i"."product_id" = "p"."id"
+							ORDER BY "i"."is_primary" DESC, "i"."position"
+							LIMIT 1
+						), '') AS "image_url",
+						"ci"."quantity",
+						CASE WHEN "ci"."added_amount" IS NOT NULL THEN
+							jsonb_build_object('currency', "c"."currency", 'minor_units', "ci"."added_amount")
+						END AS "added_price",
+						(
+							SELECT
+								jsonb_build_object('currency', "c"."currency", 'minor_units', "ep"."amount")
+							FROM (
+								SELECT product_effective_price("p"."id", "c"."currency", COALESCE("c"."user_id", '')) AS "amount"
+							) AS "ep"
+							WHERE "ep"."amount" IS NOT NULL
+						) AS "unit_price",
+						"p"."tax_class_id",
+						COALESCE("p"."weight", 0) AS "weight",
+						COALESCE("p"."length", 0) AS "length",
+						COALESCE("p"."width", 0) AS "width",
+						COALESCE("p"."height", 0) AS "height",
+						("p"."deleted_at" IS NULL AND "p"."status" = 'published') AS "available",
+						"ci"."created_at",
+						"ci"."updated_at"
+					FROM "carts_items" "ci"
+						JOIN "products" "p" ON "p"."id" = "ci"."product_id"
+					WHERE "ci"."cart_id" = "c"."id"
+					ORDER BY "ci"."created_at", "ci"."id"
+				) AS "it"
+			) AS "items"
+		FROM "carts" "c"
+		WHERE ("c"."user_id" = NULLIF($1, '') OR "c"."token" = NULLIF($2, ''))
+		ORDER BY "c"."user_id" NULLS LAST
+		LIMIT 1
+	) AS "t";`
+
+	cartBytes := make([]byte, 0)
+	if err := r.db.Get(&cartBytes, query, owner.UserId, owner.Token); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("cart not found")
+		}
+		return nil, fmt.Errorf("get cart failed: %v", err)
+	}
+
+	cart := &carts.Cart{
+		Items: make([]*carts.CartItem, 0),
+	}
+	if err := json.Unmarshal(cartBytes, &cart); err != nil {
+		return nil, fmt.Errorf("unmarshal cart failed: %v", err)
+	}
+	return cart, nil
+}
+
+func (r *cartsRepository) InsertCart(owner *carts.CartOwner, currency string) (string, error) {
+	query := `
+	INSERT INTO "carts" (
+		"user_id",
+		"token",
+		"currency"
+	)
+	VALUES (NULLIF($1, ''), NULLIF($2, ''), $3)
+	ON CONFLICT ("user_id") DO UPDATE SET
+		"updated_at" = now()
+		RETURNING "id";`
+
+	var cartId string
+	if err := r.db.QueryRowContext(
+		context.Background(),
+		query,
+		owner.UserId,
+		owner.Token,
+		currency,
+	).Scan(&cartId); err != nil {
+		return "", fmt.Errorf("insert cart failed: %v", err)
+	}
+	return cartId, nil
+}
+
+// checkProduct return error when product can't be added to cart of currency
+func checkProduct(ctx context.Context, tx sqlx.QueryerContext, productId, currency string) error {
+	query := `
+	SELECT
+		EXISTS (SELECT 1 FROM "product_prices" "pp" WHERE "pp"."product_id" = "p"."id" AND "pp"."currency" = $2)
+	FROM "products" "p"
+	WHERE "p"."id" = $1
+	AND "p"."deleted_at" IS NULL
+	AND "p"."status" = 'published';`
+
+	var hasPrice bool
+	if err := sqlx.GetContext(ctx, tx, &hasPrice, query, productId, currency); err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("product not found")
+		}
+		return fmt.Errorf("check product failed: %v", err)
+	}
+	if !hasPrice {
+		return fmt.Errorf("product has no price in %s", currency)
+	}
+	return nil
+}
+
+// UpsertItem add quantity to the item of product, added price is the current price for userId
+func (r *cartsRepository) UpsertItem(cartId string, req *carts.CartItemReq, userId string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*15)
+	defer cancel()
+
+	var currency string
+	if err := r.db.GetContext(ctx, &currency, `SELECT "currency" FROM "carts" WHERE "id" = $1;`, cartId); err != nil {
+		return fmt.Errorf("get cart failed: %v", err)
+	}
+	if err := checkProduct(ctx, r.db, req.ProductId, currency); err != nil {
+		return err
+	}
+
+	query := `
+	INSERT INTO "carts_items" (
+		"cart_id",
+		"product_id",
+		"quantity",
+		"added_amount"
+	)
+	VALUES ($1, $2, $3, product_effective_price($2, $4, $5))
+	ON CONFLICT ("cart_id", "product_id") DO UPDATE SET
+		"quantity" = LEAST("carts_items"."quantity" + EXCLUDED."quantity", $6),
+		"added_amount" = EXCLUDED."added_amount";`
+
+	if _, err := r.db.ExecContext(ctx, query, cartId, req.ProductId, req.Quantity, currency, userId, carts.MaxQuantity); err != nil {
+		return fmt.Errorf("insert cart item failed: %v", err)
+	}
+	return nil
+}
+
+// UpdateItem set quantity, added price is refreshed as the user has seen the current price
+func (r *cartsRepository) UpdateItem(cartId, itemId string, quantity int, userId string) error {
+	query := `
+	UPDATE "carts_items" "ci" SET
+		"quantity" = $3,
+		"added_amount" = product_effective_price("ci"."product_id", "c"."currency", $4)
+	FROM "carts" "c"
+	WHERE "c"."id" = "ci"."cart_id"
+	AND "ci"."cart_id" = $1
+	AND "ci"."id"::text = $2;`
+
+	result, err := r.db.ExecContext(context.Background(), query, cartId, itemId, quantity, userId)
+	if err != nil {
+		return fmt.Errorf("update cart item failed: %v", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("item not found")
+	}
+	return nil
+}
+
+func (r *cartsRepository) DeleteItem(cartId, itemId string) error {
+	query := `
+	DELETE FROM "carts_items"
+	WHERE "cart_id" = $1
+	AND "id"::text = $2;`
+
+	result, err := r.db.ExecContext(context.Background(), query, cartId, itemId)
+	if err != nil {
+		return fmt.Errorf("delete cart item failed: %v", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("item not found")
+	}
+	return nil
+}
+
+// MergeCart move items of guest cart into cart of user then delete the guest cart,
+// quantity of the same product is summed and priced in the user cart currency
+func (r *cartsRepository) MergeCart(token, userId, currency string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*15)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	var guestCartId string
+	if err := tx.GetContext(ctx, &guestCartId, `SELECT "id" FROM "carts" WHERE "token" = $1 AND "user_id" IS NULL FOR UPDATE;`, token); err != nil {
+		tx.Rollback()
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		return fmt.Errorf("get guest cart failed: %v", err)
+	}
+
+	userCartQuery := `
+	INSERT INTO "carts" (
+		"user_id",
+		"currency"
+	)
+	VALUES ($1, $2)
+	ON CONFLICT ("user_id") DO UPDATE SET
+		"updated_at" = now()
+		RETURNING "id", "currency";`
+
+	var userCartId string
+	if err := tx.QueryRowContext(ctx, userCartQuery, userId, currency).Scan(&userCartId, &currency); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("insert user cart failed: %v", err)
+	}
+
+	mergeQuery := `
+	INSERT INTO "carts_items" (
+		"cart_id",
+		"product_id",
+		"quantity",
+		"added_amount"
+	)
+	SELECT
+		$2,
+		"ci"."product_id",
+		"ci"."quantity",
+		product_effective_price("ci"."product_id", $3, $4)
+	FROM "carts_items" "ci"
+	WHERE "ci"."cart_id" = $1
+	ON CONFLICT ("cart_id", "product_id") DO UPDATE SET
+		"quantity" = LEAST("carts_items"."quantity" + EXCLUDED."quantity", $5),
+		"added_amount" = EXCLUDED."added_amount";`
+
+	if _, err := tx.ExecContext(ctx, mergeQuery, guestCartId, userCartId, currency, userId, carts.MaxQuantity); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("merge cart items failed: %v", err)
+	}
+
+	// coupon of guest cart is kept when user cart has none
+	if _, err := tx.ExecContext(ctx, `UPDATE "carts" SET "coupon_id" = COALESCE("coupon_id", (SELECT "coupon_id" FROM "carts" WHERE "id" = $1)) WHERE "id" = $2;`, guestCartId, userCartId); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("merge cart coupon failed: %v", err)
+	}
+
+	// items of guest cart are removed by ON DELETE CASCADE
+	if _, err := tx.ExecContext(ctx, `DELETE FROM "carts" WHERE "id" = $1;`, guestCartId); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("delete guest cart failed: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return nil
+}
+
+// UpdateCoupon set coupon of cart, empty couponId remove it
+func (r *cartsRepository) UpdateCoupon(cartId, couponId string) error {
+	query := `
+	UPDATE "carts" SET
+		"coupon_id" = NULLIF($2, '')::uuid
+	WHERE "id" = $1;`
+
+	if _, err := r.db.ExecContext(context.Background(), query, cartId, couponId); err != nil {
+		return fmt.Errorf("update cart coupon failed: %v", err)
+	}
+	return nil
+}
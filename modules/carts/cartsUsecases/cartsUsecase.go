@@ -0,0 +1,245 @@
+package cartsUsecases
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/DrumPatiphon/go-rest-api-service/config"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/carts"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/carts/cartsRepositories"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/coupons"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/coupons/couponsUsecases"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/taxes"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/taxes/taxesUsecases"
+)
+
+type ICartsUsecase interface {
+	FindCart(owner *carts.CartOwner, address *taxes.Address) (*carts.Cart, error)
+	AddItem(owner *carts.CartOwner, req *carts.CartItemReq) (*carts.Cart, error)
+	UpdateItem(owner *carts.CartOwner, itemId string, quantity int) (*carts.Cart, error)
+	DeleteItem(owner *carts.CartOwner, itemId string) (*carts.Cart, error)
+	MergeCart(token, userId string) error
+	ApplyCoupon(owner *carts.CartOwner, code string) (*carts.Cart, error)
+	RemoveCoupon(owner *carts.CartOwner) (*carts.Cart, error)
+}
+
+type cartsUsecase struct {
+	cfg             config.Iconfig
+	cartsRepository cartsRepositories.ICartsRepository
+	couponsUsecase  couponsUsecases.ICouponsUsecase
+	taxesUsecase    taxesUsecases.ITaxesUsecase
+}
+
+func CartsUsecase(cfg config.Iconfig, cartsRepository cartsRepositories.ICartsRepository, couponsUsecase couponsUsecases.ICouponsUsecase, taxesUsecase taxesUsecases.ITaxesUsecase) ICartsUsecase {
+	return &cartsUsecase{
+		cfg:             cfg,
+		cartsRepository: cartsRepository,
+		couponsUsecase:  couponsUsecase,
+		taxesUsecase:    taxesUsecase,
+	}
+}
+
+// newToken return random token of guest cart
+func newToken() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate cart token failed: %v", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// FindCart return empty cart when owner has no cart yet, tax is of the zone of address or TAX_COUNTRY when it is nil
+func (u *cartsUsecase) FindCart(owner *carts.CartOwner, address *taxes.Address) (*carts.Cart, error) {
+	cart, err := u.cartsRepository.FindCart(owner)
+	if err != nil {
+		if err.Error() != "cart not found" {
+			return nil, err
+		}
+		cart = &carts.Cart{
+			UserId:   owner.UserId,
+			Currency: u.cfg.App().Currency(),
+			Items:    make([]*carts.CartItem, 0),
+		}
+	}
+	cart.Compute()
+
+	if cart.CouponId != "" {
+		coupon, err := u.couponsUsecase.FindOneCoupon(cart.CouponId)
+		switch {
+		case err == nil:
+			if _, err := u.applyCoupon(cart, coupon); err != nil {
+				return nil, err
+			}
+		case err.Error() != "coupon not found": // deleted coupon is removed from cart by foreign key
+			return nil, err
+		}
+	}
+
+	if err := u.applyTax(cart, address); err != nil {
+		return nil, err
+	}
+	return cart, nil
+}
+
+// applyTax set tax of items after discount, so it must be called after the coupon
+func (u *cartsUsecase) applyTax(cart *carts.Cart, address *taxes.Address) error {
+	if address == nil || address.Country == "" {
+		address = &taxes.Address{Country: u.cfg.Tax().Country()}
+	}
+	result, err := u.taxesUsecase.Calculate(address, cart.TaxLines())
+	if err != nil {
+		return err
+	}
+	cart.TaxAddress = address
+	cart.SetTax(result)
+	return nil
+}
+
+// applyCoupon check coupon with items which can be bought and set the discount when it is applied
+func (u *cartsUsecase) applyCoupon(cart *carts.Cart, coupon *coupons.Coupon) (*coupons.Result, error) {
+	lines := make([]*coupons.Line, 0)
+	for _, item := range cart.Items {
+		if item.LineTotal != nil {
+			lines = append(lines, &coupons.Line{
+				Id:        item.Id,
+				ProductId: item.ProductId,
+				Amount:    item.LineTotal.Amount,
+			})
+		}
+	}
+
+	result, err := u.couponsUsecase.Apply(coupon, cart.UserId, cart.Currency, lines)
+	if err != nil {
+		return nil, err
+	}
+
+	cart.Coupon = &carts.CartCoupon{
+		Code:    coupon.Code,
+		Type:    coupon.Type,
+		Percent: coupon.Percent,
+		Amount:  coupon.Amount,
+		Applied: result.Reason == "",
+		Reason:  result.Reason,
+		Message: result.Message,
+	}
+	if cart.Coupon.Applied {
+		cart.SetDiscount(result.Discount, result.Allocations)
+	}
+	return result, nil
+}
+
+// ApplyCoupon save coupon to cart only when it can be applied now, otherwise the reason is returned
+func (u *cartsUsecase) ApplyCoupon(owner *carts.CartOwner, code string) (*carts.Cart, error) {
+	cart, err := u.FindCart(owner, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	coupon, err := u.couponsUsecase.FindOneCouponByCode(code)
+	if err != nil {
+		if err.Error() == "coupon not found" {
+			result := coupons.NotFound(code)
+			return nil, fmt.Errorf("coupon rejected, %s: %s", result.Reason, result.Message)
+		}
+		return nil, err
+	}
+
+	cart.Compute()
+	result, err := u.applyCoupon(cart, coupon)
+	if err != nil {
+		return nil, err
+	}
+	if result.Reason != "" {
+		return nil, fmt.Errorf("coupon rejected, %s: %s", result.Reason, result.Message)
+	}
+
+	cartId, err := u.cartId(owner)
+	if err != nil {
+		return nil, err
+	}
+	if err := u.cartsRepository.UpdateCoupon(cartId, coupon.Id); err != nil {
+		return nil, err
+	}
+	return u.FindCart(owner, nil)
+}
+
+func (u *cartsUsecase) RemoveCoupon(owner *carts.CartOwner) (*carts.Cart, error) {
+	cart, err := u.cartsRepository.FindCart(owner)
+	if err != nil && err.Error() != "cart not found" {
+		return nil, err
+	}
+	if cart != nil && cart.CouponId != "" {
+		if err := u.cartsRepository.UpdateCoupon(cart.Id, ""); err != nil {
+			return nil, err
+		}
+	}
+	return u.FindCart(owner, nil)
+}
+
+// cartId find or create cart of owner, guest without cart get a new token
+func (u *cartsUsecase) cartId(owner *carts.CartOwner) (string, error) {
+	cart, err := u.cartsRepository.FindCart(owner)
+	if err == nil {
+		return cart.Id, nil
+	}
+	if err.Error() != "cart not found" {
+		return "", err
+	}
+
+	if owner.UserId == "" {
+		token, err := newToken()
+		if err != nil {
+			return "", err
+		}
+		owner.Token = token
+	}
+	return u.cartsRepository.InsertCart(owner, u.cfg.App().Currency())
+}
+
+func (u *cartsUsecase) AddItem(owner *carts.CartOwner, req *carts.CartItemReq) (*carts.Cart, error) {
+	cartId, err := u.cartId(owner)
+	if err != nil {
+		return nil, err
+	}
+	if err := u.cartsRepository.UpsertItem(cartId, req, owner.UserId); err != nil {
+		return nil, err
+	}
+	return u.FindCart(owner, nil)
+}
+
+func (u *cartsUsecase) UpdateItem(owner *carts.CartOwner, itemId string, quantity int) (*carts.Cart, error) {
+	cart, err := u.cartsRepository.FindCart(owner)
+	if err != nil {
+		if err.Error() == "cart not found" {
+			return nil, fmt.Errorf("item not found")
+		}
+		return nil, err
+	}
+	if err := u.cartsRepository.UpdateItem(cart.Id, itemId, quantity, owner.UserId); err != nil {
+		return nil, err
+	}
+	return u.FindCart(owner, nil)
+}
+
+func (u *cartsUsecase) DeleteItem(owner *carts.CartOwner, itemId string) (*carts.Cart, error) {
+	cart, err := u.cartsRepository.FindCart(owner)
+	if err != nil {
+		if err.Error() == "cart not found" {
+			return nil, fmt.Errorf("item not found")
+		}
+		return nil, err
+	}
+	if err := u.cartsRepository.DeleteItem(cart.Id, itemId); err != nil {
+		return nil, err
+	}
+	return u.FindCart(owner, nil)
+}
+
+// MergeCart move guest cart of token into cart of user, unknown token is ignored
+func (u *cartsUsecase) MergeCart(token, userId string) error {
+	if token == "" || userId == "" {
+		return nil
+	}
+	return u.cartsRepository.MergeCart(token, userId, u.cfg.App().Currency())
+}
@@ -0,0 +1,274 @@
+package coupons
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/DrumPatiphon/go-rest-api-service/modules/entities"
+	"github.com/DrumPatiphon/go-rest-api-service/pkg/money"
+)
+
+type Coupon struct {
+	Id                string       `json:"id"`
+	Code              string       `json:"code"`
+	Description       string       `json:"description"`
+	Type              string       `json:"type"`              // percentage, fixed
+	Percent           int          `json:"percent,omitempty"` // percentage only
+	Amount            *money.Money `json:"amount,omitempty"`  // fixed only
+	MinSpend          *money.Money `json:"min_spend,omitempty"`
+	UsageLimit        *int         `json:"usage_limit"` // nil is unlimited
+	UsageLimitPerUser *int         `json:"usage_limit_per_user"`
+	UsedCount         int          `json:"used_count"`
+	StartsAt          string       `json:"starts_at"` // empty is no limit
+	EndsAt            string       `json:"ends_at"`
+	IsActive          bool         `json:"is_active"`
+	ProductIds        []string     `json:"product_ids"`  // empty with no category is every product
+	CategoryIds       []int        `json:"category_ids"` // include sub categories
+	CreatedAt         string       `json:"created_at"`
+	UpdatedAt         string       `json:"updated_at"`
+}
+
+type CouponFilter struct {
+	Search                  string `query:"search"`
+	*entities.PaginationReq        // like inherit class
+}
+
+type ApplyCouponReq struct {
+	Code string `json:"code" form:"code"`
+}
+
+// Line is a line of cart, amount is line total in minor units
+type Line struct {
+	Id        string
+	ProductId string
+	Amount    int64
+}
+
+// Usage is times the coupon was used in orders
+type Usage struct {
+	Total int
+	User  int
+}
+
+// Result of applying coupon, reason is empty when the coupon is applied
+type Result struct {
+	Discount    int64
+	Allocations map[string]int64 // line id -> discount
+	Reason      string
+	Message     string
+}
+
+// Reject reasons
+const (
+	ReasonNotFound           = "not_found"
+	ReasonInactive           = "inactive"
+	ReasonNotStarted         = "not_started"
+	ReasonExpired            = "expired"
+	ReasonCurrency           = "currency_mismatch"
+	ReasonUsageLimit         = "usage_limit_reached"
+	ReasonUserUsageLimit     = "user_usage_limit_reached"
+	ReasonSignInRequired     = "sign_in_required"
+	ReasonNoEligibleItems    = "no_eligible_items"
+	ReasonMinimumSpendNotMet = "minimum_spend_not_met"
+)
+
+// timeLayout of starts_at and ends_at
+const timeLayout = "2006-01-02T15:04:05"
+
+func reject(reason, message string) *Result {
+	return &Result{
+		Allocations: make(map[string]int64),
+		Reason:      reason,
+		Message:     message,
+	}
+}
+
+// NotFound is the result of unknown code
+func NotFound(code string) *Result {
+	return reject(ReasonNotFound, fmt.Sprintf("coupon %s not found", code))
+}
+
+func parseTime(text string) (time.Time, error) {
+	return time.Parse(timeLayout, strings.TrimSuffix(text, "Z"))
+}
+
+// Validate check coupon from admin and normalize code, money without currency is defaultCurrency
+func (obj *Coupon) Validate(defaultCurrency string) error {
+	obj.Code = strings.ToUpper(strings.TrimSpace(obj.Code))
+	if obj.Code == "" {
+		return fmt.Errorf("code is required")
+	}
+	if strings.ContainsAny(obj.Code, " \t\n") {
+		return fmt.Errorf("code must not contain space")
+	}
+
+	switch obj.Type {
+	case "percentage":
+		if obj.Percent < 1 || obj.Percent > 100 {
+			return fmt.Errorf("percent must be between 1 and 100")
+		}
+		obj.Amount = nil
+	case "fixed":
+		if obj.Amount == nil {
+			return fmt.Errorf("amount is required")
+		}
+		if err := obj.Amount.Validate(defaultCurrency); err != nil {
+			return err
+		}
+		if obj.Amount.Amount == 0 {
+			return fmt.Errorf("amount must be positive")
+		}
+		obj.Percent = 0
+	default:
+		return fmt.Errorf("type must be percentage or fixed")
+	}
+
+	if obj.MinSpend != nil {
+		if err := obj.MinSpend.Validate(defaultCurrency); err != nil {
+			return err
+		}
+		if obj.Amount != nil && obj.MinSpend.Currency != obj.Amount.Currency {
+			return fmt.Errorf("min spend and amount must be in the same currency")
+		}
+		if obj.MinSpend.Amount == 0 {
+			obj.MinSpend = nil
+		}
+	}
+
+	if obj.UsageLimit != nil && *obj.UsageLimit < 1 {
+		return fmt.Errorf("usage limit must be positive")
+	}
+	if obj.UsageLimitPerUser != nil && *obj.UsageLimitPerUser < 1 {
+		return fmt.Errorf("usage limit per user must be positive")
+	}
+
+	var startsAt, endsAt time.Time
+	var err error
+	if obj.StartsAt != "" {
+		if startsAt, err = parseTime(obj.StartsAt); err != nil {
+			return fmt.Errorf("starts at is invalid")
+		}
+	}
+	if obj.EndsAt != "" {
+		if endsAt, err = parseTime(obj.EndsAt); err != nil {
+			return fmt.Errorf("ends at is invalid")
+		}
+	}
+	if obj.StartsAt != "" && obj.EndsAt != "" && !endsAt.After(startsAt) {
+		return fmt.Errorf("ends at must be after starts at")
+	}
+
+	if obj.ProductIds == nil {
+		obj.ProductIds = make([]string, 0)
+	}
+	if obj.CategoryIds == nil {
+		obj.CategoryIds = make([]int, 0)
+	}
+	return nil
+}
+
+// Currency of amount and min spend, empty when coupon work with every currency
+func (obj *Coupon) Currency() string {
+	if obj.Amount != nil {
+		return obj.Amount.Currency
+	}
+	if obj.MinSpend != nil && obj.MinSpend.Amount > 0 {
+		return obj.MinSpend.Currency
+	}
+	return ""
+}
+
+// Apply check coupon with lines of cart, eligible is product ids the coupon target.
+// Discount is split to eligible lines by line total and the remainder goes to the biggest remainders,
+// so allocations always sum to discount
+func (obj *Coupon) Apply(currency string, lines []*Line, eligible map[string]bool, usage *Usage, userId string, now time.Time) *Result {
+	if !obj.IsActive {
+		return reject(ReasonInactive, "coupon is not active")
+	}
+	if obj.StartsAt != "" {
+		if t, err := parseTime(obj.StartsAt); err == nil && now.Before(t) {
+			return reject(ReasonNotStarted, fmt.Sprintf("coupon can be used from %s", obj.StartsAt))
+		}
+	}
+	if obj.EndsAt != "" {
+		if t, err := parseTime(obj.EndsAt); err == nil && !now.Before(t) {
+			return reject(ReasonExpired, fmt.Sprintf("coupon expired at %s", obj.EndsAt))
+		}
+	}
+	if c := obj.Currency(); c != "" && c != currency {
+		return reject(ReasonCurrency, fmt.Sprintf("coupon is only for %s", c))
+	}
+	if obj.UsageLimit != nil && usage.Total >= *obj.UsageLimit {
+		return reject(ReasonUsageLimit, "coupon has been used up")
+	}
+	if obj.UsageLimitPerUser != nil {
+		if userId == "" {
+			return reject(ReasonSignInRequired, "sign in to use this coupon")
+		}
+		if usage.User >= *obj.UsageLimitPerUser {
+			return reject(ReasonUserUsageLimit, fmt.Sprintf("coupon can be used %d times per user", *obj.UsageLimitPerUser))
+		}
+	}
+
+	eligibleLines := make([]*Line, 0)
+	var base int64
+	for _, line := range lines {
+		if line.Amount > 0 && eligible[line.ProductId] {
+			eligibleLines = append(eligibleLines, line)
+			base += line.Amount
+		}
+	}
+	if len(eligibleLines) == 0 {
+		return reject(ReasonNoEligibleItems, "no item in cart can use this coupon")
+	}
+	if obj.MinSpend != nil && base < obj.MinSpend.Amount {
+		return reject(ReasonMinimumSpendNotMet, fmt.Sprintf("spend %s more on eligible items to use this coupon", money.New(obj.MinSpend.Amount-base, currency).Display()))
+	}
+
+	var discount int64
+	if obj.Type == "percentage" {
+		discount = base * int64(obj.Percent) / 100
+	} else {
+		discount = obj.Amount.Amount
+	}
+	if discount > base {
+		discount = base
+	}
+
+	return &Result{
+		Discount:    discount,
+		Allocations: allocate(discount, base, eligibleLines),
+	}
+}
+
+// allocate split discount by amount of lines with largest remainder
+func allocate(discount, base int64, lines []*Line) map[string]int64 {
+	allocations := make(map[string]int64)
+	remainders := make([]int64, len(lines))
+	var given int64
+	for i, line := range lines {
+		share := discount * line.Amount
+		allocations[line.Id] = share / base
+		remainders[i] = share % base
+		given += allocations[line.Id]
+	}
+
+	for left := discount - given; left > 0; left-- {
+		best := -1
+		for i := range lines {
+			if allocations[lines[i].Id] >= lines[i].Amount {
+				continue
+			}
+			if best < 0 || remainders[i] > remainders[best] {
+				best = i
+			}
+		}
+		if best < 0 {
+			break
+		}
+		allocations[lines[best].Id]++
+		remainders[best] = -1
+	}
+	return allocations
+}
@@ -0,0 +1,200 @@
+package couponsHandlers
+
+import (
+	"strings"
+
+	"github.com/DrumPatiphon/go-rest-api-service/config"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/coupons"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/coupons/couponsUsecases"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/entities"
+	"github.com/gofiber/fiber/v2"
+)
+
+type couponsHandlerErrCode string
+
+const (
+	findCouponsErr   couponsHandlerErrCode = "coupons-001"
+	findOneCouponErr couponsHandlerErrCode = "coupons-002"
+	insertCouponErr  couponsHandlerErrCode = "coupons-003"
+	updateCouponErr  couponsHandlerErrCode = "coupons-004"
+	deleteCouponErr  couponsHandlerErrCode = "coupons-005"
+)
+
+type ICouponsHandler interface {
+	FindCoupons(c *fiber.Ctx) error
+	FindOneCoupon(c *fiber.Ctx) error
+	InsertCoupon(c *fiber.Ctx) error
+	UpdateCoupon(c *fiber.Ctx) error
+	DeleteCoupon(c *fiber.Ctx) error
+}
+
+type couponsHandler struct {
+	cfg            config.Iconfig
+	couponsUsecase couponsUsecases.ICouponsUsecase
+}
+
+func CouponsHandler(cfg config.Iconfig, couponsUsecase couponsUsecases.ICouponsUsecase) ICouponsHandler {
+	return &couponsHandler{
+		cfg:            cfg,
+		couponsUsecase: couponsUsecase,
+	}
+}
+
+func (h *couponsHandler) FindCoupons(c *fiber.Ctx) error {
+	req := &coupons.CouponFilter{
+		PaginationReq: &entities.PaginationReq{},
+	}
+	if err := c.QueryParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(findCouponsErr),
+			err.Error(),
+		).Res()
+	}
+	req.Search = strings.TrimSpace(req.Search)
+	if req.Page < 1 {
+		req.Page = 1
+	}
+	if req.Limit < 5 {
+		req.Limit = 5
+	}
+
+	couponsData, err := h.couponsUsecase.FindCoupons(req)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(findCouponsErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, couponsData).Res()
+}
+
+func (h *couponsHandler) FindOneCoupon(c *fiber.Ctx) error {
+	couponId := strings.TrimSpace(c.Params("coupon_id"))
+
+	coupon, err := h.couponsUsecase.FindOneCoupon(couponId)
+	if err != nil {
+		switch err.Error() {
+		case "coupon not found":
+			return entities.NewResponse(c).Error(
+				fiber.ErrNotFound.Code,
+				string(findOneCouponErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(findOneCouponErr),
+				err.Error(),
+			).Res()
+		}
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, coupon).Res()
+}
+
+func (h *couponsHandler) InsertCoupon(c *fiber.Ctx) error {
+	req := &coupons.Coupon{
+		IsActive: true,
+	}
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(insertCouponErr),
+			err.Error(),
+		).Res()
+	}
+	if err := req.Validate(h.cfg.App().Currency()); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(insertCouponErr),
+			err.Error(),
+		).Res()
+	}
+
+	coupon, err := h.couponsUsecase.InsertCoupon(req)
+	if err != nil {
+		switch err.Error() {
+		case "code has been used", "product not found", "category not found":
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(insertCouponErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(insertCouponErr),
+				err.Error(),
+			).Res()
+		}
+	}
+	return entities.NewResponse(c).Success(fiber.StatusCreated, coupon).Res()
+}
+
+// UpdateCoupon replace every field of coupon
+func (h *couponsHandler) UpdateCoupon(c *fiber.Ctx) error {
+	req := new(coupons.Coupon)
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(updateCouponErr),
+			err.Error(),
+		).Res()
+	}
+	req.Id = strings.TrimSpace(c.Params("coupon_id"))
+	if err := req.Validate(h.cfg.App().Currency()); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(updateCouponErr),
+			err.Error(),
+		).Res()
+	}
+
+	coupon, err := h.couponsUsecase.UpdateCoupon(req)
+	if err != nil {
+		switch err.Error() {
+		case "coupon not found":
+			return entities.NewResponse(c).Error(
+				fiber.ErrNotFound.Code,
+				string(updateCouponErr),
+				err.Error(),
+			).Res()
+		case "code has been used", "product not found", "category not found":
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(updateCouponErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(updateCouponErr),
+				err.Error(),
+			).Res()
+		}
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, coupon).Res()
+}
+
+func (h *couponsHandler) DeleteCoupon(c *fiber.Ctx) error {
+	couponId := strings.TrimSpace(c.Params("coupon_id"))
+
+	if err := h.couponsUsecase.DeleteCoupon(couponId); err != nil {
+		switch err.Error() {
+		case "coupon not found":
+			return entities.NewResponse(c).Error(
+				fiber.ErrNotFound.Code,
+				string(deleteCouponErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(deleteCouponErr),
+				err.Error(),
+			).Res()
+		}
+	}
+	return entities.NewResponse(c).Success(fiber.StatusNoContent, nil).Res()
+}
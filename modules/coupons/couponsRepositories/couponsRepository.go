@@ -0,0 +1,380 @@
+package couponsRepositories
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/DrumPatiphon/go-rest-api-service/modules/coupons"
+	"github.com/jmoiron/sqlx"
+)
+
+type ICouponsRepository interface {
+	InsertCoupon(req *coupons.Coupon) error
+	UpdateCoupon(req *coupons.Coupon) error
+	DeleteCoupon(couponId string) error
+	FindOneCoupon(couponId string) (*coupons.Coupon, error)
+	FindOneCouponByCode(code string) (*coupons.Coupon, error)
+	FindCoupons(req *coupons.CouponFilter) ([]*coupons.Coupon, int, error)
+	FindUsage(couponId, userId string) (*coupons.Usage, error)
+	FindEligibleProducts(couponId string, productIds []string) (map[string]bool, error)
+}
+
+type couponsRepository struct {
+	db *sqlx.DB
+}
+
+func CouponsRepository(db *sqlx.DB) ICouponsRepository {
+	return &couponsRepository{
+		db: db,
+	}
+}
+
+// couponColumns is columns of coupon in json
+const couponColumns = `
+			"c"."id",
+			"c"."code",
+			"c"."description",
+			"c"."type",
+			COALESCE("c"."percent", 0) AS "percent",
+			CASE WHEN "c"."amount" IS NOT NULL THEN
+				jsonb_build_object('currency', "c"."currency", 'minor_units', "c"."amount")
+			END AS "amount",
+			CASE WHEN "c"."min_spend" > 0 THEN
+				jsonb_build_object('currency', "c"."currency", 'minor_units', "c"."min_spend")
+			END AS "min_spend",
+			"c"."usage_limit",
+			"c"."usage_limit_per_user",
+			(SELECT COUNT(*) FROM "coupons_usages" "cu" WHERE "cu"."coupon_id" = "c"."id") AS "used_count",
+			COALESCE(to_char("c"."starts_at", 'YYYY-MM-DD"T"HH24:MI:SS'), '') AS "starts_at",
+			COALESCE(to_char("c"."ends_at", 'YYYY-MM-DD"T"HH24:MI:SS'), '') AS "ends_at",
+			"c"."is_active",
+			(
+				SELECT
+					COALESCE(array_to_json(array_agg("cp"."product_id" ORDER BY "cp"."product_id")), '[]'::json)
+				FROM "coupons_products" "cp"
+				WHERE "cp"."coupon_id" = "c"."id"
+			) AS "product_ids",
+			(
+				SELECT
+					COALESCE(array_to_json(array_agg("cc"."category_id" ORDER BY "cc"."category_id")), '[]'::json)
+				FROM "coupons_categories" "cc"
+				WHERE "cc"."coupon_id" = "c"."id"
+			) AS "category_ids",
+			"c"."created_at",
+			"c"."updated_at"`
+
+// couponValues is values of coupon columns for insert and update
+func couponValues(req *coupons.Coupon) []any {
+	var amount, minSpend any
+	currency := req.Currency()
+	if req.Amount != nil {
+		amount = req.Amount.Amount
+	}
+	minSpend = int64(0)
+	if req.MinSpend != nil {
+		minSpend = req.MinSpend.Amount
+	}
+	var percent any
+	if req.Type == "percentage" {
+		percent = req.Percent
+	}
+	return []any{
+		req.Code,
+		req.Description,
+		req.Type,
+		percent,
+		amount,
+		currency,
+		minSpend,
+		req.UsageLimit,
+		req.UsageLimitPerUser,
+		req.StartsAt,
+		req.EndsAt,
+		req.IsActive,
+	}
+}
+
+func couponErr(err error) error {
+	switch {
+	case strings.Contains(err.Error(), "coupons_code_key"):
+		return fmt.Errorf("code has been used")
+	case strings.Contains(err.Error(), "coupons_products_product_id_fkey"):
+		return fmt.Errorf("product not found")
+	case strings.Contains(err.Error(), "coupons_categories_category_id_fkey"):
+		return fmt.Errorf("category not found")
+	default:
+		return fmt.Errorf("save coupon failed: %v", err)
+	}
+}
+
+// setTargets replace products and categories of coupon
+func setTargets(ctx context.Context, tx *sqlx.Tx, req *coupons.Coupon) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM "coupons_products" WHERE "coupon_id" = $1;`, req.Id); err != nil {
+		return couponErr(err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM "coupons_categories" WHERE "coupon_id" = $1;`, req.Id); err != nil {
+		return couponErr(err)
+	}
+
+	productsQuery := `
+	INSERT INTO "coupons_products" (
+		"coupon_id",
+		"product_id"
+	)
+	SELECT $1, UNNEST($2::VARCHAR[])
+	ON CONFLICT DO NOTHING;`
+
+	if _, err := tx.ExecContext(ctx, productsQuery, req.Id, req.ProductIds); err != nil {
+		return couponErr(err)
+	}
+
+	categoriesQuery := `
+	INSERT INTO "coupons_categories" (
+		"coupon_id",
+		"category_id"
+	)
+	SELECT $1, UNNEST($2::INT[])
+	ON CONFLICT DO NOTHING;`
+
+	if _, err := tx.ExecContext(ctx, categoriesQuery, req.Id, req.CategoryIds); err != nil {
+		return couponErr(err)
+	}
+	return nil
+}
+
+func (r *couponsRepository) InsertCoupon(req *coupons.Coupon) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*15)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	query := `
+	INSERT INTO "coupons" (
+		"code",
+		"description",
+		"type",
+		"percent",
+		"amount",
+		"currency",
+		"min_spend",
+		"usage_limit",
+		"usage_limit_per_user",
+		"starts_at",
+		"ends_at",
+		"is_active"
+	)
+	VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), $7, $8, $9, NULLIF($10, '')::TIMESTAMP, NULLIF($11, '')::TIMESTAMP, $12)
+		RETURNING "id";`
+
+	if err := tx.QueryRowContext(ctx, query, couponValues(req)...).Scan(&req.Id); err != nil {
+		tx.Rollback()
+		return couponErr(err)
+	}
+	if err := setTargets(ctx, tx, req); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return nil
+}
+
+func (r *couponsRepository) UpdateCoupon(req *coupons.Coupon) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*15)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	query := `
+	UPDATE "coupons" SET
+		"code" = $1,
+		"description" = $2,
+		"type" = $3,
+		"percent" = $4,
+		"amount" = $5,
+		"currency" = NULLIF($6, ''),
+		"min_spend" = $7,
+		"usage_limit" = $8,
+		"usage_limit_per_user" = $9,
+		"starts_at" = NULLIF($10, '')::TIMESTAMP,
+		"ends_at" = NULLIF($11, '')::TIMESTAMP,
+		"is_active" = $12
+	WHERE "id"::TEXT = $13;`
+
+	result, err := tx.ExecContext(ctx, query, append(couponValues(req), req.Id)...)
+	if err != nil {
+		tx.Rollback()
+		return couponErr(err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		tx.Rollback()
+		return fmt.Errorf("coupon not found")
+	}
+	if err := setTargets(ctx, tx, req); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return nil
+}
+
+func (r *couponsRepository) DeleteCoupon(couponId string) error {
+	result, err := r.db.ExecContext(context.Background(), `DELETE FROM "coupons" WHERE "id"::TEXT = $1;`, couponId)
+	if err != nil {
+		return fmt.Errorf("delete coupon failed: %v", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("coupon not found")
+	}
+	return nil
+}
+
+func (r *couponsRepository) findOneCoupon(where string, value string) (*coupons.Coupon, error) {
+	query := `
+	SELECT
+		to_jsonb("t")
+	FROM (
+		SELECT` + couponColumns + `
+		FROM "coupons" "c"
+		WHERE ` + where + `
+	) AS "t";`
+
+	couponBytes := make([]byte, 0)
+	coupon := new(coupons.Coupon)
+
+	if err := r.db.Get(&couponBytes, query, value); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("coupon not found")
+		}
+		return nil, fmt.Errorf("get coupon failed: %v", err)
+	}
+	if err := json.Unmarshal(couponBytes, coupon); err != nil {
+		return nil, fmt.Errorf("unmarshal coupon failed: %v", err)
+	}
+	return coupon, nil
+}
+
+func (r *couponsRepository) FindOneCoupon(couponId string) (*coupons.Coupon, error) {
+	return r.findOneCoupon(`"c"."id"::TEXT = $1`, couponId)
+}
+
+func (r *couponsRepository) FindOneCouponByCode(code string) (*coupons.Coupon, error) {
+	return r.findOneCoupon(`"c"."code" = UPPER($1)`, code)
+}
+
+func (r *couponsRepository) FindCoupons(req *coupons.CouponFilter) ([]*coupons.Coupon, int, error) {
+	queryWhere := `
+		WHERE 1 = 1`
+	values := make([]any, 0)
+
+	if req.Search != "" {
+		values = append(values, "%"+strings.ToLower(req.Search)+"%")
+		queryWhere += `
+		AND (LOWER("c"."code") LIKE $` + strconv.Itoa(len(values)) + ` OR LOWER("c"."description") LIKE $` + strconv.Itoa(len(values)) + `)`
+	}
+
+	countQuery := `
+	SELECT
+		COUNT(*)
+	FROM "coupons" "c"` + queryWhere
+
+	var count int
+	if err := r.db.Get(&count, countQuery, values...); err != nil {
+		return nil, 0, fmt.Errorf("count coupons failed: %v", err)
+	}
+
+	values = append(values, (req.Page-1)*req.Limit, req.Limit)
+	query := fmt.Sprintf(`
+	SELECT
+		COALESCE(array_to_json(array_agg("t")), '[]'::json)
+	FROM (
+		SELECT%s
+		FROM "coupons" "c"%s
+		ORDER BY "c"."created_at" DESC
+		OFFSET $%d LIMIT $%d
+	) AS "t";`, couponColumns, queryWhere, len(values)-1, len(values))
+
+	couponsBytes := make([]byte, 0)
+	couponsData := make([]*coupons.Coupon, 0)
+
+	if err := r.db.Get(&couponsBytes, query, values...); err != nil {
+		return nil, 0, fmt.Errorf("find coupons failed: %v", err)
+	}
+	if err := json.Unmarshal(couponsBytes, &couponsData); err != nil {
+		return nil, 0, fmt.Errorf("unmarshal coupons failed: %v", err)
+	}
+	return couponsData, count, nil
+}
+
+func (r *couponsRepository) FindUsage(couponId, userId string) (*coupons.Usage, error) {
+	query := `
+	SELECT
+		COUNT(*) AS "total",
+		COUNT(*) FILTER (WHERE "user_id" = $2) AS "user"
+	FROM "coupons_usages"
+	WHERE "coupon_id"::TEXT = $1;`
+
+	usage := new(coupons.Usage)
+	if err := r.db.QueryRowContext(context.Background(), query, couponId, userId).Scan(&usage.Total, &usage.User); err != nil {
+		return nil, fmt.Errorf("find coupon usage failed: %v", err)
+	}
+	return usage, nil
+}
+
+// FindEligibleProducts return product ids the coupon can discount, with sub categories of targeted categories
+func (r *couponsRepository) FindEligibleProducts(couponId string, productIds []string) (map[string]bool, error) {
+	query := `
+	WITH RECURSIVE "targets" AS (
+		SELECT
+			"cc"."category_id" AS "id"
+		FROM "coupons_categories" "cc"
+		WHERE "cc"."coupon_id"::TEXT = $1
+		UNION
+		SELECT
+			"c"."id"
+		FROM "categories" "c"
+			JOIN "targets" "t" ON "c"."parent_id" = "t"."id"
+	)
+	SELECT
+		"l"."product_id"
+	FROM UNNEST($2::VARCHAR[]) AS "l" ("product_id")
+	WHERE (
+		NOT EXISTS (SELECT 1 FROM "coupons_products" WHERE "coupon_id"::TEXT = $1)
+		AND NOT EXISTS (SELECT 1 FROM "targets")
+	)
+	OR EXISTS (
+		SELECT 1 FROM "coupons_products" "cp" WHERE "cp"."coupon_id"::TEXT = $1 AND "cp"."product_id" = "l"."product_id"
+	)
+	OR EXISTS (
+		SELECT 1 FROM "products_categories" "pc" WHERE "pc"."product_id" = "l"."product_id" AND "pc"."category_id" IN (SELECT "id" FROM "targets")
+	);`
+
+	eligibleIds := make([]string, 0)
+	if err := r.db.Select(&eligibleIds, query, couponId, productIds); err != nil {
+		return nil, fmt.Errorf("find eligible products failed: %v", err)
+	}
+
+	eligible := make(map[string]bool)
+	for _, id := range eligibleIds {
+		eligible[id] = true
+	}
+	return eligible, nil
+}
@@ -0,0 +1,103 @@
+package couponsUsecases
+
+import (
+	"math"
+	"time"
+
+	"github.com/DrumPatiphon/go-rest-api-service/modules/coupons"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/coupons/couponsRepositories"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/entities"
+)
+
+type ICouponsUsecase interface {
+	InsertCoupon(req *coupons.Coupon) (*coupons.Coupon, error)
+	UpdateCoupon(req *coupons.Coupon) (*coupons.Coupon, error)
+	DeleteCoupon(couponId string) error
+	FindOneCoupon(couponId string) (*coupons.Coupon, error)
+	FindOneCouponByCode(code string) (*coupons.Coupon, error)
+	FindCoupons(req *coupons.CouponFilter) (*entities.PageRes, error)
+	Apply(coupon *coupons.Coupon, userId, currency string, lines []*coupons.Line) (*coupons.Result, error)
+}
+
+type couponsUsecase struct {
+	couponsRepository couponsRepositories.ICouponsRepository
+}
+
+func CouponsUsecase(couponsRepository couponsRepositories.ICouponsRepository) ICouponsUsecase {
+	return &couponsUsecase{
+		couponsRepository: couponsRepository,
+	}
+}
+
+func (u *couponsUsecase) InsertCoupon(req *coupons.Coupon) (*coupons.Coupon, error) {
+	if err := u.couponsRepository.InsertCoupon(req); err != nil {
+		return nil, err
+	}
+	return u.couponsRepository.FindOneCoupon(req.Id)
+}
+
+func (u *couponsUsecase) UpdateCoupon(req *coupons.Coupon) (*coupons.Coupon, error) {
+	if err := u.couponsRepository.UpdateCoupon(req); err != nil {
+		return nil, err
+	}
+	return u.couponsRepository.FindOneCoupon(req.Id)
+}
+
+func (u *couponsUsecase) DeleteCoupon(couponId string) error {
+	if err := u.couponsRepository.DeleteCoupon(couponId); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (u *couponsUsecase) FindOneCoupon(couponId string) (*coupons.Coupon, error) {
+	coupon, err := u.couponsRepository.FindOneCoupon(couponId)
+	if err != nil {
+		return nil, err
+	}
+	return coupon, nil
+}
+
+func (u *couponsUsecase) FindOneCouponByCode(code string) (*coupons.Coupon, error) {
+	coupon, err := u.couponsRepository.FindOneCouponByCode(code)
+	if err != nil {
+		return nil, err
+	}
+	return coupon, nil
+}
+
+func (u *couponsUsecase) FindCoupons(req *coupons.CouponFilter) (*entities.PageRes, error) {
+	couponsData, count, err := u.couponsRepository.FindCoupons(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return &entities.PageRes{
+		Data:       couponsData,
+		Page:       req.Page,
+		Limit:      req.Limit,
+		TotalItems: count,
+		TotalPage:  int(math.Ceil(float64(count) / float64(req.Limit))),
+	}, nil
+}
+
+// Apply check coupon against lines of cart with usage of the user
+func (u *couponsUsecase) Apply(coupon *coupons.Coupon, userId, currency string, lines []*coupons.Line) (*coupons.Result, error) {
+	usage, err := u.couponsRepository.FindUsage(coupon.Id, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	productIds := make([]string, 0)
+	for _, line := range lines {
+		productIds = append(productIds, line.ProductId)
+	}
+	eligible, err := u.couponsRepository.FindEligibleProducts(coupon.Id, productIds)
+	if err != nil {
+		return nil, err
+	}
+
+	// times are saved without zone like created_at, compare in local time of server
+	now, _ := time.Parse("2006-01-02T15:04:05", time.Now().Format("2006-01-02T15:04:05"))
+	return coupon.Apply(currency, lines, eligible, usage, userId, now), nil
+}
@@ -0,0 +1,51 @@
+package coupons
+
+import "testing"
+
+func TestAllocate(t *testing.T) {
+	tests := []struct {
+		name     string
+		discount int64
+		amounts  []int64
+		want     []int64
+	}{
+		{name: "one line", discount: 100, amounts: []int64{1000}, want: []int64{100}},
+		{name: "even split", discount: 100, amounts: []int64{500, 500}, want: []int64{50, 50}},
+		{name: "by amount", discount: 300, amounts: []int64{1000, 2000}, want: []int64{100, 200}},
+		{name: "remainder to first of equal lines", discount: 100, amounts: []int64{100, 100, 100}, want: []int64{34, 33, 33}},
+		{name: "remainder to largest remainder", discount: 10, amounts: []int64{100, 200}, want: []int64{3, 7}},
+		{name: "remainders on many lines", discount: 2, amounts: []int64{1, 1, 1}, want: []int64{1, 1, 0}},
+		{name: "whole base", discount: 3, amounts: []int64{1, 2}, want: []int64{1, 2}},
+		{name: "zero discount", discount: 0, amounts: []int64{100, 200}, want: []int64{0, 0}},
+		{name: "uneven lines", discount: 1000, amounts: []int64{333, 333, 334}, want: []int64{333, 333, 334}},
+		{name: "odd discount", discount: 7, amounts: []int64{150, 150, 150, 150}, want: []int64{2, 2, 2, 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines := make([]*Line, 0)
+			var base int64
+			for i, amount := range tt.amounts {
+				lines = append(lines, &Line{Id: string(rune('a' + i)), Amount: amount})
+				base += amount
+			}
+
+			allocations := allocate(tt.discount, base, lines)
+
+			var total int64
+			for i, line := range lines {
+				got := allocations[line.Id]
+				if got != tt.want[i] {
+					t.Errorf("line %s got %d, want %d", line.Id, got, tt.want[i])
+				}
+				if got > line.Amount {
+					t.Errorf("line %s got %d, more than its amount %d", line.Id, got, line.Amount)
+				}
+				total += got
+			}
+			if total != tt.discount {
+				t.Errorf("allocated %d, want %d", total, tt.discount)
+			}
+		})
+	}
+}
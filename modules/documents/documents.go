@@ -0,0 +1,33 @@
+package documents
+
+// Type of document
+const (
+	Invoice = "invoice"
+	Receipt = "receipt"
+)
+
+type Document struct {
+	Id          string `db:"id" json:"id"`
+	OrderId     string `db:"order_id" json:"order_id"`
+	Type        string `db:"type" json:"type"`
+	Number      string `db:"number" json:"number"` // INV000001 or RCP000001
+	Destination string `db:"destination" json:"-"` // empty until the PDF is uploaded
+	CreatedAt   string `db:"created_at" json:"created_at"`
+}
+
+func IsType(kind string) bool {
+	return kind == Invoice || kind == Receipt
+}
+
+// IsPaid report whether an order in status has been paid, only paid order has documents
+func IsPaid(status string) bool {
+	switch status {
+	case "paid", "fulfilled", "shipped", "delivered", "refunded":
+		return true
+	}
+	return false
+}
+
+func (obj *Document) Filename() string {
+	return obj.Number + ".pdf"
+}
@@ -0,0 +1,104 @@
+package documentsHandlers
+
+import (
+	"strings"
+
+	"github.com/DrumPatiphon/go-rest-api-service/config"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/documents"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/documents/documentsUsecases"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/entities"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/orders/ordersUsecases"
+	"github.com/gofiber/fiber/v2"
+)
+
+type documentsHandlerErrCode string
+
+const (
+	downloadDocumentErr documentsHandlerErrCode = "documents-001"
+)
+
+type IDocumentsHandler interface {
+	DownloadDocument(c *fiber.Ctx) error
+}
+
+type documentsHandler struct {
+	cfg              config.Iconfig
+	documentsUsecase documentsUsecases.IDocumentsUsecase
+	ordersUsecase    ordersUsecases.IOrdersUsecase
+}
+
+func DocumentsHandler(cfg config.Iconfig, documentsUsecase documentsUsecases.IDocumentsUsecase, ordersUsecase ordersUsecases.IOrdersUsecase) IDocumentsHandler {
+	return &documentsHandler{
+		cfg:              cfg,
+		documentsUsecase: documentsUsecase,
+		ordersUsecase:    ordersUsecase,
+	}
+}
+
+// DownloadDocument customer can only download document of own order, admin can download every document
+func (h *documentsHandler) DownloadDocument(c *fiber.Ctx) error {
+	orderId := strings.TrimSpace(c.Params("order_id"))
+	kind := strings.ToLower(strings.TrimSpace(c.Params("type")))
+	if !documents.IsType(kind) {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(downloadDocumentErr),
+			"type must be invoice or receipt",
+		).Res()
+	}
+
+	order, err := h.ordersUsecase.FindOneOrder(orderId)
+	if err != nil {
+		switch err.Error() {
+		case "order not found":
+			return entities.NewResponse(c).Error(
+				fiber.ErrNotFound.Code,
+				string(downloadDocumentErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(downloadDocumentErr),
+				err.Error(),
+			).Res()
+		}
+	}
+	userId, _ := c.Locals("userId").(string)
+	userRoleId, _ := c.Locals("userRoleId").(int)
+	if order.UserId != userId && userRoleId != 2 {
+		return entities.NewResponse(c).Error(
+			fiber.ErrNotFound.Code,
+			string(downloadDocumentErr),
+			"order not found",
+		).Res()
+	}
+
+	document, data, err := h.documentsUsecase.Download(order, kind)
+	if err != nil {
+		switch {
+		case err.Error() == "order is not paid":
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(downloadDocumentErr),
+				err.Error(),
+			).Res()
+		case strings.HasPrefix(err.Error(), "document font is not"):
+			return entities.NewResponse(c).Error(
+				fiber.ErrServiceUnavailable.Code,
+				string(downloadDocumentErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(downloadDocumentErr),
+				err.Error(),
+			).Res()
+		}
+	}
+
+	c.Attachment(document.Filename())
+	c.Set(fiber.HeaderContentType, "application/pdf")
+	return c.Send(data)
+}
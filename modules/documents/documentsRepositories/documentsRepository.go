@@ -0,0 +1,90 @@
+package documentsRepositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/DrumPatiphon/go-rest-api-service/modules/documents"
+	"github.com/jmoiron/sqlx"
+)
+
+type IDocumentsRepository interface {
+	FindOrInsertDocument(orderId, kind string) (*documents.Document, error)
+	UpdateDestination(documentId, destination string) error
+}
+
+type documentsRepository struct {
+	db *sqlx.DB
+}
+
+func DocumentsRepository(db *sqlx.DB) IDocumentsRepository {
+	return &documentsRepository{
+		db: db,
+	}
+}
+
+const documentColumns = `
+		"id",
+		"order_id",
+		"type",
+		"number",
+		COALESCE("destination", '') AS "destination",
+		"created_at"::TEXT AS "created_at"`
+
+// FindOrInsertDocument return document of the order, number is taken when it is first requested.
+// Number is only taken when the document is not found, so numbers only skip when two requests race
+func (r *documentsRepository) FindOrInsertDocument(orderId, kind string) (*documents.Document, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*15)
+	defer cancel()
+
+	findQuery := `
+	SELECT` + documentColumns + `
+	FROM "documents"
+	WHERE "order_id" = $1
+	AND "type" = $2;`
+
+	document := new(documents.Document)
+	err := r.db.GetContext(ctx, document, findQuery, orderId, kind)
+	if err == nil {
+		return document, nil
+	}
+	if err != sql.ErrNoRows {
+		return nil, fmt.Errorf("find document failed: %v", err)
+	}
+
+	insertQuery := `
+	INSERT INTO "documents" (
+		"order_id",
+		"type",
+		"number"
+	)
+	VALUES (
+		$1,
+		$2::VARCHAR,
+		CASE $2::VARCHAR
+			WHEN 'invoice' THEN CONCAT('INV', LPAD(NEXTVAL('invoices_number_seq')::TEXT, 6, '0'))
+			ELSE CONCAT('RCP', LPAD(NEXTVAL('receipts_number_seq')::TEXT, 6, '0'))
+		END
+	)
+	ON CONFLICT ("order_id", "type") DO NOTHING;`
+
+	if _, err := r.db.ExecContext(ctx, insertQuery, orderId, kind); err != nil {
+		return nil, fmt.Errorf("insert document failed: %v", err)
+	}
+	if err := r.db.GetContext(ctx, document, findQuery, orderId, kind); err != nil {
+		return nil, fmt.Errorf("find document failed: %v", err)
+	}
+	return document, nil
+}
+
+func (r *documentsRepository) UpdateDestination(documentId, destination string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*15)
+	defer cancel()
+
+	if _, err := r.db.ExecContext(ctx, `UPDATE "documents" SET "destination" = $2 WHERE "id" = $1;`, documentId, destination); err != nil {
+		return fmt.Errorf("update document failed: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package documentsUsecases
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/DrumPatiphon/go-rest-api-service/config"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/documents"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/documents/documentsRepositories"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/files/filesUsecases"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/orders"
+	"github.com/DrumPatiphon/go-rest-api-service/pkg/pdf"
+)
+
+type IDocumentsUsecase interface {
+	Download(order *orders.Order, kind string) (*documents.Document, []byte, error)
+}
+
+type documentsUsecase struct {
+	cfg                 config.Iconfig
+	documentsRepository documentsRepositories.IDocumentsRepository
+	filesUsecase        filesUsecases.IFilesUsecases
+	fontMu              sync.Mutex
+	font                *pdf.Font // from DOCUMENT_FONT with Thai glyphs, loaded on first render
+}
+
+func DocumentsUsecase(cfg config.Iconfig, documentsRepository documentsRepositories.IDocumentsRepository, filesUsecase filesUsecases.IFilesUsecases) IDocumentsUsecase {
+	return &documentsUsecase{
+		cfg:                 cfg,
+		documentsRepository: documentsRepository,
+		filesUsecase:        filesUsecase,
+	}
+}
+
+// loadFont read DOCUMENT_FONT once, a failed load is tried again on the next render
+func (u *documentsUsecase) loadFont() (*pdf.Font, error) {
+	u.fontMu.Lock()
+	defer u.fontMu.Unlock()
+
+	if u.font != nil {
+		return u.font, nil
+	}
+	path := u.cfg.Document().FontPath()
+	if path == "" {
+		return nil, fmt.Errorf("document font is not set")
+	}
+	font, err := pdf.LoadFontFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("document font is not available: %v", err)
+	}
+	u.font = font
+	return font, nil
+}
+
+// Download render the document on first download then keep it in storage,
+// an issued document is never rendered again so it stays the same as sent
+func (u *documentsUsecase) Download(order *orders.Order, kind string) (*documents.Document, []byte, error) {
+	if !documents.IsPaid(order.Status) {
+		return nil, nil, fmt.Errorf("order is not paid")
+	}
+
+	document, err := u.documentsRepository.FindOrInsertDocument(order.Id, kind)
+	if err != nil {
+		return nil, nil, err
+	}
+	if document.Destination != "" {
+		data, err := u.filesUsecase.Download(document.Destination)
+		if err != nil {
+			return nil, nil, err
+		}
+		return document, data, nil
+	}
+
+	font, err := u.loadFont()
+	if err != nil {
+		return nil, nil, err
+	}
+	data, err := u.render(document, order, font)
+	if err != nil {
+		return nil, nil, fmt.Errorf("render %s failed: %v", kind, err)
+	}
+	destination := fmt.Sprintf("documents/%s/%s", order.Id, document.Filename())
+	if err := u.filesUsecase.UploadPrivate(destination, "application/pdf", data); err != nil {
+		return nil, nil, err
+	}
+	if err := u.documentsRepository.UpdateDestination(document.Id, destination); err != nil {
+		return nil, nil, err
+	}
+	document.Destination = destination
+	return document, data, nil
+}
@@ -0,0 +1,284 @@
+package documentsUsecases
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/DrumPatiphon/go-rest-api-service/modules/addresses"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/documents"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/orders"
+	"github.com/DrumPatiphon/go-rest-api-service/pkg/money"
+	"github.com/DrumPatiphon/go-rest-api-service/pkg/pdf"
+)
+
+// layout of A4 page in points
+const (
+	marginX    = 40.0
+	rightX     = pdf.PageWidth - marginX
+	bottomY    = pdf.PageHeight - 60
+	lineHeight = 14.0
+)
+
+// columns of items table, amounts are right aligned at their x
+var itemColumns = []struct {
+	en, th string
+	x      float64
+	right  bool
+}{
+	{"#", "#", marginX + 4, false},
+	{"Description", "รายการ", marginX + 28, false},
+	{"Qty", "จำนวน", 340, true},
+	{"Unit price", "ราคาต่อหน่วย", 420, true},
+	{"Discount", "ส่วนลด", 485, true},
+	{"Amount", "จำนวนเงิน", rightX - 4, true},
+}
+
+type renderer struct {
+	doc      *pdf.Document
+	thai     bool // font has Thai, labels are in English and Thai
+	y        float64
+	document *documents.Document
+	order    *orders.Order
+}
+
+// label is English, and Thai when the font has it
+func (r *renderer) label(en, th string) string {
+	if r.thai {
+		return en + " / " + th
+	}
+	return en
+}
+
+// date is date part of timestamp text
+func date(timestamp string) string {
+	if len(timestamp) < 10 {
+		return timestamp
+	}
+	return timestamp[:10]
+}
+
+func amount(m *money.Money) string {
+	if m == nil {
+		return "-"
+	}
+	return m.Number()
+}
+
+// render draw the document of order, it is a tax invoice or a receipt of the same order
+func (u *documentsUsecase) render(document *documents.Document, order *orders.Order, font *pdf.Font) ([]byte, error) {
+	r := &renderer{
+		doc:      pdf.New(font),
+		thai:     font != nil,
+		document: document,
+		order:    order,
+	}
+	r.doc.AddPage()
+	r.header(u)
+	r.parties()
+	r.itemsHeader()
+	for i, item := range order.Items {
+		r.item(i+1, item)
+	}
+	r.totals()
+	return r.doc.Bytes()
+}
+
+func (r *renderer) header(u *documentsUsecase) {
+	cfg := u.cfg.Document()
+
+	r.doc.Text(marginX, 60, 16, cfg.CompanyName())
+	y := 78.0
+	lines := make([]string, 0)
+	for _, line := range strings.Split(cfg.CompanyAddress(), "|") {
+		lines = append(lines, strings.TrimSpace(line))
+	}
+	if cfg.CompanyTaxId() != "" {
+		lines = append(lines, r.label("Tax ID", "เลขประจำตัวผู้เสียภาษี")+": "+cfg.CompanyTaxId())
+	}
+	contacts := make([]string, 0)
+	for _, c := range []string{cfg.CompanyPhone(), cfg.CompanyEmail()} {
+		if c != "" {
+			contacts = append(contacts, c)
+		}
+	}
+	lines = append(lines, strings.Join(contacts, "  "))
+	for _, line := range lines {
+		if line == "" {
+			continue
+		}
+		r.doc.Text(marginX, y, 9, r.doc.Fit(line, 9, 300))
+		y += 12
+	}
+
+	title := r.label("TAX INVOICE", "ใบกำกับภาษี")
+	if r.document.Type == documents.Receipt {
+		title = r.label("RECEIPT", "ใบเสร็จรับเงิน")
+	}
+	r.doc.TextRight(rightX, 60, 14, title)
+
+	details := [][2]string{
+		{r.label("No.", "เลขที่"), r.document.Number},
+		{r.label("Date", "วันที่"), date(r.document.CreatedAt)},
+		{r.label("Order", "คำสั่งซื้อ"), r.order.Id},
+	}
+	if r.document.Type == documents.Receipt {
+		for _, h := range r.order.History {
+			if h.ToStatus == "paid" {
+				details = append(details, [2]string{r.label("Paid on", "วันที่ชำระเงิน"), date(h.CreatedAt)})
+				break
+			}
+		}
+	}
+	dy := 78.0
+	for _, d := range details {
+		r.doc.TextRight(rightX-90, dy, 9, d[0])
+		r.doc.TextRight(rightX, dy, 9, d[1])
+		dy += 12
+	}
+
+	r.y = max(y, dy) + 12
+	r.doc.Line(marginX, r.y, rightX, r.y, 0.5)
+	r.y += 20
+}
+
+// addressLines is recipient and address, or contact and address of order placed without address book
+func (r *renderer) addressLines(address *addresses.Address, width float64) []string {
+	lines := make([]string, 0)
+	if address == nil {
+		lines = append(lines, r.order.Contact)
+		return append(lines, r.doc.Wrap(r.order.Address, 9, width)...)
+	}
+	lines = append(lines, address.Contact())
+	return append(lines, r.doc.Wrap(address.Format(), 9, width)...)
+}
+
+// parties draw bill to and ship to side by side
+func (r *renderer) parties() {
+	width := (rightX-marginX)/2 - 20
+	columns := []struct {
+		title string
+		lines []string
+	}{
+		{r.label("Bill to", "ลูกค้า"), r.addressLines(r.order.BillingAddress, width)},
+		{r.label("Ship to", "จัดส่งที่"), r.addressLines(r.order.ShippingAddress, width)},
+	}
+	bottom := r.y
+	for i, col := range columns {
+		x := marginX + float64(i)*(width+40)
+		r.doc.Text(x, r.y, 10, col.title)
+		y := r.y + lineHeight
+		for _, line := range col.lines {
+			r.doc.Text(x, y, 9, line)
+			y += 12
+		}
+		bottom = max(bottom, y)
+	}
+	r.y = bottom + 12
+}
+
+// itemsHeader is English, with Thai on the second line when the font has it
+func (r *renderer) itemsHeader() {
+	height := 18.0
+	if r.thai {
+		height += 10
+	}
+	r.doc.FillRect(marginX, r.y-12, rightX-marginX, height, 0.9)
+	for i, col := range itemColumns {
+		texts := []string{col.en}
+		if r.thai {
+			texts = append(texts, col.th)
+		}
+		if i == len(itemColumns)-1 {
+			texts[0] += " (" + r.order.Currency + ")"
+		}
+		for j, text := range texts {
+			if col.right {
+				r.doc.TextRight(col.x, r.y+float64(j)*10, 8, text)
+			} else {
+				r.doc.Text(col.x, r.y+float64(j)*10, 8, text)
+			}
+		}
+	}
+	r.y += height + 2
+}
+
+// newPageIf start a new page when height doesn't fit, the table header is drawn again when table is true
+func (r *renderer) newPageIf(height float64, table bool) {
+	if r.y+height <= bottomY {
+		return
+	}
+	r.doc.AddPage()
+	r.y = 60
+	if table {
+		r.itemsHeader()
+	}
+}
+
+func (r *renderer) item(no int, item *orders.OrderItem) {
+	title := item.ProductId
+	if item.Product != nil && item.Product.Title != "" {
+		title = item.Product.Title
+	}
+	lines := r.doc.Wrap(title, 9, itemColumns[2].x-itemColumns[1].x-50)
+	if len(lines) == 0 {
+		lines = []string{""}
+	}
+	r.newPageIf(float64(len(lines))*12, true)
+
+	discount := "-"
+	if item.Discount != nil && item.Discount.Amount > 0 {
+		discount = amount(item.Discount)
+	}
+	r.doc.Text(itemColumns[0].x, r.y, 9, strconv.Itoa(no))
+	r.doc.TextRight(itemColumns[2].x, r.y, 9, strconv.Itoa(item.Quantity))
+	r.doc.TextRight(itemColumns[3].x, r.y, 9, amount(item.UnitPrice))
+	r.doc.TextRight(itemColumns[4].x, r.y, 9, discount)
+	r.doc.TextRight(itemColumns[5].x, r.y, 9, amount(item.LineTotal))
+	for _, line := range lines {
+		r.doc.Text(itemColumns[1].x, r.y, 9, line)
+		r.y += 12
+	}
+	r.y += 4
+	r.doc.Line(marginX, r.y-10, rightX, r.y-10, 0.25)
+}
+
+// totals is subtotal, discount, shipping, tax and total, tax is a part of total when prices include tax
+func (r *renderer) totals() {
+	o := r.order
+	rows := [][2]string{
+		{r.label("Subtotal", "รวมเป็นเงิน"), amount(o.Subtotal)},
+	}
+	if o.Discount != nil && o.Discount.Amount > 0 {
+		label := r.label("Discount", "ส่วนลด")
+		if o.CouponCode != "" {
+			label += " (" + o.CouponCode + ")"
+		}
+		rows = append(rows, [2]string{label, "-" + amount(o.Discount)})
+	}
+	if o.ShippingMethodId != "" {
+		rows = append(rows, [2]string{r.label("Shipping", "ค่าจัดส่ง") + " (" + o.ShippingMethod + ")", amount(o.Shipping)})
+	}
+	taxLabel := r.label("VAT", "ภาษีมูลค่าเพิ่ม")
+	if o.TaxInclusive {
+		taxLabel += " (" + r.label("included", "รวมในราคาแล้ว") + ")"
+	}
+	rows = append(rows, [2]string{taxLabel, amount(o.Tax)})
+
+	r.newPageIf(float64(len(rows)+2)*lineHeight+20, false)
+	r.y += 8
+	for _, row := range rows {
+		r.doc.TextRight(rightX-100, r.y, 9, r.doc.Fit(row[0], 9, 250))
+		r.doc.TextRight(rightX-4, r.y, 9, row[1])
+		r.y += lineHeight
+	}
+	r.doc.Line(rightX-300, r.y-8, rightX, r.y-8, 0.5)
+	r.y += 4
+	r.doc.TextRight(rightX-100, r.y, 11, r.label("Total", "จำนวนเงินทั้งสิ้น")+" ("+o.Currency+")")
+	r.doc.TextRight(rightX-4, r.y, 11, amount(o.Total))
+	r.y += lineHeight
+
+	if o.Status == "refunded" {
+		r.y += lineHeight
+		r.doc.Text(marginX, r.y, 10, r.label("This order has been refunded", "คำสั่งซื้อนี้ได้รับการคืนเงินแล้ว"))
+	}
+}
@@ -10,6 +10,6 @@ type PaginationReq struct {
 }
 
 type SortReq struct {
-	OrderBy string `query"order_by"`
-	Sort    string `query"sort"` //DESC ASC
+	OrderBy string `query:"order_by"`
+	Sort    string `query:"sort"` //DESC ASC
 }
@@ -1,7 +1,11 @@
 package entities
 
+// Image fields from the first version keep their Go names on the wire, clients depend on them
 type Image struct {
-	Id       string `db:"id" json: "id"`
-	FileName string `db:"filename" json: "filename"`
-	Url      string `db:"url" json: "url"`
+	Id        string `db:"id" json:"Id"`
+	FileName  string `db:"filename" json:"FileName"`
+	Url       string `db:"url" json:"Url"`
+	Position  int    `db:"position" json:"position"` // images of product are listed by position
+	Alt       string `db:"alt" json:"alt"`
+	IsPrimary bool   `db:"is_primary" json:"is_primary"`
 }
@@ -16,6 +16,8 @@ import (
 type IFilesUsecases interface {
 	UploadToGCP(req []*files.FileReq) ([]*files.FileRes, error)
 	DeleteFileOnGCP(req []*files.DeleteFileReq) error
+	UploadPrivate(destination, contentType string, data []byte) error
+	Download(destination string) ([]byte, error)
 }
 
 type filesUsecase struct {
@@ -191,3 +193,49 @@ func (u *filesUsecase) DeleteFileOnGCP(req []*files.DeleteFileReq) error {
 
 	return nil
 }
+
+// UploadPrivate upload data without public access, it is read back by Download only
+func (u *filesUsecase) UploadPrivate(destination, contentType string, data []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*60)
+	defer cancel()
+
+	client, err := storage.NewClient(ctx)
+	if err != nil {
+		return fmt.Errorf("storage.NewClient: %v", err)
+	}
+	defer client.Close()
+
+	wc := client.Bucket(u.cfg.App().Gcpbucket()).Object(destination).NewWriter(ctx)
+	wc.ContentType = contentType
+	if _, err := io.Copy(wc, bytes.NewReader(data)); err != nil {
+		return fmt.Errorf("io.Copy: %v", err)
+	}
+	if err := wc.Close(); err != nil {
+		return fmt.Errorf("Writer.Close: %v", err)
+	}
+	fmt.Printf("Blob %v uploaded.\n", destination)
+	return nil
+}
+
+func (u *filesUsecase) Download(destination string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*60)
+	defer cancel()
+
+	client, err := storage.NewClient(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("storage.NewClient: %v", err)
+	}
+	defer client.Close()
+
+	rc, err := client.Bucket(u.cfg.App().Gcpbucket()).Object(destination).NewReader(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("Object(%q).NewReader: %v", destination, err)
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		return nil, fmt.Errorf("io.ReadAll: %v", err)
+	}
+	return data, nil
+}
@@ -28,6 +28,7 @@ type ImiddlewareHandler interface {
 	RouterCheck() fiber.Handler
 	Logger() fiber.Handler
 	JwtAuth() fiber.Handler
+	OptionalJwtAuth() fiber.Handler
 	ParamsCheck() fiber.Handler
 	Autorize(expectRoleId ...int) fiber.Handler
 	ApiKeyAuth() fiber.Handler
@@ -52,7 +53,7 @@ func (handler *middlewaresHandler) Cors() fiber.Handler {
 		AllowMethods:     "GET,POST,HEAD,PUT,DELETE,PATCH",
 		AllowHeaders:     "",
 		AllowCredentials: false,
-		ExposeHeaders:    "",
+		ExposeHeaders:    "ETag, X-Cart-Token",
 		MaxAge:           0,
 	})
 }
@@ -103,6 +104,17 @@ func (h *middlewaresHandler) JwtAuth() fiber.Handler {
 	}
 }
 
+// OptionalJwtAuth is JwtAuth for public routes, request without Authorization header is anonymous
+func (h *middlewaresHandler) OptionalJwtAuth() fiber.Handler {
+	jwtAuth := h.JwtAuth()
+	return func(c *fiber.Ctx) error {
+		if c.Get("Authorization") == "" {
+			return c.Next()
+		}
+		return jwtAuth(c)
+	}
+}
+
 func (h *middlewaresHandler) ParamsCheck() fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		userId := c.Locals("userId")
@@ -0,0 +1,125 @@
+package orders
+
+import (
+	"github.com/DrumPatiphon/go-rest-api-service/modules/addresses"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/entities"
+	"github.com/DrumPatiphon/go-rest-api-service/pkg/money"
+)
+
+type Order struct {
+	Id               string                `json:"id"`
+	UserId           string                `json:"user_id"`
+	Contact          string                `json:"contact"`
+	Address          string                `json:"address"`
+	Status           string                `json:"status"`
+	Currency         string                `json:"currency"`
+	Subtotal         *money.Money          `json:"subtotal"`
+	Discount         *money.Money          `json:"discount"`
+	Tax              *money.Money          `json:"tax"`
+	TaxInclusive     bool                  `json:"tax_inclusive"`
+	Shipping         *money.Money          `json:"shipping"`
+	ShippingMethodId string                `json:"shipping_method_id,omitempty"`
+	ShippingMethod   string                `json:"shipping_method,omitempty"` // title when the order is placed
+	Total            *money.Money          `json:"total"`                     // subtotal - discount + shipping, + tax when it is not inclusive
+	Country          string                `json:"country"`                   // tax zone of shipping address
+	Region           string                `json:"region"`
+	ShippingAddress  *addresses.Address    `json:"shipping_address,omitempty"` // snapshot of address book
+	BillingAddress   *addresses.Address    `json:"billing_address,omitempty"`
+	CouponId         string                `json:"-"`
+	CouponCode       string                `json:"coupon_code,omitempty"`
+	Items            []*OrderItem          `json:"items"`
+	History          []*OrderStatusHistory `json:"history,omitempty"`
+	CreatedAt        string                `json:"created_at"`
+	UpdatedAt        string                `json:"updated_at"`
+}
+
+// OrderItem is a snapshot when the order is placed, later change of product is not applied
+type OrderItem struct {
+	Id        string        `json:"id"`
+	ProductId string        `json:"product_id"`
+	Product   *OrderProduct `json:"product"`
+	Quantity  int           `json:"quantity"`
+	UnitPrice *money.Money  `json:"unit_price"`
+	LineTotal *money.Money  `json:"line_total"`
+	Discount  *money.Money  `json:"discount"` // share of coupon discount
+	TaxRate   string        `json:"tax_rate"` // percent
+	Tax       *money.Money  `json:"tax"`
+}
+
+type OrderProduct struct {
+	Id       string `json:"id"`
+	Title    string `json:"title"`
+	Slug     string `json:"slug"`
+	ImageUrl string `json:"image_url"`
+}
+
+type OrderStatusHistory struct {
+	Id         string `json:"id"`
+	FromStatus string `json:"from_status"`
+	ToStatus   string `json:"to_status"`
+	Note       string `json:"note"`
+	UserId     string `json:"user_id"`
+	CreatedAt  string `json:"created_at"`
+}
+
+type InsertOrderReq struct {
+	Contact string `json:"contact" form:"contact"`
+	Address string `json:"address" form:"address"`
+	Country string `json:"country" form:"country"` // tax zone, TAX_COUNTRY when empty
+	Region  string `json:"region" form:"region"`
+	// ShippingMethodId is one of rates of the cart, nothing is shipped when it is empty
+	ShippingMethodId string `json:"shipping_method_id" form:"shipping_method_id"`
+	// ShippingAddressId replace contact, address, country and region, default shipping address is used when address is empty
+	ShippingAddressId string `json:"shipping_address_id" form:"shipping_address_id"`
+	// BillingAddressId is default billing address, or the shipping address when it is empty
+	BillingAddressId string `json:"billing_address_id" form:"billing_address_id"`
+}
+
+type TransitionReq struct {
+	Status string `json:"status" form:"status"`
+	Note   string `json:"note" form:"note"`
+}
+
+type OrderFilter struct {
+	Search                  string `query:"search"` // id or contact
+	Status                  string `query:"status"`
+	UserId                  string `query:"user_id"`
+	StartDate               string `query:"start_date"`
+	EndDate                 string `query:"end_date"`
+	*entities.PaginationReq        // like inherit class
+}
+
+// transitions is every status an order can go to from a status, cancelled and refunded are final
+var transitions = map[string][]string{
+	"pending":   {"paid", "cancelled"},
+	"paid":      {"fulfilled", "cancelled", "refunded"},
+	"fulfilled": {"shipped", "refunded"},
+	"shipped":   {"delivered"},
+	"delivered": {"refunded"},
+	"cancelled": {},
+	"refunded":  {},
+}
+
+func IsStatus(status string) bool {
+	_, ok := transitions[status]
+	return ok
+}
+
+func CanTransition(from, to string) bool {
+	for _, s := range transitions[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Compute set line totals from snapshot unit prices
+func (obj *Order) Compute() {
+	for _, item := range obj.Items {
+		if item.UnitPrice == nil {
+			continue
+		}
+		item.LineTotal = money.New(item.UnitPrice.Amount*int64(item.Quantity), obj.Currency)
+	}
+}
@@ -0,0 +1,294 @@
+package ordersHandlers
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/DrumPatiphon/go-rest-api-service/config"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/entities"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/orders"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/orders/ordersUsecases"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/payments/paymentsUsecases"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/taxes"
+	"github.com/gofiber/fiber/v2"
+)
+
+type ordersHandlerErrCode string
+
+const (
+	insertOrderErr     ordersHandlerErrCode = "orders-001"
+	findMyOrdersErr    ordersHandlerErrCode = "orders-002"
+	findOneOrderErr    ordersHandlerErrCode = "orders-003"
+	findOrdersErr      ordersHandlerErrCode = "orders-004"
+	transitionOrderErr ordersHandlerErrCode = "orders-005"
+	cancelOrderErr     ordersHandlerErrCode = "orders-006"
+)
+
+type IOrdersHandler interface {
+	InsertOrder(c *fiber.Ctx) error
+	FindMyOrders(c *fiber.Ctx) error
+	FindOneOrder(c *fiber.Ctx) error
+	FindOrders(c *fiber.Ctx) error
+	TransitionOrder(c *fiber.Ctx) error
+	CancelOrder(c *fiber.Ctx) error
+}
+
+type ordersHandler struct {
+	cfg             config.Iconfig
+	ordersUsecase   ordersUsecases.IOrdersUsecase
+	paymentsUsecase paymentsUsecases.IPaymentsUsecase
+}
+
+func OrdersHandler(cfg config.Iconfig, ordersUsecase ordersUsecases.IOrdersUsecase, paymentsUsecase paymentsUsecases.IPaymentsUsecase) IOrdersHandler {
+	return &ordersHandler{
+		cfg:             cfg,
+		ordersUsecase:   ordersUsecase,
+		paymentsUsecase: paymentsUsecase,
+	}
+}
+
+func (h *ordersHandler) InsertOrder(c *fiber.Ctx) error {
+	req := new(orders.InsertOrderReq)
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(insertOrderErr),
+			err.Error(),
+		).Res()
+	}
+	// contact and address can be from address book, they are checked by usecase
+	req.Contact = strings.TrimSpace(req.Contact)
+	req.Address = strings.TrimSpace(req.Address)
+	req.ShippingAddressId = strings.TrimSpace(req.ShippingAddressId)
+	req.BillingAddressId = strings.TrimSpace(req.BillingAddressId)
+
+	// tax zone of shipping address
+	address := &taxes.Address{Country: req.Country, Region: req.Region}
+	if err := address.Normalize(); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(insertOrderErr),
+			err.Error(),
+		).Res()
+	}
+	req.Country, req.Region = address.Country, address.Region
+	req.ShippingMethodId = strings.TrimSpace(req.ShippingMethodId)
+
+	userId, _ := c.Locals("userId").(string)
+	order, err := h.ordersUsecase.InsertOrder(userId, req)
+	if err != nil {
+		switch {
+		case err.Error() == "cart is empty",
+			err.Error() == "contact and address are required",
+			err.Error() == "address not found",
+			err.Error() == "cart has stale items",
+			err.Error() == "product is out of stock",
+			strings.HasPrefix(err.Error(), "coupon can't be applied"),
+			strings.HasPrefix(err.Error(), "shipping method is not available"):
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(insertOrderErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(insertOrderErr),
+				err.Error(),
+			).Res()
+		}
+	}
+	return entities.NewResponse(c).Success(fiber.StatusCreated, order).Res()
+}
+
+// parseFilter parse query and check status, page and limit get default value
+func parseFilter(c *fiber.Ctx) (*orders.OrderFilter, error) {
+	req := &orders.OrderFilter{
+		PaginationReq: &entities.PaginationReq{},
+	}
+	if err := c.QueryParser(req); err != nil {
+		return nil, err
+	}
+	req.Status = strings.TrimSpace(req.Status)
+	if req.Status != "" && !orders.IsStatus(req.Status) {
+		return nil, fmt.Errorf("status is invalid")
+	}
+	req.Search = strings.TrimSpace(req.Search)
+	if req.Page < 1 {
+		req.Page = 1
+	}
+	if req.Limit < 5 {
+		req.Limit = 5
+	}
+	return req, nil
+}
+
+// FindMyOrders return orders of the signed in user only
+func (h *ordersHandler) FindMyOrders(c *fiber.Ctx) error {
+	req, err := parseFilter(c)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(findMyOrdersErr),
+			err.Error(),
+		).Res()
+	}
+	req.UserId, _ = c.Locals("userId").(string)
+
+	ordersData, err := h.ordersUsecase.FindOrders(req)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(findMyOrdersErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, ordersData).Res()
+}
+
+// FindOneOrder customer can only see own order, admin can see every order
+func (h *ordersHandler) FindOneOrder(c *fiber.Ctx) error {
+	orderId := strings.TrimSpace(c.Params("order_id"))
+
+	order, err := h.ordersUsecase.FindOneOrder(orderId)
+	if err != nil {
+		switch err.Error() {
+		case "order not found":
+			return entities.NewResponse(c).Error(
+				fiber.ErrNotFound.Code,
+				string(findOneOrderErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(findOneOrderErr),
+				err.Error(),
+			).Res()
+		}
+	}
+
+	userId, _ := c.Locals("userId").(string)
+	userRoleId, _ := c.Locals("userRoleId").(int)
+	if order.UserId != userId && userRoleId != 2 {
+		return entities.NewResponse(c).Error(
+			fiber.ErrNotFound.Code,
+			string(findOneOrderErr),
+			"order not found",
+		).Res()
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, order).Res()
+}
+
+func (h *ordersHandler) FindOrders(c *fiber.Ctx) error {
+	req, err := parseFilter(c)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(findOrdersErr),
+			err.Error(),
+		).Res()
+	}
+
+	ordersData, err := h.ordersUsecase.FindOrders(req)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(findOrdersErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, ordersData).Res()
+}
+
+func (h *ordersHandler) TransitionOrder(c *fiber.Ctx) error {
+	orderId := strings.TrimSpace(c.Params("order_id"))
+	req := new(orders.TransitionReq)
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(transitionOrderErr),
+			err.Error(),
+		).Res()
+	}
+	req.Status = strings.TrimSpace(req.Status)
+	if !orders.IsStatus(req.Status) {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(transitionOrderErr),
+			"status is invalid",
+		).Res()
+	}
+
+	userId, _ := c.Locals("userId").(string)
+	return h.transitionOrder(c, orderId, req, userId, transitionOrderErr)
+}
+
+// CancelOrder customer can cancel own order before it is paid, paid order is refunded by admin
+func (h *ordersHandler) CancelOrder(c *fiber.Ctx) error {
+	orderId := strings.TrimSpace(c.Params("order_id"))
+	userId, _ := c.Locals("userId").(string)
+
+	order, err := h.ordersUsecase.FindOneOrder(orderId)
+	if err != nil || order.UserId != userId {
+		return entities.NewResponse(c).Error(
+			fiber.ErrNotFound.Code,
+			string(cancelOrderErr),
+			"order not found",
+		).Res()
+	}
+	if order.Status != "pending" {
+		return entities.NewResponse(c).Error(
+			fiber.ErrConflict.Code,
+			string(cancelOrderErr),
+			fmt.Sprintf("order can't be changed from %s to cancelled", order.Status),
+		).Res()
+	}
+
+	req := &orders.TransitionReq{
+		Status: "cancelled",
+		Note:   "cancelled by customer",
+	}
+	return h.transitionOrder(c, orderId, req, userId, cancelOrderErr)
+}
+
+// changeStatus cancel or refund paid order by payments, so the money is refunded with the status change
+func (h *ordersHandler) changeStatus(orderId string, req *orders.TransitionReq, userId string) (*orders.Order, error) {
+	if req.Status == "cancelled" || req.Status == "refunded" {
+		order, err := h.ordersUsecase.FindOneOrder(orderId)
+		if err != nil {
+			return nil, err
+		}
+		if order.Status != "pending" {
+			return h.paymentsUsecase.RefundOrder(orderId, req, userId)
+		}
+	}
+	return h.ordersUsecase.TransitionOrder(orderId, req, userId)
+}
+
+func (h *ordersHandler) transitionOrder(c *fiber.Ctx, orderId string, req *orders.TransitionReq, userId string, code ordersHandlerErrCode) error {
+	order, err := h.changeStatus(orderId, req, userId)
+	if err != nil {
+		switch {
+		case err.Error() == "order not found":
+			return entities.NewResponse(c).Error(
+				fiber.ErrNotFound.Code,
+				string(code),
+				err.Error(),
+			).Res()
+		case strings.HasPrefix(err.Error(), "order can't be changed"):
+			return entities.NewResponse(c).Error(
+				fiber.ErrConflict.Code,
+				string(code),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(code),
+				err.Error(),
+			).Res()
+		}
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, order).Res()
+}
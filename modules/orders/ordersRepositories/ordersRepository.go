@@ -0,0 +1,490 @@
+package ordersRepositories
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/DrumPatiphon/go-rest-api-service/modules/addresses"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/orders"
+	"github.com/jmoiron/sqlx"
+)
+
+type IOrdersRepository interface {
+	InsertOrder(req *orders.Order, cartItemIds []string) (string, error)
+	FindOneOrder(orderId string) (*orders.Order, error)
+	FindOrders(req *orders.OrderFilter) ([]*orders.Order, int, error)
+	TransitionOrder(orderId string, req *orders.TransitionReq, userId string) error
+}
+
+type ordersRepository struct {
+	db *sqlx.DB
+}
+
+func OrdersRepository(db *sqlx.DB) IOrdersRepository {
+	return &ordersRepository{
+		db: db,
+	}
+}
+
+// orderColumns is columns of order in json, history is only added when finding one order
+const orderColumns = `
+			"o"."id",
+			"o"."user_id",
+			"o"."contact",
+			"o"."address",
+			"o"."status",
+			"o"."currency",
+			jsonb_build_object('currency', "o"."currency", 'minor_units', "o"."subtotal") AS "subtotal",
+			jsonb_build_object('currency', "o"."currency", 'minor_units', "o"."discount") AS "discount",
+			jsonb_build_object('currency', "o"."currency", 'minor_units', "o"."tax") AS "tax",
+			"o"."tax_inclusive",
+			jsonb_build_object('currency', "o"."currency", 'minor_units', "o"."shipping") AS "shipping",
+			COALESCE("o"."shipping_method_id"::TEXT, '') AS "shipping_method_id",
+			COALESCE("o"."shipping_method", '') AS "shipping_method",
+			jsonb_build_object('currency', "o"."currency", 'minor_units', "o"."total") AS "total",
+			COALESCE("o"."country", '') AS "country",
+			COALESCE("o"."region", '') AS "region",
+			"o"."shipping_address",
+			"o"."billing_address",
+			COALESCE("o"."coupon_code", '') AS "coupon_code",
+			(
+				SELECT
+					COALESCE(array_to_json(array_agg("it")), '[]'::json)
+				FROM (
+					SELECT
+						"po"."id",
+						COALESCE("po"."product_id", '') AS "product_id",
+						"po"."product",
+						"po"."qty" AS "quantity",
+						jsonb_build_object('currency', "o"."currency", 'minor_units', "po"."unit_amount") AS "unit_price",
+						jsonb_build_object('currency', "o"."currency", 'minor_units', "po"."discount_amount") AS "discount",
+						"po"."tax_rate"::TEXT AS "tax_rate",
+						jsonb_build_object('currency', "o"."currency", 'minor_units', "po"."tax_amount") AS "tax"
+					FROM "products_orders" "po"
+					WHERE "po"."order_id" = "o"."id"
+					ORDER BY "po"."id"
+				) AS "it"
+			) AS "items",
+			"o"."created_at",
+			"o"."updated_at"`
+
+func jsonOrNil(address *addresses.Address) (any, error) {
+	if address == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal(address)
+	if err != nil {
+		return nil, fmt.Errorf("marshal address failed: %v", err)
+	}
+	return string(b), nil
+}
+
+// InsertOrder insert order with snapshot of lines, first history and remove ordered items from cart in one transaction
+func (r *ordersRepository) InsertOrder(req *orders.Order, cartItemIds []string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*15)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return "", err
+	}
+
+	// snapshot of address book, NULL when the address isn't from it
+	shippingAddress, err := jsonOrNil(req.ShippingAddress)
+	if err != nil {
+		tx.Rollback()
+		return "", err
+	}
+	billingAddress, err := jsonOrNil(req.BillingAddress)
+	if err != nil {
+		tx.Rollback()
+		return "", err
+	}
+
+	orderQuery := `
+	INSERT INTO "orders" (
+		"user_id",
+		"contact",
+		"address",
+		"status",
+		"currency",
+		"subtotal",
+		"discount",
+		"total",
+		"coupon_code",
+		"tax",
+		"tax_inclusive",
+		"country",
+		"region",
+		"shipping_method_id",
+		"shipping_method",
+		"shipping",
+		"shipping_address",
+		"billing_address",
+		"stock_reserved"
+	)
+	VALUES ($1, $2, $3, 'pending', $4, $5, $6, $7, NULLIF($8, ''), $9, $10, NULLIF($11, ''), NULLIF($12, ''), NULLIF($13, '')::uuid, NULLIF($14, ''), $15, $16::JSONB, $17::JSONB, TRUE)
+		RETURNING "id";`
+
+	if err := tx.QueryRowContext(
+		ctx,
+		orderQuery,
+		req.UserId,
+		req.Contact,
+		req.Address,
+		req.Currency,
+		req.Subtotal.Amount,
+		req.Discount.Amount,
+		req.Total.Amount,
+		req.CouponCode,
+		req.Tax.Amount,
+		req.TaxInclusive,
+		req.Country,
+		req.Region,
+		req.ShippingMethodId,
+		req.ShippingMethod,
+		req.Shipping.Amount,
+		shippingAddress,
+		billingAddress,
+	).Scan(&req.Id); err != nil {
+		tx.Rollback()
+		return "", fmt.Errorf("insert order failed: %v", err)
+	}
+
+	productIds := make([]string, 0)
+	snapshots := make([]string, 0)
+	quantities := make([]int64, 0)
+	amounts := make([]int64, 0)
+	discounts := make([]int64, 0)
+	taxRates := make([]string, 0)
+	taxAmounts := make([]int64, 0)
+	for _, item := range req.Items {
+		snapshot, err := json.Marshal(item.Product)
+		if err != nil {
+			tx.Rollback()
+			return "", fmt.Errorf("marshal product snapshot failed: %v", err)
+		}
+		productIds = append(productIds, item.ProductId)
+		snapshots = append(snapshots, string(snapshot))
+		quantities = append(quantities, int64(item.Quantity))
+		amounts = append(amounts, item.UnitPrice.Amount)
+		if item.Discount != nil {
+			discounts = append(discounts, item.Discount.Amount)
+		} else {
+			discounts = append(discounts, 0)
+		}
+		if item.Tax != nil {
+			taxRates = append(taxRates, item.TaxRate)
+			taxAmounts = append(taxAmounts, item.Tax.Amount)
+		} else {
+			taxRates = append(taxRates, "0")
+			taxAmounts = append(taxAmounts, 0)
+		}
+	}
+
+	itemsQuery := `
+	INSERT INTO "products_orders" (
+		"order_id",
+		"product_id",
+		"product",
+		"qty",
+		"unit_amount",
+		"discount_amount",
+		"tax_rate",
+		"tax_amount"
+	)
+	SELECT $1, UNNEST($2::VARCHAR[]), UNNEST($3::TEXT[])::jsonb, UNNEST($4::INT[]), UNNEST($5::BIGINT[]), UNNEST($6::BIGINT[]), UNNEST($7::TEXT[])::NUMERIC, UNNEST($8::BIGINT[]);`
+
+	if _, err := tx.ExecContext(ctx, itemsQuery, req.Id, productIds, snapshots, quantities, amounts, discounts, taxRates, taxAmounts); err != nil {
+		tx.Rollback()
+		return "", fmt.Errorf("insert order items failed: %v", err)
+	}
+
+	// take stock of tracked products, check constraint fail when there is not enough.
+	// Version is bumped so the product ETag changes with its stock
+	stockQuery := `
+	UPDATE "products" "p" SET
+		"stock" = "p"."stock" - "l"."qty",
+		"version" = "p"."version" + 1
+	FROM (
+		SELECT UNNEST($1::VARCHAR[]) AS "product_id", UNNEST($2::INT[]) AS "qty"
+	) AS "l"
+	WHERE "p"."id" = "l"."product_id"
+	AND "p"."stock" IS NOT NULL;`
+
+	if _, err := tx.ExecContext(ctx, stockQuery, productIds, quantities); err != nil {
+		tx.Rollback()
+		if strings.Contains(err.Error(), "products_stock_check") {
+			return "", fmt.Errorf("product is out of stock")
+		}
+		return "", fmt.Errorf("reserve stock failed: %v", err)
+	}
+
+	if req.CouponId != "" {
+		if err := useCoupon(ctx, tx, req); err != nil {
+			tx.Rollback()
+			return "", err
+		}
+	}
+
+	if err := insertHistory(ctx, tx, req.Id, "", "pending", "", req.UserId); err != nil {
+		tx.Rollback()
+		return "", err
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM "carts_items" WHERE "id"::TEXT = ANY($1::TEXT[]);`, cartItemIds); err != nil {
+		tx.Rollback()
+		return "", fmt.Errorf("clear cart failed: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		tx.Rollback()
+		return "", err
+	}
+	return req.Id, nil
+}
+
+// useCoupon record usage of coupon, coupon is locked so usage limits hold when orders are placed at the same time
+func useCoupon(ctx context.Context, tx *sqlx.Tx, req *orders.Order) error {
+	if _, err := tx.ExecContext(ctx, `SELECT 1 FROM "coupons" WHERE "id"::TEXT = $1 FOR UPDATE;`, req.CouponId); err != nil {
+		return fmt.Errorf("lock coupon failed: %v", err)
+	}
+
+	query := `
+	SELECT
+		COALESCE("c"."usage_limit" - (SELECT COUNT(*) FROM "coupons_usages" "cu" WHERE "cu"."coupon_id" = "c"."id"), 1) > 0
+		AND COALESCE("c"."usage_limit_per_user" - (SELECT COUNT(*) FROM "coupons_usages" "cu" WHERE "cu"."coupon_id" = "c"."id" AND "cu"."user_id" = $2), 1) > 0
+	FROM "coupons" "c"
+	WHERE "c"."id"::TEXT = $1;`
+
+	var available bool
+	if err := tx.GetContext(ctx, &available, query, req.CouponId, req.UserId); err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("coupon can't be applied: coupon not found")
+		}
+		return fmt.Errorf("check coupon failed: %v", err)
+	}
+	if !available {
+		return fmt.Errorf("coupon can't be applied: coupon has been used up")
+	}
+
+	usageQuery := `
+	INSERT INTO "coupons_usages" (
+		"coupon_id",
+		"user_id",
+		"order_id",
+		"discount"
+	)
+	VALUES ($1::uuid, $2, $3, $4);`
+
+	if _, err := tx.ExecContext(ctx, usageQuery, req.CouponId, req.UserId, req.Id, req.Discount.Amount); err != nil {
+		return fmt.Errorf("insert coupon usage failed: %v", err)
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE "carts" SET "coupon_id" = NULL WHERE "user_id" = $1;`, req.UserId); err != nil {
+		return fmt.Errorf("clear cart coupon failed: %v", err)
+	}
+	return nil
+}
+
+// insertHistory record a status change, empty from is the first status
+func insertHistory(ctx context.Context, tx *sqlx.Tx, orderId, from, to, note, userId string) error {
+	query := `
+	INSERT INTO "orders_status_history" (
+		"order_id",
+		"from_status",
+		"to_status",
+		"note",
+		"user_id"
+	)
+	VALUES ($1, NULLIF($2, ''), $3, $4, NULLIF($5, ''));`
+
+	if _, err := tx.ExecContext(ctx, query, orderId, from, to, note, userId); err != nil {
+		return fmt.Errorf("insert order history failed: %v", err)
+	}
+	return nil
+}
+
+func (r *ordersRepository) FindOneOrder(orderId string) (*orders.Order, error) {
+	query := `
+	SELECT
+		to_jsonb("t")
+	FROM (
+		SELECT` + orderColumns + `,
+			(
+				SELECT
+					COALESCE(array_to_json(array_agg("ht")), '[]'::json)
+				FROM (
+					SELECT
+						"h"."id",
+						COALESCE("h"."from_status", '') AS "from_status",
+						"h"."to_status",
+						"h"."note",
+						COALESCE("h"."user_id", '') AS "user_id",
+						"h"."created_at"
+					FROM "orders_status_history" "h"
+					WHERE "h"."order_id" = "o"."id"
+					ORDER BY "h"."created_at", "h"."id"
+				) AS "ht"
+			) AS "history"
+		FROM "orders" "o"
+		WHERE "o"."id" = $1
+	) AS "t";`
+
+	orderBytes := make([]byte, 0)
+	order := new(orders.Order)
+
+	if err := r.db.Get(&orderBytes, query, orderId); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("order not found")
+		}
+		return nil, fmt.Errorf("get order failed: %v", err)
+	}
+	if err := json.Unmarshal(orderBytes, order); err != nil {
+		return nil, fmt.Errorf("unmarshal order failed: %v", err)
+	}
+	return order, nil
+}
+
+func (r *ordersRepository) FindOrders(req *orders.OrderFilter) ([]*orders.Order, int, error) {
+	queryWhere := `
+		WHERE 1 = 1`
+	values := make([]any, 0)
+
+	if req.UserId != "" {
+		values = append(values, req.UserId)
+		queryWhere += `
+		AND "o"."user_id" = $` + strconv.Itoa(len(values))
+	}
+	if req.Status != "" {
+		values = append(values, req.Status)
+		queryWhere += `
+		AND "o"."status" = $` + strconv.Itoa(len(values))
+	}
+	if req.Search != "" {
+		values = append(values, "%"+req.Search+"%")
+		queryWhere += `
+		AND ("o"."id" ILIKE $` + strconv.Itoa(len(values)) + ` OR "o"."contact" ILIKE $` + strconv.Itoa(len(values)) + `)`
+	}
+	if req.StartDate != "" {
+		values = append(values, req.StartDate)
+		queryWhere += `
+		AND "o"."created_at" >= $` + strconv.Itoa(len(values)) + `::DATE`
+	}
+	if req.EndDate != "" {
+		values = append(values, req.EndDate)
+		queryWhere += `
+		AND "o"."created_at" < $` + strconv.Itoa(len(values)) + `::DATE + 1`
+	}
+
+	countQuery := `
+	SELECT
+		COUNT(*)
+	FROM "orders" "o"` + queryWhere
+
+	var count int
+	if err := r.db.Get(&count, countQuery, values...); err != nil {
+		return nil, 0, fmt.Errorf("count orders failed: %v", err)
+	}
+
+	values = append(values, (req.Page-1)*req.Limit, req.Limit)
+	query := fmt.Sprintf(`
+	SELECT
+		COALESCE(array_to_json(array_agg("t")), '[]'::json)
+	FROM (
+		SELECT%s
+		FROM "orders" "o"%s
+		ORDER BY "o"."created_at" DESC, "o"."id" DESC
+		OFFSET $%d LIMIT $%d
+	) AS "t";`, orderColumns, queryWhere, len(values)-1, len(values))
+
+	ordersBytes := make([]byte, 0)
+	ordersData := make([]*orders.Order, 0)
+
+	if err := r.db.Get(&ordersBytes, query, values...); err != nil {
+		return nil, 0, fmt.Errorf("find orders failed: %v", err)
+	}
+	if err := json.Unmarshal(ordersBytes, &ordersData); err != nil {
+		return nil, 0, fmt.Errorf("unmarshal orders failed: %v", err)
+	}
+	return ordersData, count, nil
+}
+
+// TransitionOrder lock the order so two transitions from the same status can't both pass
+func (r *ordersRepository) TransitionOrder(orderId string, req *orders.TransitionReq, userId string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*15)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := Transition(ctx, tx, orderId, req, userId); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return nil
+}
+
+// Transition change status of order inside tx of the caller, so money can move in the same transaction.
+// Cancelled order give stock and coupon usage back
+func Transition(ctx context.Context, tx *sqlx.Tx, orderId string, req *orders.TransitionReq, userId string) error {
+	var from string
+	var stockReserved bool
+	if err := tx.QueryRowxContext(ctx, `SELECT "status", "stock_reserved" FROM "orders" WHERE "id" = $1 FOR UPDATE;`, orderId).Scan(&from, &stockReserved); err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("order not found")
+		}
+		return fmt.Errorf("get order failed: %v", err)
+	}
+	if !orders.CanTransition(from, req.Status) {
+		return fmt.Errorf("order can't be changed from %s to %s", from, req.Status)
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE "orders" SET "status" = $1 WHERE "id" = $2;`, req.Status, orderId); err != nil {
+		return fmt.Errorf("update order status failed: %v", err)
+	}
+	if err := insertHistory(ctx, tx, orderId, from, req.Status, req.Note, userId); err != nil {
+		return err
+	}
+
+	if req.Status == "cancelled" {
+		if stockReserved {
+			if err := releaseStock(ctx, tx, orderId); err != nil {
+				return err
+			}
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM "coupons_usages" WHERE "order_id" = $1;`, orderId); err != nil {
+			return fmt.Errorf("release coupon usage failed: %v", err)
+		}
+	}
+	return nil
+}
+
+// releaseStock give stock taken by the order back to tracked products, their version is bumped like on checkout
+func releaseStock(ctx context.Context, tx *sqlx.Tx, orderId string) error {
+	query := `
+	UPDATE "products" "p" SET
+		"stock" = "p"."stock" + "po"."qty",
+		"version" = "p"."version" + 1
+	FROM "products_orders" "po"
+	WHERE "po"."order_id" = $1
+	AND "p"."id" = "po"."product_id"
+	AND "p"."stock" IS NOT NULL;`
+
+	if _, err := tx.ExecContext(ctx, query, orderId); err != nil {
+		return fmt.Errorf("release stock failed: %v", err)
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE "orders" SET "stock_reserved" = FALSE WHERE "id" = $1;`, orderId); err != nil {
+		return fmt.Errorf("release stock failed: %v", err)
+	}
+	return nil
+}
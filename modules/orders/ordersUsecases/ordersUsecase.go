@@ -0,0 +1,192 @@
+package ordersUsecases
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/DrumPatiphon/go-rest-api-service/modules/addresses"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/addresses/addressesUsecases"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/carts"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/carts/cartsUsecases"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/entities"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/orders"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/orders/ordersRepositories"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/shippingMethods"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/shippingMethods/shippingMethodsUsecases"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/taxes"
+	"github.com/DrumPatiphon/go-rest-api-service/pkg/money"
+)
+
+type IOrdersUsecase interface {
+	InsertOrder(userId string, req *orders.InsertOrderReq) (*orders.Order, error)
+	FindOneOrder(orderId string) (*orders.Order, error)
+	FindOrders(req *orders.OrderFilter) (*entities.PageRes, error)
+	TransitionOrder(orderId string, req *orders.TransitionReq, userId string) (*orders.Order, error)
+}
+
+type ordersUsecase struct {
+	ordersRepository       ordersRepositories.IOrdersRepository
+	cartsUsecase           cartsUsecases.ICartsUsecase
+	shippingMethodsUsecase shippingMethodsUsecases.IShippingMethodsUsecase
+	addressesUsecase       addressesUsecases.IAddressesUsecase
+}
+
+func OrdersUsecase(ordersRepository ordersRepositories.IOrdersRepository, cartsUsecase cartsUsecases.ICartsUsecase, shippingMethodsUsecase shippingMethodsUsecases.IShippingMethodsUsecase, addressesUsecase addressesUsecases.IAddressesUsecase) IOrdersUsecase {
+	return &ordersUsecase{
+		ordersRepository:       ordersRepository,
+		cartsUsecase:           cartsUsecase,
+		shippingMethodsUsecase: shippingMethodsUsecase,
+		addressesUsecase:       addressesUsecase,
+	}
+}
+
+// pickAddresses fill contact, address, country and region of req from address book.
+// Billing address is the default billing address, or the shipping address
+func (u *ordersUsecase) pickAddresses(userId string, req *orders.InsertOrderReq) (*addresses.Address, *addresses.Address, error) {
+	var shippingAddress *addresses.Address
+	if req.ShippingAddressId != "" || req.Address == "" {
+		address, err := u.addressesUsecase.PickAddress(userId, req.ShippingAddressId, addresses.Shipping)
+		if err != nil {
+			return nil, nil, err
+		}
+		shippingAddress = address
+	}
+	if shippingAddress != nil {
+		if req.Contact == "" {
+			req.Contact = shippingAddress.Contact()
+		}
+		req.Address = shippingAddress.Format()
+		req.Country = shippingAddress.Country
+		req.Region = shippingAddress.Region
+	}
+	if req.Contact == "" || req.Address == "" {
+		return nil, nil, fmt.Errorf("contact and address are required")
+	}
+
+	billingAddress, err := u.addressesUsecase.PickAddress(userId, req.BillingAddressId, addresses.Billing)
+	if err != nil {
+		return nil, nil, err
+	}
+	if billingAddress == nil {
+		billingAddress = shippingAddress
+	}
+	return shippingAddress, billingAddress, nil
+}
+
+// InsertOrder place order from cart of user, stale cart must be reviewed before
+func (u *ordersUsecase) InsertOrder(userId string, req *orders.InsertOrderReq) (*orders.Order, error) {
+	shippingAddress, billingAddress, err := u.pickAddresses(userId, req)
+	if err != nil {
+		return nil, err
+	}
+
+	cart, err := u.cartsUsecase.FindCart(&carts.CartOwner{UserId: userId}, &taxes.Address{
+		Country: req.Country,
+		Region:  req.Region,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(cart.Items) == 0 {
+		return nil, fmt.Errorf("cart is empty")
+	}
+	if cart.HasStale {
+		return nil, fmt.Errorf("cart has stale items")
+	}
+	if cart.Coupon != nil && !cart.Coupon.Applied {
+		return nil, fmt.Errorf("coupon can't be applied: %s", cart.Coupon.Message)
+	}
+
+	order := &orders.Order{
+		UserId:          userId,
+		Contact:         req.Contact,
+		Address:         req.Address,
+		Currency:        cart.Currency,
+		Subtotal:        cart.Subtotal,
+		Discount:        cart.Discount,
+		Tax:             cart.Tax,
+		TaxInclusive:    cart.TaxInclusive,
+		Shipping:        money.New(0, cart.Currency),
+		Total:           cart.Total,
+		Country:         cart.TaxAddress.Country,
+		Region:          cart.TaxAddress.Region,
+		ShippingAddress: shippingAddress,
+		BillingAddress:  billingAddress,
+		CouponId:        cart.CouponId,
+		Items:           make([]*orders.OrderItem, 0),
+	}
+	if req.ShippingMethodId != "" {
+		rate, err := u.shippingMethodsUsecase.Quote(cart, &shippingMethods.RatesReq{
+			Country: cart.TaxAddress.Country,
+			Region:  cart.TaxAddress.Region,
+		}, req.ShippingMethodId)
+		if err != nil {
+			return nil, err
+		}
+		order.ShippingMethodId = rate.MethodId
+		order.ShippingMethod = rate.Title
+		order.Shipping = rate.Price
+		order.Total = money.New(cart.Total.Amount+rate.Price.Amount, cart.Currency)
+	}
+	if cart.Coupon != nil {
+		order.CouponCode = cart.Coupon.Code
+	}
+	cartItemIds := make([]string, 0)
+	for _, item := range cart.Items {
+		order.Items = append(order.Items, &orders.OrderItem{
+			ProductId: item.ProductId,
+			Product: &orders.OrderProduct{
+				Id:       item.ProductId,
+				Title:    item.Title,
+				Slug:     item.Slug,
+				ImageUrl: item.ImageUrl,
+			},
+			Quantity:  item.Quantity,
+			UnitPrice: item.UnitPrice,
+			Discount:  item.Discount,
+			TaxRate:   item.TaxRate,
+			Tax:       item.Tax,
+		})
+		cartItemIds = append(cartItemIds, item.Id)
+	}
+
+	orderId, err := u.ordersRepository.InsertOrder(order, cartItemIds)
+	if err != nil {
+		return nil, err
+	}
+	return u.FindOneOrder(orderId)
+}
+
+func (u *ordersUsecase) FindOneOrder(orderId string) (*orders.Order, error) {
+	order, err := u.ordersRepository.FindOneOrder(orderId)
+	if err != nil {
+		return nil, err
+	}
+	order.Compute()
+	return order, nil
+}
+
+func (u *ordersUsecase) FindOrders(req *orders.OrderFilter) (*entities.PageRes, error) {
+	ordersData, count, err := u.ordersRepository.FindOrders(req)
+	if err != nil {
+		return nil, err
+	}
+	for _, order := range ordersData {
+		order.Compute()
+	}
+
+	return &entities.PageRes{
+		Data:       ordersData,
+		Page:       req.Page,
+		Limit:      req.Limit,
+		TotalItems: count,
+		TotalPage:  int(math.Ceil(float64(count) / float64(req.Limit))),
+	}, nil
+}
+
+func (u *ordersUsecase) TransitionOrder(orderId string, req *orders.TransitionReq, userId string) (*orders.Order, error) {
+	if err := u.ordersRepository.TransitionOrder(orderId, req, userId); err != nil {
+		return nil, err
+	}
+	return u.FindOneOrder(orderId)
+}
@@ -0,0 +1,77 @@
+package orders
+
+import "testing"
+
+func TestIsStatus(t *testing.T) {
+	tests := []struct {
+		status string
+		want   bool
+	}{
+		{status: "pending", want: true},
+		{status: "paid", want: true},
+		{status: "fulfilled", want: true},
+		{status: "shipped", want: true},
+		{status: "delivered", want: true},
+		{status: "cancelled", want: true},
+		{status: "refunded", want: true},
+		{status: "", want: false},
+		{status: "PAID", want: false},
+		{status: "returned", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.status, func(t *testing.T) {
+			if got := IsStatus(tt.status); got != tt.want {
+				t.Errorf("IsStatus(%q) = %v, want %v", tt.status, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCanTransition(t *testing.T) {
+	tests := []struct {
+		from string
+		to   string
+		want bool
+	}{
+		{from: "pending", to: "paid", want: true},
+		{from: "pending", to: "cancelled", want: true},
+		{from: "pending", to: "refunded", want: false},
+		{from: "pending", to: "shipped", want: false},
+		{from: "paid", to: "fulfilled", want: true},
+		{from: "paid", to: "cancelled", want: true},
+		{from: "paid", to: "refunded", want: true},
+		{from: "paid", to: "pending", want: false},
+		{from: "fulfilled", to: "shipped", want: true},
+		{from: "fulfilled", to: "refunded", want: true},
+		{from: "fulfilled", to: "cancelled", want: false},
+		{from: "shipped", to: "delivered", want: true},
+		{from: "shipped", to: "refunded", want: false},
+		{from: "delivered", to: "refunded", want: true},
+		{from: "delivered", to: "shipped", want: false},
+		{from: "cancelled", to: "pending", want: false},
+		{from: "cancelled", to: "paid", want: false},
+		{from: "refunded", to: "paid", want: false},
+		{from: "paid", to: "paid", want: false},
+		{from: "unknown", to: "paid", want: false},
+		{from: "pending", to: "unknown", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.from+"_"+tt.to, func(t *testing.T) {
+			if got := CanTransition(tt.from, tt.to); got != tt.want {
+				t.Errorf("CanTransition(%q, %q) = %v, want %v", tt.from, tt.to, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTransitionsAreStatuses(t *testing.T) {
+	for from, tos := range transitions {
+		for _, to := range tos {
+			if !IsStatus(to) {
+				t.Errorf("status %s can go to unknown status %s", from, to)
+			}
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package payments
+
+import (
+	"github.com/DrumPatiphon/go-rest-api-service/modules/orders"
+	"github.com/DrumPatiphon/go-rest-api-service/pkg/money"
+)
+
+type Payment struct {
+	Id             string         `json:"id"`
+	OrderId        string         `json:"order_id"`
+	Provider       string         `json:"provider"`
+	IntentId       string         `json:"intent_id"`
+	Status         string         `json:"status"` // processing, requires_capture, succeeded, declined, refunded
+	Amount         *money.Money   `json:"amount"`
+	RefundedAmount *money.Money   `json:"refunded_amount"`
+	Payload        map[string]any `json:"payload,omitempty"` // from provider for client to complete the payment
+	CreatedAt      string         `json:"created_at"`
+	UpdatedAt      string         `json:"updated_at"`
+}
+
+type CheckoutReq struct {
+	Contact string `json:"contact" form:"contact"`
+	Address string `json:"address" form:"address"`
+	Country string `json:"country" form:"country"` // tax zone, TAX_COUNTRY when empty
+	Region  string `json:"region" form:"region"`
+	// ShippingMethodId is one of rates of the cart, nothing is shipped when it is empty
+	ShippingMethodId string `json:"shipping_method_id" form:"shipping_method_id"`
+	// ShippingAddressId and BillingAddressId are from address book, like InsertOrderReq
+	ShippingAddressId string `json:"shipping_address_id" form:"shipping_address_id"`
+	BillingAddressId  string `json:"billing_address_id" form:"billing_address_id"`
+	Scenario          string `json:"scenario" form:"scenario"` // fake provider only: success, decline, async, ignored on others
+}
+
+type CheckoutRes struct {
+	Order   *orders.Order `json:"order"`
+	Payment *Payment      `json:"payment"`
+}
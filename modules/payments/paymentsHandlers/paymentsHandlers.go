@@ -0,0 +1,129 @@
+package paymentsHandlers
+
+import (
+	"strings"
+
+	"github.com/DrumPatiphon/go-rest-api-service/config"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/entities"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/payments"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/payments/paymentsUsecases"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/taxes"
+	"github.com/gofiber/fiber/v2"
+)
+
+type paymentsHandlerErrCode string
+
+const (
+	checkoutErr paymentsHandlerErrCode = "payments-001"
+	webhookErr  paymentsHandlerErrCode = "payments-002"
+)
+
+// SignatureHeader is the header of webhook signature
+const SignatureHeader = "X-Payment-Signature"
+
+type IPaymentsHandler interface {
+	Checkout(c *fiber.Ctx) error
+	Webhook(c *fiber.Ctx) error
+}
+
+type paymentsHandler struct {
+	cfg             config.Iconfig
+	paymentsUsecase paymentsUsecases.IPaymentsUsecase
+}
+
+func PaymentsHandler(cfg config.Iconfig, paymentsUsecase paymentsUsecases.IPaymentsUsecase) IPaymentsHandler {
+	return &paymentsHandler{
+		cfg:             cfg,
+		paymentsUsecase: paymentsUsecase,
+	}
+}
+
+func (h *paymentsHandler) Checkout(c *fiber.Ctx) error {
+	req := new(payments.CheckoutReq)
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(checkoutErr),
+			err.Error(),
+		).Res()
+	}
+	// contact and address can be from address book, they are checked by usecase
+	req.Contact = strings.TrimSpace(req.Contact)
+	req.Address = strings.TrimSpace(req.Address)
+	req.ShippingAddressId = strings.TrimSpace(req.ShippingAddressId)
+	req.BillingAddressId = strings.TrimSpace(req.BillingAddressId)
+
+	// tax zone of shipping address
+	address := &taxes.Address{Country: req.Country, Region: req.Region}
+	if err := address.Normalize(); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(checkoutErr),
+			err.Error(),
+		).Res()
+	}
+	req.Country, req.Region = address.Country, address.Region
+	req.ShippingMethodId = strings.TrimSpace(req.ShippingMethodId)
+
+	userId, _ := c.Locals("userId").(string)
+	res, err := h.paymentsUsecase.Checkout(userId, req)
+	if err != nil {
+		switch {
+		case err.Error() == "payment declined":
+			return entities.NewResponse(c).Error(
+				fiber.StatusPaymentRequired,
+				string(checkoutErr),
+				err.Error(),
+			).Res()
+		case err.Error() == "cart is empty",
+			err.Error() == "contact and address are required",
+			err.Error() == "address not found",
+			err.Error() == "cart has stale items",
+			err.Error() == "product is out of stock",
+			strings.HasPrefix(err.Error(), "coupon can't be applied"),
+			strings.HasPrefix(err.Error(), "shipping method is not available"),
+			strings.HasPrefix(err.Error(), "scenario must be"):
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(checkoutErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(checkoutErr),
+				err.Error(),
+			).Res()
+		}
+	}
+	return entities.NewResponse(c).Success(fiber.StatusCreated, res).Res()
+}
+
+// Webhook is called by provider, it is trusted by signature instead of api key
+func (h *paymentsHandler) Webhook(c *fiber.Ctx) error {
+	provider := strings.TrimSpace(c.Params("provider"))
+
+	if err := h.paymentsUsecase.Webhook(provider, c.Body(), c.Get(SignatureHeader)); err != nil {
+		switch {
+		case err.Error() == "provider not found", err.Error() == "payment not found":
+			return entities.NewResponse(c).Error(
+				fiber.ErrNotFound.Code,
+				string(webhookErr),
+				err.Error(),
+			).Res()
+		case err.Error() == "signature is invalid", strings.HasPrefix(err.Error(), "event is invalid"):
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(webhookErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(webhookErr),
+				err.Error(),
+			).Res()
+		}
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, nil).Res()
+}
@@ -0,0 +1,289 @@
+package paymentsRepositories
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/DrumPatiphon/go-rest-api-service/modules/orders"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/orders/ordersRepositories"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/payments"
+	"github.com/DrumPatiphon/go-rest-api-service/pkg/payment"
+	"github.com/jmoiron/sqlx"
+)
+
+type IPaymentsRepository interface {
+	InsertPayment(req *payments.Payment) error
+	FindOnePayment(provider, intentId string) (*payments.Payment, error)
+	FindOrderPayment(orderId string) (*payments.Payment, error)
+	UpdatePaymentStatus(paymentId, status string) error
+	ReserveRefund(paymentId string, amount int64) (int64, error)
+	ReleaseRefund(paymentId string, amount int64) error
+	AddRefund(paymentId string, amount int64, orderId string, transition *orders.TransitionReq, onlyLast bool, userId string) error
+	HandleEvent(provider string, event *payment.Event, paymentData *payments.Payment, status string, transition *orders.TransitionReq) (bool, error)
+}
+
+type paymentsRepository struct {
+	db *sqlx.DB
+}
+
+func PaymentsRepository(db *sqlx.DB) IPaymentsRepository {
+	return &paymentsRepository{
+		db: db,
+	}
+}
+
+func (r *paymentsRepository) InsertPayment(req *payments.Payment) error {
+	query := `
+	INSERT INTO "payments" (
+		"order_id",
+		"provider",
+		"intent_id",
+		"status",
+		"amount",
+		"currency"
+	)
+	VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING "id", "created_at"::TEXT, "updated_at"::TEXT;`
+
+	if err := r.db.QueryRowContext(
+		context.Background(),
+		query,
+		req.OrderId,
+		req.Provider,
+		req.IntentId,
+		req.Status,
+		req.Amount.Amount,
+		req.Amount.Currency,
+	).Scan(&req.Id, &req.CreatedAt, &req.UpdatedAt); err != nil {
+		return fmt.Errorf("insert payment failed: %v", err)
+	}
+	return nil
+}
+
+const paymentColumns = `
+			"p"."id",
+			"p"."order_id",
+			"p"."provider",
+			"p"."intent_id",
+			"p"."status",
+			jsonb_build_object('currency', "p"."currency", 'minor_units', "p"."amount") AS "amount",
+			jsonb_build_object('currency', "p"."currency", 'minor_units', "p"."refunded_amount") AS "refunded_amount",
+			"p"."created_at",
+			"p"."updated_at"`
+
+func (r *paymentsRepository) FindOnePayment(provider, intentId string) (*payments.Payment, error) {
+	query := `
+	SELECT
+		to_jsonb("t")
+	FROM (
+		SELECT` + paymentColumns + `
+		FROM "payments" "p"
+		WHERE "p"."provider" = $1
+		AND "p"."intent_id" = $2
+	) AS "t";`
+
+	return r.getPayment(query, provider, intentId)
+}
+
+// FindOrderPayment return the payment which took the money of order, refunded in part or not yet
+func (r *paymentsRepository) FindOrderPayment(orderId string) (*payments.Payment, error) {
+	query := `
+	SELECT
+		to_jsonb("t")
+	FROM (
+		SELECT` + paymentColumns + `
+		FROM "payments" "p"
+		WHERE "p"."order_id" = $1
+		AND "p"."status" = 'succeeded'
+		ORDER BY "p"."created_at" DESC
+		LIMIT 1
+	) AS "t";`
+
+	return r.getPayment(query, orderId)
+}
+
+func (r *paymentsRepository) getPayment(query string, args ...any) (*payments.Payment, error) {
+	paymentBytes := make([]byte, 0)
+	paymentData := new(payments.Payment)
+
+	if err := r.db.Get(&paymentBytes, query, args...); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("payment not found")
+		}
+		return nil, fmt.Errorf("get payment failed: %v", err)
+	}
+	if err := json.Unmarshal(paymentBytes, paymentData); err != nil {
+		return nil, fmt.Errorf("unmarshal payment failed: %v", err)
+	}
+	return paymentData, nil
+}
+
+// UpdatePaymentStatus refunded status refund the whole amount
+func (r *paymentsRepository) UpdatePaymentStatus(paymentId, status string) error {
+	query := `
+	UPDATE "payments" SET
+		"status" = $2,
+		"refunded_amount" = CASE WHEN $2 = 'refunded' THEN "amount" ELSE "refunded_amount" END
+	WHERE "id"::TEXT = $1;`
+
+	result, err := r.db.ExecContext(context.Background(), query, paymentId, status)
+	if err != nil {
+		return fmt.Errorf("update payment status failed: %v", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("payment not found")
+	}
+	return nil
+}
+
+// ReserveRefund hold amount of the payment before it is sent to provider, 0 is all that is left.
+// The payment row is locked so refunds at the same time can't reserve more than paid, it return the reserved amount
+func (r *paymentsRepository) ReserveRefund(paymentId string, amount int64) (int64, error) {
+	query := `
+	WITH "p" AS (
+		SELECT
+			"id",
+			"amount" - "refunded_amount" - "pending_refund_amount" AS "remaining"
+		FROM "payments"
+		WHERE "id"::TEXT = $1
+		AND "status" = 'succeeded'
+		FOR UPDATE
+	)
+	UPDATE "payments" SET
+		"pending_refund_amount" = "payments"."pending_refund_amount" + (CASE WHEN $2 > 0 THEN $2 ELSE "p"."remaining" END)
+	FROM "p"
+	WHERE "payments"."id" = "p"."id"
+	AND "p"."remaining" > 0
+	AND $2 <= "p"."remaining"
+	RETURNING CASE WHEN $2 > 0 THEN $2 ELSE "p"."remaining" END;`
+
+	var reserved int64
+	if err := r.db.Get(&reserved, query, paymentId, amount); err != nil {
+		if err == sql.ErrNoRows {
+			return 0, fmt.Errorf("refund is more than paid")
+		}
+		return 0, fmt.Errorf("reserve payment refund failed: %v", err)
+	}
+	return reserved, nil
+}
+
+// ReleaseRefund give back amount reserved for a refund the provider did not take
+func (r *paymentsRepository) ReleaseRefund(paymentId string, amount int64) error {
+	query := `
+	UPDATE "payments" SET
+		"pending_refund_amount" = "pending_refund_amount" - $2
+	WHERE "id"::TEXT = $1;`
+
+	if _, err := r.db.ExecContext(context.Background(), query, paymentId, amount); err != nil {
+		return fmt.Errorf("release payment refund failed: %v", err)
+	}
+	return nil
+}
+
+// AddRefund move reserved amount to refunded amount and change status of order in one transaction, transition is nil
+// to keep the status. With onlyLast the order is changed only when all of the payment is refunded.
+// The payment is refunded when all of it is refunded
+func (r *paymentsRepository) AddRefund(paymentId string, amount int64, orderId string, transition *orders.TransitionReq, onlyLast bool, userId string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*15)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	query := `
+	UPDATE "payments" SET
+		"refunded_amount" = "refunded_amount" + $2,
+		"pending_refund_amount" = "pending_refund_amount" - $2,
+		"status" = CASE WHEN "refunded_amount" + $2 = "amount" THEN 'refunded' ELSE "status" END
+	WHERE "id"::TEXT = $1
+	AND "pending_refund_amount" >= $2
+	RETURNING "status";`
+
+	var status string
+	if err := tx.GetContext(ctx, &status, query, paymentId, amount); err != nil {
+		tx.Rollback()
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("refund is not reserved")
+		}
+		return fmt.Errorf("update payment refund failed: %v", err)
+	}
+
+	if transition != nil && (!onlyLast || status == "refunded") {
+		if err := ordersRepositories.Transition(ctx, tx, orderId, transition, userId); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return nil
+}
+
+// HandleEvent save event and change status of payment and order in one transaction, the saved event is the gate
+// so an event handled before change nothing. Payment is changed only while waiting for the result and
+// order is changed only with it, order which can't be changed anymore is kept as it is.
+// It return true when payment is changed
+func (r *paymentsRepository) HandleEvent(provider string, event *payment.Event, paymentData *payments.Payment, status string, transition *orders.TransitionReq) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*15)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+
+	query := `
+	INSERT INTO "payments_events" (
+		"provider",
+		"event_id",
+		"type",
+		"intent_id"
+	)
+	VALUES ($1, $2, $3, $4)
+	ON CONFLICT ("provider", "event_id") DO NOTHING;`
+
+	result, err := tx.ExecContext(ctx, query, provider, event.Id, event.Type, event.IntentId)
+	if err != nil {
+		tx.Rollback()
+		return false, fmt.Errorf("insert payment event failed: %v", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		tx.Rollback()
+		return false, nil
+	}
+
+	query = `
+	UPDATE "payments" SET
+		"status" = $2
+	WHERE "id"::TEXT = $1
+	AND "status" IN ('processing', 'requires_capture');`
+
+	result, err = tx.ExecContext(ctx, query, paymentData.Id, status)
+	if err != nil {
+		tx.Rollback()
+		return false, fmt.Errorf("update payment status failed: %v", err)
+	}
+	changed, _ := result.RowsAffected()
+
+	if changed > 0 && transition != nil {
+		if err := ordersRepositories.Transition(ctx, tx, paymentData.OrderId, transition, ""); err != nil && !strings.HasPrefix(err.Error(), "order can't be changed") {
+			tx.Rollback()
+			return false, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		tx.Rollback()
+		return false, err
+	}
+	return changed > 0, nil
+}
@@ -0,0 +1,286 @@
+package paymentsUsecases
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/DrumPatiphon/go-rest-api-service/modules/orders"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/orders/ordersUsecases"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/payments"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/payments/paymentsRepositories"
+	"github.com/DrumPatiphon/go-rest-api-service/pkg/money"
+	"github.com/DrumPatiphon/go-rest-api-service/pkg/payment"
+)
+
+type IPaymentsUsecase interface {
+	Checkout(userId string, req *payments.CheckoutReq) (*payments.CheckoutRes, error)
+	Webhook(provider string, body []byte, signature string) error
+	Refund(orderId string, amount *money.Money, note, userId string) (*payment.Refund, error)
+	RefundOrder(orderId string, req *orders.TransitionReq, userId string) (*orders.Order, error)
+}
+
+type paymentsUsecase struct {
+	paymentsRepository paymentsRepositories.IPaymentsRepository
+	ordersUsecase      ordersUsecases.IOrdersUsecase
+	provider           payment.Provider
+}
+
+func PaymentsUsecase(paymentsRepository paymentsRepositories.IPaymentsRepository, ordersUsecase ordersUsecases.IOrdersUsecase, provider payment.Provider) IPaymentsUsecase {
+	return &paymentsUsecase{
+		paymentsRepository: paymentsRepository,
+		ordersUsecase:      ordersUsecase,
+		provider:           provider,
+	}
+}
+
+// Checkout place order from cart which reserve stock, then create intent of provider.
+// Success is captured and paid now, async wait for webhook, decline cancel the order and give stock back
+func (u *paymentsUsecase) Checkout(userId string, req *payments.CheckoutReq) (*payments.CheckoutRes, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
+	defer cancel()
+
+	order, err := u.ordersUsecase.InsertOrder(userId, &orders.InsertOrderReq{
+		Contact:           req.Contact,
+		Address:           req.Address,
+		Country:           req.Country,
+		Region:            req.Region,
+		ShippingMethodId:  req.ShippingMethodId,
+		ShippingAddressId: req.ShippingAddressId,
+		BillingAddressId:  req.BillingAddressId,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// client choose the result only on the fake provider
+	scenario := ""
+	if u.provider.Name() == "fake" {
+		scenario = req.Scenario
+	}
+	intent, err := u.provider.CreateIntent(ctx, &payment.IntentReq{
+		Reference: order.Id,
+		Amount:    order.Total.Amount,
+		Currency:  order.Currency,
+		Scenario:  scenario,
+	})
+	if err != nil {
+		u.cancelOrder(order.Id, "create payment failed")
+		return nil, err
+	}
+
+	paymentData := &payments.Payment{
+		OrderId:        order.Id,
+		Provider:       u.provider.Name(),
+		IntentId:       intent.Id,
+		Status:         intent.Status,
+		Amount:         money.New(order.Total.Amount, order.Currency),
+		RefundedAmount: money.New(0, order.Currency),
+		Payload:        intent.Payload,
+	}
+	if err := u.paymentsRepository.InsertPayment(paymentData); err != nil {
+		u.cancelOrder(order.Id, "save payment failed")
+		return nil, err
+	}
+
+	switch intent.Status {
+	case payment.StatusDeclined:
+		u.cancelOrder(order.Id, "payment declined")
+		return nil, fmt.Errorf("payment declined")
+	case payment.StatusRequiresCapture:
+		captured, err := u.provider.Capture(ctx, intent.Id)
+		if err != nil {
+			if err := u.paymentsRepository.UpdatePaymentStatus(paymentData.Id, payment.StatusDeclined); err != nil {
+				log.Printf("update payment %s failed: %v", paymentData.Id, err)
+			}
+			u.cancelOrder(order.Id, "capture payment failed")
+			return nil, fmt.Errorf("capture payment failed: %v", err)
+		}
+		if err := u.paymentsRepository.UpdatePaymentStatus(paymentData.Id, captured.Status); err != nil {
+			return nil, err
+		}
+		paymentData.Status = captured.Status
+		if err := u.markPaid(paymentData); err != nil {
+			return nil, err
+		}
+	}
+
+	order, err = u.ordersUsecase.FindOneOrder(order.Id)
+	if err != nil {
+		return nil, err
+	}
+	return &payments.CheckoutRes{
+		Order:   order,
+		Payment: paymentData,
+	}, nil
+}
+
+// Webhook is idempotent, event is saved with the change it make so event handled before is ignored
+func (u *paymentsUsecase) Webhook(provider string, body []byte, signature string) error {
+	if provider != u.provider.Name() {
+		return fmt.Errorf("provider not found")
+	}
+	event, err := u.provider.VerifyWebhook(body, signature)
+	if err != nil {
+		return err
+	}
+
+	paymentData, err := u.paymentsRepository.FindOnePayment(provider, event.IntentId)
+	if err != nil {
+		return err
+	}
+
+	switch event.Type {
+	case payment.EventSucceeded:
+		changed, err := u.paymentsRepository.HandleEvent(provider, event, paymentData, payment.StatusSucceeded, &orders.TransitionReq{
+			Status: "paid",
+			Note:   fmt.Sprintf("paid by %s %s", paymentData.Provider, paymentData.IntentId),
+		})
+		if err != nil || !changed {
+			return err
+		}
+		paymentData.Status = payment.StatusSucceeded
+		return u.refundCancelled(paymentData)
+	case payment.EventFailed:
+		_, err := u.paymentsRepository.HandleEvent(provider, event, paymentData, payment.StatusDeclined, &orders.TransitionReq{
+			Status: "cancelled",
+			Note:   "payment failed",
+		})
+		return err
+	}
+	return nil
+}
+
+// markPaid move order to paid, order paid by an earlier event is fine.
+// Money of an order cancelled while waiting for payment is refunded
+func (u *paymentsUsecase) markPaid(paymentData *payments.Payment) error {
+	_, err := u.ordersUsecase.TransitionOrder(paymentData.OrderId, &orders.TransitionReq{
+		Status: "paid",
+		Note:   fmt.Sprintf("paid by %s %s", paymentData.Provider, paymentData.IntentId),
+	}, "")
+	if err == nil || !strings.HasPrefix(err.Error(), "order can't be changed") {
+		return err
+	}
+	return u.refundCancelled(paymentData)
+}
+
+// refundCancelled give money back when the order is cancelled while waiting for payment
+func (u *paymentsUsecase) refundCancelled(paymentData *payments.Payment) error {
+	order, err := u.ordersUsecase.FindOneOrder(paymentData.OrderId)
+	if err != nil {
+		return err
+	}
+	if order.Status != "cancelled" {
+		return nil
+	}
+
+	// nothing left is refunded already
+	amount, err := u.paymentsRepository.ReserveRefund(paymentData.Id, 0)
+	if err != nil {
+		if err.Error() == "refund is more than paid" {
+			return nil
+		}
+		return err
+	}
+	refund, err := u.sendRefund(paymentData, amount)
+	if err != nil {
+		return fmt.Errorf("refund payment of cancelled order failed: %v", err)
+	}
+	if err := u.paymentsRepository.AddRefund(paymentData.Id, amount, paymentData.OrderId, nil, false, ""); err != nil {
+		return fmt.Errorf("refund %s is sent but not saved: %v", refund.Id, err)
+	}
+	return nil
+}
+
+// Refund give amount of the payment of order back, it can be a part of it.
+// The order is refunded with the last part of the payment when its status can be refunded
+func (u *paymentsUsecase) Refund(orderId string, amount *money.Money, note, userId string) (*payment.Refund, error) {
+	return u.refund(orderId, amount, &orders.TransitionReq{Status: "refunded", Note: note}, false, userId)
+}
+
+// RefundOrder cancel or refund a paid order, what is left of its payment is refunded with the status change.
+// Order paid without provider, like by transfer slip, is only changed
+func (u *paymentsUsecase) RefundOrder(orderId string, req *orders.TransitionReq, userId string) (*orders.Order, error) {
+	if _, err := u.refund(orderId, nil, req, true, userId); err != nil {
+		if err.Error() != "payment not found" {
+			return nil, err
+		}
+		if _, err := u.ordersUsecase.TransitionOrder(orderId, req, userId); err != nil {
+			return nil, err
+		}
+	}
+	return u.ordersUsecase.FindOneOrder(orderId)
+}
+
+// refund send amount back by provider, nil amount is all that is left. The order take transition with the refund
+// when always is true, or when it is the last part of the payment and the order can take it.
+// Amount is reserved on the payment first and settled after provider take it
+func (u *paymentsUsecase) refund(orderId string, amount *money.Money, transition *orders.TransitionReq, always bool, userId string) (*payment.Refund, error) {
+	order, err := u.ordersUsecase.FindOneOrder(orderId)
+	if err != nil {
+		return nil, err
+	}
+	canTransition := orders.CanTransition(order.Status, transition.Status)
+	if always && !canTransition {
+		return nil, fmt.Errorf("order can't be changed from %s to %s", order.Status, transition.Status)
+	}
+
+	paymentData, err := u.paymentsRepository.FindOrderPayment(orderId)
+	if err != nil {
+		return nil, err
+	}
+	var requested int64
+	if amount != nil {
+		if amount.Currency != paymentData.Amount.Currency {
+			return nil, fmt.Errorf("refund currency must be %s", paymentData.Amount.Currency)
+		}
+		if amount.Amount <= 0 {
+			return nil, fmt.Errorf("refund is more than paid")
+		}
+		requested = amount.Amount
+	}
+	if !always && !canTransition {
+		transition = nil
+	}
+
+	// reserved before sending, so refunds at the same time can't send more than paid to provider
+	reserved, err := u.paymentsRepository.ReserveRefund(paymentData.Id, requested)
+	if err != nil {
+		return nil, err
+	}
+	refund, err := u.sendRefund(paymentData, reserved)
+	if err != nil {
+		return nil, fmt.Errorf("refund payment failed: %v", err)
+	}
+	if err := u.paymentsRepository.AddRefund(paymentData.Id, reserved, orderId, transition, !always, userId); err != nil {
+		return nil, fmt.Errorf("refund %s is sent but not saved: %v", refund.Id, err)
+	}
+	return refund, nil
+}
+
+// sendRefund send reserved amount to provider, the reservation is released when provider refuse it
+func (u *paymentsUsecase) sendRefund(paymentData *payments.Payment, amount int64) (*payment.Refund, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
+	defer cancel()
+
+	refund, err := u.provider.Refund(ctx, paymentData.IntentId, amount)
+	if err != nil {
+		if err := u.paymentsRepository.ReleaseRefund(paymentData.Id, amount); err != nil {
+			log.Printf("release refund of payment %s failed: %v", paymentData.Id, err)
+		}
+		return nil, err
+	}
+	return refund, nil
+}
+
+// cancelOrder give stock back when payment can't go on, the error is only logged as the caller already failed
+func (u *paymentsUsecase) cancelOrder(orderId, note string) {
+	if _, err := u.ordersUsecase.TransitionOrder(orderId, &orders.TransitionReq{
+		Status: "cancelled",
+		Note:   note,
+	}, ""); err != nil {
+		log.Printf("cancel order %s failed: %v", orderId, err)
+	}
+}
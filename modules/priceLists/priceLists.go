@@ -0,0 +1,13 @@
+package priceLists
+
+type PriceList struct {
+	Id        int    `db:"id" json:"id"`
+	Title     string `db:"title" json:"title"`
+	Users     int    `db:"users" json:"users"` // number of assigned users
+	CreatedAt string `db:"created_at" json:"created_at"`
+	UpdatedAt string `db:"updated_at" json:"updated_at"`
+}
+
+type AssignPriceListReq struct {
+	UserIds []string `json:"user_ids" form:"user_ids"`
+}
@@ -0,0 +1,205 @@
+package priceListsHandlers
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/DrumPatiphon/go-rest-api-service/config"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/entities"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/priceLists"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/priceLists/priceListsUsecases"
+	"github.com/gofiber/fiber/v2"
+)
+
+type priceListsHandlerErrCode string
+
+const (
+	findPriceListsErr  priceListsHandlerErrCode = "priceLists-001"
+	insertPriceListErr priceListsHandlerErrCode = "priceLists-002"
+	deletePriceListErr priceListsHandlerErrCode = "priceLists-003"
+	assignUsersErr     priceListsHandlerErrCode = "priceLists-004"
+	unassignUserErr    priceListsHandlerErrCode = "priceLists-005"
+)
+
+type IPriceListsHandler interface {
+	FindPriceLists(c *fiber.Ctx) error
+	InsertPriceList(c *fiber.Ctx) error
+	DeletePriceList(c *fiber.Ctx) error
+	AssignUsers(c *fiber.Ctx) error
+	UnassignUser(c *fiber.Ctx) error
+}
+
+type priceListsHandler struct {
+	cfg               config.Iconfig
+	priceListsUsecase priceListsUsecases.IPriceListsUsecase
+}
+
+func PriceListsHandler(cfg config.Iconfig, priceListsUsecase priceListsUsecases.IPriceListsUsecase) IPriceListsHandler {
+	return &priceListsHandler{
+		cfg:               cfg,
+		priceListsUsecase: priceListsUsecase,
+	}
+}
+
+func (h *priceListsHandler) FindPriceLists(c *fiber.Ctx) error {
+	priceListsData, err := h.priceListsUsecase.FindPriceLists()
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(findPriceListsErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, priceListsData).Res()
+}
+
+func (h *priceListsHandler) InsertPriceList(c *fiber.Ctx) error {
+	req := new(priceLists.PriceList)
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(insertPriceListErr),
+			err.Error(),
+		).Res()
+	}
+	req.Title = strings.TrimSpace(req.Title)
+	if req.Title == "" {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(insertPriceListErr),
+			"title is required",
+		).Res()
+	}
+
+	if err := h.priceListsUsecase.InsertPriceList(req); err != nil {
+		switch err.Error() {
+		case "title has been used":
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(insertPriceListErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(insertPriceListErr),
+				err.Error(),
+			).Res()
+		}
+	}
+	return entities.NewResponse(c).Success(fiber.StatusCreated, req).Res()
+}
+
+func (h *priceListsHandler) DeletePriceList(c *fiber.Ctx) error {
+	priceListId, err := strconv.Atoi(strings.Trim(c.Params("price_list_id"), " "))
+	if err != nil || priceListId <= 0 {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(deletePriceListErr),
+			"price list id is invalid",
+		).Res()
+	}
+
+	if err := h.priceListsUsecase.DeletePriceList(priceListId); err != nil {
+		switch err.Error() {
+		case "price list not found":
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(deletePriceListErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(deletePriceListErr),
+				err.Error(),
+			).Res()
+		}
+	}
+	return entities.NewResponse(c).Success(fiber.StatusNoContent, nil).Res()
+}
+
+func (h *priceListsHandler) AssignUsers(c *fiber.Ctx) error {
+	priceListId, err := strconv.Atoi(strings.Trim(c.Params("price_list_id"), " "))
+	if err != nil || priceListId <= 0 {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(assignUsersErr),
+			"price list id is invalid",
+		).Res()
+	}
+
+	req := new(priceLists.AssignPriceListReq)
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(assignUsersErr),
+			err.Error(),
+		).Res()
+	}
+	if len(req.UserIds) == 0 {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(assignUsersErr),
+			"user_ids is required",
+		).Res()
+	}
+
+	assigned, err := h.priceListsUsecase.AssignUsers(priceListId, req)
+	if err != nil {
+		switch err.Error() {
+		case "price list not found":
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(assignUsersErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(assignUsersErr),
+				err.Error(),
+			).Res()
+		}
+	}
+	return entities.NewResponse(c).Success(
+		fiber.StatusOK,
+		&struct {
+			PriceListId int `json:"price_list_id"`
+			Assigned    int `json:"assigned"`
+		}{
+			PriceListId: priceListId,
+			Assigned:    assigned,
+		},
+	).Res()
+}
+
+func (h *priceListsHandler) UnassignUser(c *fiber.Ctx) error {
+	priceListId, err := strconv.Atoi(strings.Trim(c.Params("price_list_id"), " "))
+	if err != nil || priceListId <= 0 {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(unassignUserErr),
+			"price list id is invalid",
+		).Res()
+	}
+	userId := strings.Trim(c.Params("user_id"), " ")
+
+	if err := h.priceListsUsecase.UnassignUser(priceListId, userId); err != nil {
+		switch err.Error() {
+		case "user is not in the price list":
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(unassignUserErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(unassignUserErr),
+				err.Error(),
+			).Res()
+		}
+	}
+	return entities.NewResponse(c).Success(fiber.StatusNoContent, nil).Res()
+}
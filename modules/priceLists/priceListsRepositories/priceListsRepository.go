@@ -0,0 +1,123 @@
+package priceListsRepositories
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/DrumPatiphon/go-rest-api-service/modules/priceLists"
+	"github.com/jmoiron/sqlx"
+)
+
+type IPriceListsRepository interface {
+	FindPriceLists() ([]*priceLists.PriceList, error)
+	InsertPriceList(req *priceLists.PriceList) error
+	DeletePriceList(priceListId int) error
+	AssignUsers(priceListId int, userIds []string) (int, error)
+	UnassignUser(priceListId int, userId string) error
+}
+
+type priceListsRepository struct {
+	db *sqlx.DB
+}
+
+func PriceListsRepository(db *sqlx.DB) IPriceListsRepository {
+	return &priceListsRepository{
+		db: db,
+	}
+}
+
+func (r *priceListsRepository) FindPriceLists() ([]*priceLists.PriceList, error) {
+	query := `
+	SELECT
+		"pl"."id",
+		"pl"."title",
+		(
+			SELECT
+				COUNT(*)
+			FROM "users" "u"
+			WHERE "u"."price_list_id" = "pl"."id"
+		) AS "users",
+		"pl"."created_at",
+		"pl"."updated_at"
+	FROM "price_lists" "pl"
+	ORDER BY "pl"."id";`
+
+	priceListsData := make([]*priceLists.PriceList, 0)
+	if err := r.db.Select(&priceListsData, query); err != nil {
+		return nil, fmt.Errorf("select price lists failed: %v", err)
+	}
+	return priceListsData, nil
+}
+
+func (r *priceListsRepository) InsertPriceList(req *priceLists.PriceList) error {
+	query := `
+	INSERT INTO "price_lists" (
+		"title"
+	)
+	VALUES ($1)
+		RETURNING "id", "created_at", "updated_at";`
+
+	if err := r.db.QueryRowContext(
+		context.Background(),
+		query,
+		req.Title,
+	).Scan(&req.Id, &req.CreatedAt, &req.UpdatedAt); err != nil {
+		if strings.Contains(err.Error(), "price_lists_title_key") {
+			return fmt.Errorf("title has been used")
+		}
+		return fmt.Errorf("insert price list failed: %v", err)
+	}
+	return nil
+}
+
+// DeletePriceList remove special prices of the list, users are back to regular prices
+func (r *priceListsRepository) DeletePriceList(priceListId int) error {
+	query := `
+	DELETE FROM "price_lists"
+	WHERE "id" = $1;`
+
+	result, err := r.db.ExecContext(context.Background(), query, priceListId)
+	if err != nil {
+		return fmt.Errorf("delete price list failed: %v", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("price list not found")
+	}
+	return nil
+}
+
+// AssignUsers move users to the price list, a user has one price list at a time
+func (r *priceListsRepository) AssignUsers(priceListId int, userIds []string) (int, error) {
+	query := `
+	UPDATE "users" SET
+		"price_list_id" = $1
+	WHERE "id" = ANY($2::VARCHAR[]);`
+
+	result, err := r.db.ExecContext(context.Background(), query, priceListId, userIds)
+	if err != nil {
+		if strings.Contains(err.Error(), "price_list_id_fkey") {
+			return 0, fmt.Errorf("price list not found")
+		}
+		return 0, fmt.Errorf("assign price list failed: %v", err)
+	}
+	rows, _ := result.RowsAffected()
+	return int(rows), nil
+}
+
+func (r *priceListsRepository) UnassignUser(priceListId int, userId string) error {
+	query := `
+	UPDATE "users" SET
+		"price_list_id" = NULL
+	WHERE "id" = $1
+	AND "price_list_id" = $2;`
+
+	result, err := r.db.ExecContext(context.Background(), query, userId, priceListId)
+	if err != nil {
+		return fmt.Errorf("unassign price list failed: %v", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("user is not in the price list")
+	}
+	return nil
+}
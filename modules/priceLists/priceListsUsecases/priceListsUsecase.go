@@ -0,0 +1,61 @@
+package priceListsUsecases
+
+import (
+	"github.com/DrumPatiphon/go-rest-api-service/modules/priceLists"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/priceLists/priceListsRepositories"
+)
+
+type IPriceListsUsecase interface {
+	FindPriceLists() ([]*priceLists.PriceList, error)
+	InsertPriceList(req *priceLists.PriceList) error
+	DeletePriceList(priceListId int) error
+	AssignUsers(priceListId int, req *priceLists.AssignPriceListReq) (int, error)
+	UnassignUser(priceListId int, userId string) error
+}
+
+type priceListsUsecase struct {
+	priceListsRepository priceListsRepositories.IPriceListsRepository
+}
+
+func PriceListsUsecase(priceListsRepository priceListsRepositories.IPriceListsRepository) IPriceListsUsecase {
+	return &priceListsUsecase{
+		priceListsRepository: priceListsRepository,
+	}
+}
+
+func (u *priceListsUsecase) FindPriceLists() ([]*priceLists.PriceList, error) {
+	priceListsData, err := u.priceListsRepository.FindPriceLists()
+	if err != nil {
+		return nil, err
+	}
+	return priceListsData, nil
+}
+
+func (u *priceListsUsecase) InsertPriceList(req *priceLists.PriceList) error {
+	if err := u.priceListsRepository.InsertPriceList(req); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (u *priceListsUsecase) DeletePriceList(priceListId int) error {
+	if err := u.priceListsRepository.DeletePriceList(priceListId); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (u *priceListsUsecase) AssignUsers(priceListId int, req *priceLists.AssignPriceListReq) (int, error) {
+	assigned, err := u.priceListsRepository.AssignUsers(priceListId, req.UserIds)
+	if err != nil {
+		return 0, err
+	}
+	return assigned, nil
+}
+
+func (u *priceListsUsecase) UnassignUser(priceListId int, userId string) error {
+	if err := u.priceListsRepository.UnassignUser(priceListId, userId); err != nil {
+		return err
+	}
+	return nil
+}
@@ -25,6 +25,9 @@ type IfindProductBuilder interface {
 	paginate()
 	closeJsonQuery()
 	resetQuery()
+	openRowJsonQuery()
+	closeRowJsonQuery()
+	Query() (string, []any)
 	Result() []*products.Product
 	Count() int
 	PrintQuery()
@@ -33,6 +36,7 @@ type IfindProductBuilder interface {
 type findProductBuilder struct {
 	db             *sqlx.DB
 	req            *products.ProductFilter
+	isTrash        bool // find soft deleted products instead
 	query          string
 	lastStackIndex int
 	values         []any
@@ -49,8 +53,22 @@ func (b *findProductBuilder) initQuery() {
 		SELECT
 			"p"."id",
 			"p"."title",
+			"p"."slug",
 			"p"."description",
-			"p"."price",
+			"p"."status",
+			"p"."publish_at",
+			(
+				SELECT
+					COALESCE(array_to_json(array_agg("pt")), '[]'::json)
+				FROM (
+					SELECT
+						"pp"."currency",
+						"pp"."amount" AS "minor_units"
+					FROM "product_prices" "pp"
+					WHERE "pp"."product_id" = "p"."id"
+					ORDER BY "pp"."currency"
+				) AS "pt"
+			) AS "prices",
 			(
 				SELECT
 					to_jsonb("ct")
@@ -61,10 +79,35 @@ func (b *findProductBuilder) initQuery() {
 					FROM "categories" "c"
 						LEFT JOIN "products_categories" "pc" ON "pc"."category_id" = "c"."id"
 					WHERE "pc"."product_id" = "p"."id"
+					ORDER BY "c"."id"
+					LIMIT 1
 				) AS "ct"
 			) AS "category",
-			"p"."created_at",
-			"p"."updated_at",
+			(
+				SELECT
+					COALESCE(array_to_json(array_agg("ct")), '[]'::json)
+				FROM (
+					SELECT
+						"c"."id",
+						"c"."title"
+					FROM "categories" "c"
+						LEFT JOIN "products_categories" "pc" ON "pc"."category_id" = "c"."id"
+					WHERE "pc"."product_id" = "p"."id"
+					ORDER BY "c"."id"
+				) AS "ct"
+			) AS "categories",
+			"p"."created_at" AS "CreatedAt",
+			"p"."updated_at" AS "UpdatedAt",
+			"p"."deleted_at",
+			"p"."version",
+			"p"."rating_average",
+			"p"."review_count",
+			"p"."stock",
+			"p"."tax_class_id",
+			"p"."weight",
+			"p"."length",
+			"p"."width",
+			"p"."height",
 			(
 				SELECT
 					COALESCE(array_to_json(array_agg("it")), '[]'::json)
@@ -72,9 +115,13 @@ func (b *findProductBuilder) initQuery() {
 					SELECT
 						"i"."id",
 						"i"."filename",
-						"i"."url"
+						"i"."url",
+						"i"."position",
+						"i"."alt",
+						"i"."is_primary"
 					FROM "images" "i"
 					WHERE "i"."product_id" = "p"."id"
+					ORDER BY "i"."position", "i"."id"
 				) AS "it"
 			) AS "images"
 		FROM "products" "p"
@@ -91,6 +138,15 @@ func (b *findProductBuilder) whereQuery() {
 	var queryWhere string
 	queryWhereStack := make([]string, 0)
 
+	// Trash check
+	if b.isTrash {
+		b.query += `
+		AND "p"."deleted_at" IS NOT NULL`
+	} else {
+		b.query += `
+		AND "p"."deleted_at" IS NULL`
+	}
+
 	// Id check
 	if b.req.Id != "" {
 		b.values = append(b.values, b.req.Id)
@@ -99,25 +155,91 @@ func (b *findProductBuilder) whereQuery() {
 		AND "p"."id" = ?`)
 	}
 
-	// Search check
+	// Wishlist check
+	if b.req.WishlistOf != "" {
+		b.values = append(b.values, b.req.WishlistOf)
+
+		queryWhereStack = append(queryWhereStack, `
+		AND EXISTS (
+			SELECT 1
+			FROM "wishlists" "wf"
+			WHERE "wf"."product_id" = "p"."id"
+			AND "wf"."user_id" = ?
+		)`)
+	}
+
+	// Search check, default locale by LIKE and translations by full-text search of their dictionary.
+	// Thai has no space between words so the simple dictionary can't split them, those translations use LIKE too
 	if b.req.Search != "" {
 		b.values = append(
 			b.values,
 			"%"+strings.ToLower(b.req.Search)+"%",
 			"%"+strings.ToLower(b.req.Search)+"%",
+			b.req.Search,
+			"%"+strings.ToLower(b.req.Search)+"%",
+			"%"+strings.ToLower(b.req.Search)+"%",
 		)
 
 		queryWhereStack = append(queryWhereStack, `
-		AND (LOWER("p"."title") LIKE ? OR LOWER("p"."description") LIKE ?)`)
+		AND (
+			LOWER("p"."title") LIKE ?
+			OR LOWER("p"."description") LIKE ?
+			OR EXISTS (
+				SELECT 1
+				FROM "products_translations" "ptf"
+				WHERE "ptf"."product_id" = "p"."id"
+				AND (
+					"ptf"."search" @@ plainto_tsquery("ptf"."dictionary", ?)
+					OR (
+						"ptf"."dictionary" = 'simple'::regconfig
+						AND (LOWER("ptf"."title") LIKE ? OR LOWER("ptf"."description") LIKE ?)
+					)
+				)
+			)
+		)`)
 	}
 
+	// Status check, scheduled is draft waiting for publish_at
+	switch b.req.Status {
+	case "":
+	case "scheduled":
+		queryWhereStack = append(queryWhereStack, `
+		AND "p"."status" = 'draft' AND "p"."publish_at" IS NOT NULL`)
+	default:
+		b.values = append(b.values, b.req.Status)
+
+		queryWhereStack = append(queryWhereStack, `
+		AND "p"."status" = ?`)
+	}
+
+	// Category check, match any category of the product or their descendants
+	if len(b.req.CategoryIds) > 0 {
+		b.values = append(b.values, b.req.CategoryIds)
+
+		queryWhereStack = append(queryWhereStack, `
+		AND EXISTS (
+			SELECT 1
+			FROM "products_categories" "pcf"
+			WHERE "pcf"."product_id" = "p"."id"
+			AND "pcf"."category_id" IN (
+				WITH RECURSIVE "descendants" AS (
+					SELECT "id" FROM "categories" WHERE "id" = ANY(?)
+					UNION
+					SELECT "c"."id" FROM "categories" "c" JOIN "descendants" "d" ON "c"."parent_id" = "d"."id"
+				)
+				SELECT "id" FROM "descendants"
+			)
+		)`)
+	}
+
+	// Replace every ? with $n in order of b.values
+	var index int
 	for i := range queryWhereStack {
-		if i != len(queryWhereStack)-1 {
-			queryWhere += strings.Replace(queryWhereStack[i], "?", "$"+strconv.Itoa(i+1), 1)
-		} else {
-			queryWhere += strings.Replace(queryWhereStack[i], "?", "$"+strconv.Itoa(i+1), 1)
-			queryWhere = strings.Replace(queryWhere, "?", "$"+strconv.Itoa(i+2), 1)
+		for strings.Contains(queryWhereStack[i], "?") {
+			index++
+			queryWhereStack[i] = strings.Replace(queryWhereStack[i], "?", "$"+strconv.Itoa(index), 1)
 		}
+		queryWhere += queryWhereStack[i]
 	}
 	// Last stack record
 	b.lastStackIndex = len(b.values)
@@ -126,31 +248,34 @@ func (b *findProductBuilder) whereQuery() {
 	b.query += queryWhere
 }
 func (b *findProductBuilder) sort() {
+	// column can't be a placeholder, only values from the maps are put into query
 	orderByMap := map[string]string{
 		"id":    "\"p\".\"id\"",
 		"title": "\"p\".\"title\"",
-		"price": "\"p\".\"price\"",
+		"price": fmt.Sprintf(`product_effective_price("p"."id", $%d, $%d)`, b.lastStackIndex+1, b.lastStackIndex+2),
 	}
 	if orderByMap[b.req.OrderBy] == "" {
-		b.req.OrderBy = orderByMap["title"]
-	} else {
-		b.req.OrderBy = orderByMap[b.req.OrderBy]
+		b.req.OrderBy = "title"
+	}
+	// price for the caller, sale and price list included
+	if b.req.OrderBy == "price" {
+		b.values = append(b.values, b.req.Currency, b.req.UserId)
+		b.lastStackIndex = len(b.values)
 	}
 
 	sortMap := map[string]string{
 		"DESC": "DESC",
 		"ASC":  "ASC",
 	}
-	if sortMap[b.req.Sort] == "" {
+	if sortMap[strings.ToUpper(b.req.Sort)] == "" {
 		b.req.Sort = sortMap["ASC"]
 	} else {
 		b.req.Sort = sortMap[strings.ToUpper(b.req.Sort)]
 	}
 
-	b.values = append(b.values, b.req.OrderBy)
+	// products without price in the currency go last
 	b.query += fmt.Sprintf(`
-		ORDER BY $%d %s`, b.lastStackIndex+1, b.req.Sort)
-	b.lastStackIndex = len(b.values)
+		ORDER BY %s %s NULLS LAST, "p"."id"`, orderByMap[b.req.OrderBy], b.req.Sort)
 }
 func (b *findProductBuilder) paginate() {
 	// offset (page - 1)*limit
@@ -163,6 +288,21 @@ func (b *findProductBuilder) closeJsonQuery() {
 	b.query += `
 	) AS "t";`
 }
+
+// openRowJsonQuery return one json per row, for reading with cursor
+func (b *findProductBuilder) openRowJsonQuery() {
+	b.query += `
+	SELECT
+		to_jsonb("t")
+	FROM (`
+}
+func (b *findProductBuilder) closeRowJsonQuery() {
+	b.query += `
+	) AS "t"`
+}
+func (b *findProductBuilder) Query() (string, []any) {
+	return b.query, b.values
+}
 func (b *findProductBuilder) resetQuery() {
 	b.query = ""
 	b.values = make([]any, 0)
@@ -212,6 +352,14 @@ func FindProductBuilder(db *sqlx.DB, req *products.ProductFilter) IfindProductBu
 	}
 }
 
+func FindTrashProductBuilder(db *sqlx.DB, req *products.ProductFilter) IfindProductBuilder {
+	return &findProductBuilder{
+		db:      db,
+		req:     req,
+		isTrash: true,
+	}
+}
+
 // Engineer
 type findProductEngineer struct {
 	builder IfindProductBuilder
@@ -233,6 +381,16 @@ func (en *findProductEngineer) FindProduct() IfindProductBuilder {
 	return en.builder
 }
 
+// ExportProduct is FindProduct without pagination
+func (en *findProductEngineer) ExportProduct() IfindProductBuilder {
+	en.builder.openRowJsonQuery()
+	en.builder.initQuery()
+	en.builder.whereQuery()
+	en.builder.sort()
+	en.builder.closeRowJsonQuery()
+	return en.builder
+}
+
 func (en *findProductEngineer) CountProduct() IfindProductBuilder {
 	en.builder.countQuery()
 	en.builder.whereQuery()
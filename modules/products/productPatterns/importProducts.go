@@ -0,0 +1,104 @@
+package productPatterns
+
+import (
+	"context"
+
+	"github.com/DrumPatiphon/go-rest-api-service/modules/products"
+	"github.com/jmoiron/sqlx"
+)
+
+type IImportProductBuilder interface {
+	initTransaction() error
+	insertProducts() error
+	commit() error
+	getInsertedRows() int
+}
+
+// importProductBuilder insert a batch of products in one transaction,
+// every product use the same steps as insertProductBuilder
+type importProductBuilder struct {
+	db       *sqlx.DB
+	tx       *sqlx.Tx // for roll-back
+	req      []*products.Product
+	userId   string
+	inserted int
+}
+
+func ImportProductBuilder(db *sqlx.DB, req []*products.Product, userId string) IImportProductBuilder {
+	return &importProductBuilder{
+		db:     db,
+		req:    req,
+		userId: userId,
+	}
+}
+
+type importProductEngineer struct {
+	builder IImportProductBuilder
+}
+
+func (b *importProductBuilder) initTransaction() error {
+	tx, err := b.db.BeginTxx(context.Background(), nil)
+	if err != nil {
+		return err
+	}
+
+	b.tx = tx
+	return nil
+}
+func (b *importProductBuilder) insertProducts() error {
+	for _, p := range b.req {
+		row := &insertProductBuilder{
+			db:     b.db,
+			tx:     b.tx,
+			req:    p,
+			userId: b.userId,
+		}
+		// every step roll back the whole batch when failed
+		if err := row.setSlug(); err != nil {
+			return err
+		}
+		if err := row.insertProduct(); err != nil {
+			return err
+		}
+		if err := row.insertPrices(); err != nil {
+			return err
+		}
+		if err := row.InsertCagetory(); err != nil {
+			return err
+		}
+		if err := row.InsertAttachment(); err != nil {
+			return err
+		}
+		if err := row.insertRevision(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+func (b *importProductBuilder) commit() error {
+	if err := b.tx.Commit(); err != nil {
+		return err
+	}
+	b.inserted = len(b.req)
+	return nil
+}
+func (b *importProductBuilder) getInsertedRows() int {
+	return b.inserted
+}
+
+func ImportProductEngineer(b IImportProductBuilder) *importProductEngineer {
+	return &importProductEngineer{builder: b}
+}
+
+func (en *importProductEngineer) ImportProducts() (int, error) {
+	if err := en.builder.initTransaction(); err != nil {
+		return 0, err
+	}
+	if err := en.builder.insertProducts(); err != nil {
+		return 0, err
+	}
+	if err := en.builder.commit(); err != nil {
+		return 0, err
+	}
+	return en.builder.getInsertedRows(), nil
+}
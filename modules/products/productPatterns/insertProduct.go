@@ -3,31 +3,39 @@ package productPatterns
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
+	"github.com/DrumPatiphon/go-rest-api-service/modules/entities"
 	"github.com/DrumPatiphon/go-rest-api-service/modules/products"
+	"github.com/DrumPatiphon/go-rest-api-service/pkg/money"
 	"github.com/jmoiron/sqlx"
 )
 
 type IInsertProductBuilder interface {
 	initTransaction() error
+	setSlug() error
 	insertProduct() error
+	insertPrices() error
 	InsertCagetory() error
 	InsertAttachment() error
+	insertRevision() error
 	commit() error
 	getProductId() string
 }
 
 type insertProductBuilder struct {
-	db  *sqlx.DB
-	tx  *sqlx.Tx // for roll-back
-	req *products.Product
+	db     *sqlx.DB
+	tx     *sqlx.Tx // for roll-back
+	req    *products.Product
+	userId string // who insert, saved in revision
 }
 
-func InsertProductBuilder(db *sqlx.DB, req *products.Product) IInsertProductBuilder {
+func InsertProductBuilder(db *sqlx.DB, req *products.Product, userId string) IInsertProductBuilder {
 	return &insertProductBuilder{
-		db:  db,
-		req: req,
+		db:     db,
+		req:    req,
+		userId: userId,
 	}
 }
 
@@ -44,32 +52,108 @@ func (b *insertProductBuilder) initTransaction() error {
 	b.tx = tx
 	return nil
 }
+
+// setSlug use slug from request or title, inside tx so products of the same import don't collide
+func (b *insertProductBuilder) setSlug() error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*15)
+	defer cancel()
+
+	text := b.req.Slug
+	if text == "" {
+		text = b.req.Title
+	}
+	slug, err := FreeSlug(ctx, b.tx, text, "")
+	if err != nil {
+		b.tx.Rollback()
+		return err
+	}
+	b.req.Slug = slug
+	return nil
+}
 func (b *insertProductBuilder) insertProduct() error {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*15)
 	defer cancel()
 
+	// published product is published now, draft is scheduled when publish_at is set
 	query := `
 		INSERT INTO products(
 			title, 
-			description, 
-			price
+			description,
+			slug,
+			status,
+			publish_at,
+			tax_class_id,
+			weight,
+			length,
+			width,
+			height
 		)
-		VALUES($1, $2, $3)
+		VALUES($1, $2, $3, $4::VARCHAR, CASE WHEN $4::VARCHAR = 'published' THEN now() ELSE NULLIF($5, '')::TIMESTAMPTZ END, COALESCE(NULLIF($6, ''), 'standard'), $7, $8, $9, $10)
 			RETURNING id;
 	`
+	if b.req.Status == "" {
+		b.req.Status = "draft"
+	}
 	if err := b.tx.QueryRowContext(
 		ctx,
 		query,
 		b.req.Title,
 		b.req.Description,
-		b.req.Price,
+		b.req.Slug,
+		b.req.Status,
+		b.req.PublishAt,
+		b.req.TaxClassId,
+		b.req.Weight,
+		b.req.Length,
+		b.req.Width,
+		b.req.Height,
 	).Scan(&b.req.Id); err != nil {
 		b.tx.Rollback()
+		if strings.Contains(err.Error(), "products_tax_class_id_fkey") {
+			return fmt.Errorf("tax class not found")
+		}
 		return fmt.Errorf("insert product failled : %v", err)
 	}
 
 	return nil
 }
+func (b *insertProductBuilder) insertPrices() error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*15)
+	defer cancel()
+
+	query := `
+	INSERT INTO product_prices (
+		product_id,
+		currency,
+		amount
+	)
+	SELECT $1, UNNEST($2::VARCHAR[]), UNNEST($3::BIGINT[]);`
+
+	currencies, amounts := priceColumns(b.req.Prices)
+	if _, err := b.tx.ExecContext(
+		ctx,
+		query,
+		b.req.Id,
+		currencies,
+		amounts,
+	); err != nil {
+		b.tx.Rollback()
+		return fmt.Errorf("insert product_prices failled : %v", err)
+	}
+
+	return nil
+}
+
+// priceColumns split prices into arrays for UNNEST
+func priceColumns(prices []*money.Money) ([]string, []int64) {
+	currencies := make([]string, 0)
+	amounts := make([]int64, 0)
+	for _, p := range prices {
+		currencies = append(currencies, p.Currency)
+		amounts = append(amounts, p.Amount)
+	}
+	return currencies, amounts
+}
 func (b *insertProductBuilder) InsertCagetory() error {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*15)
 	defer cancel()
@@ -79,14 +163,14 @@ func (b *insertProductBuilder) InsertCagetory() error {
 		product_id,
 		category_id
 	)
-	VALUES($1, $2)
-	`
+	SELECT $1, UNNEST($2::INT[])
+	ON CONFLICT (product_id, category_id) DO NOTHING;`
 
 	if _, err := b.tx.ExecContext(
 		ctx,
 		query,
 		b.req.Id,
-		b.req.Category.Id,
+		b.req.CategoryIds(),
 	); err != nil {
 		b.tx.Rollback()
 		return fmt.Errorf("insert products_categories failled : %v", err)
@@ -98,14 +182,22 @@ func (b *insertProductBuilder) InsertAttachment() error {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*15)
 	defer cancel()
 
+	if len(b.req.Images) == 0 {
+		return nil
+	}
+
 	query := `
 	INSERT INTO images (
 		filename,
 		url,
-		product_id
+		product_id,
+		position,
+		alt,
+		is_primary
 	)
 	VALUES
 	`
+	orderImages(b.req.Images)
 	valueStack := make([]any, 0)
 	var index int
 	for i := range b.req.Images {
@@ -113,15 +205,18 @@ func (b *insertProductBuilder) InsertAttachment() error {
 			b.req.Images[i].FileName,
 			b.req.Images[i].Url,
 			b.req.Id,
+			b.req.Images[i].Position,
+			b.req.Images[i].Alt,
+			b.req.Images[i].IsPrimary,
 		)
 		if i != len(b.req.Images)-1 {
 			query += fmt.Sprintf(`
-			($%d, $%d, $%d),`, index+1, index+2, index+3)
+			($%d, $%d, $%d, $%d, $%d, $%d),`, index+1, index+2, index+3, index+4, index+5, index+6)
 		} else {
 			query += fmt.Sprintf(`
-			($%d, $%d, $%d);`, index+1, index+2, index+3)
+			($%d, $%d, $%d, $%d, $%d, $%d);`, index+1, index+2, index+3, index+4, index+5, index+6)
 		}
-		index += 3
+		index += 6
 	}
 
 	if _, err := b.tx.ExecContext(
@@ -135,6 +230,30 @@ func (b *insertProductBuilder) InsertAttachment() error {
 
 	return nil
 }
+
+// orderImages set position by order of request, the first primary image is kept or the first image is primary
+func orderImages(images []*entities.Image) {
+	primary := -1
+	for i, img := range images {
+		img.Position = i
+		if img.IsPrimary && primary < 0 {
+			primary = i
+		}
+		img.IsPrimary = false
+	}
+	if primary < 0 {
+		primary = 0
+	}
+	if len(images) > 0 {
+		images[primary].IsPrimary = true
+	}
+}
+func (b *insertProductBuilder) insertRevision() error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*15)
+	defer cancel()
+
+	return InsertRevision(ctx, b.tx, b.req.Id, b.userId)
+}
 func (b *insertProductBuilder) commit() error {
 	if err := b.tx.Commit(); err != nil {
 		return err
@@ -154,15 +273,24 @@ func (en *insertProductEngineer) InsertProduct() (string, error) {
 	if err := en.builder.initTransaction(); err != nil {
 		return "", err
 	}
+	if err := en.builder.setSlug(); err != nil {
+		return "", err
+	}
 	if err := en.builder.insertProduct(); err != nil {
 		return "", err
 	}
+	if err := en.builder.insertPrices(); err != nil {
+		return "", err
+	}
 	if err := en.builder.InsertCagetory(); err != nil {
 		return "", err
 	}
 	if err := en.builder.InsertAttachment(); err != nil {
 		return "", err
 	}
+	if err := en.builder.insertRevision(); err != nil {
+		return "", err
+	}
 	if err := en.builder.commit(); err != nil {
 		return "", err
 	}
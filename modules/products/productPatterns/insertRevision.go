@@ -0,0 +1,66 @@
+package productPatterns
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// InsertRevision save the current state of product inside tx as the next revision.
+// Product row is locked first so concurrent saves don't take the same revision number
+func InsertRevision(ctx context.Context, tx *sqlx.Tx, productId, userId string) error {
+	if _, err := tx.ExecContext(ctx, `SELECT 1 FROM "products" WHERE "id" = $1 FOR UPDATE;`, productId); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("lock product failed: %v", err)
+	}
+
+	query := `
+	INSERT INTO "products_revisions" (
+		"product_id",
+		"revision",
+		"user_id",
+		"snapshot"
+	)
+	SELECT
+		"p"."id",
+		COALESCE((
+			SELECT
+				MAX("r"."revision")
+			FROM "products_revisions" "r"
+			WHERE "r"."product_id" = "p"."id"
+		), 0) + 1,
+		$2,
+		jsonb_build_object(
+			'id', "p"."id",
+			'title', "p"."title",
+			'description', "p"."description",
+			'prices', (
+				SELECT
+					COALESCE(jsonb_agg(jsonb_build_object('currency', "pp"."currency", 'minor_units', "pp"."amount") ORDER BY "pp"."currency"), '[]'::jsonb)
+				FROM "product_prices" "pp"
+				WHERE "pp"."product_id" = "p"."id"
+			),
+			'categories', (
+				SELECT
+					COALESCE(jsonb_agg(jsonb_build_object('id', "c"."id", 'title', "c"."title") ORDER BY "c"."id"), '[]'::jsonb)
+				FROM "categories" "c"
+					JOIN "products_categories" "pc" ON "pc"."category_id" = "c"."id"
+				WHERE "pc"."product_id" = "p"."id"
+			),
+			'images', (
+				SELECT
+					COALESCE(jsonb_agg(jsonb_build_object('id', "i"."id", 'filename', "i"."filename", 'url', "i"."url", 'position', "i"."position", 'alt', "i"."alt", 'is_primary', "i"."is_primary") ORDER BY "i"."position", "i"."id"), '[]'::jsonb)
+				FROM "images" "i"
+				WHERE "i"."product_id" = "p"."id"
+			)
+		)
+	FROM "products" "p"
+	WHERE "p"."id" = $1;`
+
+	if _, err := tx.ExecContext(ctx, query, productId, userId); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("insert product revision failed: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package productPatterns
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/DrumPatiphon/go-rest-api-service/pkg/slug"
+	"github.com/jmoiron/sqlx"
+)
+
+// FreeSlug return slug from text which is not used by other products, current or old slug.
+// Used slug get a suffix -2, -3, ... and text without any letter use productId
+func FreeSlug(ctx context.Context, tx *sqlx.Tx, text, productId string) (string, error) {
+	base := slug.Make(text)
+	if base == "" {
+		base = "product"
+	}
+
+	query := `
+	SELECT
+		slug
+	FROM products
+	WHERE (slug = $1 OR slug LIKE $1 || '-%')
+	AND id <> $2
+	UNION
+	SELECT
+		slug
+	FROM products_slugs
+	WHERE (slug = $1 OR slug LIKE $1 || '-%')
+	AND product_id <> $2;`
+
+	used := make([]string, 0)
+	if err := tx.SelectContext(ctx, &used, query, base, productId); err != nil {
+		return "", fmt.Errorf("find product slugs failed: %v", err)
+	}
+	usedMap := make(map[string]bool)
+	for _, s := range used {
+		usedMap[strings.ToLower(s)] = true
+	}
+
+	result := base
+	for i := 2; usedMap[result]; i++ {
+		result = fmt.Sprintf("%s-%d", base, i)
+	}
+	return result, nil
+}
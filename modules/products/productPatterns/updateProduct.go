@@ -3,9 +3,9 @@ package productPatterns
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/DrumPatiphon/go-rest-api-service/modules/entities"
-	"github.com/DrumPatiphon/go-rest-api-service/modules/files"
 	"github.com/DrumPatiphon/go-rest-api-service/modules/files/filesUsecases"
 	"github.com/DrumPatiphon/go-rest-api-service/modules/products"
 	"github.com/jmoiron/sqlx"
@@ -14,15 +14,25 @@ import (
 type IUpdateProductBuilder interface {
 	initTransaction() error
 	initQuery()
+	setSlug() error
+	updateSlugQuery()
 	updateTitleQuery()
 	updateDescriptionQuery()
-	updatePriceQuery()
+	updateStatusQuery()
+	updateTaxClassQuery()
+	updateShippingQuery()
+	updateVersionQuery()
+	updatePrices() error
+	removePrices() error
 	updateCategory() error
+	addCategories() error
+	removeCategories() error
 	insertImages() error
 	getOldImages() []*entities.Image
 	deleteOldImages() error
 	closeQuery()
 	updateProduct() error
+	insertRevision() error
 	getQueryFields() []string
 	getvalue() []any
 	getQuery() string
@@ -34,7 +44,8 @@ type IUpdateProductBuilder interface {
 type updateProductbuilder struct {
 	db             *sqlx.DB
 	tx             *sqlx.Tx // for roll-back
-	req            *products.Product
+	req            *products.UpdateProductReq
+	userId         string // who update, saved in revision
 	filesUsecases  filesUsecases.IFilesUsecases
 	query          string
 	queryFields    []string
@@ -42,10 +53,11 @@ type updateProductbuilder struct {
 	value          []any
 }
 
-func UpdateProductBuilder(db *sqlx.DB, req *products.Product, filesUsecases filesUsecases.IFilesUsecases) IUpdateProductBuilder {
+func UpdateProductBuilder(db *sqlx.DB, req *products.UpdateProductReq, userId string, filesUsecases filesUsecases.IFilesUsecases) IUpdateProductBuilder {
 	return &updateProductbuilder{
 		db:            db,
 		req:           req,
+		userId:        userId,
 		filesUsecases: filesUsecases,
 		queryFields:   make([]string, 0),
 		value:         make([]any, 0),
@@ -69,6 +81,69 @@ func (b *updateProductbuilder) initQuery() {
 	b.query += `
 	UPDATE products SET`
 }
+
+// setSlug make new slug when title or slug is sent, old slug is kept for redirect
+func (b *updateProductbuilder) setSlug() error {
+	text := b.req.Slug
+	if text == "" {
+		text = b.req.Title
+	}
+	if text == "" {
+		return nil
+	}
+
+	newSlug, err := FreeSlug(context.Background(), b.tx, text, b.req.Id)
+	if err != nil {
+		b.tx.Rollback()
+		return err
+	}
+
+	var oldSlug string
+	if err := b.tx.GetContext(context.Background(), &oldSlug, `SELECT slug FROM products WHERE id = $1;`, b.req.Id); err != nil {
+		b.tx.Rollback()
+		return fmt.Errorf("get product slug failed: %v", err)
+	}
+	if oldSlug == newSlug {
+		b.req.Slug = ""
+		return nil
+	}
+
+	historyQuery := `
+	INSERT INTO products_slugs (
+		slug,
+		product_id
+	)
+	VALUES ($1, $2)
+	ON CONFLICT (slug) DO NOTHING;`
+
+	if _, err := b.tx.ExecContext(context.Background(), historyQuery, oldSlug, b.req.Id); err != nil {
+		b.tx.Rollback()
+		return fmt.Errorf("insert products_slugs failed: %v", err)
+	}
+
+	// product get back its own old slug
+	reclaimQuery := `
+	DELETE FROM products_slugs
+	WHERE slug = $1
+	AND product_id = $2;`
+
+	if _, err := b.tx.ExecContext(context.Background(), reclaimQuery, newSlug, b.req.Id); err != nil {
+		b.tx.Rollback()
+		return fmt.Errorf("delete products_slugs failed: %v", err)
+	}
+
+	b.req.Slug = newSlug
+	return nil
+}
+func (b *updateProductbuilder) updateSlugQuery() {
+	if b.req.Slug != "" {
+		b.value = append(b.value, b.req.Slug)
+		b.lastStackIndex = len(b.value)
+
+		b.queryFields = append(b.queryFields, fmt.Sprintf(`
+		slug = $%d`, b.lastStackIndex))
+	}
+}
 func (b *updateProductbuilder) updateTitleQuery() {
 	if b.req.Title != "" {
 		b.value = append(b.value, b.req.Title)
@@ -87,37 +162,190 @@ func (b *updateProductbuilder) updateDescriptionQuery() {
 		description = $%d`, b.lastStackIndex))
 	}
 }
-func (b *updateProductbuilder) updatePriceQuery() {
-	if b.req.Price > 0 {
-		b.value = append(b.value, b.req.Price)
+
+// updateStatusQuery publish now, schedule or unschedule when status is sent
+func (b *updateProductbuilder) updateStatusQuery() {
+	if b.req.Status == "" {
+		return
+	}
+	b.value = append(b.value, b.req.Status)
+	b.lastStackIndex = len(b.value)
+
+	b.queryFields = append(b.queryFields, fmt.Sprintf(`
+		status = $%d`, b.lastStackIndex))
+
+	switch b.req.Status {
+	case "published":
+		// keep publish_at of product published before
+		b.queryFields = append(b.queryFields, `
+		publish_at = LEAST(COALESCE(publish_at, now()), now())`)
+	case "draft":
+		b.value = append(b.value, b.req.PublishAt)
 		b.lastStackIndex = len(b.value)
 
 		b.queryFields = append(b.queryFields, fmt.Sprintf(`
-		price = $%d`, b.lastStackIndex))
+		publish_at = NULLIF($%d, '')::TIMESTAMPTZ`, b.lastStackIndex))
 	}
 }
-func (b *updateProductbuilder) updateCategory() error {
-	if b.req.Category == nil {
+
+func (b *updateProductbuilder) updateTaxClassQuery() {
+	if b.req.TaxClassId != "" {
+		b.value = append(b.value, b.req.TaxClassId)
+		b.lastStackIndex = len(b.value)
+
+		b.queryFields = append(b.queryFields, fmt.Sprintf(`
+		tax_class_id = $%d`, b.lastStackIndex))
+	}
+}
+
+// updateShippingQuery set weight and dimensions which are sent
+func (b *updateProductbuilder) updateShippingQuery() {
+	fields := []struct {
+		column string
+		value  *int
+	}{
+		{"weight", b.req.Weight},
+		{"length", b.req.Length},
+		{"width", b.req.Width},
+		{"height", b.req.Height},
+	}
+	for _, f := range fields {
+		if f.value == nil {
+			continue
+		}
+		b.value = append(b.value, *f.value)
+		b.lastStackIndex = len(b.value)
+
+		b.queryFields = append(b.queryFields, fmt.Sprintf(`
+		%s = $%d`, f.column, b.lastStackIndex))
+	}
+}
+
+// updateVersionQuery always bump version, so every update changes the ETag
+func (b *updateProductbuilder) updateVersionQuery() {
+	b.queryFields = append(b.queryFields, `
+		version = version + 1`)
+}
+
+// updatePrices upsert price of every currency sent, price 0 is a valid price
+func (b *updateProductbuilder) updatePrices() error {
+	if len(b.req.Prices) == 0 {
+		return nil
+	}
+
+	query := `
+	INSERT INTO product_prices (
+		product_id,
+		currency,
+		amount
+	)
+	SELECT $1, UNNEST($2::VARCHAR[]), UNNEST($3::BIGINT[])
+	ON CONFLICT (product_id, currency) DO UPDATE SET
+		amount = EXCLUDED.amount;`
+
+	currencies, amounts := priceColumns(b.req.Prices)
+	if _, err := b.tx.ExecContext(
+		context.Background(),
+		query,
+		b.req.Id,
+		currencies,
+		amounts,
+	); err != nil {
+		b.tx.Rollback()
+		return fmt.Errorf("update product_prices failed: %v", err)
+	}
+	return nil
+}
+func (b *updateProductbuilder) removePrices() error {
+	if len(b.req.RemovePrices) == 0 {
 		return nil
 	}
-	if b.req.Category.Id == 0 {
+
+	query := `
+	DELETE FROM product_prices
+	WHERE product_id = $1
+	AND currency = ANY($2::VARCHAR[]);`
+
+	if _, err := b.tx.ExecContext(
+		context.Background(),
+		query,
+		b.req.Id,
+		b.req.RemovePrices,
+	); err != nil {
+		b.tx.Rollback()
+		return fmt.Errorf("remove product_prices failed: %v", err)
+	}
+	return nil
+}
+
+// updateCategory replace all categories when category or categories is sent
+func (b *updateProductbuilder) updateCategory() error {
+	categoryIds := b.req.CategoryIds()
+	if len(categoryIds) == 0 {
 		return nil
 	}
 
 	query := `
-	UPDATE products_categories SET
-		category_id = $1
-	WHERE product_id = $2;`
+	DELETE FROM products_categories
+	WHERE product_id = $1
+	AND NOT (category_id = ANY($2::INT[]));`
 
 	if _, err := b.tx.ExecContext(
 		context.Background(),
 		query,
-		b.req.Category.Id,
 		b.req.Id,
+		categoryIds,
 	); err != nil {
 		b.tx.Rollback()
 		return fmt.Errorf("update products_categories failed: %v", err)
 	}
+
+	b.req.AddCategories = append(b.req.AddCategories, categoryIds...)
+	return nil
+}
+func (b *updateProductbuilder) addCategories() error {
+	if len(b.req.AddCategories) == 0 {
+		return nil
+	}
+
+	query := `
+	INSERT INTO products_categories (
+		product_id,
+		category_id
+	)
+	SELECT $1, UNNEST($2::INT[])
+	ON CONFLICT (product_id, category_id) DO NOTHING;`
+
+	if _, err := b.tx.ExecContext(
+		context.Background(),
+		query,
+		b.req.Id,
+		b.req.AddCategories,
+	); err != nil {
+		b.tx.Rollback()
+		return fmt.Errorf("add products_categories failed: %v", err)
+	}
+	return nil
+}
+func (b *updateProductbuilder) removeCategories() error {
+	if len(b.req.RemoveCategories) == 0 {
+		return nil
+	}
+
+	query := `
+	DELETE FROM products_categories
+	WHERE product_id = $1
+	AND category_id = ANY($2::INT[]);`
+
+	if _, err := b.tx.ExecContext(
+		context.Background(),
+		query,
+		b.req.Id,
+		b.req.RemoveCategories,
+	); err != nil {
+		b.tx.Rollback()
+		return fmt.Errorf("remove products_categories failed: %v", err)
+	}
 	return nil
 }
 func (b *updateProductbuilder) insertImages() error {
@@ -126,10 +354,14 @@ func (b *updateProductbuilder) insertImages() error {
 	INSERT INTO images (
 		filename,
 		url,
-		product_id
+		product_id,
+		position,
+		alt,
+		is_primary
 	)
 	VALUES
 	`
+	orderImages(b.req.Images)
 	valueStack := make([]any, 0)
 	var index int
 	for i := range b.req.Images {
@@ -137,15 +369,18 @@ func (b *updateProductbuilder) insertImages() error {
 			b.req.Images[i].FileName,
 			b.req.Images[i].Url,
 			b.req.Id,
+			b.req.Images[i].Position,
+			b.req.Images[i].Alt,
+			b.req.Images[i].IsPrimary,
 		)
 		if i != len(b.req.Images)-1 {
 			query += fmt.Sprintf(`
-			($%d, $%d, $%d),`, index+1, index+2, index+3)
+			($%d, $%d, $%d, $%d, $%d, $%d),`, index+1, index+2, index+3, index+4, index+5, index+6)
 		} else {
 			query += fmt.Sprintf(`
-			($%d, $%d, $%d);`, index+1, index+2, index+3)
+			($%d, $%d, $%d, $%d, $%d, $%d);`, index+1, index+2, index+3, index+4, index+5, index+6)
 		}
-		index += 3
+		index += 6
 	}
 
 	if _, err := b.tx.ExecContext(
@@ -178,25 +413,13 @@ func (b *updateProductbuilder) getOldImages() []*entities.Image {
 	}
 	return images
 }
+
+// deleteOldImages remove only the records, files are kept on GCP for rollback until the product is purged from trash
 func (b *updateProductbuilder) deleteOldImages() error {
 	query := `
 	DELETE FROM images
 	WHERE product_id = $1;`
 
-	images := b.getOldImages()
-	if len(images) > 0 {
-		deleteFileReq := make([]*files.DeleteFileReq, 0)
-		for _, img := range images {
-			deleteFileReq = append(deleteFileReq, &files.DeleteFileReq{
-				Destination: fmt.Sprintf("image/test/%s", img.FileName),
-			})
-		}
-		if err := b.filesUsecases.DeleteFileOnGCP(deleteFileReq); err != nil {
-			b.tx.Rollback()
-			return err
-		}
-	}
-
 	if _, err := b.tx.ExecContext(
 		context.Background(),
 		query,
@@ -213,18 +436,38 @@ func (b *updateProductbuilder) closeQuery() {
 
 	b.query += fmt.Sprintf(`
 	WHERE id = $%d`, b.lastStackIndex)
+
+	// If-Match, update only when nobody changed the product since client read it
+	if b.req.Version > 0 {
+		b.value = append(b.value, b.req.Version)
+		b.lastStackIndex = len(b.value)
+
+		b.query += fmt.Sprintf(`
+	AND version = $%d`, b.lastStackIndex)
+	}
 }
 func (b *updateProductbuilder) updateProduct() error {
-	if _, err := b.tx.ExecContext(
+	result, err := b.tx.ExecContext(
 		context.Background(),
 		b.query,
 		b.value...,
-	); err != nil {
+	)
+	if err != nil {
 		b.tx.Rollback()
+		if strings.Contains(err.Error(), "products_tax_class_id_fkey") {
+			return fmt.Errorf("tax class not found")
+		}
 		return fmt.Errorf("uapdte products failed: %v", err)
 	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		b.tx.Rollback()
+		return fmt.Errorf("product version is stale")
+	}
 	return nil
 }
+func (b *updateProductbuilder) insertRevision() error {
+	return InsertRevision(context.Background(), b.tx, b.req.Id, b.userId)
+}
 func (b *updateProductbuilder) getQueryFields() []string {
 	return b.queryFields
 }
@@ -254,9 +497,13 @@ func UpdateProductProductEngineer(b IUpdateProductBuilder) *updateProductProduct
 }
 
 func (en *updateProductProductEngineer) sumQueryFields() {
+	en.builder.updateSlugQuery()
 	en.builder.updateTitleQuery()
 	en.builder.updateDescriptionQuery()
-	en.builder.updatePriceQuery()
+	en.builder.updateStatusQuery()
+	en.builder.updateTaxClassQuery()
+	en.builder.updateShippingQuery()
+	en.builder.updateVersionQuery()
 
 	fields := en.builder.getQueryFields()
 
@@ -271,7 +518,13 @@ func (en *updateProductProductEngineer) sumQueryFields() {
 }
 
 func (en *updateProductProductEngineer) UpdateProduct() error {
-	en.builder.initTransaction()
+	if err := en.builder.initTransaction(); err != nil {
+		return err
+	}
+
+	if err := en.builder.setSlug(); err != nil {
+		return err
+	}
 
 	en.builder.initQuery()
 	en.sumQueryFields()
@@ -279,13 +532,27 @@ func (en *updateProductProductEngineer) UpdateProduct() error {
 
 	// Update Product
 	if err := en.builder.updateProduct(); err != nil {
-		return nil
+		return err
+	}
+
+	// update prices
+	if err := en.builder.updatePrices(); err != nil {
+		return err
+	}
+	if err := en.builder.removePrices(); err != nil {
+		return err
 	}
 
 	// update category
 	if err := en.builder.updateCategory(); err != nil {
 		return err
 	}
+	if err := en.builder.addCategories(); err != nil {
+		return err
+	}
+	if err := en.builder.removeCategories(); err != nil {
+		return err
+	}
 
 	if en.builder.getImagesLen() > 0 {
 		if err := en.builder.deleteOldImages(); err != nil {
@@ -296,6 +563,11 @@ func (en *updateProductProductEngineer) UpdateProduct() error {
 		}
 	}
 
+	// Save revision of updated product
+	if err := en.builder.insertRevision(); err != nil {
+		return err
+	}
+
 	// Commit
 	if err := en.builder.commit(); err != nil {
 		return err
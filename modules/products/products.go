@@ -1,24 +1,424 @@
 package products
 
 import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
 	"github.com/DrumPatiphon/go-rest-api-service/modules/appInfo"
 	"github.com/DrumPatiphon/go-rest-api-service/modules/entities"
+	"github.com/DrumPatiphon/go-rest-api-service/pkg/locale"
+	"github.com/DrumPatiphon/go-rest-api-service/pkg/money"
 )
 
+// Product fields from the first version keep their Go names on the wire, clients depend on them
 type Product struct {
-	Id          string            `json: "id"`
-	Title       string            `json: "title"`
-	Description string            `json: "description"`
-	Category    *appInfo.Category `json: "category"`
-	CreatedAt   string            `json: "created_at"`
-	UpdatedAt   string            `json: "updated_at"`
-	Price       float64           `json: "price"`
-	Images      []*entities.Image `json: "images"`
+	Id              string                `json:"Id"`
+	Title           string                `json:"Title"`
+	Slug            string                `json:"slug"`   // from title when empty
+	Locale          string                `json:"locale"` // of title, description and categories
+	Description     string                `json:"Description"`
+	Status          string                `json:"status"`     // draft, published, archived
+	PublishAt       string                `json:"publish_at"` // draft with publish_at is scheduled
+	Category        *appInfo.Category     `json:"Category"`   // first category, kept for old clients
+	Categories      []*appInfo.Category   `json:"categories"`
+	Breadcrumbs     [][]*appInfo.Category `json:"breadcrumbs,omitempty"` // path from root of every category
+	CreatedAt       string                `json:"CreatedAt"`
+	UpdatedAt       string                `json:"UpdatedAt"`
+	DeletedAt       string                `json:"deleted_at,omitempty"`
+	Price           *money.Money          `json:"Price"`         // effective price for the caller in requested currency
+	RegularPrice    *money.Money          `json:"regular_price"` // before sale and price list
+	Prices          []*money.Money        `json:"prices"`        // regular price of every currency
+	EffectivePrices []*money.Money        `json:"-"`
+	Images          []*entities.Image     `json:"Images"`
+	Version         int                   `json:"version"`
+	RatingAverage   float64               `json:"rating_average"`
+	ReviewCount     int                   `json:"review_count"`
+	Stock           *int                  `json:"stock"`        // nil is not tracked
+	TaxClassId      string                `json:"tax_class_id"` // standard when empty on insert
+	Weight          *int                  `json:"weight"`       // grams, nil is unknown
+	Length          *int                  `json:"length"`       // millimetres
+	Width           *int                  `json:"width"`
+	Height          *int                  `json:"height"`
+}
+
+type UpdateProductReq struct {
+	*Product
+	AddCategories    []int    `json:"add_categories"`
+	RemoveCategories []int    `json:"remove_categories"`
+	RemovePrices     []string `json:"remove_prices"` // currencies
+	Version          int      `json:"-"`             // from If-Match header, 0 is no check
+}
+
+// NormalizePrices validate price and prices of request then merge them into Prices,
+// price without currency is in defaultCurrency
+func (obj *Product) NormalizePrices(defaultCurrency string) error {
+	prices := make([]*money.Money, 0)
+	if obj.Price != nil {
+		prices = append(prices, obj.Price)
+	}
+	for _, p := range obj.Prices {
+		if p != nil {
+			prices = append(prices, p)
+		}
+	}
+
+	currencies := make(map[string]bool)
+	for _, p := range prices {
+		if err := p.Validate(defaultCurrency); err != nil {
+			return err
+		}
+		if currencies[p.Currency] {
+			return fmt.Errorf("price in %s is duplicated", p.Currency)
+		}
+		currencies[p.Currency] = true
+	}
+	obj.Price = nil
+	obj.Prices = prices
+	return nil
+}
+
+// ValidateDimensions check weight and dimensions which are sent
+func (obj *Product) ValidateDimensions() error {
+	names := []string{"weight", "length", "width", "height"}
+	for i, v := range []*int{obj.Weight, obj.Length, obj.Width, obj.Height} {
+		if v != nil && *v < 0 {
+			return fmt.Errorf("%s must not be negative", names[i])
+		}
+	}
+	return nil
+}
+
+// PriceIn return price in currency or nil
+func (obj *Product) PriceIn(currency string) *money.Money {
+	for _, p := range obj.Prices {
+		if p.Currency == currency {
+			return p
+		}
+	}
+	return nil
+}
+
+// SetPrice pick price for response, product without price in currency use defaultCurrency.
+// Price is the effective price in the same currency when it's set
+func (obj *Product) SetPrice(currency, defaultCurrency string) {
+	obj.RegularPrice = obj.PriceIn(currency)
+	if obj.RegularPrice == nil {
+		obj.RegularPrice = obj.PriceIn(defaultCurrency)
+	}
+
+	obj.Price = obj.RegularPrice
+	if obj.RegularPrice == nil {
+		return
+	}
+	for _, p := range obj.EffectivePrices {
+		if p.Currency == obj.RegularPrice.Currency {
+			obj.Price = p
+		}
+	}
+}
+
+// statuses of product, only published one is shown to public
+var statuses = map[string]bool{
+	"draft":     true,
+	"published": true,
+	"archived":  true,
+}
+
+func IsStatus(status string) bool {
+	return statuses[status]
+}
+
+// ETag of the current product version
+func (obj *Product) ETag() string {
+	return fmt.Sprintf(`"%d"`, obj.Version)
+}
+
+// ParseETag return version from If-Match header, 0 when header is empty or *
+func ParseETag(header string) (int, error) {
+	header = strings.TrimSpace(header)
+	if header == "" || header == "*" {
+		return 0, nil
+	}
+
+	version, err := strconv.Atoi(strings.Trim(strings.TrimPrefix(header, "W/"), `"`))
+	if err != nil || version <= 0 {
+		return 0, fmt.Errorf("If-Match header is invalid")
+	}
+	return version, nil
+}
+
+// ProductSlug is the product of a slug, Redirect is true when slug is an old one
+type ProductSlug struct {
+	ProductId string `db:"product_id" json:"product_id"`
+	Slug      string `db:"slug" json:"slug"` // current slug
+	Status    string `db:"status" json:"-"`
+	Redirect  bool   `db:"redirect" json:"-"`
+}
+
+// ProductViewReq is how products are shown to the caller
+type ProductViewReq struct {
+	Currency string `query:"currency"` // price and sort by price in this currency
+	Locale   string `query:"lang"`     // from Accept-Language when empty
+	UserId   string `query:"-"`        // caller, for effective price
+	IsAdmin  bool   `query:"-"`        // admin can see draft and archived product
+}
+
+// NewViewReq check currency and lang of the caller, locale is lang, then Accept-Language, then defaultLocale
+func NewViewReq(currency, lang, acceptLanguage, defaultLocale, userId string) (*ProductViewReq, error) {
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	if currency != "" && !money.IsCurrency(currency) {
+		return nil, fmt.Errorf("currency %s is not supported", currency)
+	}
+	lang = strings.ToLower(strings.TrimSpace(lang))
+	if lang != "" && !locale.IsLocale(lang) {
+		return nil, fmt.Errorf("lang %s is not supported", lang)
+	}
+
+	return &ProductViewReq{
+		Currency: currency,
+		Locale:   locale.Parse(lang, acceptLanguage, defaultLocale),
+		UserId:   userId,
+	}, nil
 }
 
 type ProductFilter struct {
-	Id                      string `query:"id"`
-	Search                  string `query:"search"`
-	*entities.PaginationReq        // like inherit class
+	Id          string `query:"id"`
+	Search      string `query:"search"`
+	CategoryIds []int  `query:"category_id"` // ?category_id=1&category_id=2 match any
+	Status      string `query:"status"`      // draft, scheduled, published, archived, public listing is published only
+	WishlistOf  string `query:"-"`           // products in wishlist of the user
+	ProductViewReq
+	*entities.PaginationReq // like inherit class
 	*entities.SortReq
 }
+
+// CategoryIds return every category id of req, the legacy category comes first
+func (obj *Product) CategoryIds() []int {
+	ids := make([]int, 0)
+	if obj.Category != nil && obj.Category.Id != 0 {
+		ids = append(ids, obj.Category.Id)
+	}
+	for _, c := range obj.Categories {
+		if c == nil || c.Id == 0 {
+			continue
+		}
+		ids = append(ids, c.Id)
+	}
+	return ids
+}
+
+// SpecialPrice is a sale price for everyone when PriceListId is 0, otherwise for users of the price list.
+// Empty StartsAt or EndsAt is no limit
+type SpecialPrice struct {
+	Id          string       `json:"id"`
+	ProductId   string       `json:"product_id"`
+	PriceListId int          `json:"price_list_id"`
+	Price       *money.Money `json:"price"`
+	StartsAt    string       `json:"starts_at"`
+	EndsAt      string       `json:"ends_at"`
+	CreatedAt   string       `json:"created_at"`
+	UpdatedAt   string       `json:"updated_at"`
+}
+
+type ProductTranslation struct {
+	ProductId   string `db:"product_id" json:"product_id"`
+	Locale      string `db:"locale" json:"locale"`
+	Title       string `db:"title" json:"title" form:"title"`
+	Description string `db:"description" json:"description" form:"description"`
+	CreatedAt   string `db:"created_at" json:"created_at"`
+	UpdatedAt   string `db:"updated_at" json:"updated_at"`
+}
+
+// Translate replace title, description and category titles with the locale ones,
+// field without translation keep the default locale
+func (obj *Product) Translate(locale string, translation *ProductTranslation, categoryTitles map[int]string) {
+	obj.Locale = locale
+	if translation != nil {
+		obj.Title = translation.Title
+		if translation.Description != "" {
+			obj.Description = translation.Description
+		}
+	}
+
+	categories := obj.Categories
+	if obj.Category != nil {
+		categories = append([]*appInfo.Category{obj.Category}, categories...)
+	}
+	for _, c := range categories {
+		if c == nil {
+			continue
+		}
+		if title, ok := categoryTitles[c.Id]; ok {
+			c.Title = title
+		}
+	}
+}
+
+// SetBreadcrumbs set path from root of every category, categories is by id
+func (obj *Product) SetBreadcrumbs(categories map[int]*appInfo.Category) {
+	obj.Breadcrumbs = make([][]*appInfo.Category, 0)
+	for _, c := range obj.Categories {
+		if c == nil {
+			continue
+		}
+		if path := appInfo.Breadcrumb(categories, c.Id); len(path) > 0 {
+			obj.Breadcrumbs = append(obj.Breadcrumbs, path)
+		}
+	}
+}
+
+// InsertImageReq add an uploaded image to product, empty position append it to the end
+type InsertImageReq struct {
+	FileName  string `json:"filename"`
+	Url       string `json:"url"`
+	Alt       string `json:"alt"`
+	IsPrimary bool   `json:"is_primary"`
+	Position  *int   `json:"position"`
+}
+
+// UpdateImageReq set alt text or make image the primary one
+type UpdateImageReq struct {
+	Alt       *string `json:"alt"`
+	IsPrimary bool    `json:"is_primary"`
+}
+
+// UpdateStockReq set stock, null stop tracking stock of product
+type UpdateStockReq struct {
+	Stock *int `json:"stock"`
+}
+
+// ReorderImagesReq is every image id of product in the new order
+type ReorderImagesReq struct {
+	ImageIds []string `json:"image_ids"`
+}
+
+type ProductRevision struct {
+	Id        string   `json:"id"`
+	ProductId string   `json:"product_id"`
+	Revision  int      `json:"revision"`
+	UserId    string   `json:"user_id"`
+	Snapshot  *Product `json:"snapshot"`
+	CreatedAt string   `json:"created_at"`
+}
+
+type ProductRevisionDiffReq struct {
+	From int `query:"from"`
+	To   int `query:"to"`
+}
+
+type ProductRevisionDiff struct {
+	ProductId string                `json:"product_id"`
+	From      int                   `json:"from"`
+	To        int                   `json:"to"`
+	Changes   []*ProductFieldChange `json:"changes"`
+}
+
+type ProductFieldChange struct {
+	Field string `json:"field"`
+	From  any    `json:"from"`
+	To    any    `json:"to"`
+}
+
+// Diff compare snapshot of obj with to field by field
+func (obj *ProductRevision) Diff(to *ProductRevision) *ProductRevisionDiff {
+	from, next := obj.Snapshot, to.Snapshot
+	changes := make([]*ProductFieldChange, 0)
+
+	if from.Title != next.Title {
+		changes = append(changes, &ProductFieldChange{Field: "title", From: from.Title, To: next.Title})
+	}
+	if from.Description != next.Description {
+		changes = append(changes, &ProductFieldChange{Field: "description", From: from.Description, To: next.Description})
+	}
+	if !sameValues(priceValues(from.Prices), priceValues(next.Prices)) {
+		changes = append(changes, &ProductFieldChange{Field: "prices", From: from.Prices, To: next.Prices})
+	}
+	if !sameValues(categoryIds(from.Categories), categoryIds(next.Categories)) {
+		changes = append(changes, &ProductFieldChange{Field: "categories", From: from.Categories, To: next.Categories})
+	}
+	if !sameValues(imageUrls(from.Images), imageUrls(next.Images)) {
+		changes = append(changes, &ProductFieldChange{Field: "images", From: from.Images, To: next.Images})
+	}
+
+	return &ProductRevisionDiff{
+		ProductId: obj.ProductId,
+		From:      obj.Revision,
+		To:        to.Revision,
+		Changes:   changes,
+	}
+}
+
+func categoryIds(categories []*appInfo.Category) []string {
+	ids := make([]string, 0)
+	for _, c := range categories {
+		ids = append(ids, strconv.Itoa(c.Id))
+	}
+	return ids
+}
+
+func imageUrls(images []*entities.Image) []string {
+	urls := make([]string, 0)
+	for _, i := range images {
+		urls = append(urls, i.Url)
+	}
+	return urls
+}
+
+func priceValues(prices []*money.Money) []string {
+	values := make([]string, 0)
+	for _, p := range prices {
+		values = append(values, fmt.Sprintf("%s %d", p.Currency, p.Amount))
+	}
+	return values
+}
+
+func sameValues(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	a, b = append([]string{}, a...), append([]string{}, b...)
+	sort.Strings(a)
+	sort.Strings(b)
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+type ImportProductReq struct {
+	Format string `form:"format"` // csv, ndjson, default from file extension
+	DryRun bool   `form:"dry_run"`
+}
+
+// ImportProductRow is a row of import file, Categories can be title or id
+type ImportProductRow struct {
+	Line        int            `json:"line"`
+	Title       string         `json:"title"`
+	Description string         `json:"description"`
+	Prices      []*money.Money `json:"prices"`
+	Categories  []string       `json:"categories"`
+	ImageUrls   []string       `json:"images"`
+	Errors      []string       `json:"errors,omitempty"`
+}
+
+type ImportRowError struct {
+	Line   int      `json:"line"`
+	Errors []string `json:"errors"`
+}
+
+type ImportJob struct {
+	Id           string            `json:"id"`
+	UserId       string            `json:"user_id"`
+	Status       string            `json:"status"` // running, completed, failed
+	DryRun       bool              `json:"dry_run"`
+	TotalRows    int               `json:"total_rows"`
+	ValidRows    int               `json:"valid_rows"`
+	InsertedRows int               `json:"inserted_rows"`
+	RowErrors    []*ImportRowError `json:"row_errors"`
+	CreatedAt    string            `json:"created_at"`
+	UpdatedAt    string            `json:"updated_at"`
+}
@@ -1,36 +1,85 @@
 package productsHandlers
 
 import (
+	"bufio"
 	"fmt"
+	"log"
+	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/DrumPatiphon/go-rest-api-service/config"
 	"github.com/DrumPatiphon/go-rest-api-service/modules/appInfo"
 	"github.com/DrumPatiphon/go-rest-api-service/modules/entities"
-	"github.com/DrumPatiphon/go-rest-api-service/modules/files"
 	"github.com/DrumPatiphon/go-rest-api-service/modules/files/filesUsecases"
 	"github.com/DrumPatiphon/go-rest-api-service/modules/products"
 	"github.com/DrumPatiphon/go-rest-api-service/modules/products/productsUsecases"
+	"github.com/DrumPatiphon/go-rest-api-service/pkg/locale"
+	"github.com/DrumPatiphon/go-rest-api-service/pkg/money"
 	"github.com/gofiber/fiber/v2"
 )
 
 type productsHandlerErrCode string
 
 const (
-	findOneProductErr productsHandlerErrCode = "products-001"
-	findProductErr    productsHandlerErrCode = "products-002"
-	insertProductErr  productsHandlerErrCode = "products-003"
-	updateProductErr  productsHandlerErrCode = "products-004"
-	deleteProductErr  productsHandlerErrCode = "products-005"
+	findOneProductErr     productsHandlerErrCode = "products-001"
+	findProductErr        productsHandlerErrCode = "products-002"
+	insertProductErr      productsHandlerErrCode = "products-003"
+	updateProductErr      productsHandlerErrCode = "products-004"
+	deleteProductErr      productsHandlerErrCode = "products-005"
+	findTrashErr          productsHandlerErrCode = "products-006"
+	restoreProductErr     productsHandlerErrCode = "products-007"
+	findRevisionsErr      productsHandlerErrCode = "products-008"
+	diffRevisionsErr      productsHandlerErrCode = "products-009"
+	rollbackErr           productsHandlerErrCode = "products-010"
+	versionStaleErr       productsHandlerErrCode = "products-011"
+	importProductErr      productsHandlerErrCode = "products-012"
+	findImportJobErr      productsHandlerErrCode = "products-013"
+	exportProductErr      productsHandlerErrCode = "products-014"
+	findSpecialPriceErr   productsHandlerErrCode = "products-015"
+	insertSpecialPriceErr productsHandlerErrCode = "products-016"
+	deleteSpecialPriceErr productsHandlerErrCode = "products-017"
+	findProductBySlugErr  productsHandlerErrCode = "products-018"
+	findTranslationsErr   productsHandlerErrCode = "products-019"
+	upsertTranslationErr  productsHandlerErrCode = "products-020"
+	deleteTranslationErr  productsHandlerErrCode = "products-021"
+	findAdminProductErr   productsHandlerErrCode = "products-022"
+	insertImageErr        productsHandlerErrCode = "products-023"
+	updateImageErr        productsHandlerErrCode = "products-024"
+	deleteImageErr        productsHandlerErrCode = "products-025"
+	reorderImagesErr      productsHandlerErrCode = "products-026"
+	updateStockErr        productsHandlerErrCode = "products-027"
 )
 
 type IProductHandler interface {
 	FindOneProduct(c *fiber.Ctx) error
+	FindOneProductBySlug(c *fiber.Ctx) error
 	FindProduct(c *fiber.Ctx) error
+	FindAdminProduct(c *fiber.Ctx) error
 	InsertProduct(c *fiber.Ctx) error
 	UpdateProduct(c *fiber.Ctx) error
 	DeleteProduct(c *fiber.Ctx) error
+	FindTrashProduct(c *fiber.Ctx) error
+	RestoreProduct(c *fiber.Ctx) error
+	UpdateStock(c *fiber.Ctx) error
+	FindRevisions(c *fiber.Ctx) error
+	DiffRevisions(c *fiber.Ctx) error
+	RollbackProduct(c *fiber.Ctx) error
+	ImportProducts(c *fiber.Ctx) error
+	FindOneImportJob(c *fiber.Ctx) error
+	ExportProducts(c *fiber.Ctx) error
+	FindSpecialPrices(c *fiber.Ctx) error
+	InsertSpecialPrice(c *fiber.Ctx) error
+	DeleteSpecialPrice(c *fiber.Ctx) error
+	FindTranslations(c *fiber.Ctx) error
+	InsertImage(c *fiber.Ctx) error
+	UpdateImage(c *fiber.Ctx) error
+	DeleteImage(c *fiber.Ctx) error
+	ReorderImages(c *fiber.Ctx) error
+	UpsertTranslation(c *fiber.Ctx) error
+	DeleteTranslation(c *fiber.Ctx) error
 }
 
 type productsHandler struct {
@@ -50,14 +99,84 @@ func ProductsHandler(cfg config.Iconfig, productsUsecases productsUsecases.IProd
 func (h *productsHandler) FindOneProduct(c *fiber.Ctx) error {
 	productId := strings.Trim(c.Params("product_id"), " ")
 
-	product, err := h.productsUsecases.FindOneProduct(productId)
+	req, err := h.viewReq(c, c.Query("currency"), c.Query("lang"))
 	if err != nil {
 		return entities.NewResponse(c).Error(
-			fiber.ErrInternalServerError.Code,
+			fiber.ErrBadRequest.Code,
 			string(findOneProductErr),
 			err.Error(),
 		).Res()
 	}
+
+	product, err := h.productsUsecases.FindOneProduct(productId, req)
+	if err != nil {
+		switch err.Error() {
+		case "product not found":
+			return entities.NewResponse(c).Error(
+				fiber.ErrNotFound.Code,
+				string(findOneProductErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(findOneProductErr),
+				err.Error(),
+			).Res()
+		}
+	}
+
+	c.Set(fiber.HeaderETag, product.ETag())
+	return entities.NewResponse(c).Success(fiber.StatusOK, product).Res()
+}
+
+// FindOneProductBySlug response 301 with the current slug when slug is an old one
+func (h *productsHandler) FindOneProductBySlug(c *fiber.Ctx) error {
+	slug := strings.ToLower(strings.Trim(c.Params("slug"), " "))
+
+	req, err := h.viewReq(c, c.Query("currency"), c.Query("lang"))
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(findProductBySlugErr),
+			err.Error(),
+		).Res()
+	}
+
+	product, productSlug, err := h.productsUsecases.FindOneProductBySlug(slug, req)
+	if err != nil {
+		switch err.Error() {
+		case "product not found":
+			return entities.NewResponse(c).Error(
+				fiber.ErrNotFound.Code,
+				string(findProductBySlugErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(findProductBySlugErr),
+				err.Error(),
+			).Res()
+		}
+	}
+
+	if product == nil {
+		location := strings.TrimSuffix(c.Path(), c.Params("slug")) + productSlug.Slug
+		c.Location(location)
+		return entities.NewResponse(c).Success(
+			fiber.StatusMovedPermanently,
+			&struct {
+				*products.ProductSlug
+				Location string `json:"location"`
+			}{
+				ProductSlug: productSlug,
+				Location:    location,
+			},
+		).Res()
+	}
+
+	c.Set(fiber.HeaderETag, product.ETag())
 	return entities.NewResponse(c).Success(fiber.StatusOK, product).Res()
 }
 
@@ -75,6 +194,62 @@ func (h *productsHandler) FindProduct(c *fiber.Ctx) error {
 		).Res()
 	}
 
+	view, err := h.viewReq(c, req.Currency, req.Locale)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(findProductErr),
+			err.Error(),
+		).Res()
+	}
+	req.ProductViewReq = *view
+	req.Status = "published"
+	setDefaultFilter(req)
+
+	products := h.productsUsecases.FindProduct(req)
+	return entities.NewResponse(c).Success(fiber.StatusOK, products).Res()
+}
+
+// FindAdminProduct list products of every status, filter by status=draft|scheduled|published|archived
+func (h *productsHandler) FindAdminProduct(c *fiber.Ctx) error {
+	req := &products.ProductFilter{
+		PaginationReq: &entities.PaginationReq{},
+		SortReq:       &entities.SortReq{},
+	}
+
+	if err := c.QueryParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(findAdminProductErr),
+			err.Error(),
+		).Res()
+	}
+
+	view, err := h.viewReq(c, req.Currency, req.Locale)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(findAdminProductErr),
+			err.Error(),
+		).Res()
+	}
+	req.ProductViewReq = *view
+
+	req.Status = strings.ToLower(strings.TrimSpace(req.Status))
+	if req.Status != "" && req.Status != "scheduled" && !products.IsStatus(req.Status) {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(findAdminProductErr),
+			fmt.Sprintf("status %s is invalid", req.Status),
+		).Res()
+	}
+	setDefaultFilter(req)
+
+	products := h.productsUsecases.FindProduct(req)
+	return entities.NewResponse(c).Success(fiber.StatusOK, products).Res()
+}
+
+func setDefaultFilter(req *products.ProductFilter) {
 	if req.Page < 1 {
 		req.Page = 1
 	}
@@ -88,15 +263,58 @@ func (h *productsHandler) FindProduct(c *fiber.Ctx) error {
 	if req.Sort == "" {
 		req.Sort = "ASC"
 	}
+}
+
+// viewReq return how products are shown to the caller, locale is from lang or Accept-Language header.
+// userId is set when caller send access token, price is for the user
+func (h *productsHandler) viewReq(c *fiber.Ctx, currency, lang string) (*products.ProductViewReq, error) {
+	userId, _ := c.Locals("userId").(string)
+	userRoleId, _ := c.Locals("userRoleId").(int)
+	req, err := products.NewViewReq(currency, lang, c.Get(fiber.HeaderAcceptLanguage), h.cfg.App().Locale(), userId)
+	if err != nil {
+		return nil, err
+	}
+	req.IsAdmin = userRoleId == 2
+	return req, nil
+}
 
-	products := h.productsUsecases.FindProduct(req)
-	return entities.NewResponse(c).Success(fiber.StatusOK, products).Res()
+// checkStatus validate status and publish_at of req, publish_at is RFC3339 and only for draft.
+// status is draft when only publish_at is sent
+func checkStatus(req *products.Product) error {
+	req.Status = strings.ToLower(strings.TrimSpace(req.Status))
+	req.PublishAt = strings.TrimSpace(req.PublishAt)
+	if req.PublishAt != "" && req.Status == "" {
+		req.Status = "draft"
+	}
+
+	if req.Status != "" && !products.IsStatus(req.Status) {
+		return fmt.Errorf("status %s is invalid", req.Status)
+	}
+	if req.PublishAt != "" {
+		if req.Status != "draft" {
+			return fmt.Errorf("publish_at is only for draft product")
+		}
+		if _, err := time.Parse(time.RFC3339, req.PublishAt); err != nil {
+			return fmt.Errorf("publish_at is invalid")
+		}
+	}
+	return nil
+}
+
+// checkCurrency return currency in upper case, empty is the default currency
+func checkCurrency(currency string) (string, error) {
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	if currency != "" && !money.IsCurrency(currency) {
+		return "", fmt.Errorf("currency %s is not supported", currency)
+	}
+	return currency, nil
 }
 
 func (h *productsHandler) InsertProduct(c *fiber.Ctx) error {
 	req := &products.Product{
-		Category: &appInfo.Category{},
-		Images:   make([]*entities.Image, 0),
+		Category:   &appInfo.Category{},
+		Categories: make([]*appInfo.Category, 0),
+		Images:     make([]*entities.Image, 0),
 	}
 	if err := c.BodyParser(req); err != nil {
 		return entities.NewResponse(c).Error(
@@ -106,16 +324,63 @@ func (h *productsHandler) InsertProduct(c *fiber.Ctx) error {
 		).Res()
 	}
 
-	if req.Category.Id <= 0 {
+	categoryIds := req.CategoryIds()
+	if len(categoryIds) == 0 {
 		return entities.NewResponse(c).Error(
 			fiber.ErrBadRequest.Code,
 			string(insertProductErr),
 			"cagetory is is invalid",
 		).Res()
 	}
+	for _, id := range categoryIds {
+		if id < 0 {
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(insertProductErr),
+				"cagetory is is invalid",
+			).Res()
+		}
+	}
+
+	if err := req.NormalizePrices(h.cfg.App().Currency()); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(insertProductErr),
+			err.Error(),
+		).Res()
+	}
+	if err := req.ValidateDimensions(); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(insertProductErr),
+			err.Error(),
+		).Res()
+	}
+	if req.PriceIn(h.cfg.App().Currency()) == nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(insertProductErr),
+			fmt.Sprintf("price in %s is required", h.cfg.App().Currency()),
+		).Res()
+	}
+	if err := checkStatus(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(insertProductErr),
+			err.Error(),
+		).Res()
+	}
 
-	product, err := h.productsUsecases.InsertProduct(req)
+	userId, _ := c.Locals("userId").(string)
+	product, err := h.productsUsecases.InsertProduct(req, userId)
 	if err != nil {
+		if err.Error() == "tax class not found" {
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(insertProductErr),
+				err.Error(),
+			).Res()
+		}
 		return entities.NewResponse(c).Error(
 			fiber.ErrInternalServerError.Code,
 			string(insertProductErr),
@@ -129,9 +394,15 @@ func (h *productsHandler) InsertProduct(c *fiber.Ctx) error {
 func (h *productsHandler) UpdateProduct(c *fiber.Ctx) error {
 	productId := strings.Trim(c.Params("product_id"), " ")
 
-	req := &products.Product{
-		Images:   make([]*entities.Image, 0),
-		Category: &appInfo.Category{},
+	req := &products.UpdateProductReq{
+		Product: &products.Product{
+			Images:     make([]*entities.Image, 0),
+			Category:   &appInfo.Category{},
+			Categories: make([]*appInfo.Category, 0),
+		},
+		AddCategories:    make([]int, 0),
+		RemoveCategories: make([]int, 0),
+		RemovePrices:     make([]string, 0),
 	}
 	if err := c.BodyParser(req); err != nil {
 		return entities.NewResponse(c).Error(
@@ -142,8 +413,66 @@ func (h *productsHandler) UpdateProduct(c *fiber.Ctx) error {
 	}
 	req.Id = productId
 
-	product, err := h.productsUsecases.UpdateProduct(req)
+	// price is updated only when sent, so it can be set to 0
+	if err := req.NormalizePrices(h.cfg.App().Currency()); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(updateProductErr),
+			err.Error(),
+		).Res()
+	}
+	if err := req.ValidateDimensions(); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(updateProductErr),
+			err.Error(),
+		).Res()
+	}
+	for i, currency := range req.RemovePrices {
+		req.RemovePrices[i] = strings.ToUpper(strings.TrimSpace(currency))
+		if req.RemovePrices[i] == h.cfg.App().Currency() || req.PriceIn(req.RemovePrices[i]) != nil {
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(updateProductErr),
+				fmt.Sprintf("price in %s can't be removed", req.RemovePrices[i]),
+			).Res()
+		}
+	}
+	if err := checkStatus(req.Product); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(updateProductErr),
+			err.Error(),
+		).Res()
+	}
+
+	version, err := products.ParseETag(c.Get(fiber.HeaderIfMatch))
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(updateProductErr),
+			err.Error(),
+		).Res()
+	}
+	req.Version = version
+
+	userId, _ := c.Locals("userId").(string)
+	product, err := h.productsUsecases.UpdateProduct(req, userId)
 	if err != nil {
+		if err.Error() == "product version is stale" {
+			return entities.NewResponse(c).Error(
+				fiber.ErrPreconditionFailed.Code,
+				string(versionStaleErr),
+				err.Error(),
+			).Res()
+		}
+		if err.Error() == "tax class not found" {
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(updateProductErr),
+				err.Error(),
+			).Res()
+		}
 		_, file, line, _ := runtime.Caller(0)
 		errMsg := fmt.Sprintf("%s:%d %s", file, line, err.Error())
 		return entities.NewResponse(c).Error(
@@ -152,47 +481,672 @@ func (h *productsHandler) UpdateProduct(c *fiber.Ctx) error {
 			errMsg,
 		).Res()
 	}
+
+	c.Set(fiber.HeaderETag, product.ETag())
 	return entities.NewResponse(c).Success(fiber.StatusCreated, product).Res()
 }
 
 func (h *productsHandler) DeleteProduct(c *fiber.Ctx) error {
 	productId := strings.Trim(c.Params("product_id"), " ")
 
-	product, err := h.productsUsecases.FindOneProduct(productId)
+	// images are kept on GCP until the product is purged from trash
+	if err := h.productsUsecases.DeleteProduct(productId); err != nil {
+		switch err.Error() {
+		case "product not found":
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(deleteProductErr),
+				err.Error(),
+			).Res()
+		default:
+			_, file, line, _ := runtime.Caller(0)
+			errMsg := fmt.Sprintf("%s:%d %s", file, line, err.Error())
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(deleteProductErr),
+				errMsg,
+			).Res()
+		}
+	}
+
+	return entities.NewResponse(c).Success(fiber.StatusNoContent, nil).Res()
+}
+
+func (h *productsHandler) FindTrashProduct(c *fiber.Ctx) error {
+	req := &products.ProductFilter{
+		PaginationReq: &entities.PaginationReq{},
+		SortReq:       &entities.SortReq{},
+	}
+
+	if err := c.QueryParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(findTrashErr),
+			err.Error(),
+		).Res()
+	}
+
+	currency, err := checkCurrency(req.Currency)
 	if err != nil {
 		return entities.NewResponse(c).Error(
 			fiber.ErrBadRequest.Code,
-			string(deleteProductErr),
+			string(findTrashErr),
 			err.Error(),
 		).Res()
 	}
+	req.Currency = currency
+	setDefaultFilter(req)
+
+	products := h.productsUsecases.FindTrashProduct(req)
+	return entities.NewResponse(c).Success(fiber.StatusOK, products).Res()
+}
+
+func (h *productsHandler) RestoreProduct(c *fiber.Ctx) error {
+	productId := strings.Trim(c.Params("product_id"), " ")
 
-	deleteFileReq := make([]*files.DeleteFileReq, 0)
-	for _, p := range product.Images {
-		deleteFileReq = append(deleteFileReq, &files.DeleteFileReq{
-			Destination: fmt.Sprintf("image/test/%s", p.FileName),
-		})
+	product, err := h.productsUsecases.RestoreProduct(productId)
+	if err != nil {
+		switch err.Error() {
+		case "product not found in trash":
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(restoreProductErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(restoreProductErr),
+				err.Error(),
+			).Res()
+		}
 	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, product).Res()
+}
 
-	if err := h.filesUsecases.DeleteFileOnGCP(deleteFileReq); err != nil {
-		_, file, line, _ := runtime.Caller(0)
-		errMsg := fmt.Sprintf("%s:%d %s", file, line, err.Error())
+func (h *productsHandler) UpdateStock(c *fiber.Ctx) error {
+	productId := strings.Trim(c.Params("product_id"), " ")
+
+	req := new(products.UpdateStockReq)
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(updateStockErr),
+			err.Error(),
+		).Res()
+	}
+	if req.Stock != nil && *req.Stock < 0 {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(updateStockErr),
+			"stock must not be negative",
+		).Res()
+	}
+
+	userId, _ := c.Locals("userId").(string)
+	product, err := h.productsUsecases.UpdateStock(productId, req, userId)
+	if err != nil {
+		switch err.Error() {
+		case "product not found":
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(updateStockErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(updateStockErr),
+				err.Error(),
+			).Res()
+		}
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, product).Res()
+}
+
+func (h *productsHandler) FindRevisions(c *fiber.Ctx) error {
+	productId := strings.Trim(c.Params("product_id"), " ")
+
+	revisions, err := h.productsUsecases.FindRevisions(productId)
+	if err != nil {
 		return entities.NewResponse(c).Error(
 			fiber.ErrInternalServerError.Code,
-			string(deleteProductErr),
-			errMsg,
+			string(findRevisionsErr),
+			err.Error(),
 		).Res()
 	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, revisions).Res()
+}
 
-	if err := h.productsUsecases.DeleteProduct(productId); err != nil {
-		_, file, line, _ := runtime.Caller(0)
-		errMsg := fmt.Sprintf("%s:%d %s", file, line, err.Error())
+func (h *productsHandler) DiffRevisions(c *fiber.Ctx) error {
+	productId := strings.Trim(c.Params("product_id"), " ")
+
+	req := new(products.ProductRevisionDiffReq)
+	if err := c.QueryParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(diffRevisionsErr),
+			err.Error(),
+		).Res()
+	}
+	if req.From <= 0 || req.To <= 0 {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(diffRevisionsErr),
+			"from and to revision are required",
+		).Res()
+	}
+
+	diff, err := h.productsUsecases.DiffRevisions(productId, req)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(diffRevisionsErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, diff).Res()
+}
+
+func (h *productsHandler) RollbackProduct(c *fiber.Ctx) error {
+	productId := strings.Trim(c.Params("product_id"), " ")
+
+	revision, err := strconv.Atoi(strings.Trim(c.Params("revision"), " "))
+	if err != nil || revision <= 0 {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(rollbackErr),
+			"revision is invalid",
+		).Res()
+	}
+
+	userId, _ := c.Locals("userId").(string)
+	product, err := h.productsUsecases.RollbackProduct(productId, revision, userId)
+	if err != nil {
 		return entities.NewResponse(c).Error(
 			fiber.ErrInternalServerError.Code,
-			string(deleteProductErr),
-			errMsg,
+			string(rollbackErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, product).Res()
+}
+
+func (h *productsHandler) ImportProducts(c *fiber.Ctx) error {
+	req := new(products.ImportProductReq)
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(importProductErr),
+			err.Error(),
+		).Res()
+	}
+
+	fileReq, err := c.FormFile("file")
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(importProductErr),
+			err.Error(),
+		).Res()
+	}
+
+	// Format from file extension when not sent
+	if req.Format == "" {
+		switch strings.ToLower(filepath.Ext(fileReq.Filename)) {
+		case ".csv":
+			req.Format = "csv"
+		case ".ndjson", ".jsonl":
+			req.Format = "ndjson"
+		}
+	}
+	if req.Format != "csv" && req.Format != "ndjson" {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(importProductErr),
+			"format must be csv or ndjson",
+		).Res()
+	}
+
+	file, err := fileReq.Open()
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(importProductErr),
+			err.Error(),
+		).Res()
+	}
+	defer file.Close()
+
+	userId, _ := c.Locals("userId").(string)
+	job, err := h.productsUsecases.ImportProducts(req, file, userId)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(importProductErr),
+			err.Error(),
 		).Res()
 	}
 
+	// Large file still running, check GET /products/import/:job_id
+	if job.Status == "running" {
+		return entities.NewResponse(c).Success(fiber.StatusAccepted, job).Res()
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, job).Res()
+}
+
+func (h *productsHandler) FindOneImportJob(c *fiber.Ctx) error {
+	jobId := strings.Trim(c.Params("job_id"), " ")
+
+	job, err := h.productsUsecases.FindOneImportJob(jobId)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(findImportJobErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, job).Res()
+}
+
+func (h *productsHandler) ExportProducts(c *fiber.Ctx) error {
+	req := &products.ProductFilter{
+		PaginationReq: &entities.PaginationReq{},
+		SortReq:       &entities.SortReq{},
+	}
+	if err := c.QueryParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(exportProductErr),
+			err.Error(),
+		).Res()
+	}
+
+	currency, err := checkCurrency(req.Currency)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(exportProductErr),
+			err.Error(),
+		).Res()
+	}
+	req.Currency = currency
+	setDefaultFilter(req)
+
+	contentTypes := map[string]string{
+		"csv":    "text/csv; charset=utf-8",
+		"ndjson": "application/x-ndjson",
+		"xlsx":   "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	}
+	format := strings.ToLower(c.Query("format", "csv"))
+	if contentTypes[format] == "" {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(exportProductErr),
+			"format must be csv, ndjson or xlsx",
+		).Res()
+	}
+
+	c.Attachment(fmt.Sprintf("products.%s", format))
+	c.Set(fiber.HeaderContentType, contentTypes[format])
+
+	// Stream file while reading products, status is already sent so errors can only be logged
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		if err := h.productsUsecases.ExportProducts(req, format, w); err != nil {
+			log.Printf("export products failed: %v", err)
+		}
+		w.Flush()
+	})
+	return nil
+}
+
+func (h *productsHandler) FindSpecialPrices(c *fiber.Ctx) error {
+	productId := strings.Trim(c.Params("product_id"), " ")
+
+	specialPrices, err := h.productsUsecases.FindSpecialPrices(productId)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(findSpecialPriceErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, specialPrices).Res()
+}
+
+func (h *productsHandler) InsertSpecialPrice(c *fiber.Ctx) error {
+	req := new(products.SpecialPrice)
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(insertSpecialPriceErr),
+			err.Error(),
+		).Res()
+	}
+	req.ProductId = strings.Trim(c.Params("product_id"), " ")
+
+	if req.Price == nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(insertSpecialPriceErr),
+			"price is required",
+		).Res()
+	}
+	if err := req.Price.Validate(h.cfg.App().Currency()); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(insertSpecialPriceErr),
+			err.Error(),
+		).Res()
+	}
+	if req.PriceListId < 0 {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(insertSpecialPriceErr),
+			"price_list_id is invalid",
+		).Res()
+	}
+
+	// starts_at and ends_at are RFC3339, like 2024-01-01T00:00:00+07:00
+	var startsAt, endsAt time.Time
+	var err error
+	if req.StartsAt != "" {
+		if startsAt, err = time.Parse(time.RFC3339, req.StartsAt); err != nil {
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(insertSpecialPriceErr),
+				"starts_at is invalid",
+			).Res()
+		}
+	}
+	if req.EndsAt != "" {
+		if endsAt, err = time.Parse(time.RFC3339, req.EndsAt); err != nil {
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(insertSpecialPriceErr),
+				"ends_at is invalid",
+			).Res()
+		}
+	}
+	if req.StartsAt != "" && req.EndsAt != "" && !startsAt.Before(endsAt) {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(insertSpecialPriceErr),
+			"ends_at must be after starts_at",
+		).Res()
+	}
+
+	userId, _ := c.Locals("userId").(string)
+	specialPrice, err := h.productsUsecases.InsertSpecialPrice(req, userId)
+	if err != nil {
+		switch {
+		case err.Error() == "product not found",
+			err.Error() == "price list not found",
+			strings.HasPrefix(err.Error(), "product has no regular price"):
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(insertSpecialPriceErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(insertSpecialPriceErr),
+				err.Error(),
+			).Res()
+		}
+	}
+	return entities.NewResponse(c).Success(fiber.StatusCreated, specialPrice).Res()
+}
+
+func (h *productsHandler) DeleteSpecialPrice(c *fiber.Ctx) error {
+	productId := strings.Trim(c.Params("product_id"), " ")
+	specialPriceId := strings.Trim(c.Params("special_price_id"), " ")
+
+	userId, _ := c.Locals("userId").(string)
+	if err := h.productsUsecases.DeleteSpecialPrice(productId, specialPriceId, userId); err != nil {
+		switch err.Error() {
+		case "product not found", "special price not found":
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(deleteSpecialPriceErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(deleteSpecialPriceErr),
+				err.Error(),
+			).Res()
+		}
+	}
 	return entities.NewResponse(c).Success(fiber.StatusNoContent, nil).Res()
 }
+
+func (h *productsHandler) FindTranslations(c *fiber.Ctx) error {
+	productId := strings.Trim(c.Params("product_id"), " ")
+
+	translations, err := h.productsUsecases.FindTranslations(productId)
+	if err != nil {
+		switch err.Error() {
+		case "product not found":
+			return entities.NewResponse(c).Error(
+				fiber.ErrNotFound.Code,
+				string(findTranslationsErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(findTranslationsErr),
+				err.Error(),
+			).Res()
+		}
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, translations).Res()
+}
+
+// UpsertTranslation create or replace translation of a locale, the default locale is in products itself
+func (h *productsHandler) UpsertTranslation(c *fiber.Ctx) error {
+	req := new(products.ProductTranslation)
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(upsertTranslationErr),
+			err.Error(),
+		).Res()
+	}
+	req.ProductId = strings.Trim(c.Params("product_id"), " ")
+	req.Locale = strings.ToLower(strings.Trim(c.Params("locale"), " "))
+	req.Title = strings.TrimSpace(req.Title)
+
+	if !locale.IsLocale(req.Locale) {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(upsertTranslationErr),
+			fmt.Sprintf("locale %s is not supported", req.Locale),
+		).Res()
+	}
+	if req.Locale == h.cfg.App().Locale() {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(upsertTranslationErr),
+			fmt.Sprintf("locale %s is the default locale, update the product instead", req.Locale),
+		).Res()
+	}
+	if req.Title == "" {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(upsertTranslationErr),
+			"title is required",
+		).Res()
+	}
+
+	userId, _ := c.Locals("userId").(string)
+	translation, err := h.productsUsecases.UpsertTranslation(req, userId)
+	if err != nil {
+		switch err.Error() {
+		case "product not found":
+			return entities.NewResponse(c).Error(
+				fiber.ErrNotFound.Code,
+				string(upsertTranslationErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(upsertTranslationErr),
+				err.Error(),
+			).Res()
+		}
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, translation).Res()
+}
+
+func (h *productsHandler) DeleteTranslation(c *fiber.Ctx) error {
+	productId := strings.Trim(c.Params("product_id"), " ")
+	lang := strings.ToLower(strings.Trim(c.Params("locale"), " "))
+
+	userId, _ := c.Locals("userId").(string)
+	if err := h.productsUsecases.DeleteTranslation(productId, lang, userId); err != nil {
+		switch err.Error() {
+		case "product not found", "translation not found":
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(deleteTranslationErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(deleteTranslationErr),
+				err.Error(),
+			).Res()
+		}
+	}
+	return entities.NewResponse(c).Success(fiber.StatusNoContent, nil).Res()
+}
+
+// imageErr response error of image endpoints, missing product or image is 404
+func imageErr(c *fiber.Ctx, code productsHandlerErrCode, err error) error {
+	switch err.Error() {
+	case "product not found", "image not found":
+		return entities.NewResponse(c).Error(
+			fiber.ErrNotFound.Code,
+			string(code),
+			err.Error(),
+		).Res()
+	case "image_ids must be every image of product":
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(code),
+			err.Error(),
+		).Res()
+	default:
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(code),
+			err.Error(),
+		).Res()
+	}
+}
+
+// InsertImage add an uploaded image to product without touching the others
+func (h *productsHandler) InsertImage(c *fiber.Ctx) error {
+	productId := strings.Trim(c.Params("product_id"), " ")
+
+	req := new(products.InsertImageReq)
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(insertImageErr),
+			err.Error(),
+		).Res()
+	}
+	if strings.TrimSpace(req.FileName) == "" || strings.TrimSpace(req.Url) == "" {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(insertImageErr),
+			"filename and url are required",
+		).Res()
+	}
+	if req.Position != nil && *req.Position < 0 {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(insertImageErr),
+			"position is invalid",
+		).Res()
+	}
+
+	userId, _ := c.Locals("userId").(string)
+	product, err := h.productsUsecases.InsertImage(productId, req, userId)
+	if err != nil {
+		return imageErr(c, insertImageErr, err)
+	}
+
+	c.Set(fiber.HeaderETag, product.ETag())
+	return entities.NewResponse(c).Success(fiber.StatusCreated, product).Res()
+}
+
+// UpdateImage set alt text or make image the primary one
+func (h *productsHandler) UpdateImage(c *fiber.Ctx) error {
+	productId := strings.Trim(c.Params("product_id"), " ")
+	imageId := strings.Trim(c.Params("image_id"), " ")
+
+	req := new(products.UpdateImageReq)
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(updateImageErr),
+			err.Error(),
+		).Res()
+	}
+
+	userId, _ := c.Locals("userId").(string)
+	product, err := h.productsUsecases.UpdateImage(productId, imageId, req, userId)
+	if err != nil {
+		return imageErr(c, updateImageErr, err)
+	}
+
+	c.Set(fiber.HeaderETag, product.ETag())
+	return entities.NewResponse(c).Success(fiber.StatusOK, product).Res()
+}
+
+func (h *productsHandler) DeleteImage(c *fiber.Ctx) error {
+	productId := strings.Trim(c.Params("product_id"), " ")
+	imageId := strings.Trim(c.Params("image_id"), " ")
+
+	userId, _ := c.Locals("userId").(string)
+	product, err := h.productsUsecases.DeleteImage(productId, imageId, userId)
+	if err != nil {
+		return imageErr(c, deleteImageErr, err)
+	}
+
+	c.Set(fiber.HeaderETag, product.ETag())
+	return entities.NewResponse(c).Success(fiber.StatusOK, product).Res()
+}
+
+// ReorderImages set order of images, image_ids must be every image of product
+func (h *productsHandler) ReorderImages(c *fiber.Ctx) error {
+	productId := strings.Trim(c.Params("product_id"), " ")
+
+	req := &products.ReorderImagesReq{
+		ImageIds: make([]string, 0),
+	}
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(reorderImagesErr),
+			err.Error(),
+		).Res()
+	}
+
+	userId, _ := c.Locals("userId").(string)
+	product, err := h.productsUsecases.ReorderImages(productId, req, userId)
+	if err != nil {
+		return imageErr(c, reorderImagesErr, err)
+	}
+
+	c.Set(fiber.HeaderETag, product.ETag())
+	return entities.NewResponse(c).Success(fiber.StatusOK, product).Res()
+}
@@ -2,23 +2,57 @@ package productsRepositories
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/DrumPatiphon/go-rest-api-service/config"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/appInfo"
 	"github.com/DrumPatiphon/go-rest-api-service/modules/entities"
 	"github.com/DrumPatiphon/go-rest-api-service/modules/files/filesUsecases"
 	"github.com/DrumPatiphon/go-rest-api-service/modules/products"
 	"github.com/DrumPatiphon/go-rest-api-service/modules/products/productPatterns"
+	"github.com/DrumPatiphon/go-rest-api-service/pkg/money"
 	"github.com/jmoiron/sqlx"
 )
 
 type IProductRepository interface {
 	FindOneProduct(productId string) (*products.Product, error)
 	FindProduct(req *products.ProductFilter) ([]*products.Product, int)
-	InsertProduct(req *products.Product) (*products.Product, error)
-	UpdateProduct(req *products.Product) (*products.Product, error)
+	InsertProduct(req *products.Product, userId string) (*products.Product, error)
+	UpdateProduct(req *products.UpdateProductReq, userId string) (*products.Product, error)
 	DeleteProduct(productId string) error
+	FindTrashProduct(req *products.ProductFilter) ([]*products.Product, int)
+	RestoreProduct(productId string) error
+	UpdateStock(productId string, stock *int, userId string) error
+	PurgeProducts(retention time.Duration) ([]*entities.Image, error)
+	PublishScheduledProducts() ([]string, error)
+	InsertImage(productId string, req *products.InsertImageReq, userId string) (*entities.Image, error)
+	UpdateImage(productId, imageId string, req *products.UpdateImageReq, userId string) error
+	DeleteImage(productId, imageId string, userId string) (*entities.Image, error)
+	ReorderImages(productId string, imageIds []string, userId string) error
+	FindRevisions(productId string) ([]*products.ProductRevision, error)
+	FindOneRevision(productId string, revision int) (*products.ProductRevision, error)
+	RollbackProduct(productId string, revision int, userId string) error
+	FindCategories() ([]*appInfo.Category, error)
+	ImportProducts(req []*products.Product, userId string) (int, error)
+	InsertImportJob(req *products.ImportJob) error
+	UpdateImportJob(req *products.ImportJob) error
+	FindOneImportJob(jobId string) (*products.ImportJob, error)
+	ExportProducts(req *products.ProductFilter, fn func(product *products.Product) error) error
+	FindProductSlug(slug string) (*products.ProductSlug, error)
+	FindTranslations(productIds []string, locale string) (map[string]*products.ProductTranslation, error)
+	FindCategoryTitles(locale string) (map[int]string, error)
+	FindCategoriesByLocale(locale string) (map[int]*appInfo.Category, error)
+	FindProductTranslations(productId string) ([]*products.ProductTranslation, error)
+	UpsertTranslation(req *products.ProductTranslation, dictionary, userId string) error
+	DeleteTranslation(productId, locale, userId string) error
+	FindEffectivePrices(productIds []string, userId string) (map[string][]*money.Money, error)
+	FindSpecialPrices(productId string) ([]*products.SpecialPrice, error)
+	InsertSpecialPrice(req *products.SpecialPrice, userId string) error
+	DeleteSpecialPrice(productId, specialPriceId, userId string) error
 }
 
 type productRepository struct {
@@ -43,8 +77,22 @@ func (r *productRepository) FindOneProduct(productId string) (*products.Product,
 		SELECT
 			p.id,
 			p.title,
+			p.slug,
+			p.status,
+			p.publish_at,
 			p.description,
-			p.price,
+			(
+				SELECT
+					COALESCE(array_to_json(array_agg(pt)), '[]'::json)
+				FROM (
+					SELECT
+						pp.currency,
+						pp.amount AS minor_units
+					FROM product_prices pp
+					WHERE pp.product_id = p.id
+					ORDER BY pp.currency
+				) AS pt
+			) AS prices,
 			(
 				SELECT
 					to_jsonb(ct)
@@ -55,11 +103,28 @@ func (r *productRepository) FindOneProduct(productId string) (*products.Product,
 					FROM categories c
 					LEFT JOIN products_categories pc ON pc.category_id  = c.id
 					WHERE pc.product_id = $1
+					ORDER BY c.id
+					LIMIT 1
 				) AS ct
 			) AS category,
-			p.created_at,
-			p.updated_at,
-			p.price,
+			(
+				SELECT
+					COALESCE(array_to_json(array_agg(ct)), '[]'::json)
+				FROM (
+					SELECT
+						c.id,
+						c.title
+					FROM categories c
+					LEFT JOIN products_categories pc ON pc.category_id  = c.id
+					WHERE pc.product_id = $1
+					ORDER BY c.id
+				) AS ct
+			) AS categories,
+			p.created_at AS "CreatedAt",
+			p.updated_at AS "UpdatedAt",
+			p.version,
+			p.rating_average,
+			p.review_count,
 			(
 				SELECT 
 					COALESCE(array_to_json(array_agg(it)), '[]'::json)  
@@ -67,13 +132,18 @@ func (r *productRepository) FindOneProduct(productId string) (*products.Product,
 					SELECT 
 						i.id,
 						i.filename,
-						i.url
+						i.url,
+						i.position,
+						i.alt,
+						i.is_primary
 					FROM images i 
 					WHERE i.product_id = p.id 
+					ORDER BY i.position, i.id
 				)AS it
 			)AS images
 		FROM products p
 		WHERE p.id = $1
+		AND p.deleted_at IS NULL
 	) AS t;`
 
 	productBytes := make([]byte, 0)
@@ -82,6 +152,9 @@ func (r *productRepository) FindOneProduct(productId string) (*products.Product,
 	}
 
 	if err := r.db.Get(&productBytes, query, productId); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("product not found")
+		}
 		return nil, fmt.Errorf("get product failed %v", err)
 	}
 	if err := json.Unmarshal(productBytes, &product); err != nil {
@@ -100,8 +173,8 @@ func (r *productRepository) FindProduct(req *products.ProductFilter) ([]*product
 
 }
 
-func (r *productRepository) InsertProduct(req *products.Product) (*products.Product, error) {
-	builder := productPatterns.InsertProductBuilder(r.db, req)
+func (r *productRepository) InsertProduct(req *products.Product, userId string) (*products.Product, error) {
+	builder := productPatterns.InsertProductBuilder(r.db, req, userId)
 	productId, err := productPatterns.InsertProductEngineer(builder).InsertProduct()
 	if err != nil {
 		return nil, err
@@ -114,8 +187,8 @@ func (r *productRepository) InsertProduct(req *products.Product) (*products.Prod
 	return product, nil
 }
 
-func (r *productRepository) UpdateProduct(req *products.Product) (*products.Product, error) {
-	builder := productPatterns.UpdateProductBuilder(r.db, req, r.filesUsecase)
+func (r *productRepository) UpdateProduct(req *products.UpdateProductReq, userId string) (*products.Product, error) {
+	builder := productPatterns.UpdateProductBuilder(r.db, req, userId, r.filesUsecase)
 	engineer := productPatterns.UpdateProductProductEngineer(builder)
 
 	if err := engineer.UpdateProduct(); err != nil {
@@ -130,10 +203,1135 @@ func (r *productRepository) UpdateProduct(req *products.Product) (*products.Prod
 	return product, nil
 }
 
+// DeleteProduct move product to trash, PurgeProducts will remove it later
 func (r *productRepository) DeleteProduct(productId string) error {
-	query := `DELETE FROM products WHERE id = $1;`
-	if _, err := r.db.ExecContext(context.Background(), query, productId); err != nil {
+	query := `
+	UPDATE products SET
+		deleted_at = now()
+	WHERE id = $1
+	AND deleted_at IS NULL;`
+
+	result, err := r.db.ExecContext(context.Background(), query, productId)
+	if err != nil {
 		return fmt.Errorf("delete product failed: %v", err)
 	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("product not found")
+	}
+	return nil
+}
+
+func (r *productRepository) FindTrashProduct(req *products.ProductFilter) ([]*products.Product, int) {
+	builder := productPatterns.FindTrashProductBuilder(r.db, req)
+	engineer := productPatterns.FindProductEngineer(builder)
+
+	result := engineer.FindProduct().Result()
+	count := engineer.CountProduct().Count()
+	return result, count
+}
+
+func (r *productRepository) RestoreProduct(productId string) error {
+	query := `
+	UPDATE products SET
+		deleted_at = NULL
+	WHERE id = $1
+	AND deleted_at IS NOT NULL;`
+
+	result, err := r.db.ExecContext(context.Background(), query, productId)
+	if err != nil {
+		return fmt.Errorf("restore product failed: %v", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("product not found in trash")
+	}
+	return nil
+}
+
+func (r *productRepository) UpdateStock(productId string, stock *int, userId string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*15)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := lockProduct(ctx, tx, productId); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE products SET stock = $2 WHERE id = $1;`, productId, stock); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("update stock failed: %v", err)
+	}
+
+	if err := touchProduct(ctx, tx, productId, userId); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return nil
+}
+
+// PurgeProducts hard delete products in trash longer than retention and return their images
+func (r *productRepository) PurgeProducts(retention time.Duration) ([]*entities.Image, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*60)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// images removed by updates are kept for rollback, so files in revisions are purged as well
+	imagesQuery := `
+	SELECT DISTINCT ON (t.filename)
+		t.id,
+		t.filename,
+		t.url
+	FROM (
+		SELECT
+			i.id::TEXT,
+			i.filename,
+			i.url
+		FROM images i
+		JOIN products p ON p.id = i.product_id
+		WHERE p.deleted_at < now() - $1 * INTERVAL '1 second'
+		UNION ALL
+		SELECT
+			it->>'id',
+			it->>'filename',
+			it->>'url'
+		FROM products_revisions r
+		JOIN products p ON p.id = r.product_id,
+			jsonb_array_elements(r.snapshot->'images') it
+		WHERE p.deleted_at < now() - $1 * INTERVAL '1 second'
+	) AS t
+	ORDER BY t.filename;`
+
+	images := make([]*entities.Image, 0)
+	if err := tx.SelectContext(ctx, &images, imagesQuery, retention.Seconds()); err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("find purge images failed: %v", err)
+	}
+
+	// images and products_categories are removed by ON DELETE CASCADE
+	query := `
+	DELETE FROM products
+	WHERE deleted_at < now() - $1 * INTERVAL '1 second';`
+
+	if _, err := tx.ExecContext(ctx, query, retention.Seconds()); err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("purge products failed: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return images, nil
+}
+
+func (r *productRepository) FindRevisions(productId string) ([]*products.ProductRevision, error) {
+	query := `
+	SELECT
+		COALESCE(array_to_json(array_agg(t)), '[]'::json)
+	FROM (
+		SELECT
+			r.id,
+			r.product_id,
+			r.revision,
+			r.user_id,
+			r.snapshot,
+			r.created_at
+		FROM products_revisions r
+		WHERE r.product_id = $1
+		ORDER BY r.revision DESC
+	) AS t;`
+
+	revisionsBytes := make([]byte, 0)
+	revisions := make([]*products.ProductRevision, 0)
+
+	if err := r.db.Get(&revisionsBytes, query, productId); err != nil {
+		return nil, fmt.Errorf("get product revisions failed: %v", err)
+	}
+	if err := json.Unmarshal(revisionsBytes, &revisions); err != nil {
+		return nil, fmt.Errorf("unmarshal product revisions failed: %v", err)
+	}
+	return revisions, nil
+}
+
+func (r *productRepository) FindOneRevision(productId string, revision int) (*products.ProductRevision, error) {
+	query := `
+	SELECT
+		to_jsonb(t)
+	FROM (
+		SELECT
+			r.id,
+			r.product_id,
+			r.revision,
+			r.user_id,
+			r.snapshot,
+			r.created_at
+		FROM products_revisions r
+		WHERE r.product_id = $1
+		AND r.revision = $2
+	) AS t;`
+
+	revisionBytes := make([]byte, 0)
+	productRevision := new(products.ProductRevision)
+
+	if err := r.db.Get(&revisionBytes, query, productId, revision); err != nil {
+		return nil, fmt.Errorf("revision %d not found", revision)
+	}
+	if err := json.Unmarshal(revisionBytes, &productRevision); err != nil {
+		return nil, fmt.Errorf("unmarshal product revision failed: %v", err)
+	}
+	return productRevision, nil
+}
+
+// RollbackProduct restore product, categories and images from revision then save it as a new revision.
+// Image files are kept on GCP until the product is purged, so the restored records still have their files.
+func (r *productRepository) RollbackProduct(productId string, revision int, userId string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*15)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	productQuery := `
+	UPDATE products p SET
+		title = r.snapshot->>'title',
+		description = r.snapshot->>'description',
+		version = p.version + 1
+	FROM products_revisions r
+	WHERE r.product_id = p.id
+	AND p.id = $1
+	AND r.revision = $2
+	AND p.deleted_at IS NULL;`
+
+	result, err := tx.ExecContext(ctx, productQuery, productId, revision)
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("rollback product failed: %v", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		tx.Rollback()
+		return fmt.Errorf("revision %d not found", revision)
+	}
+
+	// Replace prices, categories and images with the ones in snapshot,
+	// snapshot before multi-currency has no prices and keep the current prices
+	restoreQueries := []struct {
+		query string
+		args  []any
+	}{
+		{
+			query: `
+	DELETE FROM product_prices pp
+	USING products_revisions r
+	WHERE pp.product_id = $1
+	AND r.product_id = $1
+	AND r.revision = $2
+	AND r.snapshot ? 'prices';`,
+			args: []any{productId, revision},
+		},
+		{
+			query: `
+	INSERT INTO product_prices (
+		product_id,
+		currency,
+		amount
+	)
+	SELECT
+		$1,
+		pt->>'currency',
+		(pt->>'minor_units')::BIGINT
+	FROM products_revisions r,
+		jsonb_array_elements(r.snapshot->'prices') pt
+	WHERE r.product_id = $1
+	AND r.revision = $2;`,
+			args: []any{productId, revision},
+		},
+		{
+			query: `
+	DELETE FROM products_categories
+	WHERE product_id = $1;`,
+			args: []any{productId},
+		},
+		{
+			query: `
+	INSERT INTO products_categories (
+		product_id,
+		category_id
+	)
+	SELECT
+		$1,
+		(ct->>'id')::INT
+	FROM products_revisions r,
+		jsonb_array_elements(r.snapshot->'categories') ct
+	WHERE r.product_id = $1
+	AND r.revision = $2
+	AND EXISTS (SELECT 1 FROM categories c WHERE c.id = (ct->>'id')::INT);`,
+			args: []any{productId, revision},
+		},
+		{
+			query: `
+	DELETE FROM images
+	WHERE product_id = $1;`,
+			args: []any{productId},
+		},
+		{
+			query: `
+	INSERT INTO images (
+		id,
+		filename,
+		url,
+		product_id,
+		position,
+		alt,
+		is_primary
+	)
+	SELECT
+		(it->>'id')::uuid,
+		it->>'filename',
+		it->>'url',
+		$1,
+		COALESCE((it->>'position')::INT, n - 1),
+		COALESCE(it->>'alt', ''),
+		COALESCE((it->>'is_primary')::BOOLEAN, n = 1)
+	FROM products_revisions r,
+		jsonb_array_elements(r.snapshot->'images') WITH ORDINALITY AS ie(it, n)
+	WHERE r.product_id = $1
+	AND r.revision = $2;`,
+			args: []any{productId, revision},
+		},
+	}
+	for _, q := range restoreQueries {
+		if _, err := tx.ExecContext(ctx, q.query, q.args...); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("rollback product failed: %v", err)
+		}
+	}
+
+	if err := productPatterns.InsertRevision(ctx, tx, productId, userId); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *productRepository) FindCategories() ([]*appInfo.Category, error) {
+	query := `
+	SELECT
+		id,
+		title
+	FROM categories;`
+
+	categories := make([]*appInfo.Category, 0)
+	if err := r.db.Select(&categories, query); err != nil {
+		return nil, fmt.Errorf("select categories failed: %v", err)
+	}
+	return categories, nil
+}
+
+func (r *productRepository) ImportProducts(req []*products.Product, userId string) (int, error) {
+	builder := productPatterns.ImportProductBuilder(r.db, req, userId)
+	inserted, err := productPatterns.ImportProductEngineer(builder).ImportProducts()
+	if err != nil {
+		return 0, err
+	}
+	return inserted, nil
+}
+
+func (r *productRepository) InsertImportJob(req *products.ImportJob) error {
+	query := `
+	INSERT INTO products_import_jobs (
+		user_id,
+		status,
+		dry_run,
+		total_rows,
+		valid_rows,
+		inserted_rows,
+		row_errors
+	)
+	VALUES ($1, $2, $3, $4, $5, $6, $7::jsonb)
+		RETURNING id, created_at, updated_at;`
+
+	rowErrors, err := json.Marshal(req.RowErrors)
+	if err != nil {
+		return fmt.Errorf("marshal row errors failed: %v", err)
+	}
+
+	if err := r.db.QueryRowContext(
+		context.Background(),
+		query,
+		req.UserId,
+		req.Status,
+		req.DryRun,
+		req.TotalRows,
+		req.ValidRows,
+		req.InsertedRows,
+		string(rowErrors),
+	).Scan(&req.Id, &req.CreatedAt, &req.UpdatedAt); err != nil {
+		return fmt.Errorf("insert import job failed: %v", err)
+	}
+	return nil
+}
+
+func (r *productRepository) UpdateImportJob(req *products.ImportJob) error {
+	query := `
+	UPDATE products_import_jobs SET
+		status = $1,
+		inserted_rows = $2,
+		row_errors = $3::jsonb
+	WHERE id = $4;`
+
+	rowErrors, err := json.Marshal(req.RowErrors)
+	if err != nil {
+		return fmt.Errorf("marshal row errors failed: %v", err)
+	}
+
+	if _, err := r.db.ExecContext(
+		context.Background(),
+		query,
+		req.Status,
+		req.InsertedRows,
+		string(rowErrors),
+		req.Id,
+	); err != nil {
+		return fmt.Errorf("update import job failed: %v", err)
+	}
+	return nil
+}
+
+func (r *productRepository) FindOneImportJob(jobId string) (*products.ImportJob, error) {
+	query := `
+	SELECT
+		to_jsonb(t)
+	FROM (
+		SELECT
+			j.id,
+			j.user_id,
+			j.status,
+			j.dry_run,
+			j.total_rows,
+			j.valid_rows,
+			j.inserted_rows,
+			j.row_errors,
+			j.created_at,
+			j.updated_at
+		FROM products_import_jobs j
+		WHERE j.id::TEXT = $1
+	) AS t;`
+
+	jobBytes := make([]byte, 0)
+	job := new(products.ImportJob)
+
+	if err := r.db.Get(&jobBytes, query, jobId); err != nil {
+		return nil, fmt.Errorf("import job not found")
+	}
+	if err := json.Unmarshal(jobBytes, &job); err != nil {
+		return nil, fmt.Errorf("unmarshal import job failed: %v", err)
+	}
+	return job, nil
+}
+
+// ExportProducts read products with a server-side cursor and call fn for every product,
+// only one fetch size of products is in memory at a time
+func (r *productRepository) ExportProducts(req *products.ProductFilter, fn func(product *products.Product) error) error {
+	builder := productPatterns.FindProductBuilder(r.db, req)
+	query, values := productPatterns.FindProductEngineer(builder).ExportProduct().Query()
+
+	ctx := context.Background()
+	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DECLARE "products_export" NO SCROLL CURSOR FOR`+query, values...); err != nil {
+		return fmt.Errorf("declare products cursor failed: %v", err)
+	}
+
+	for {
+		rows, err := tx.QueryxContext(ctx, `FETCH FORWARD 500 FROM "products_export"`)
+		if err != nil {
+			return fmt.Errorf("fetch products failed: %v", err)
+		}
+
+		var fetched int
+		for rows.Next() {
+			fetched++
+
+			productBytes := make([]byte, 0)
+			if err := rows.Scan(&productBytes); err != nil {
+				rows.Close()
+				return fmt.Errorf("scan product failed: %v", err)
+			}
+			product := new(products.Product)
+			if err := json.Unmarshal(productBytes, product); err != nil {
+				rows.Close()
+				return fmt.Errorf("unmarshal product failed: %v", err)
+			}
+			if err := fn(product); err != nil {
+				rows.Close()
+				return err
+			}
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("fetch products failed: %v", err)
+		}
+		if fetched == 0 {
+			return nil
+		}
+	}
+}
+
+// FindEffectivePrices return effective price of every currency by product id, empty userId is anonymous
+func (r *productRepository) FindEffectivePrices(productIds []string, userId string) (map[string][]*money.Money, error) {
+	query := `
+	SELECT
+		COALESCE(jsonb_object_agg(t.product_id, t.prices), '{}'::jsonb)
+	FROM (
+		SELECT
+			pp.product_id,
+			jsonb_agg(jsonb_build_object(
+				'currency', pp.currency,
+				'minor_units', product_effective_price(pp.product_id, pp.currency, $2)
+			) ORDER BY pp.currency) AS prices
+		FROM product_prices pp
+		WHERE pp.product_id = ANY($1::VARCHAR[])
+		GROUP BY pp.product_id
+	) AS t;`
+
+	pricesBytes := make([]byte, 0)
+	prices := make(map[string][]*money.Money)
+
+	if err := r.db.Get(&pricesBytes, query, productIds, userId); err != nil {
+		return nil, fmt.Errorf("get effective prices failed: %v", err)
+	}
+	if err := json.Unmarshal(pricesBytes, &prices); err != nil {
+		return nil, fmt.Errorf("unmarshal effective prices failed: %v", err)
+	}
+	return prices, nil
+}
+
+func (r *productRepository) FindSpecialPrices(productId string) ([]*products.SpecialPrice, error) {
+	query := `
+	SELECT
+		COALESCE(array_to_json(array_agg(t)), '[]'::json)
+	FROM (
+		SELECT
+			sp.id,
+			sp.product_id,
+			sp.price_list_id,
+			jsonb_build_object('currency', sp.currency, 'minor_units', sp.amount) AS price,
+			sp.starts_at,
+			sp.ends_at,
+			sp.created_at,
+			sp.updated_at
+		FROM product_special_prices sp
+		WHERE sp.product_id = $1
+		ORDER BY sp.starts_at NULLS FIRST, sp.created_at
+	) AS t;`
+
+	specialPricesBytes := make([]byte, 0)
+	specialPrices := make([]*products.SpecialPrice, 0)
+
+	if err := r.db.Get(&specialPricesBytes, query, productId); err != nil {
+		return nil, fmt.Errorf("get special prices failed: %v", err)
+	}
+	if err := json.Unmarshal(specialPricesBytes, &specialPrices); err != nil {
+		return nil, fmt.Errorf("unmarshal special prices failed: %v", err)
+	}
+	return specialPrices, nil
+}
+
+func (r *productRepository) InsertSpecialPrice(req *products.SpecialPrice, userId string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*15)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := lockProduct(ctx, tx, req.ProductId); err != nil {
+		return err
+	}
+
+	query := `
+	INSERT INTO product_special_prices (
+		product_id,
+		price_list_id,
+		currency,
+		amount,
+		starts_at,
+		ends_at
+	)
+	VALUES ($1, NULLIF($2, 0), $3, $4, NULLIF($5, '')::TIMESTAMPTZ, NULLIF($6, '')::TIMESTAMPTZ)
+		RETURNING id;`
+
+	if err := tx.QueryRowContext(
+		ctx,
+		query,
+		req.ProductId,
+		req.PriceListId,
+		req.Price.Currency,
+		req.Price.Amount,
+		req.StartsAt,
+		req.EndsAt,
+	).Scan(&req.Id); err != nil {
+		tx.Rollback()
+		switch {
+		case strings.Contains(err.Error(), "product_special_prices_product_id_currency_fkey"):
+			return fmt.Errorf("product has no regular price in %s", req.Price.Currency)
+		case strings.Contains(err.Error(), "product_special_prices_price_list_id_fkey"):
+			return fmt.Errorf("price list not found")
+		default:
+			return fmt.Errorf("insert special price failed: %v", err)
+		}
+	}
+
+	if err := touchProduct(ctx, tx, req.ProductId, userId); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return nil
+}
+
+func (r *productRepository) DeleteSpecialPrice(productId, specialPriceId, userId string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*15)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := lockProduct(ctx, tx, productId); err != nil {
+		return err
+	}
+
+	query := `
+	DELETE FROM product_special_prices
+	WHERE product_id = $1
+	AND id::TEXT = $2;`
+
+	result, err := tx.ExecContext(ctx, query, productId, specialPriceId)
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("delete special price failed: %v", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		tx.Rollback()
+		return fmt.Errorf("special price not found")
+	}
+
+	if err := touchProduct(ctx, tx, productId, userId); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return nil
+}
+
+// FindProductSlug find product by current slug first, then old slugs
+func (r *productRepository) FindProductSlug(slug string) (*products.ProductSlug, error) {
+	query := `
+	SELECT
+		p.id AS product_id,
+		p.slug,
+		p.status,
+		FALSE AS redirect
+	FROM products p
+	WHERE p.slug = $1
+	AND p.deleted_at IS NULL
+	UNION ALL
+	SELECT
+		p.id AS product_id,
+		p.slug,
+		p.status,
+		TRUE AS redirect
+	FROM products_slugs s
+		JOIN products p ON p.id = s.product_id
+	WHERE s.slug = $1
+	AND p.deleted_at IS NULL
+	LIMIT 1;`
+
+	productSlug := new(products.ProductSlug)
+	if err := r.db.Get(productSlug, query, slug); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("product not found")
+		}
+		return nil, fmt.Errorf("find product slug failed: %v", err)
+	}
+	return productSlug, nil
+}
+
+// FindTranslations return translation of locale by product id
+func (r *productRepository) FindTranslations(productIds []string, locale string) (map[string]*products.ProductTranslation, error) {
+	query := `
+	SELECT
+		product_id,
+		locale,
+		title,
+		description,
+		created_at,
+		updated_at
+	FROM products_translations
+	WHERE product_id = ANY($1::VARCHAR[])
+	AND locale = $2;`
+
+	translations := make([]*products.ProductTranslation, 0)
+	if err := r.db.Select(&translations, query, productIds, locale); err != nil {
+		return nil, fmt.Errorf("select products translations failed: %v", err)
+	}
+
+	result := make(map[string]*products.ProductTranslation)
+	for _, t := range translations {
+		result[t.ProductId] = t
+	}
+	return result, nil
+}
+
+// FindCategoryTitles return category title of locale by category id
+func (r *productRepository) FindCategoryTitles(locale string) (map[int]string, error) {
+	query := `
+	SELECT
+		category_id AS id,
+		title
+	FROM categories_translations
+	WHERE locale = $1;`
+
+	categories := make([]*appInfo.Category, 0)
+	if err := r.db.Select(&categories, query, locale); err != nil {
+		return nil, fmt.Errorf("select categories translations failed: %v", err)
+	}
+
+	result := make(map[int]string)
+	for _, c := range categories {
+		result[c.Id] = c.Title
+	}
+	return result, nil
+}
+
+// FindCategoriesByLocale return every category with title of locale by id, for breadcrumbs
+func (r *productRepository) FindCategoriesByLocale(locale string) (map[int]*appInfo.Category, error) {
+	query := `
+	SELECT
+		c.id,
+		COALESCE(ct.title, c.title) AS title,
+		COALESCE(c.parent_id, 0) AS parent_id
+	FROM categories c
+		LEFT JOIN categories_translations ct ON ct.category_id = c.id AND ct.locale = $1;`
+
+	categories := make([]*appInfo.Category, 0)
+	if err := r.db.Select(&categories, query, locale); err != nil {
+		return nil, fmt.Errorf("select categories failed: %v", err)
+	}
+
+	result := make(map[int]*appInfo.Category)
+	for _, c := range categories {
+		result[c.Id] = c
+	}
+	return result, nil
+}
+
+func (r *productRepository) FindProductTranslations(productId string) ([]*products.ProductTranslation, error) {
+	query := `
+	SELECT
+		product_id,
+		locale,
+		title,
+		description,
+		created_at,
+		updated_at
+	FROM products_translations
+	WHERE product_id = $1
+	ORDER BY locale;`
+
+	translations := make([]*products.ProductTranslation, 0)
+	if err := r.db.Select(&translations, query, productId); err != nil {
+		return nil, fmt.Errorf("select products translations failed: %v", err)
+	}
+	return translations, nil
+}
+
+// UpsertTranslation insert or replace translation, dictionary is used by search of the locale
+func (r *productRepository) UpsertTranslation(req *products.ProductTranslation, dictionary, userId string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*15)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := lockProduct(ctx, tx, req.ProductId); err != nil {
+		return err
+	}
+
+	query := `
+	INSERT INTO products_translations (
+		product_id,
+		locale,
+		title,
+		description,
+		dictionary
+	)
+	VALUES ($1, $2, $3, $4, $5::regconfig)
+	ON CONFLICT (product_id, locale) DO UPDATE SET
+		title = EXCLUDED.title,
+		description = EXCLUDED.description,
+		dictionary = EXCLUDED.dictionary
+	RETURNING created_at, updated_at;`
+
+	if err := tx.QueryRowContext(
+		ctx,
+		query,
+		req.ProductId,
+		req.Locale,
+		req.Title,
+		req.Description,
+		dictionary,
+	).Scan(&req.CreatedAt, &req.UpdatedAt); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("upsert products translation failed: %v", err)
+	}
+
+	if err := touchProduct(ctx, tx, req.ProductId, userId); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return nil
+}
+
+func (r *productRepository) DeleteTranslation(productId, locale, userId string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*15)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := lockProduct(ctx, tx, productId); err != nil {
+		return err
+	}
+
+	query := `
+	DELETE FROM products_translations
+	WHERE product_id = $1
+	AND locale = $2;`
+
+	result, err := tx.ExecContext(ctx, query, productId, locale)
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("delete products translation failed: %v", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		tx.Rollback()
+		return fmt.Errorf("translation not found")
+	}
+
+	if err := touchProduct(ctx, tx, productId, userId); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return nil
+}
+
+// PublishScheduledProducts publish draft products which publish_at is passed and return their ids
+func (r *productRepository) PublishScheduledProducts() ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*60)
+	defer cancel()
+
+	query := `
+	UPDATE products SET
+		status = 'published',
+		version = version + 1
+	WHERE status = 'draft'
+	AND publish_at <= now()
+	AND deleted_at IS NULL
+	RETURNING id;`
+
+	productIds := make([]string, 0)
+	if err := r.db.SelectContext(ctx, &productIds, query); err != nil {
+		return nil, fmt.Errorf("publish scheduled products failed: %v", err)
+	}
+	return productIds, nil
+}
+
+// lockProduct lock product row in tx, so changes of the same product outside its own row run one by one
+func lockProduct(ctx context.Context, tx *sqlx.Tx, productId string) error {
+	var id string
+	if err := tx.GetContext(ctx, &id, `SELECT id FROM products WHERE id = $1 AND deleted_at IS NULL FOR UPDATE;`, productId); err != nil {
+		tx.Rollback()
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("product not found")
+		}
+		return fmt.Errorf("lock product failed: %v", err)
+	}
+	return nil
+}
+
+// touchProduct bump version and save revision after images, stock, special prices or translations are changed
+func touchProduct(ctx context.Context, tx *sqlx.Tx, productId, userId string) error {
+	if _, err := tx.ExecContext(ctx, `UPDATE products SET version = version + 1 WHERE id = $1;`, productId); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("update product version failed: %v", err)
+	}
+	return productPatterns.InsertRevision(ctx, tx, productId, userId)
+}
+
+// InsertImage add image at position, images from the position are moved back
+func (r *productRepository) InsertImage(productId string, req *products.InsertImageReq, userId string) (*entities.Image, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*15)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := lockProduct(ctx, tx, productId); err != nil {
+		return nil, err
+	}
+
+	var count int
+	if err := tx.GetContext(ctx, &count, `SELECT COUNT(*) FROM images WHERE product_id = $1;`, productId); err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("count images failed: %v", err)
+	}
+
+	image := &entities.Image{
+		FileName:  req.FileName,
+		Url:       req.Url,
+		Alt:       req.Alt,
+		Position:  count,
+		IsPrimary: req.IsPrimary || count == 0,
+	}
+	if req.Position != nil && *req.Position < count {
+		image.Position = *req.Position
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE images SET position = position + 1 WHERE product_id = $1 AND position >= $2;`, productId, image.Position); err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("move images failed: %v", err)
+	}
+	if image.IsPrimary {
+		if _, err := tx.ExecContext(ctx, `UPDATE images SET is_primary = false WHERE product_id = $1 AND is_primary;`, productId); err != nil {
+			tx.Rollback()
+			return nil, fmt.Errorf("unset primary image failed: %v", err)
+		}
+	}
+
+	query := `
+	INSERT INTO images (
+		filename,
+		url,
+		product_id,
+		position,
+		alt,
+		is_primary
+	)
+	VALUES ($1, $2, $3, $4, $5, $6)
+	RETURNING id;`
+
+	if err := tx.QueryRowContext(
+		ctx,
+		query,
+		image.FileName,
+		image.Url,
+		productId,
+		image.Position,
+		image.Alt,
+		image.IsPrimary,
+	).Scan(&image.Id); err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("insert image failed: %v", err)
+	}
+
+	if err := touchProduct(ctx, tx, productId, userId); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	return image, nil
+}
+
+func (r *productRepository) UpdateImage(productId, imageId string, req *products.UpdateImageReq, userId string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*15)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := lockProduct(ctx, tx, productId); err != nil {
+		return err
+	}
+
+	if req.IsPrimary {
+		if _, err := tx.ExecContext(ctx, `UPDATE images SET is_primary = false WHERE product_id = $1 AND is_primary AND id::text <> $2;`, productId, imageId); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("unset primary image failed: %v", err)
+		}
+	}
+
+	// NULL alt keep the old one
+	query := `
+	UPDATE images SET
+		alt = COALESCE($3, alt),
+		is_primary = is_primary OR $4
+	WHERE product_id = $1
+	AND id::text = $2;`
+
+	result, err := tx.ExecContext(ctx, query, productId, imageId, req.Alt, req.IsPrimary)
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("update image failed: %v", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		tx.Rollback()
+		return fmt.Errorf("image not found")
+	}
+
+	if err := touchProduct(ctx, tx, productId, userId); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return nil
+}
+
+// DeleteImage remove image record and return it, positions are packed and
+// the first image become primary when the primary one is removed
+func (r *productRepository) DeleteImage(productId, imageId string, userId string) (*entities.Image, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*15)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := lockProduct(ctx, tx, productId); err != nil {
+		return nil, err
+	}
+
+	query := `
+	DELETE FROM images
+	WHERE product_id = $1
+	AND id::text = $2
+	RETURNING id, filename, url, position, alt, is_primary;`
+
+	image := new(entities.Image)
+	if err := tx.GetContext(ctx, image, query, productId, imageId); err != nil {
+		tx.Rollback()
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("image not found")
+		}
+		return nil, fmt.Errorf("delete image failed: %v", err)
+	}
+
+	packQuery := `
+	UPDATE images i SET
+		position = o.n - 1,
+		is_primary = i.is_primary OR ($2 AND o.n = 1)
+	FROM (
+		SELECT
+			id,
+			row_number() OVER (ORDER BY position, id) AS n
+		FROM images
+		WHERE product_id = $1
+	) AS o
+	WHERE o.id = i.id;`
+
+	if _, err := tx.ExecContext(ctx, packQuery, productId, image.IsPrimary); err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("move images failed: %v", err)
+	}
+
+	if err := touchProduct(ctx, tx, productId, userId); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	return image, nil
+}
+
+// ReorderImages set position by order of imageIds, which must be every image of product
+func (r *productRepository) ReorderImages(productId string, imageIds []string, userId string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*15)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := lockProduct(ctx, tx, productId); err != nil {
+		return err
+	}
+
+	current := make([]string, 0)
+	if err := tx.SelectContext(ctx, &current, `SELECT id::text FROM images WHERE product_id = $1;`, productId); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("select images failed: %v", err)
+	}
+	ids := make(map[string]bool)
+	for _, id := range current {
+		ids[id] = true
+	}
+	for _, id := range imageIds {
+		if !ids[id] {
+			tx.Rollback()
+			return fmt.Errorf("image_ids must be every image of product")
+		}
+		delete(ids, id)
+	}
+	if len(ids) > 0 || len(imageIds) != len(current) {
+		tx.Rollback()
+		return fmt.Errorf("image_ids must be every image of product")
+	}
+
+	query := `
+	UPDATE images i SET
+		position = o.n - 1
+	FROM unnest($2::VARCHAR[]) WITH ORDINALITY AS o(id, n)
+	WHERE i.id::text = o.id
+	AND i.product_id = $1;`
+
+	if _, err := tx.ExecContext(ctx, query, productId, imageIds); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("reorder images failed: %v", err)
+	}
+
+	if err := touchProduct(ctx, tx, productId, userId); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		tx.Rollback()
+		return err
+	}
 	return nil
 }
@@ -0,0 +1,130 @@
+package productsUsecases
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/DrumPatiphon/go-rest-api-service/modules/products"
+	"github.com/DrumPatiphon/go-rest-api-service/pkg/xlsx"
+)
+
+// exportColumns use the same category and images format as import, so the file can be imported back
+var exportColumns = []string{
+	"id",
+	"title",
+	"description",
+	"price",
+	"currency",
+	"prices",
+	"category_ids",
+	"category",
+	"images",
+	"created_at",
+	"updated_at",
+}
+
+// exportRow price is in currency of the filter, prices has every currency
+func exportRow(p *products.Product) []any {
+	categoryIds := make([]string, 0)
+	categoryTitles := make([]string, 0)
+	for _, c := range p.Categories {
+		categoryIds = append(categoryIds, strconv.Itoa(c.Id))
+		categoryTitles = append(categoryTitles, c.Title)
+	}
+	prices := make([]string, 0)
+	for _, price := range p.Prices {
+		prices = append(prices, price.Currency+" "+price.String())
+	}
+	price, currency := "", ""
+	if p.Price != nil {
+		price, currency = p.Price.String(), p.Price.Currency
+	}
+	imageUrls := make([]string, 0)
+	for _, i := range p.Images {
+		imageUrls = append(imageUrls, i.Url)
+	}
+
+	return []any{
+		p.Id,
+		p.Title,
+		p.Description,
+		price,
+		currency,
+		strings.Join(prices, "|"),
+		strings.Join(categoryIds, "|"),
+		strings.Join(categoryTitles, "|"),
+		strings.Join(imageUrls, "|"),
+		p.CreatedAt,
+		p.UpdatedAt,
+	}
+}
+
+func (u *productsUsecases) ExportProducts(req *products.ProductFilter, format string, w io.Writer) error {
+	if req.Currency == "" {
+		req.Currency = u.cfg.App().Currency()
+	}
+	export := func(fn func(product *products.Product) error) error {
+		return u.productRepository.ExportProducts(req, func(product *products.Product) error {
+			u.setPrice(product, req.Currency)
+			return fn(product)
+		})
+	}
+
+	switch format {
+	case "csv":
+		writer := csv.NewWriter(w)
+		if err := writer.Write(exportColumns); err != nil {
+			return err
+		}
+		err := export(func(product *products.Product) error {
+			record := make([]string, 0)
+			for _, cell := range exportRow(product) {
+				switch v := cell.(type) {
+				case float64:
+					record = append(record, strconv.FormatFloat(v, 'f', -1, 64))
+				default:
+					record = append(record, fmt.Sprint(v))
+				}
+			}
+			return writer.Write(record)
+		})
+		if err != nil {
+			return err
+		}
+		writer.Flush()
+		return writer.Error()
+
+	case "ndjson":
+		encoder := json.NewEncoder(w)
+		return export(func(product *products.Product) error {
+			return encoder.Encode(product)
+		})
+
+	case "xlsx":
+		writer, err := xlsx.NewStreamWriter(w, "products")
+		if err != nil {
+			return err
+		}
+		header := make([]any, 0)
+		for _, column := range exportColumns {
+			header = append(header, column)
+		}
+		if err := writer.WriteRow(header); err != nil {
+			return err
+		}
+		err = export(func(product *products.Product) error {
+			return writer.WriteRow(exportRow(product))
+		})
+		if err != nil {
+			return err
+		}
+		return writer.Close()
+
+	default:
+		return fmt.Errorf("format %s is not acceptable", format)
+	}
+}
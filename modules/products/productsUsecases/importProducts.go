@@ -0,0 +1,338 @@
+package productsUsecases
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/DrumPatiphon/go-rest-api-service/modules/appInfo"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/entities"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/products"
+	"github.com/DrumPatiphon/go-rest-api-service/pkg/money"
+)
+
+const (
+	importBatchSize = 100 // products per transaction
+	importAsyncRows = 500 // file with more valid rows than this run in background
+)
+
+func (u *productsUsecases) ImportProducts(req *products.ImportProductReq, file io.Reader, userId string) (*products.ImportJob, error) {
+	var rows []*products.ImportProductRow
+	var err error
+	switch req.Format {
+	case "csv":
+		rows, err = parseImportCsv(file)
+	case "ndjson":
+		rows, err = parseImportNdjson(file)
+	default:
+		return nil, fmt.Errorf("format %s is not acceptable", req.Format)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	categories, err := u.productRepository.FindCategories()
+	if err != nil {
+		return nil, err
+	}
+
+	// Validate every row before insert anything
+	valid := make([]*products.Product, 0)
+	validLines := make([]int, 0)
+	rowErrors := make([]*products.ImportRowError, 0)
+	for _, row := range rows {
+		product := validateImportRow(row, categories, u.cfg.App().Currency())
+		if len(row.Errors) > 0 {
+			rowErrors = append(rowErrors, &products.ImportRowError{
+				Line:   row.Line,
+				Errors: row.Errors,
+			})
+			continue
+		}
+		valid = append(valid, product)
+		validLines = append(validLines, row.Line)
+	}
+
+	job := &products.ImportJob{
+		UserId:    userId,
+		Status:    "running",
+		DryRun:    req.DryRun,
+		TotalRows: len(rows),
+		ValidRows: len(valid),
+		RowErrors: rowErrors,
+	}
+	if req.DryRun || len(valid) == 0 {
+		job.Status = "completed"
+	}
+	if err := u.productRepository.InsertImportJob(job); err != nil {
+		return nil, err
+	}
+	if job.Status == "completed" {
+		return job, nil
+	}
+
+	if len(valid) > importAsyncRows {
+		// copy job, so the response is not changed while the job is running
+		running := *job
+		running.RowErrors = append(make([]*products.ImportRowError, 0), job.RowErrors...)
+		go u.runImportJob(&running, valid, validLines)
+		return job, nil
+	}
+	u.runImportJob(job, valid, validLines)
+	return job, nil
+}
+
+// runImportJob insert valid products batch by batch, a failed batch is reported on every row of it.
+// A panic mark the job failed instead of leaving it running
+func (u *productsUsecases) runImportJob(job *products.ImportJob, valid []*products.Product, validLines []int) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("import job %s panic: %v", job.Id, r)
+			job.Status = "failed"
+			if err := u.productRepository.UpdateImportJob(job); err != nil {
+				log.Printf("update import job %s failed: %v", job.Id, err)
+			}
+		}
+	}()
+
+	for start := 0; start < len(valid); start += importBatchSize {
+		end := start + importBatchSize
+		if end > len(valid) {
+			end = len(valid)
+		}
+
+		inserted, err := u.productRepository.ImportProducts(valid[start:end], job.UserId)
+		if err != nil {
+			for _, line := range validLines[start:end] {
+				job.RowErrors = append(job.RowErrors, &products.ImportRowError{
+					Line:   line,
+					Errors: []string{err.Error()},
+				})
+			}
+			continue
+		}
+		job.InsertedRows += inserted
+	}
+
+	job.Status = "completed"
+	if job.InsertedRows == 0 {
+		job.Status = "failed"
+	}
+	if err := u.productRepository.UpdateImportJob(job); err != nil {
+		log.Printf("update import job %s failed: %v", job.Id, err)
+	}
+}
+
+func (u *productsUsecases) FindOneImportJob(jobId string) (*products.ImportJob, error) {
+	job, err := u.productRepository.FindOneImportJob(jobId)
+	if err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// parseImportCsv read csv with header title,description,price,currency,prices,category,images
+// prices, category and images can have many values split by |, a price in prices is "USD 4.50".
+// When prices is sent, price and currency are not used
+func parseImportCsv(file io.Reader) ([]*products.ImportProductRow, error) {
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("read csv header failed: %v", err)
+	}
+	columns := make(map[string]int)
+	for i, h := range header {
+		columns[strings.ToLower(strings.TrimSpace(h))] = i
+	}
+	for _, required := range []string{"title", "category"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("csv column %s is required", required)
+		}
+	}
+	_, hasPrice := columns["price"]
+	_, hasPrices := columns["prices"]
+	if !hasPrice && !hasPrices {
+		return nil, fmt.Errorf("csv column price or prices is required")
+	}
+
+	column := func(record []string, name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+	split := func(value string) []string {
+		values := make([]string, 0)
+		for _, v := range strings.Split(value, "|") {
+			if v = strings.TrimSpace(v); v != "" {
+				values = append(values, v)
+			}
+		}
+		return values
+	}
+
+	rows := make([]*products.ImportProductRow, 0)
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		row := new(products.ImportProductRow)
+		rows = append(rows, row)
+		if err != nil {
+			if parseErr, ok := err.(*csv.ParseError); ok {
+				row.Line = parseErr.StartLine
+			}
+			row.Errors = append(row.Errors, err.Error())
+			continue
+		}
+		row.Line, _ = reader.FieldPos(0)
+
+		row.Title = column(record, "title")
+		row.Description = column(record, "description")
+		row.Categories = split(column(record, "category"))
+		row.ImageUrls = split(column(record, "images"))
+
+		row.Prices = make([]*money.Money, 0)
+		for _, price := range split(column(record, "prices")) {
+			currency, amount, ok := strings.Cut(price, " ")
+			if !ok {
+				row.Errors = append(row.Errors, fmt.Sprintf("price %s must be currency and amount", price))
+				continue
+			}
+			row.Prices = append(row.Prices, money.Decimal(amount, currency))
+		}
+		if len(row.Prices) == 0 && column(record, "price") != "" {
+			row.Prices = append(row.Prices, money.Decimal(column(record, "price"), column(record, "currency")))
+		}
+	}
+	return rows, nil
+}
+
+// parseImportNdjson read one product object per line, category can be title, id or list of them
+func parseImportNdjson(file io.Reader) ([]*products.ImportProductRow, error) {
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	rows := make([]*products.ImportProductRow, 0)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		raw := &struct {
+			Title       string         `json:"title"`
+			Description string         `json:"description"`
+			Price       *money.Money   `json:"price"`
+			Prices      []*money.Money `json:"prices"`
+			Category    any            `json:"category"`
+			Images      any            `json:"images"`
+		}{}
+		row := &products.ImportProductRow{Line: line}
+		rows = append(rows, row)
+		if err := json.Unmarshal([]byte(text), raw); err != nil {
+			row.Errors = append(row.Errors, fmt.Sprintf("invalid json: %v", err))
+			continue
+		}
+
+		row.Title = strings.TrimSpace(raw.Title)
+		row.Description = raw.Description
+		// exported products have both price and prices, prices already has every currency
+		row.Prices = raw.Prices
+		if len(row.Prices) == 0 && raw.Price != nil {
+			row.Prices = []*money.Money{raw.Price}
+		}
+		row.Categories = anyToStrings(raw.Category)
+		row.ImageUrls = anyToStrings(raw.Images)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read ndjson failed: %v", err)
+	}
+	return rows, nil
+}
+
+func anyToStrings(value any) []string {
+	values := make([]string, 0)
+	switch v := value.(type) {
+	case string:
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	case float64:
+		values = append(values, strconv.FormatFloat(v, 'f', -1, 64))
+	case []any:
+		for _, item := range v {
+			values = append(values, anyToStrings(item)...)
+		}
+	}
+	return values
+}
+
+// validateImportRow append errors to row and return product when row is valid
+func validateImportRow(row *products.ImportProductRow, categories []*appInfo.Category, defaultCurrency string) *products.Product {
+	if row.Title == "" {
+		row.Errors = append(row.Errors, "title is required")
+	}
+
+	product := &products.Product{
+		Title:       row.Title,
+		Description: row.Description,
+		Prices:      row.Prices,
+		Categories:  make([]*appInfo.Category, 0),
+		Images:      make([]*entities.Image, 0),
+	}
+	if err := product.NormalizePrices(defaultCurrency); err != nil {
+		row.Errors = append(row.Errors, err.Error())
+	} else if product.PriceIn(defaultCurrency) == nil {
+		row.Errors = append(row.Errors, fmt.Sprintf("price in %s is required", defaultCurrency))
+	}
+
+	if len(row.Categories) == 0 {
+		row.Errors = append(row.Errors, "category is required")
+	}
+	for _, value := range row.Categories {
+		category := findImportCategory(value, categories)
+		if category == nil {
+			row.Errors = append(row.Errors, fmt.Sprintf("category %s not found", value))
+			continue
+		}
+		product.Categories = append(product.Categories, category)
+	}
+
+	for _, value := range row.ImageUrls {
+		u, err := url.ParseRequestURI(value)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			row.Errors = append(row.Errors, fmt.Sprintf("image url %s is invalid", value))
+			continue
+		}
+		// external image has no filename, so it is never deleted from GCP
+		product.Images = append(product.Images, &entities.Image{
+			Url: value,
+		})
+	}
+	return product
+}
+
+func findImportCategory(value string, categories []*appInfo.Category) *appInfo.Category {
+	id, err := strconv.Atoi(value)
+	for _, c := range categories {
+		if err == nil && c.Id == id {
+			return c
+		}
+		if strings.EqualFold(c.Title, value) {
+			return c
+		}
+	}
+	return nil
+}
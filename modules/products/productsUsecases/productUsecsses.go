@@ -1,64 +1,231 @@
 package productsUsecases
 
 import (
+	"fmt"
+	"io"
+	"log"
 	"math"
 
+	"github.com/DrumPatiphon/go-rest-api-service/config"
 	"github.com/DrumPatiphon/go-rest-api-service/modules/entities"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/files"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/files/filesUsecases"
 	"github.com/DrumPatiphon/go-rest-api-service/modules/products"
 	"github.com/DrumPatiphon/go-rest-api-service/modules/products/productsRepositories"
+	"github.com/DrumPatiphon/go-rest-api-service/pkg/locale"
 )
 
 type IProductUseCase interface {
-	FindOneProduct(productId string) (*products.Product, error)
+	FindOneProduct(productId string, req *products.ProductViewReq) (*products.Product, error)
+	FindOneProductBySlug(slug string, req *products.ProductViewReq) (*products.Product, *products.ProductSlug, error)
 	FindProduct(req *products.ProductFilter) *entities.PageRes
-	InsertProduct(req *products.Product) (*products.Product, error)
-	UpdateProduct(req *products.Product) (*products.Product, error)
+	InsertProduct(req *products.Product, userId string) (*products.Product, error)
+	UpdateProduct(req *products.UpdateProductReq, userId string) (*products.Product, error)
 	DeleteProduct(productId string) error
+	FindTrashProduct(req *products.ProductFilter) *entities.PageRes
+	RestoreProduct(productId string) (*products.Product, error)
+	UpdateStock(productId string, req *products.UpdateStockReq, userId string) (*products.Product, error)
+	PurgeProducts() error
+	PublishScheduledProducts() error
+	InsertImage(productId string, req *products.InsertImageReq, userId string) (*products.Product, error)
+	UpdateImage(productId, imageId string, req *products.UpdateImageReq, userId string) (*products.Product, error)
+	DeleteImage(productId, imageId string, userId string) (*products.Product, error)
+	ReorderImages(productId string, req *products.ReorderImagesReq, userId string) (*products.Product, error)
+	FindRevisions(productId string) ([]*products.ProductRevision, error)
+	DiffRevisions(productId string, req *products.ProductRevisionDiffReq) (*products.ProductRevisionDiff, error)
+	RollbackProduct(productId string, revision int, userId string) (*products.Product, error)
+	ImportProducts(req *products.ImportProductReq, file io.Reader, userId string) (*products.ImportJob, error)
+	FindOneImportJob(jobId string) (*products.ImportJob, error)
+	ExportProducts(req *products.ProductFilter, format string, w io.Writer) error
+	FindSpecialPrices(productId string) ([]*products.SpecialPrice, error)
+	InsertSpecialPrice(req *products.SpecialPrice, userId string) (*products.SpecialPrice, error)
+	DeleteSpecialPrice(productId, specialPriceId, userId string) error
+	FindTranslations(productId string) ([]*products.ProductTranslation, error)
+	UpsertTranslation(req *products.ProductTranslation, userId string) (*products.ProductTranslation, error)
+	DeleteTranslation(productId, locale, userId string) error
 }
 
 type productsUsecases struct {
+	cfg               config.Iconfig
 	productRepository productsRepositories.IProductRepository
+	filesUsecases     filesUsecases.IFilesUsecases
 }
 
-func ProductsUsecases(productRepository productsRepositories.IProductRepository) IProductUseCase {
+func ProductsUsecases(cfg config.Iconfig, productRepository productsRepositories.IProductRepository, filesUsecases filesUsecases.IFilesUsecases) IProductUseCase {
 	return &productsUsecases{
+		cfg:               cfg,
 		productRepository: productRepository,
+		filesUsecases:     filesUsecases,
 	}
 }
 
-func (u *productsUsecases) FindOneProduct(productId string) (*products.Product, error) {
+func (u *productsUsecases) FindOneProduct(productId string, req *products.ProductViewReq) (*products.Product, error) {
 	product, err := u.productRepository.FindOneProduct(productId)
 	if err != nil {
 		return nil, err
 	}
+	if err := u.setEffectivePrices([]*products.Product{product}, req.UserId); err != nil {
+		return nil, err
+	}
+	// draft and archived product are hidden from public, admin can still see them
+	if product.Status != "published" && !req.IsAdmin {
+		return nil, fmt.Errorf("product not found")
+	}
+	u.setPrice(product, req.Currency)
+	if err := u.translate([]*products.Product{product}, req.Locale); err != nil {
+		return nil, err
+	}
+	if err := u.setBreadcrumbs([]*products.Product{product}, req.Locale); err != nil {
+		return nil, err
+	}
 	return product, nil
 }
 
+// FindOneProductBySlug return only the ProductSlug when slug is an old one
+func (u *productsUsecases) FindOneProductBySlug(slug string, req *products.ProductViewReq) (*products.Product, *products.ProductSlug, error) {
+	productSlug, err := u.productRepository.FindProductSlug(slug)
+	if err != nil {
+		return nil, nil, err
+	}
+	// current slug of draft and archived product is hidden from public like the product
+	if productSlug.Status != "published" && !req.IsAdmin {
+		return nil, nil, fmt.Errorf("product not found")
+	}
+	if productSlug.Redirect {
+		return nil, productSlug, nil
+	}
+
+	product, err := u.FindOneProduct(productSlug.ProductId, req)
+	if err != nil {
+		return nil, nil, err
+	}
+	return product, productSlug, nil
+}
+
+// setEffectivePrices load price for the user, sale and price list included
+func (u *productsUsecases) setEffectivePrices(productsData []*products.Product, userId string) error {
+	if len(productsData) == 0 {
+		return nil
+	}
+	productIds := make([]string, 0)
+	for _, p := range productsData {
+		productIds = append(productIds, p.Id)
+	}
+
+	prices, err := u.productRepository.FindEffectivePrices(productIds, userId)
+	if err != nil {
+		return err
+	}
+	for _, p := range productsData {
+		p.EffectivePrices = prices[p.Id]
+	}
+	return nil
+}
+
+// translate replace content of products with the locale ones, empty locale is the default locale
+func (u *productsUsecases) translate(productsData []*products.Product, locale string) error {
+	if locale == "" {
+		locale = u.cfg.App().Locale()
+	}
+	if locale == u.cfg.App().Locale() || len(productsData) == 0 {
+		for _, p := range productsData {
+			p.Locale = u.cfg.App().Locale()
+		}
+		return nil
+	}
+
+	productIds := make([]string, 0)
+	for _, p := range productsData {
+		productIds = append(productIds, p.Id)
+	}
+	translations, err := u.productRepository.FindTranslations(productIds, locale)
+	if err != nil {
+		return err
+	}
+	categoryTitles, err := u.productRepository.FindCategoryTitles(locale)
+	if err != nil {
+		return err
+	}
+	for _, p := range productsData {
+		p.Translate(locale, translations[p.Id], categoryTitles)
+	}
+	return nil
+}
+
+// setBreadcrumbs set path of categories in locale, empty locale is the default locale
+func (u *productsUsecases) setBreadcrumbs(productsData []*products.Product, locale string) error {
+	if len(productsData) == 0 {
+		return nil
+	}
+	if locale == u.cfg.App().Locale() {
+		locale = ""
+	}
+
+	categories, err := u.productRepository.FindCategoriesByLocale(locale)
+	if err != nil {
+		return err
+	}
+	for _, p := range productsData {
+		p.SetBreadcrumbs(categories)
+	}
+	return nil
+}
+
+// setPrice set price of response in currency, empty currency is the default currency
+func (u *productsUsecases) setPrice(product *products.Product, currency string) {
+	if currency == "" {
+		currency = u.cfg.App().Currency()
+	}
+	product.SetPrice(currency, u.cfg.App().Currency())
+}
+
 func (u *productsUsecases) FindProduct(req *products.ProductFilter) *entities.PageRes {
+	if req.Currency == "" {
+		req.Currency = u.cfg.App().Currency()
+	}
 	products, count := u.productRepository.FindProduct(req)
+	if err := u.setEffectivePrices(products, req.UserId); err != nil {
+		log.Printf("find effective prices failed: %v", err)
+	}
+	for _, p := range products {
+		u.setPrice(p, req.Currency)
+	}
+	if err := u.translate(products, req.Locale); err != nil {
+		log.Printf("find translations failed: %v", err)
+	}
+	if err := u.setBreadcrumbs(products, req.Locale); err != nil {
+		log.Printf("find breadcrumbs failed: %v", err)
+	}
 
 	return &entities.PageRes{
 		Data:       products,
 		Page:       req.Page,
-		Limit:      req.Page,
+		Limit:      req.Limit,
 		TotalItems: count,
 		TotalPage:  int(math.Ceil(float64(count) / float64(req.Limit))),
 	}
 }
 
-func (u *productsUsecases) InsertProduct(req *products.Product) (*products.Product, error) {
-	product, err := u.productRepository.InsertProduct(req)
+func (u *productsUsecases) InsertProduct(req *products.Product, userId string) (*products.Product, error) {
+	product, err := u.productRepository.InsertProduct(req, userId)
 	if err != nil {
 		return nil, err
 	}
+	u.setPrice(product, "")
 	return product, nil
 }
 
-func (u *productsUsecases) UpdateProduct(req *products.Product) (*products.Product, error) {
-	product, err := u.productRepository.UpdateProduct(req)
+func (u *productsUsecases) UpdateProduct(req *products.UpdateProductReq, userId string) (*products.Product, error) {
+	// product in trash can't be updated until restored
+	if _, err := u.productRepository.FindOneProduct(req.Id); err != nil {
+		return nil, err
+	}
+
+	product, err := u.productRepository.UpdateProduct(req, userId)
 	if err != nil {
 		return nil, err
 	}
+	u.setPrice(product, "")
 	return product, nil
 }
 
@@ -68,3 +235,210 @@ func (u *productsUsecases) DeleteProduct(productId string) error {
 	}
 	return nil
 }
+
+func (u *productsUsecases) FindTrashProduct(req *products.ProductFilter) *entities.PageRes {
+	if req.Currency == "" {
+		req.Currency = u.cfg.App().Currency()
+	}
+	products, count := u.productRepository.FindTrashProduct(req)
+	for _, p := range products {
+		u.setPrice(p, req.Currency)
+	}
+
+	return &entities.PageRes{
+		Data:       products,
+		Page:       req.Page,
+		Limit:      req.Limit,
+		TotalItems: count,
+		TotalPage:  int(math.Ceil(float64(count) / float64(req.Limit))),
+	}
+}
+
+func (u *productsUsecases) RestoreProduct(productId string) (*products.Product, error) {
+	if err := u.productRepository.RestoreProduct(productId); err != nil {
+		return nil, err
+	}
+
+	product, err := u.productRepository.FindOneProduct(productId)
+	if err != nil {
+		return nil, err
+	}
+	u.setPrice(product, "")
+	return product, nil
+}
+
+func (u *productsUsecases) UpdateStock(productId string, req *products.UpdateStockReq, userId string) (*products.Product, error) {
+	if err := u.productRepository.UpdateStock(productId, req.Stock, userId); err != nil {
+		return nil, err
+	}
+	return u.findUpdatedProduct(productId)
+}
+
+// PurgeProducts delete products from database first, then remove their images on GCP, the ones in revisions too
+func (u *productsUsecases) PurgeProducts() error {
+	images, err := u.productRepository.PurgeProducts(u.cfg.App().TrashRetention())
+	if err != nil {
+		return err
+	}
+	if len(images) == 0 {
+		return nil
+	}
+
+	deleteFileReq := make([]*files.DeleteFileReq, 0)
+	for _, img := range images {
+		// external image has no file on GCP
+		if img.FileName == "" {
+			continue
+		}
+		deleteFileReq = append(deleteFileReq, &files.DeleteFileReq{
+			Destination: fmt.Sprintf("image/test/%s", img.FileName),
+		})
+	}
+	if len(deleteFileReq) == 0 {
+		return nil
+	}
+	if err := u.filesUsecases.DeleteFileOnGCP(deleteFileReq); err != nil {
+		return err
+	}
+	return nil
+}
+
+// PublishScheduledProducts publish products on their publish_at, run by scheduler
+func (u *productsUsecases) PublishScheduledProducts() error {
+	productIds, err := u.productRepository.PublishScheduledProducts()
+	if err != nil {
+		return err
+	}
+	if len(productIds) > 0 {
+		log.Printf("published %d scheduled products: %v", len(productIds), productIds)
+	}
+	return nil
+}
+
+func (u *productsUsecases) FindRevisions(productId string) ([]*products.ProductRevision, error) {
+	revisions, err := u.productRepository.FindRevisions(productId)
+	if err != nil {
+		return nil, err
+	}
+	return revisions, nil
+}
+
+func (u *productsUsecases) DiffRevisions(productId string, req *products.ProductRevisionDiffReq) (*products.ProductRevisionDiff, error) {
+	from, err := u.productRepository.FindOneRevision(productId, req.From)
+	if err != nil {
+		return nil, err
+	}
+	to, err := u.productRepository.FindOneRevision(productId, req.To)
+	if err != nil {
+		return nil, err
+	}
+	return from.Diff(to), nil
+}
+
+func (u *productsUsecases) RollbackProduct(productId string, revision int, userId string) (*products.Product, error) {
+	if err := u.productRepository.RollbackProduct(productId, revision, userId); err != nil {
+		return nil, err
+	}
+
+	product, err := u.productRepository.FindOneProduct(productId)
+	if err != nil {
+		return nil, err
+	}
+	u.setPrice(product, "")
+	return product, nil
+}
+
+func (u *productsUsecases) FindSpecialPrices(productId string) ([]*products.SpecialPrice, error) {
+	specialPrices, err := u.productRepository.FindSpecialPrices(productId)
+	if err != nil {
+		return nil, err
+	}
+	return specialPrices, nil
+}
+
+func (u *productsUsecases) InsertSpecialPrice(req *products.SpecialPrice, userId string) (*products.SpecialPrice, error) {
+	if _, err := u.productRepository.FindOneProduct(req.ProductId); err != nil {
+		return nil, fmt.Errorf("product not found")
+	}
+	if err := u.productRepository.InsertSpecialPrice(req, userId); err != nil {
+		return nil, err
+	}
+
+	specialPrices, err := u.productRepository.FindSpecialPrices(req.ProductId)
+	if err != nil {
+		return nil, err
+	}
+	for _, sp := range specialPrices {
+		if sp.Id == req.Id {
+			return sp, nil
+		}
+	}
+	return nil, fmt.Errorf("special price not found")
+}
+
+func (u *productsUsecases) DeleteSpecialPrice(productId, specialPriceId, userId string) error {
+	if err := u.productRepository.DeleteSpecialPrice(productId, specialPriceId, userId); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (u *productsUsecases) FindTranslations(productId string) ([]*products.ProductTranslation, error) {
+	if _, err := u.productRepository.FindOneProduct(productId); err != nil {
+		return nil, err
+	}
+	return u.productRepository.FindProductTranslations(productId)
+}
+
+func (u *productsUsecases) UpsertTranslation(req *products.ProductTranslation, userId string) (*products.ProductTranslation, error) {
+	if _, err := u.productRepository.FindOneProduct(req.ProductId); err != nil {
+		return nil, err
+	}
+	if err := u.productRepository.UpsertTranslation(req, locale.Dictionary(req.Locale), userId); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+func (u *productsUsecases) DeleteTranslation(productId, locale, userId string) error {
+	return u.productRepository.DeleteTranslation(productId, locale, userId)
+}
+
+func (u *productsUsecases) InsertImage(productId string, req *products.InsertImageReq, userId string) (*products.Product, error) {
+	if _, err := u.productRepository.InsertImage(productId, req, userId); err != nil {
+		return nil, err
+	}
+	return u.findUpdatedProduct(productId)
+}
+
+func (u *productsUsecases) UpdateImage(productId, imageId string, req *products.UpdateImageReq, userId string) (*products.Product, error) {
+	if err := u.productRepository.UpdateImage(productId, imageId, req, userId); err != nil {
+		return nil, err
+	}
+	return u.findUpdatedProduct(productId)
+}
+
+// DeleteImage remove image record, its file is kept on GCP for rollback until the product is purged from trash
+func (u *productsUsecases) DeleteImage(productId, imageId string, userId string) (*products.Product, error) {
+	if _, err := u.productRepository.DeleteImage(productId, imageId, userId); err != nil {
+		return nil, err
+	}
+	return u.findUpdatedProduct(productId)
+}
+
+func (u *productsUsecases) ReorderImages(productId string, req *products.ReorderImagesReq, userId string) (*products.Product, error) {
+	if err := u.productRepository.ReorderImages(productId, req.ImageIds, userId); err != nil {
+		return nil, err
+	}
+	return u.findUpdatedProduct(productId)
+}
+
+// findUpdatedProduct return product after an admin change, price in the default currency
+func (u *productsUsecases) findUpdatedProduct(productId string) (*products.Product, error) {
+	product, err := u.productRepository.FindOneProduct(productId)
+	if err != nil {
+		return nil, err
+	}
+	u.setPrice(product, "")
+	return product, nil
+}
@@ -0,0 +1,47 @@
+package products
+
+import "testing"
+
+func TestParseETag(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  string
+		version int
+		wantErr bool
+	}{
+		{name: "empty", header: "", version: 0},
+		{name: "any", header: "*", version: 0},
+		{name: "spaces", header: "  ", version: 0},
+		{name: "quoted", header: `"3"`, version: 3},
+		{name: "weak", header: `W/"7"`, version: 7},
+		{name: "unquoted", header: "12", version: 12},
+		{name: "trimmed", header: ` "4" `, version: 4},
+		{name: "zero", header: `"0"`, wantErr: true},
+		{name: "negative", header: `"-1"`, wantErr: true},
+		{name: "text", header: `"abc"`, wantErr: true},
+		{name: "many", header: `"1", "2"`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			version, err := ParseETag(tt.header)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseETag(%q) error = %v, wantErr %v", tt.header, err, tt.wantErr)
+			}
+			if version != tt.version {
+				t.Errorf("ParseETag(%q) = %d, want %d", tt.header, version, tt.version)
+			}
+		})
+	}
+}
+
+func TestETagRoundTrip(t *testing.T) {
+	product := &Product{Version: 5}
+	version, err := ParseETag(product.ETag())
+	if err != nil {
+		t.Fatalf("ParseETag(%q) error = %v", product.ETag(), err)
+	}
+	if version != product.Version {
+		t.Errorf("ParseETag(%q) = %d, want %d", product.ETag(), version, product.Version)
+	}
+}
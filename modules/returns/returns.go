@@ -0,0 +1,110 @@
+package returns
+
+import (
+	"github.com/DrumPatiphon/go-rest-api-service/modules/entities"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/orders"
+	"github.com/DrumPatiphon/go-rest-api-service/pkg/money"
+)
+
+// MaxImages is photos a return can have
+const MaxImages = 5
+
+type Return struct {
+	Id           string         `json:"id"`
+	OrderId      string         `json:"order_id"`
+	UserId       string         `json:"user_id"`
+	Status       string         `json:"status"`
+	Reason       string         `json:"reason"`
+	Note         string         `json:"note"`       // from customer
+	AdminNote    string         `json:"admin_note"` // like why it is rejected
+	Currency     string         `json:"currency"`
+	Amount       *money.Money   `json:"amount"` // sum of items, the most can be refunded
+	RefundAmount *money.Money   `json:"refund_amount"`
+	RefundId     string         `json:"refund_id,omitempty"` // of payment provider
+	Items        []*ReturnItem  `json:"items"`
+	Images       []*ReturnImage `json:"images"`
+	CreatedAt    string         `json:"created_at"`
+	UpdatedAt    string         `json:"updated_at"`
+}
+
+type ReturnItem struct {
+	Id          string               `json:"id"`
+	OrderItemId string               `json:"order_item_id"`
+	Product     *orders.OrderProduct `json:"product"`
+	Quantity    int                  `json:"quantity"`
+	Amount      *money.Money         `json:"amount"` // paid for quantity of the line
+}
+
+type ReturnImage struct {
+	Id        string `json:"id"`
+	FileName  string `json:"filename"`
+	Url       string `json:"url"`
+	CreatedAt string `json:"created_at"`
+}
+
+type InsertReturnReq struct {
+	Reason string           `json:"reason" form:"reason"`
+	Note   string           `json:"note" form:"note"`
+	Items  []*ReturnItemReq `json:"items" form:"items"`
+}
+
+type ReturnItemReq struct {
+	OrderItemId string `json:"order_item_id" form:"order_item_id"`
+	Quantity    int    `json:"quantity" form:"quantity"`
+}
+
+type TransitionReq struct {
+	Status string `json:"status" form:"status"` // approved, rejected or received
+	Note   string `json:"note" form:"note"`
+}
+
+// RefundReq amount is decimal like 150.50, amount of the return when it is empty
+type RefundReq struct {
+	Amount string `json:"amount" form:"amount"`
+	Note   string `json:"note" form:"note"`
+}
+
+type ReturnFilter struct {
+	Status                  string `query:"status"`
+	OrderId                 string `query:"order_id"`
+	UserId                  string `query:"-"`
+	*entities.PaginationReq        // like inherit class
+}
+
+var reasons = map[string]bool{
+	"damaged":          true,
+	"defective":        true,
+	"wrong_item":       true,
+	"not_as_described": true,
+	"no_longer_needed": true,
+	"other":            true,
+}
+
+func IsReason(reason string) bool {
+	return reasons[reason]
+}
+
+// transitions is every status a return can go to from a status, refunding and refunded are only set by refund.
+// Refunding claim the return while money is sent, it goes back to received when provider refuse
+var transitions = map[string][]string{
+	"requested": {"approved", "rejected"},
+	"approved":  {"received"},
+	"received":  {"refunding"},
+	"refunding": {"refunded", "received"},
+	"rejected":  {},
+	"refunded":  {},
+}
+
+func IsStatus(status string) bool {
+	_, ok := transitions[status]
+	return ok
+}
+
+func CanTransition(from, to string) bool {
+	for _, s := range transitions[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
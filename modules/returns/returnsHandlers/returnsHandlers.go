@@ -0,0 +1,352 @@
+package returnsHandlers
+
+import (
+	"fmt"
+	"math"
+	"path/filepath"
+	"strings"
+
+	"github.com/DrumPatiphon/go-rest-api-service/config"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/entities"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/files"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/returns"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/returns/returnsUsecases"
+	"github.com/DrumPatiphon/go-rest-api-service/pkg/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+type returnsHandlerErrCode string
+
+const (
+	insertReturnErr     returnsHandlerErrCode = "returns-001"
+	findMyReturnsErr    returnsHandlerErrCode = "returns-002"
+	findReturnsErr      returnsHandlerErrCode = "returns-003"
+	findOneReturnErr    returnsHandlerErrCode = "returns-004"
+	insertImagesErr     returnsHandlerErrCode = "returns-005"
+	transitionReturnErr returnsHandlerErrCode = "returns-006"
+	refundReturnErr     returnsHandlerErrCode = "returns-007"
+)
+
+type IReturnsHandler interface {
+	InsertReturn(c *fiber.Ctx) error
+	FindMyReturns(c *fiber.Ctx) error
+	FindReturns(c *fiber.Ctx) error
+	FindOneReturn(c *fiber.Ctx) error
+	InsertImages(c *fiber.Ctx) error
+	TransitionReturn(c *fiber.Ctx) error
+	RefundReturn(c *fiber.Ctx) error
+}
+
+type returnsHandler struct {
+	cfg            config.Iconfig
+	returnsUsecase returnsUsecases.IReturnsUsecase
+}
+
+func ReturnsHandler(cfg config.Iconfig, returnsUsecase returnsUsecases.IReturnsUsecase) IReturnsHandler {
+	return &returnsHandler{
+		cfg:            cfg,
+		returnsUsecase: returnsUsecase,
+	}
+}
+
+// errStatus is status of errors from usecase, state of order or return is conflict
+func errStatus(err error) int {
+	switch {
+	case err.Error() == "order not found", err.Error() == "return not found":
+		return fiber.ErrNotFound.Code
+	case err.Error() == "order is not delivered",
+		strings.HasPrefix(err.Error(), "return can't be"),
+		strings.HasPrefix(err.Error(), "photos can only be added"):
+		return fiber.ErrConflict.Code
+	case err.Error() == "item not found",
+		err.Error() == "quantity is more than can be returned",
+		strings.HasPrefix(err.Error(), "return can have at most"),
+		strings.HasPrefix(err.Error(), "refund amount"),
+		err.Error() == "refund is more than paid",
+		err.Error() == "payment not found":
+		return fiber.ErrBadRequest.Code
+	default:
+		return fiber.ErrInternalServerError.Code
+	}
+}
+
+// InsertReturn customer request return of lines of own delivered order
+func (h *returnsHandler) InsertReturn(c *fiber.Ctx) error {
+	orderId := strings.TrimSpace(c.Params("order_id"))
+	req := new(returns.InsertReturnReq)
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(insertReturnErr),
+			err.Error(),
+		).Res()
+	}
+	req.Reason = strings.TrimSpace(req.Reason)
+	req.Note = strings.TrimSpace(req.Note)
+	if !returns.IsReason(req.Reason) {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(insertReturnErr),
+			"reason must be damaged, defective, wrong_item, not_as_described, no_longer_needed or other",
+		).Res()
+	}
+	if len(req.Items) == 0 {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(insertReturnErr),
+			"items are required",
+		).Res()
+	}
+	seen := make(map[string]bool)
+	for _, item := range req.Items {
+		item.OrderItemId = strings.TrimSpace(item.OrderItemId)
+		if item.OrderItemId == "" || seen[item.OrderItemId] {
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(insertReturnErr),
+				"order item id is required once for each item",
+			).Res()
+		}
+		if item.Quantity < 1 {
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(insertReturnErr),
+				"quantity is invalid",
+			).Res()
+		}
+		seen[item.OrderItemId] = true
+	}
+
+	userId, _ := c.Locals("userId").(string)
+	returnData, err := h.returnsUsecase.InsertReturn(orderId, userId, req)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			errStatus(err),
+			string(insertReturnErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Success(fiber.StatusCreated, returnData).Res()
+}
+
+func parseFilter(c *fiber.Ctx) (*returns.ReturnFilter, error) {
+	req := &returns.ReturnFilter{
+		PaginationReq: &entities.PaginationReq{},
+	}
+	if err := c.QueryParser(req); err != nil {
+		return nil, err
+	}
+	req.Status = strings.TrimSpace(req.Status)
+	if req.Status != "" && !returns.IsStatus(req.Status) {
+		return nil, fmt.Errorf("status is invalid")
+	}
+	req.OrderId = strings.TrimSpace(req.OrderId)
+	if req.Page < 1 {
+		req.Page = 1
+	}
+	if req.Limit < 5 {
+		req.Limit = 5
+	}
+	return req, nil
+}
+
+// FindMyReturns return returns of the signed in user only
+func (h *returnsHandler) FindMyReturns(c *fiber.Ctx) error {
+	req, err := parseFilter(c)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(findMyReturnsErr),
+			err.Error(),
+		).Res()
+	}
+	req.UserId, _ = c.Locals("userId").(string)
+
+	returnsData, err := h.returnsUsecase.FindReturns(req)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(findMyReturnsErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, returnsData).Res()
+}
+
+func (h *returnsHandler) FindReturns(c *fiber.Ctx) error {
+	req, err := parseFilter(c)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(findReturnsErr),
+			err.Error(),
+		).Res()
+	}
+
+	returnsData, err := h.returnsUsecase.FindReturns(req)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(findReturnsErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, returnsData).Res()
+}
+
+// FindOneReturn customer can only see own return, admin can see every return
+func (h *returnsHandler) FindOneReturn(c *fiber.Ctx) error {
+	returnId := strings.TrimSpace(c.Params("return_id"))
+
+	returnData, err := h.returnsUsecase.FindOneReturn(returnId)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			errStatus(err),
+			string(findOneReturnErr),
+			err.Error(),
+		).Res()
+	}
+	userId, _ := c.Locals("userId").(string)
+	userRoleId, _ := c.Locals("userRoleId").(int)
+	if returnData.UserId != userId && userRoleId != 2 {
+		return entities.NewResponse(c).Error(
+			fiber.ErrNotFound.Code,
+			string(findOneReturnErr),
+			"return not found",
+		).Res()
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, returnData).Res()
+}
+
+// InsertImages customer add photos of own return in files of multipart form, checked like files module
+func (h *returnsHandler) InsertImages(c *fiber.Ctx) error {
+	returnId := strings.TrimSpace(c.Params("return_id"))
+	userId, _ := c.Locals("userId").(string)
+
+	returnData, err := h.returnsUsecase.FindOneReturn(returnId)
+	if err != nil || returnData.UserId != userId {
+		return entities.NewResponse(c).Error(
+			fiber.ErrNotFound.Code,
+			string(insertImagesErr),
+			"return not found",
+		).Res()
+	}
+
+	form, err := c.MultipartForm()
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(insertImagesErr),
+			err.Error(),
+		).Res()
+	}
+	filesReq := form.File["files"]
+	if len(filesReq) == 0 {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(insertImagesErr),
+			"files are required",
+		).Res()
+	}
+
+	extMap := map[string]string{
+		"png":  "png",
+		"jpg":  "jpg",
+		"jpeg": "jpeg",
+	}
+	req := make([]*files.FileReq, 0)
+	for _, file := range filesReq {
+		ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(file.Filename), "."))
+		if extMap[ext] == "" {
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(insertImagesErr),
+				"extension is not acceptable",
+			).Res()
+		}
+		if file.Size > int64(h.cfg.App().FileLimit()) {
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(insertImagesErr),
+				fmt.Sprintf("file size must less than %d MB", int(math.Ceil(float64(h.cfg.App().FileLimit())/math.Pow(1024, 2)))),
+			).Res()
+		}
+
+		filename := utils.RandomFilename(ext)
+		req = append(req, &files.FileReq{
+			File:        file,
+			Destination: "returns/" + returnId + "/" + filename,
+			FileName:    filename,
+			Extension:   ext,
+		})
+	}
+
+	returnData, err = h.returnsUsecase.InsertImages(returnId, req)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			errStatus(err),
+			string(insertImagesErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Success(fiber.StatusCreated, returnData).Res()
+}
+
+// TransitionReturn admin approve or reject a request, and mark approved return received which restock the items
+func (h *returnsHandler) TransitionReturn(c *fiber.Ctx) error {
+	returnId := strings.TrimSpace(c.Params("return_id"))
+	req := new(returns.TransitionReq)
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(transitionReturnErr),
+			err.Error(),
+		).Res()
+	}
+	req.Status = strings.TrimSpace(req.Status)
+	req.Note = strings.TrimSpace(req.Note)
+	if req.Status != "approved" && req.Status != "rejected" && req.Status != "received" {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(transitionReturnErr),
+			"status must be approved, rejected or received",
+		).Res()
+	}
+
+	userId, _ := c.Locals("userId").(string)
+	returnData, err := h.returnsUsecase.TransitionReturn(returnId, req, userId)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			errStatus(err),
+			string(transitionReturnErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, returnData).Res()
+}
+
+// RefundReturn admin refund received return, amount of the return when amount is empty
+func (h *returnsHandler) RefundReturn(c *fiber.Ctx) error {
+	returnId := strings.TrimSpace(c.Params("return_id"))
+	req := new(returns.RefundReq)
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(refundReturnErr),
+			err.Error(),
+		).Res()
+	}
+	req.Amount = strings.TrimSpace(req.Amount)
+	req.Note = strings.TrimSpace(req.Note)
+
+	userId, _ := c.Locals("userId").(string)
+	returnData, err := h.returnsUsecase.RefundReturn(returnId, req, userId)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			errStatus(err),
+			string(refundReturnErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, returnData).Res()
+}
@@ -0,0 +1,460 @@
+package returnsRepositories
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/DrumPatiphon/go-rest-api-service/modules/files"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/returns"
+	"github.com/jmoiron/sqlx"
+)
+
+type IReturnsRepository interface {
+	InsertReturn(orderId, userId string, req *returns.InsertReturnReq) (string, error)
+	FindOneReturn(returnId string) (*returns.Return, error)
+	FindReturns(req *returns.ReturnFilter) ([]*returns.Return, int, error)
+	InsertImages(returnId string, images []*files.FileRes) error
+	TransitionReturn(returnId string, req *returns.TransitionReq, userId string) error
+	ClaimRefund(returnId string) error
+	ReleaseRefund(returnId string) error
+	MarkRefunded(returnId string, amount int64, refundId, note, userId string) error
+}
+
+type returnsRepository struct {
+	db *sqlx.DB
+}
+
+func ReturnsRepository(db *sqlx.DB) IReturnsRepository {
+	return &returnsRepository{
+		db: db,
+	}
+}
+
+// returnColumns is columns of return in json, amount of items is in currency of the order
+const returnColumns = `
+			"r"."id",
+			"r"."order_id",
+			"r"."user_id",
+			"r"."status",
+			"r"."reason",
+			"r"."note",
+			"r"."admin_note",
+			"o"."currency",
+			jsonb_build_object('currency', "o"."currency", 'minor_units', (
+				SELECT COALESCE(SUM("ri"."amount"), 0) FROM "returns_items" "ri" WHERE "ri"."return_id" = "r"."id"
+			)) AS "amount",
+			jsonb_build_object('currency', "o"."currency", 'minor_units', "r"."refund_amount") AS "refund_amount",
+			COALESCE("r"."refund_id", '') AS "refund_id",
+			(
+				SELECT
+					COALESCE(array_to_json(array_agg("it")), '[]'::json)
+				FROM (
+					SELECT
+						"ri"."id",
+						"ri"."order_item_id",
+						"po"."product",
+						"ri"."qty" AS "quantity",
+						jsonb_build_object('currency', "o"."currency", 'minor_units', "ri"."amount") AS "amount"
+					FROM "returns_items" "ri"
+						JOIN "products_orders" "po" ON "po"."id" = "ri"."order_item_id"
+					WHERE "ri"."return_id" = "r"."id"
+					ORDER BY "po"."id"
+				) AS "it"
+			) AS "items",
+			(
+				SELECT
+					COALESCE(array_to_json(array_agg("imt")), '[]'::json)
+				FROM (
+					SELECT
+						"i"."id",
+						"i"."filename",
+						"i"."url",
+						"i"."created_at"
+					FROM "returns_images" "i"
+					WHERE "i"."return_id" = "r"."id"
+					ORDER BY "i"."created_at", "i"."id"
+				) AS "imt"
+			) AS "images",
+			"r"."created_at",
+			"r"."updated_at"`
+
+// insertOrderNote record a step of return in history of the order, status of the order is not changed
+func insertOrderNote(ctx context.Context, tx *sqlx.Tx, orderId, note, userId string) error {
+	query := `
+	INSERT INTO "orders_status_history" (
+		"order_id",
+		"from_status",
+		"to_status",
+		"note",
+		"user_id"
+	)
+	SELECT "id", "status", "status", $2, NULLIF($3, '')
+	FROM "orders"
+	WHERE "id" = $1;`
+
+	if _, err := tx.ExecContext(ctx, query, orderId, note, userId); err != nil {
+		return fmt.Errorf("insert order history failed: %v", err)
+	}
+	return nil
+}
+
+// InsertReturn lock the order so quantity of lines can't be returned twice by requests at the same time.
+// Quantity of rejected returns can be requested again
+func (r *returnsRepository) InsertReturn(orderId, userId string, req *returns.InsertReturnReq) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*15)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return "", err
+	}
+
+	var status string
+	if err := tx.QueryRowxContext(ctx, `SELECT "status" FROM "orders" WHERE "id" = $1 AND "user_id" = $2 FOR UPDATE;`, orderId, userId).Scan(&status); err != nil {
+		tx.Rollback()
+		if err == sql.ErrNoRows {
+			return "", fmt.Errorf("order not found")
+		}
+		return "", fmt.Errorf("get order failed: %v", err)
+	}
+	if status != "delivered" {
+		tx.Rollback()
+		return "", fmt.Errorf("order is not delivered")
+	}
+
+	remainingQuery := `
+	SELECT
+		"po"."qty" - COALESCE((
+			SELECT SUM("ri"."qty")
+			FROM "returns_items" "ri"
+				JOIN "returns" "r" ON "r"."id" = "ri"."return_id"
+			WHERE "ri"."order_item_id" = "po"."id"
+			AND "r"."status" <> 'rejected'
+		), 0)
+	FROM "products_orders" "po"
+	WHERE "po"."id"::TEXT = $1
+	AND "po"."order_id" = $2;`
+
+	for _, item := range req.Items {
+		var remaining int
+		if err := tx.GetContext(ctx, &remaining, remainingQuery, item.OrderItemId, orderId); err != nil {
+			tx.Rollback()
+			if err == sql.ErrNoRows {
+				return "", fmt.Errorf("item not found")
+			}
+			return "", fmt.Errorf("get item failed: %v", err)
+		}
+		if item.Quantity > remaining {
+			tx.Rollback()
+			return "", fmt.Errorf("quantity is more than can be returned")
+		}
+	}
+
+	var returnId string
+	if err := tx.QueryRowxContext(ctx, `
+	INSERT INTO "returns" (
+		"order_id",
+		"user_id",
+		"reason",
+		"note"
+	)
+	VALUES ($1, $2, $3, $4)
+		RETURNING "id";`, orderId, userId, req.Reason, req.Note).Scan(&returnId); err != nil {
+		tx.Rollback()
+		return "", fmt.Errorf("insert return failed: %v", err)
+	}
+
+	// amount is paid for the line share by quantity, tax is added when it was not in prices
+	itemQuery := `
+	INSERT INTO "returns_items" (
+		"return_id",
+		"order_item_id",
+		"qty",
+		"amount"
+	)
+	SELECT
+		$1,
+		"po"."id",
+		$3,
+		("po"."unit_amount" * "po"."qty" - "po"."discount_amount" + CASE WHEN "o"."tax_inclusive" THEN 0 ELSE "po"."tax_amount" END) * $3 / "po"."qty"
+	FROM "products_orders" "po"
+		JOIN "orders" "o" ON "o"."id" = "po"."order_id"
+	WHERE "po"."id"::TEXT = $2;`
+
+	for _, item := range req.Items {
+		if _, err := tx.ExecContext(ctx, itemQuery, returnId, item.OrderItemId, item.Quantity); err != nil {
+			tx.Rollback()
+			return "", fmt.Errorf("insert return item failed: %v", err)
+		}
+	}
+
+	if err := insertOrderNote(ctx, tx, orderId, fmt.Sprintf("return %s requested: %s", returnId, req.Reason), userId); err != nil {
+		tx.Rollback()
+		return "", err
+	}
+
+	if err := tx.Commit(); err != nil {
+		tx.Rollback()
+		return "", err
+	}
+	return returnId, nil
+}
+
+func (r *returnsRepository) FindOneReturn(returnId string) (*returns.Return, error) {
+	query := `
+	SELECT
+		to_jsonb("t")
+	FROM (
+		SELECT` + returnColumns + `
+		FROM "returns" "r"
+			JOIN "orders" "o" ON "o"."id" = "r"."order_id"
+		WHERE "r"."id"::TEXT = $1
+	) AS "t";`
+
+	returnBytes := make([]byte, 0)
+	returnData := new(returns.Return)
+
+	if err := r.db.Get(&returnBytes, query, returnId); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("return not found")
+		}
+		return nil, fmt.Errorf("get return failed: %v", err)
+	}
+	if err := json.Unmarshal(returnBytes, returnData); err != nil {
+		return nil, fmt.Errorf("unmarshal return failed: %v", err)
+	}
+	return returnData, nil
+}
+
+func (r *returnsRepository) FindReturns(req *returns.ReturnFilter) ([]*returns.Return, int, error) {
+	queryWhere := `
+		WHERE 1 = 1`
+	values := make([]any, 0)
+
+	if req.UserId != "" {
+		values = append(values, req.UserId)
+		queryWhere += `
+		AND "r"."user_id" = $` + strconv.Itoa(len(values))
+	}
+	if req.OrderId != "" {
+		values = append(values, req.OrderId)
+		queryWhere += `
+		AND "r"."order_id" = $` + strconv.Itoa(len(values))
+	}
+	if req.Status != "" {
+		values = append(values, req.Status)
+		queryWhere += `
+		AND "r"."status" = $` + strconv.Itoa(len(values))
+	}
+
+	countQuery := `
+	SELECT
+		COUNT(*)
+	FROM "returns" "r"` + queryWhere
+
+	var count int
+	if err := r.db.Get(&count, countQuery, values...); err != nil {
+		return nil, 0, fmt.Errorf("count returns failed: %v", err)
+	}
+
+	values = append(values, (req.Page-1)*req.Limit, req.Limit)
+	query := fmt.Sprintf(`
+	SELECT
+		COALESCE(array_to_json(array_agg("t")), '[]'::json)
+	FROM (
+		SELECT%s
+		FROM "returns" "r"
+			JOIN "orders" "o" ON "o"."id" = "r"."order_id"%s
+		ORDER BY "r"."created_at" DESC, "r"."id" DESC
+		OFFSET $%d LIMIT $%d
+	) AS "t";`, returnColumns, queryWhere, len(values)-1, len(values))
+
+	returnsBytes := make([]byte, 0)
+	returnsData := make([]*returns.Return, 0)
+
+	if err := r.db.Get(&returnsBytes, query, values...); err != nil {
+		return nil, 0, fmt.Errorf("find returns failed: %v", err)
+	}
+	if err := json.Unmarshal(returnsBytes, &returnsData); err != nil {
+		return nil, 0, fmt.Errorf("unmarshal returns failed: %v", err)
+	}
+	return returnsData, count, nil
+}
+
+func (r *returnsRepository) InsertImages(returnId string, images []*files.FileRes) error {
+	query := `
+	INSERT INTO "returns_images" (
+		"return_id",
+		"filename",
+		"url"
+	)
+	SELECT $1::uuid, UNNEST($2::VARCHAR[]), UNNEST($3::VARCHAR[]);`
+
+	filenames := make([]string, 0)
+	urls := make([]string, 0)
+	for _, image := range images {
+		filenames = append(filenames, image.FileName)
+		urls = append(urls, image.Url)
+	}
+	if _, err := r.db.ExecContext(context.Background(), query, returnId, filenames, urls); err != nil {
+		return fmt.Errorf("insert return images failed: %v", err)
+	}
+	return nil
+}
+
+// TransitionReturn lock the return like orders, received items go back to stock of tracked products
+func (r *returnsRepository) TransitionReturn(returnId string, req *returns.TransitionReq, userId string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*15)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	var from, orderId string
+	if err := tx.QueryRowxContext(ctx, `SELECT "status", "order_id" FROM "returns" WHERE "id"::TEXT = $1 FOR UPDATE;`, returnId).Scan(&from, &orderId); err != nil {
+		tx.Rollback()
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("return not found")
+		}
+		return fmt.Errorf("get return failed: %v", err)
+	}
+	if !returns.CanTransition(from, req.Status) {
+		tx.Rollback()
+		return fmt.Errorf("return can't be changed from %s to %s", from, req.Status)
+	}
+
+	query := `
+	UPDATE "returns" SET
+		"status" = $2,
+		"admin_note" = CASE WHEN $3 <> '' THEN $3 ELSE "admin_note" END
+	WHERE "id"::TEXT = $1;`
+
+	if _, err := tx.ExecContext(ctx, query, returnId, req.Status, req.Note); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("update return status failed: %v", err)
+	}
+
+	if req.Status == "received" {
+		if err := restock(ctx, tx, returnId); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	note := fmt.Sprintf("return %s %s", returnId, req.Status)
+	if req.Note != "" {
+		note += ": " + req.Note
+	}
+	if err := insertOrderNote(ctx, tx, orderId, note, userId); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return nil
+}
+
+// restock add quantity of returned items to tracked products and bump their version, lines of the same product are summed first
+func restock(ctx context.Context, tx *sqlx.Tx, returnId string) error {
+	query := `
+	UPDATE "products" "p" SET
+		"stock" = "p"."stock" + "rs"."qty",
+		"version" = "p"."version" + 1
+	FROM (
+		SELECT
+			"po"."product_id",
+			SUM("ri"."qty") AS "qty"
+		FROM "returns_items" "ri"
+			JOIN "products_orders" "po" ON "po"."id" = "ri"."order_item_id"
+		WHERE "ri"."return_id"::TEXT = $1
+		GROUP BY "po"."product_id"
+	) AS "rs"
+	WHERE "p"."id" = "rs"."product_id"
+	AND "p"."stock" IS NOT NULL;`
+
+	if _, err := tx.ExecContext(ctx, query, returnId); err != nil {
+		return fmt.Errorf("restock failed: %v", err)
+	}
+	return nil
+}
+
+// ClaimRefund move received return to refunding, so only one refund of the return is sent to provider
+func (r *returnsRepository) ClaimRefund(returnId string) error {
+	query := `
+	UPDATE "returns" SET
+		"status" = 'refunding'
+	WHERE "id"::TEXT = $1
+	AND "status" = 'received';`
+
+	result, err := r.db.ExecContext(context.Background(), query, returnId)
+	if err != nil {
+		return fmt.Errorf("claim return refund failed: %v", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("return can't be refunded")
+	}
+	return nil
+}
+
+// ReleaseRefund move return back to received when its refund is not sent
+func (r *returnsRepository) ReleaseRefund(returnId string) error {
+	query := `
+	UPDATE "returns" SET
+		"status" = 'received'
+	WHERE "id"::TEXT = $1
+	AND "status" = 'refunding';`
+
+	if _, err := r.db.ExecContext(context.Background(), query, returnId); err != nil {
+		return fmt.Errorf("release return refund failed: %v", err)
+	}
+	return nil
+}
+
+// MarkRefunded save refund of provider, only a return claimed by ClaimRefund can be refunded
+func (r *returnsRepository) MarkRefunded(returnId string, amount int64, refundId, note, userId string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*15)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	query := `
+	UPDATE "returns" SET
+		"status" = 'refunded',
+		"refund_amount" = $2,
+		"refund_id" = $3
+	WHERE "id"::TEXT = $1
+	AND "status" = 'refunding'
+		RETURNING "order_id";`
+
+	var orderId string
+	if err := tx.QueryRowxContext(ctx, query, returnId, amount, refundId).Scan(&orderId); err != nil {
+		tx.Rollback()
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("return can't be refunded")
+		}
+		return fmt.Errorf("update return refund failed: %v", err)
+	}
+
+	if err := insertOrderNote(ctx, tx, orderId, note, userId); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return nil
+}
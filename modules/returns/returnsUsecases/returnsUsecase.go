@@ -0,0 +1,141 @@
+package returnsUsecases
+
+import (
+	"fmt"
+	"log"
+	"math"
+
+	"github.com/DrumPatiphon/go-rest-api-service/modules/entities"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/files"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/files/filesUsecases"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/payments/paymentsUsecases"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/returns"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/returns/returnsRepositories"
+	"github.com/DrumPatiphon/go-rest-api-service/pkg/money"
+)
+
+type IReturnsUsecase interface {
+	InsertReturn(orderId, userId string, req *returns.InsertReturnReq) (*returns.Return, error)
+	FindOneReturn(returnId string) (*returns.Return, error)
+	FindReturns(req *returns.ReturnFilter) (*entities.PageRes, error)
+	InsertImages(returnId string, req []*files.FileReq) (*returns.Return, error)
+	TransitionReturn(returnId string, req *returns.TransitionReq, userId string) (*returns.Return, error)
+	RefundReturn(returnId string, req *returns.RefundReq, userId string) (*returns.Return, error)
+}
+
+type returnsUsecase struct {
+	returnsRepository returnsRepositories.IReturnsRepository
+	filesUsecase      filesUsecases.IFilesUsecases
+	paymentsUsecase   paymentsUsecases.IPaymentsUsecase
+}
+
+func ReturnsUsecase(returnsRepository returnsRepositories.IReturnsRepository, filesUsecase filesUsecases.IFilesUsecases, paymentsUsecase paymentsUsecases.IPaymentsUsecase) IReturnsUsecase {
+	return &returnsUsecase{
+		returnsRepository: returnsRepository,
+		filesUsecase:      filesUsecase,
+		paymentsUsecase:   paymentsUsecase,
+	}
+}
+
+func (u *returnsUsecase) InsertReturn(orderId, userId string, req *returns.InsertReturnReq) (*returns.Return, error) {
+	returnId, err := u.returnsRepository.InsertReturn(orderId, userId, req)
+	if err != nil {
+		return nil, err
+	}
+	return u.FindOneReturn(returnId)
+}
+
+func (u *returnsUsecase) FindOneReturn(returnId string) (*returns.Return, error) {
+	return u.returnsRepository.FindOneReturn(returnId)
+}
+
+func (u *returnsUsecase) FindReturns(req *returns.ReturnFilter) (*entities.PageRes, error) {
+	returnsData, count, err := u.returnsRepository.FindReturns(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return &entities.PageRes{
+		Data:       returnsData,
+		Page:       req.Page,
+		Limit:      req.Limit,
+		TotalItems: count,
+		TotalPage:  int(math.Ceil(float64(count) / float64(req.Limit))),
+	}, nil
+}
+
+// InsertImages upload photos by files module, they can only be added before the return is reviewed
+func (u *returnsUsecase) InsertImages(returnId string, req []*files.FileReq) (*returns.Return, error) {
+	returnData, err := u.FindOneReturn(returnId)
+	if err != nil {
+		return nil, err
+	}
+	if returnData.Status != "requested" {
+		return nil, fmt.Errorf("photos can only be added to requested return")
+	}
+	if len(returnData.Images)+len(req) > returns.MaxImages {
+		return nil, fmt.Errorf("return can have at most %d photos", returns.MaxImages)
+	}
+
+	images, err := u.filesUsecase.UploadToGCP(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := u.returnsRepository.InsertImages(returnId, images); err != nil {
+		return nil, err
+	}
+	return u.FindOneReturn(returnId)
+}
+
+func (u *returnsUsecase) TransitionReturn(returnId string, req *returns.TransitionReq, userId string) (*returns.Return, error) {
+	if err := u.returnsRepository.TransitionReturn(returnId, req, userId); err != nil {
+		return nil, err
+	}
+	return u.FindOneReturn(returnId)
+}
+
+// RefundReturn refund amount of the return by payment provider, or less like a restocking fee.
+// The return is claimed first so the money is sent once, then it is saved as refunded.
+// A failed save return the refund id for admin to check
+func (u *returnsUsecase) RefundReturn(returnId string, req *returns.RefundReq, userId string) (*returns.Return, error) {
+	returnData, err := u.FindOneReturn(returnId)
+	if err != nil {
+		return nil, err
+	}
+	if returnData.Status != "received" {
+		return nil, fmt.Errorf("return can't be refunded")
+	}
+
+	amount := returnData.Amount
+	if req.Amount != "" {
+		amount, err = money.Parse(req.Amount, returnData.Currency)
+		if err != nil {
+			return nil, fmt.Errorf("refund amount is invalid: %v", err)
+		}
+	}
+	if amount.Amount <= 0 {
+		return nil, fmt.Errorf("refund amount must be more than 0")
+	}
+	if amount.Amount > returnData.Amount.Amount {
+		return nil, fmt.Errorf("refund amount is more than the return")
+	}
+
+	note := fmt.Sprintf("return %s refunded %s %s", returnId, amount.Number(), amount.Currency)
+	if req.Note != "" {
+		note += ": " + req.Note
+	}
+	if err := u.returnsRepository.ClaimRefund(returnId); err != nil {
+		return nil, err
+	}
+	refund, err := u.paymentsUsecase.Refund(returnData.OrderId, amount, note, userId)
+	if err != nil {
+		if err := u.returnsRepository.ReleaseRefund(returnId); err != nil {
+			log.Printf("release refund of return %s failed: %v", returnId, err)
+		}
+		return nil, err
+	}
+	if err := u.returnsRepository.MarkRefunded(returnId, amount.Amount, refund.Id, note, userId); err != nil {
+		return nil, fmt.Errorf("refund %s is sent but not saved: %v", refund.Id, err)
+	}
+	return u.FindOneReturn(returnId)
+}
@@ -0,0 +1,61 @@
+package returns
+
+import "testing"
+
+func TestIsStatus(t *testing.T) {
+	tests := []struct {
+		status string
+		want   bool
+	}{
+		{status: "requested", want: true},
+		{status: "approved", want: true},
+		{status: "rejected", want: true},
+		{status: "received", want: true},
+		{status: "refunding", want: true},
+		{status: "refunded", want: true},
+		{status: "", want: false},
+		{status: "cancelled", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.status, func(t *testing.T) {
+			if got := IsStatus(tt.status); got != tt.want {
+				t.Errorf("IsStatus(%q) = %v, want %v", tt.status, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCanTransition(t *testing.T) {
+	tests := []struct {
+		from string
+		to   string
+		want bool
+	}{
+		{from: "requested", to: "approved", want: true},
+		{from: "requested", to: "rejected", want: true},
+		{from: "requested", to: "received", want: false},
+		{from: "requested", to: "refunded", want: false},
+		{from: "approved", to: "received", want: true},
+		{from: "approved", to: "rejected", want: false},
+		{from: "approved", to: "refunded", want: false},
+		{from: "received", to: "refunding", want: true},
+		{from: "received", to: "refunded", want: false},
+		{from: "received", to: "approved", want: false},
+		{from: "refunding", to: "refunded", want: true},
+		{from: "refunding", to: "received", want: true},
+		{from: "refunding", to: "approved", want: false},
+		{from: "rejected", to: "approved", want: false},
+		{from: "refunded", to: "received", want: false},
+		{from: "requested", to: "requested", want: false},
+		{from: "unknown", to: "approved", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.from+"_"+tt.to, func(t *testing.T) {
+			if got := CanTransition(tt.from, tt.to); got != tt.want {
+				t.Errorf("CanTransition(%q, %q) = %v, want %v", tt.from, tt.to, got, tt.want)
+			}
+		})
+	}
+}
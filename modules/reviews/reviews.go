@@ -0,0 +1,38 @@
+package reviews
+
+import "github.com/DrumPatiphon/go-rest-api-service/modules/entities"
+
+type Review struct {
+	Id        string `db:"id" json:"id"`
+	ProductId string `db:"product_id" json:"product_id"`
+	UserId    string `db:"user_id" json:"user_id"`
+	Username  string `db:"username" json:"username"`
+	Rating    int    `db:"rating" json:"rating"`
+	Comment   string `db:"comment" json:"comment"`
+	Status    string `db:"status" json:"status"` // pending, approved, hidden
+	Reply     string `db:"reply" json:"reply"`
+	RepliedAt string `db:"replied_at" json:"replied_at"`
+	CreatedAt string `db:"created_at" json:"created_at"`
+	UpdatedAt string `db:"updated_at" json:"updated_at"`
+}
+
+type ReviewReq struct {
+	ProductId string `json:"-"`
+	UserId    string `json:"-"`
+	Rating    int    `json:"rating" form:"rating"`
+	Comment   string `json:"comment" form:"comment"`
+}
+
+type ReviewReplyReq struct {
+	Reply string `json:"reply" form:"reply"`
+}
+
+type ReviewFilter struct {
+	ProductId               string `query:"product_id"`
+	Status                  string `query:"status"`
+	*entities.PaginationReq        // like inherit class
+}
+
+func (obj *ReviewReq) IsRating() bool {
+	return obj.Rating >= 1 && obj.Rating <= 5
+}
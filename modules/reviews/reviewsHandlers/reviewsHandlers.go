@@ -0,0 +1,223 @@
+package reviewsHandlers
+
+import (
+	"strings"
+
+	"github.com/DrumPatiphon/go-rest-api-service/config"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/entities"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/reviews"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/reviews/reviewsUsecases"
+	"github.com/gofiber/fiber/v2"
+)
+
+type reviewsHandlerErrCode string
+
+const (
+	insertReviewErr      reviewsHandlerErrCode = "reviews-001"
+	findProductReviewErr reviewsHandlerErrCode = "reviews-002"
+	findReviewErr        reviewsHandlerErrCode = "reviews-003"
+	approveReviewErr     reviewsHandlerErrCode = "reviews-004"
+	hideReviewErr        reviewsHandlerErrCode = "reviews-005"
+	replyReviewErr       reviewsHandlerErrCode = "reviews-006"
+)
+
+type IReviewsHandler interface {
+	InsertReview(c *fiber.Ctx) error
+	FindProductReviews(c *fiber.Ctx) error
+	FindReviews(c *fiber.Ctx) error
+	ApproveReview(c *fiber.Ctx) error
+	HideReview(c *fiber.Ctx) error
+	ReplyReview(c *fiber.Ctx) error
+}
+
+type reviewsHandler struct {
+	cfg            config.Iconfig
+	reviewsUsecase reviewsUsecases.IReviewsUsecase
+}
+
+func ReviewsHandler(cfg config.Iconfig, reviewsUsecase reviewsUsecases.IReviewsUsecase) IReviewsHandler {
+	return &reviewsHandler{
+		cfg:            cfg,
+		reviewsUsecase: reviewsUsecase,
+	}
+}
+
+func (h *reviewsHandler) InsertReview(c *fiber.Ctx) error {
+	req := new(reviews.ReviewReq)
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(insertReviewErr),
+			err.Error(),
+		).Res()
+	}
+	if !req.IsRating() {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(insertReviewErr),
+			"rating must be 1 to 5",
+		).Res()
+	}
+	req.Comment = strings.TrimSpace(req.Comment)
+	req.ProductId = strings.Trim(c.Params("product_id"), " ")
+	req.UserId, _ = c.Locals("userId").(string)
+
+	review, err := h.reviewsUsecase.InsertReview(req)
+	if err != nil {
+		switch err.Error() {
+		case "review has been posted", "product not found":
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(insertReviewErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(insertReviewErr),
+				err.Error(),
+			).Res()
+		}
+	}
+	return entities.NewResponse(c).Success(fiber.StatusCreated, review).Res()
+}
+
+// FindProductReviews is public, only approved reviews are shown
+func (h *reviewsHandler) FindProductReviews(c *fiber.Ctx) error {
+	req := &reviews.ReviewFilter{
+		PaginationReq: &entities.PaginationReq{},
+	}
+	if err := c.QueryParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(findProductReviewErr),
+			err.Error(),
+		).Res()
+	}
+	req.ProductId = strings.Trim(c.Params("product_id"), " ")
+	req.Status = "approved"
+	setDefaultFilter(req)
+
+	reviewsData, err := h.reviewsUsecase.FindReviews(req)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(findProductReviewErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, reviewsData).Res()
+}
+
+func (h *reviewsHandler) FindReviews(c *fiber.Ctx) error {
+	req := &reviews.ReviewFilter{
+		PaginationReq: &entities.PaginationReq{},
+	}
+	if err := c.QueryParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(findReviewErr),
+			err.Error(),
+		).Res()
+	}
+	switch req.Status {
+	case "", "pending", "approved", "hidden":
+	default:
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(findReviewErr),
+			"status must be pending, approved or hidden",
+		).Res()
+	}
+	setDefaultFilter(req)
+
+	reviewsData, err := h.reviewsUsecase.FindReviews(req)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(findReviewErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, reviewsData).Res()
+}
+
+func setDefaultFilter(req *reviews.ReviewFilter) {
+	if req.Page < 1 {
+		req.Page = 1
+	}
+	if req.Limit < 5 {
+		req.Limit = 5
+	}
+}
+
+func (h *reviewsHandler) ApproveReview(c *fiber.Ctx) error {
+	return h.updateReviewStatus(c, "approved", approveReviewErr)
+}
+
+func (h *reviewsHandler) HideReview(c *fiber.Ctx) error {
+	return h.updateReviewStatus(c, "hidden", hideReviewErr)
+}
+
+func (h *reviewsHandler) updateReviewStatus(c *fiber.Ctx, status string, errCode reviewsHandlerErrCode) error {
+	reviewId := strings.Trim(c.Params("review_id"), " ")
+
+	review, err := h.reviewsUsecase.UpdateReviewStatus(reviewId, status)
+	if err != nil {
+		switch err.Error() {
+		case "review not found":
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(errCode),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(errCode),
+				err.Error(),
+			).Res()
+		}
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, review).Res()
+}
+
+func (h *reviewsHandler) ReplyReview(c *fiber.Ctx) error {
+	reviewId := strings.Trim(c.Params("review_id"), " ")
+
+	req := new(reviews.ReviewReplyReq)
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(replyReviewErr),
+			err.Error(),
+		).Res()
+	}
+	req.Reply = strings.TrimSpace(req.Reply)
+	if req.Reply == "" {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(replyReviewErr),
+			"reply is required",
+		).Res()
+	}
+
+	review, err := h.reviewsUsecase.ReplyReview(reviewId, req)
+	if err != nil {
+		switch err.Error() {
+		case "review not found":
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(replyReviewErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(replyReviewErr),
+				err.Error(),
+			).Res()
+		}
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, review).Res()
+}
@@ -0,0 +1,190 @@
+package reviewsRepositories
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/DrumPatiphon/go-rest-api-service/modules/reviews"
+	"github.com/jmoiron/sqlx"
+)
+
+type IReviewsRepository interface {
+	InsertReview(req *reviews.ReviewReq) (*reviews.Review, error)
+	FindOneReview(reviewId string) (*reviews.Review, error)
+	FindReviews(req *reviews.ReviewFilter) ([]*reviews.Review, int, error)
+	UpdateReviewStatus(reviewId, status string) error
+	ReplyReview(reviewId, reply string) error
+}
+
+type reviewsRepository struct {
+	db *sqlx.DB
+}
+
+func ReviewsRepository(db *sqlx.DB) IReviewsRepository {
+	return &reviewsRepository{
+		db: db,
+	}
+}
+
+func (r *reviewsRepository) InsertReview(req *reviews.ReviewReq) (*reviews.Review, error) {
+	query := `
+	INSERT INTO "reviews" (
+		"product_id",
+		"user_id",
+		"rating",
+		"comment"
+	)
+	VALUES ($1, $2, $3, $4)
+		RETURNING "id";`
+
+	var reviewId string
+	if err := r.db.QueryRowContext(
+		context.Background(),
+		query,
+		req.ProductId,
+		req.UserId,
+		req.Rating,
+		req.Comment,
+	).Scan(&reviewId); err != nil {
+		switch {
+		case strings.Contains(err.Error(), "reviews_product_id_user_id_key"):
+			return nil, fmt.Errorf("review has been posted")
+		case strings.Contains(err.Error(), "reviews_product_id_fkey"):
+			return nil, fmt.Errorf("product not found")
+		default:
+			return nil, fmt.Errorf("insert review failed: %v", err)
+		}
+	}
+	return r.FindOneReview(reviewId)
+}
+
+func (r *reviewsRepository) FindOneReview(reviewId string) (*reviews.Review, error) {
+	query := `
+	SELECT
+		to_jsonb("t")
+	FROM (
+		SELECT
+			"r"."id",
+			"r"."product_id",
+			"r"."user_id",
+			"u"."username",
+			"r"."rating",
+			"r"."comment",
+			"r"."status",
+			COALESCE("r"."reply", '') AS "reply",
+			COALESCE("r"."replied_at"::TEXT, '') AS "replied_at",
+			"r"."created_at",
+			"r"."updated_at"
+		FROM "reviews" "r"
+			JOIN "users" "u" ON "u"."id" = "r"."user_id"
+		WHERE "r"."id"::TEXT = $1
+	) AS "t";`
+
+	reviewBytes := make([]byte, 0)
+	review := new(reviews.Review)
+
+	if err := r.db.Get(&reviewBytes, query, reviewId); err != nil {
+		return nil, fmt.Errorf("review not found")
+	}
+	if err := json.Unmarshal(reviewBytes, review); err != nil {
+		return nil, fmt.Errorf("unmarshal review failed: %v", err)
+	}
+	return review, nil
+}
+
+func (r *reviewsRepository) FindReviews(req *reviews.ReviewFilter) ([]*reviews.Review, int, error) {
+	queryWhere := `
+		WHERE 1 = 1`
+	values := make([]any, 0)
+
+	if req.ProductId != "" {
+		values = append(values, req.ProductId)
+		queryWhere += `
+		AND "r"."product_id" = $` + strconv.Itoa(len(values))
+	}
+	if req.Status != "" {
+		values = append(values, req.Status)
+		queryWhere += `
+		AND "r"."status"::TEXT = $` + strconv.Itoa(len(values))
+	}
+
+	countQuery := `
+	SELECT
+		COUNT(*)
+	FROM "reviews" "r"` + queryWhere
+
+	var count int
+	if err := r.db.Get(&count, countQuery, values...); err != nil {
+		return nil, 0, fmt.Errorf("count reviews failed: %v", err)
+	}
+
+	values = append(values, (req.Page-1)*req.Limit, req.Limit)
+	query := fmt.Sprintf(`
+	SELECT
+		COALESCE(array_to_json(array_agg("t")), '[]'::json)
+	FROM (
+		SELECT
+			"r"."id",
+			"r"."product_id",
+			"r"."user_id",
+			"u"."username",
+			"r"."rating",
+			"r"."comment",
+			"r"."status",
+			COALESCE("r"."reply", '') AS "reply",
+			COALESCE("r"."replied_at"::TEXT, '') AS "replied_at",
+			"r"."created_at",
+			"r"."updated_at"
+		FROM "reviews" "r"
+			JOIN "users" "u" ON "u"."id" = "r"."user_id"%s
+		ORDER BY "r"."created_at" DESC
+		OFFSET $%d LIMIT $%d
+	) AS "t";`, queryWhere, len(values)-1, len(values))
+
+	reviewsBytes := make([]byte, 0)
+	reviewsData := make([]*reviews.Review, 0)
+
+	if err := r.db.Get(&reviewsBytes, query, values...); err != nil {
+		return nil, 0, fmt.Errorf("find reviews failed: %v", err)
+	}
+	if err := json.Unmarshal(reviewsBytes, &reviewsData); err != nil {
+		return nil, 0, fmt.Errorf("unmarshal reviews failed: %v", err)
+	}
+	return reviewsData, count, nil
+}
+
+func (r *reviewsRepository) UpdateReviewStatus(reviewId, status string) error {
+	query := `
+	UPDATE "reviews" SET
+		"status" = $1
+	WHERE "id"::TEXT = $2;`
+
+	result, err := r.db.ExecContext(context.Background(), query, status, reviewId)
+	if err != nil {
+		return fmt.Errorf("update review status failed: %v", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("review not found")
+	}
+	return nil
+}
+
+func (r *reviewsRepository) ReplyReview(reviewId, reply string) error {
+	query := `
+	UPDATE "reviews" SET
+		"reply" = $1,
+		"replied_at" = now()
+	WHERE "id"::TEXT = $2;`
+
+	result, err := r.db.ExecContext(context.Background(), query, reply, reviewId)
+	if err != nil {
+		return fmt.Errorf("reply review failed: %v", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("review not found")
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package reviewsUsecases
+
+import (
+	"math"
+
+	"github.com/DrumPatiphon/go-rest-api-service/modules/entities"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/reviews"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/reviews/reviewsRepositories"
+)
+
+type IReviewsUsecase interface {
+	InsertReview(req *reviews.ReviewReq) (*reviews.Review, error)
+	FindReviews(req *reviews.ReviewFilter) (*entities.PageRes, error)
+	UpdateReviewStatus(reviewId, status string) (*reviews.Review, error)
+	ReplyReview(reviewId string, req *reviews.ReviewReplyReq) (*reviews.Review, error)
+}
+
+type reviewsUsecase struct {
+	reviewsRepository reviewsRepositories.IReviewsRepository
+}
+
+func ReviewsUsecase(reviewsRepository reviewsRepositories.IReviewsRepository) IReviewsUsecase {
+	return &reviewsUsecase{
+		reviewsRepository: reviewsRepository,
+	}
+}
+
+// InsertReview new review is pending until admin approve it
+func (u *reviewsUsecase) InsertReview(req *reviews.ReviewReq) (*reviews.Review, error) {
+	review, err := u.reviewsRepository.InsertReview(req)
+	if err != nil {
+		return nil, err
+	}
+	return review, nil
+}
+
+func (u *reviewsUsecase) FindReviews(req *reviews.ReviewFilter) (*entities.PageRes, error) {
+	reviewsData, count, err := u.reviewsRepository.FindReviews(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return &entities.PageRes{
+		Data:       reviewsData,
+		Page:       req.Page,
+		Limit:      req.Limit,
+		TotalItems: count,
+		TotalPage:  int(math.Ceil(float64(count) / float64(req.Limit))),
+	}, nil
+}
+
+func (u *reviewsUsecase) UpdateReviewStatus(reviewId, status string) (*reviews.Review, error) {
+	if err := u.reviewsRepository.UpdateReviewStatus(reviewId, status); err != nil {
+		return nil, err
+	}
+	return u.reviewsRepository.FindOneReview(reviewId)
+}
+
+func (u *reviewsUsecase) ReplyReview(reviewId string, req *reviews.ReviewReplyReq) (*reviews.Review, error) {
+	if err := u.reviewsRepository.ReplyReview(reviewId, req.Reply); err != nil {
+		return nil, err
+	}
+	return u.reviewsRepository.FindOneReview(reviewId)
+}
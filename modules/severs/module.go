@@ -1,21 +1,64 @@
 package severs
 
 import (
+	"log"
+	"time"
+
+	"github.com/DrumPatiphon/go-rest-api-service/modules/addresses/addressesHandlers"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/addresses/addressesRepositories"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/addresses/addressesUsecases"
 	appinfoHandlers "github.com/DrumPatiphon/go-rest-api-service/modules/appInfo/appInfoHandlers"
 	appinfoRepositories "github.com/DrumPatiphon/go-rest-api-service/modules/appInfo/appInfoRepositories"
 	appinfoUsecases "github.com/DrumPatiphon/go-rest-api-service/modules/appInfo/appInfoUsecases"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/carts/cartsHandlers"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/carts/cartsRepositories"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/carts/cartsUsecases"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/coupons/couponsHandlers"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/coupons/couponsRepositories"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/coupons/couponsUsecases"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/documents/documentsHandlers"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/documents/documentsRepositories"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/documents/documentsUsecases"
 	"github.com/DrumPatiphon/go-rest-api-service/modules/files/filesHandlers"
 	"github.com/DrumPatiphon/go-rest-api-service/modules/files/filesUsecases"
 	middlewareHandlers "github.com/DrumPatiphon/go-rest-api-service/modules/middleware/middlewareHandlers"
 	middlewareRepositories "github.com/DrumPatiphon/go-rest-api-service/modules/middleware/middlewareRepositories"
 	middlewareUsecases "github.com/DrumPatiphon/go-rest-api-service/modules/middleware/middlewareUsecases"
 	mornitorHandlers "github.com/DrumPatiphon/go-rest-api-service/modules/monitor/monitorHandlers"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/orders/ordersHandlers"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/orders/ordersRepositories"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/orders/ordersUsecases"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/payments/paymentsHandlers"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/payments/paymentsRepositories"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/payments/paymentsUsecases"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/priceLists/priceListsHandlers"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/priceLists/priceListsRepositories"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/priceLists/priceListsUsecases"
 	"github.com/DrumPatiphon/go-rest-api-service/modules/products/productsHandlers"
 	"github.com/DrumPatiphon/go-rest-api-service/modules/products/productsRepositories"
 	"github.com/DrumPatiphon/go-rest-api-service/modules/products/productsUsecases"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/returns/returnsHandlers"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/returns/returnsRepositories"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/returns/returnsUsecases"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/reviews/reviewsHandlers"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/reviews/reviewsRepositories"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/reviews/reviewsUsecases"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/shippingMethods/shippingMethodsHandlers"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/shippingMethods/shippingMethodsRepositories"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/shippingMethods/shippingMethodsUsecases"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/taxes/taxesHandlers"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/taxes/taxesRepositories"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/taxes/taxesUsecases"
 	"github.com/DrumPatiphon/go-rest-api-service/modules/users/usersHandlers"
 	"github.com/DrumPatiphon/go-rest-api-service/modules/users/usersRepositories"
 	"github.com/DrumPatiphon/go-rest-api-service/modules/users/usersUsecases"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/wishlists/wishlistsHandlers"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/wishlists/wishlistsRepositories"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/wishlists/wishlistsUsecases"
+	"github.com/DrumPatiphon/go-rest-api-service/pkg/jobs"
+	"github.com/DrumPatiphon/go-rest-api-service/pkg/notify"
+	"github.com/DrumPatiphon/go-rest-api-service/pkg/payment"
+	"github.com/DrumPatiphon/go-rest-api-service/pkg/shipping"
 	"github.com/gofiber/fiber/v2"
 )
 
@@ -25,6 +68,18 @@ type IModuleFactory interface {
 	AppInfoModule()
 	FilesModule()
 	ProductsModule()
+	ReviewsModule()
+	PriceListsModule()
+	CartsModule()
+	OrdersModule()
+	PaymentsModule()
+	CouponsModule()
+	TaxesModule()
+	ShippingMethodsModule()
+	AddressesModule()
+	WishlistsModule()
+	DocumentsModule()
+	ReturnsModule()
 }
 
 type moduleFactory struct {
@@ -55,8 +110,10 @@ func (module *moduleFactory) MonitorModule() {
 }
 
 func (module *moduleFactory) UserModule() {
+	cartsUsecase := module.cartsUsecase()
+
 	repository := usersRepositories.UserRepository(module.sever.db)
-	usecase := usersUsecases.UserUsecases(module.sever.cfg, repository)
+	usecase := usersUsecases.UserUsecases(module.sever.cfg, repository, cartsUsecase)
 	handler := usersHandlers.UserHandler(module.sever.cfg, usecase)
 
 	// /v1/users/sign
@@ -86,9 +143,16 @@ func (module *moduleFactory) AppInfoModule() {
 	router.Post("/categories", module.middleware.JwtAuth(), module.middleware.Autorize(2), handler.AddCategory)
 
 	router.Get("/categories", module.middleware.ApiKeyAuth(), handler.FindCategory)
+	router.Get("/categories/tree", module.middleware.ApiKeyAuth(), handler.FindCategoryTree)
 	router.Get("/apikey", module.middleware.JwtAuth(), module.middleware.Autorize(2), handler.GenerateApiKey)
 
+	router.Patch("/:category_id/categories", module.middleware.JwtAuth(), module.middleware.Autorize(2), handler.UpdateCategory)
+	router.Patch("/:category_id/categories/parent", module.middleware.JwtAuth(), module.middleware.Autorize(2), handler.MoveCategory)
+
+	router.Put("/:category_id/categories/translations/:locale", module.middleware.JwtAuth(), module.middleware.Autorize(2), handler.UpsertCategoryTranslation)
+
 	router.Delete("/:category_id/categories", module.middleware.JwtAuth(), module.middleware.Autorize(2), handler.RemoveCategory)
+	router.Delete("/:category_id/categories/translations/:locale", module.middleware.JwtAuth(), module.middleware.Autorize(2), handler.DeleteCategoryTranslation)
 }
 
 func (m *moduleFactory) FilesModule() {
@@ -105,16 +169,295 @@ func (m *moduleFactory) ProductsModule() {
 	fileUsecase := filesUsecases.FilesUsecase(m.sever.cfg)
 
 	productsRepository := productsRepositories.ProductRepository(m.sever.db, m.sever.cfg, fileUsecase)
-	productsUsecase := productsUsecases.ProductsUsecases(productsRepository)
+	productsUsecase := productsUsecases.ProductsUsecases(m.sever.cfg, productsRepository, fileUsecase)
 	productsHandler := productsHandlers.ProductsHandler(m.sever.cfg, productsUsecase, fileUsecase)
 
+	// Remove products in trash longer than retention
+	jobs.Every("purge products", time.Hour, productsUsecase.PurgeProducts)
+	// Publish scheduled products on their publish_at
+	jobs.Every("publish products", time.Minute, productsUsecase.PublishScheduledProducts)
+
 	router := m.router.Group("/products")
 
 	router.Post("/", m.middleware.JwtAuth(), m.middleware.Autorize(2), productsHandler.InsertProduct)
+	router.Post("/import", m.middleware.JwtAuth(), m.middleware.Autorize(2), productsHandler.ImportProducts)
 	router.Patch("/:product_id", m.middleware.JwtAuth(), m.middleware.Autorize(2), productsHandler.UpdateProduct)
+	router.Patch("/:product_id/restore", m.middleware.JwtAuth(), m.middleware.Autorize(2), productsHandler.RestoreProduct)
+	router.Patch("/:product_id/stock", m.middleware.JwtAuth(), m.middleware.Autorize(2), productsHandler.UpdateStock)
+	router.Post("/:product_id/revisions/:revision/rollback", m.middleware.JwtAuth(), m.middleware.Autorize(2), productsHandler.RollbackProduct)
+	router.Post("/:product_id/special-prices", m.middleware.JwtAuth(), m.middleware.Autorize(2), productsHandler.InsertSpecialPrice)
+	router.Post("/:product_id/images", m.middleware.JwtAuth(), m.middleware.Autorize(2), productsHandler.InsertImage)
+	router.Patch("/:product_id/images/order", m.middleware.JwtAuth(), m.middleware.Autorize(2), productsHandler.ReorderImages)
+	router.Patch("/:product_id/images/:image_id", m.middleware.JwtAuth(), m.middleware.Autorize(2), productsHandler.UpdateImage)
+
+	router.Get("/", m.middleware.ApiKeyAuth(), m.middleware.OptionalJwtAuth(), productsHandler.FindProduct)
+	router.Get("/admin", m.middleware.JwtAuth(), m.middleware.Autorize(2), productsHandler.FindAdminProduct)
+	router.Get("/trash", m.middleware.JwtAuth(), m.middleware.Autorize(2), productsHandler.FindTrashProduct)
+	router.Get("/import/:job_id", m.middleware.JwtAuth(), m.middleware.Autorize(2), productsHandler.FindOneImportJob)
+	router.Get("/export", m.middleware.JwtAuth(), m.middleware.Autorize(2), productsHandler.ExportProducts)
+	router.Get("/by-slug/:slug", m.middleware.ApiKeyAuth(), m.middleware.OptionalJwtAuth(), productsHandler.FindOneProductBySlug)
+	router.Get("/:product_id", m.middleware.ApiKeyAuth(), m.middleware.OptionalJwtAuth(), productsHandler.FindOneProduct)
+	router.Get("/:product_id/revisions", m.middleware.JwtAuth(), m.middleware.Autorize(2), productsHandler.FindRevisions)
+	router.Get("/:product_id/revisions/diff", m.middleware.JwtAuth(), m.middleware.Autorize(2), productsHandler.DiffRevisions)
+	router.Get("/:product_id/special-prices", m.middleware.JwtAuth(), m.middleware.Autorize(2), productsHandler.FindSpecialPrices)
+	router.Get("/:product_id/translations", m.middleware.JwtAuth(), m.middleware.Autorize(2), productsHandler.FindTranslations)
 
-	router.Get("/", m.middleware.ApiKeyAuth(), productsHandler.FindProduct)
-	router.Get("/:product_id", m.middleware.ApiKeyAuth(), productsHandler.FindOneProduct)
+	router.Put("/:product_id/translations/:locale", m.middleware.JwtAuth(), m.middleware.Autorize(2), productsHandler.UpsertTranslation)
 
 	router.Delete("/:product_id", m.middleware.JwtAuth(), m.middleware.Autorize(2), productsHandler.DeleteProduct)
+	router.Delete("/:product_id/special-prices/:special_price_id", m.middleware.JwtAuth(), m.middleware.Autorize(2), productsHandler.DeleteSpecialPrice)
+	router.Delete("/:product_id/images/:image_id", m.middleware.JwtAuth(), m.middleware.Autorize(2), productsHandler.DeleteImage)
+	router.Delete("/:product_id/translations/:locale", m.middleware.JwtAuth(), m.middleware.Autorize(2), productsHandler.DeleteTranslation)
+}
+
+func (m *moduleFactory) ReviewsModule() {
+	reviewsRepository := reviewsRepositories.ReviewsRepository(m.sever.db)
+	reviewsUsecase := reviewsUsecases.ReviewsUsecase(reviewsRepository)
+	reviewsHandler := reviewsHandlers.ReviewsHandler(m.sever.cfg, reviewsUsecase)
+
+	m.router.Post("/products/:product_id/reviews", m.middleware.JwtAuth(), reviewsHandler.InsertReview)
+	m.router.Get("/products/:product_id/reviews", m.middleware.ApiKeyAuth(), reviewsHandler.FindProductReviews)
+
+	// Moderation
+	router := m.router.Group("/reviews")
+
+	router.Get("/", m.middleware.JwtAuth(), m.middleware.Autorize(2), reviewsHandler.FindReviews)
+	router.Patch("/:review_id/approve", m.middleware.JwtAuth(), m.middleware.Autorize(2), reviewsHandler.ApproveReview)
+	router.Patch("/:review_id/hide", m.middleware.JwtAuth(), m.middleware.Autorize(2), reviewsHandler.HideReview)
+	router.Patch("/:review_id/reply", m.middleware.JwtAuth(), m.middleware.Autorize(2), reviewsHandler.ReplyReview)
+}
+
+func (m *moduleFactory) PriceListsModule() {
+	priceListsRepository := priceListsRepositories.PriceListsRepository(m.sever.db)
+	priceListsUsecase := priceListsUsecases.PriceListsUsecase(priceListsRepository)
+	priceListsHandler := priceListsHandlers.PriceListsHandler(m.sever.cfg, priceListsUsecase)
+
+	router := m.router.Group("/price-lists")
+
+	router.Post("/", m.middleware.JwtAuth(), m.middleware.Autorize(2), priceListsHandler.InsertPriceList)
+	router.Patch("/:price_list_id/users", m.middleware.JwtAuth(), m.middleware.Autorize(2), priceListsHandler.AssignUsers)
+
+	router.Get("/", m.middleware.JwtAuth(), m.middleware.Autorize(2), priceListsHandler.FindPriceLists)
+
+	router.Delete("/:price_list_id", m.middleware.JwtAuth(), m.middleware.Autorize(2), priceListsHandler.DeletePriceList)
+	router.Delete("/:price_list_id/users/:user_id", m.middleware.JwtAuth(), m.middleware.Autorize(2), priceListsHandler.UnassignUser)
+}
+
+// cartsUsecase is used by users, orders and payments too
+func (m *moduleFactory) cartsUsecase() cartsUsecases.ICartsUsecase {
+	couponsUsecase := couponsUsecases.CouponsUsecase(couponsRepositories.CouponsRepository(m.sever.db))
+	taxesUsecase := taxesUsecases.TaxesUsecase(m.sever.cfg, taxesRepositories.TaxesRepository(m.sever.db))
+	cartsRepository := cartsRepositories.CartsRepository(m.sever.db)
+	return cartsUsecases.CartsUsecase(m.sever.cfg, cartsRepository, couponsUsecase, taxesUsecase)
+}
+
+// shippingMethodsUsecase quote with every carrier, only table for now
+func (m *moduleFactory) shippingMethodsUsecase(cartsUsecase cartsUsecases.ICartsUsecase) shippingMethodsUsecases.IShippingMethodsUsecase {
+	shippingMethodsRepository := shippingMethodsRepositories.ShippingMethodsRepository(m.sever.db)
+	return shippingMethodsUsecases.ShippingMethodsUsecase(m.sever.cfg, shippingMethodsRepository, cartsUsecase, shipping.Table())
+}
+
+func (m *moduleFactory) CartsModule() {
+	cartsUsecase := m.cartsUsecase()
+	cartsHandler := cartsHandlers.CartsHandler(m.sever.cfg, cartsUsecase)
+
+	// Signed in user use own cart, guest use X-Cart-Token header
+	router := m.router.Group("/carts")
+
+	router.Post("/items", m.middleware.ApiKeyAuth(), m.middleware.OptionalJwtAuth(), cartsHandler.AddItem)
+	router.Post("/coupon", m.middleware.ApiKeyAuth(), m.middleware.OptionalJwtAuth(), cartsHandler.ApplyCoupon)
+	router.Patch("/items/:item_id", m.middleware.ApiKeyAuth(), m.middleware.OptionalJwtAuth(), cartsHandler.UpdateItem)
+
+	router.Get("/", m.middleware.ApiKeyAuth(), m.middleware.OptionalJwtAuth(), cartsHandler.FindCart)
+
+	router.Delete("/items/:item_id", m.middleware.ApiKeyAuth(), m.middleware.OptionalJwtAuth(), cartsHandler.DeleteItem)
+	router.Delete("/coupon", m.middleware.ApiKeyAuth(), m.middleware.OptionalJwtAuth(), cartsHandler.RemoveCoupon)
+}
+
+// ordersUsecase place order from cart of user with shipping and address book
+func (m *moduleFactory) ordersUsecase() ordersUsecases.IOrdersUsecase {
+	cartsUsecase := m.cartsUsecase()
+	shippingMethodsUsecase := m.shippingMethodsUsecase(cartsUsecase)
+	addressesUsecase := addressesUsecases.AddressesUsecase(addressesRepositories.AddressesRepository(m.sever.db))
+	ordersRepository := ordersRepositories.OrdersRepository(m.sever.db)
+	return ordersUsecases.OrdersUsecase(ordersRepository, cartsUsecase, shippingMethodsUsecase, addressesUsecase)
+}
+
+func (m *moduleFactory) OrdersModule() {
+	ordersUsecase := m.ordersUsecase()
+	ordersHandler := ordersHandlers.OrdersHandler(m.sever.cfg, ordersUsecase, m.paymentsUsecase())
+
+	router := m.router.Group("/orders")
+
+	router.Post("/", m.middleware.JwtAuth(), ordersHandler.InsertOrder)
+	router.Patch("/:order_id/cancel", m.middleware.JwtAuth(), ordersHandler.CancelOrder)
+	router.Patch("/:order_id/status", m.middleware.JwtAuth(), m.middleware.Autorize(2), ordersHandler.TransitionOrder)
+
+	router.Get("/", m.middleware.JwtAuth(), ordersHandler.FindMyOrders)
+	router.Get("/admin", m.middleware.JwtAuth(), m.middleware.Autorize(2), ordersHandler.FindOrders)
+	router.Get("/:order_id", m.middleware.JwtAuth(), ordersHandler.FindOneOrder)
+}
+
+// paymentsUsecase take and refund money by the provider of config
+func (m *moduleFactory) paymentsUsecase() paymentsUsecases.IPaymentsUsecase {
+	provider, err := payment.New(m.sever.cfg.Payment().Provider(), m.sever.cfg.Payment().WebhookSecret())
+	if err != nil {
+		log.Fatalf("load payment provider failed: %v", err)
+	}
+
+	paymentsRepository := paymentsRepositories.PaymentsRepository(m.sever.db)
+	return paymentsUsecases.PaymentsUsecase(paymentsRepository, m.ordersUsecase(), provider)
+}
+
+func (m *moduleFactory) PaymentsModule() {
+	paymentsUsecase := m.paymentsUsecase()
+	paymentsHandler := paymentsHandlers.PaymentsHandler(m.sever.cfg, paymentsUsecase)
+
+	m.router.Post("/checkout", m.middleware.JwtAuth(), paymentsHandler.Checkout)
+
+	// Called by payment provider, checked by signature
+	m.router.Post("/payments/webhook/:provider", paymentsHandler.Webhook)
+}
+
+func (m *moduleFactory) CouponsModule() {
+	couponsRepository := couponsRepositories.CouponsRepository(m.sever.db)
+	couponsUsecase := couponsUsecases.CouponsUsecase(couponsRepository)
+	couponsHandler := couponsHandlers.CouponsHandler(m.sever.cfg, couponsUsecase)
+
+	router := m.router.Group("/coupons")
+
+	router.Post("/", m.middleware.JwtAuth(), m.middleware.Autorize(2), couponsHandler.InsertCoupon)
+
+	router.Get("/", m.middleware.JwtAuth(), m.middleware.Autorize(2), couponsHandler.FindCoupons)
+	router.Get("/:coupon_id", m.middleware.JwtAuth(), m.middleware.Autorize(2), couponsHandler.FindOneCoupon)
+
+	router.Put("/:coupon_id", m.middleware.JwtAuth(), m.middleware.Autorize(2), couponsHandler.UpdateCoupon)
+
+	router.Delete("/:coupon_id", m.middleware.JwtAuth(), m.middleware.Autorize(2), couponsHandler.DeleteCoupon)
+}
+
+func (m *moduleFactory) TaxesModule() {
+	taxesRepository := taxesRepositories.TaxesRepository(m.sever.db)
+	taxesUsecase := taxesUsecases.TaxesUsecase(m.sever.cfg, taxesRepository)
+	taxesHandler := taxesHandlers.TaxesHandler(taxesUsecase)
+
+	router := m.router.Group("/taxes")
+
+	router.Post("/classes", m.middleware.JwtAuth(), m.middleware.Autorize(2), taxesHandler.InsertClass)
+	router.Post("/zones", m.middleware.JwtAuth(), m.middleware.Autorize(2), taxesHandler.InsertZone)
+
+	router.Get("/classes", m.middleware.JwtAuth(), m.middleware.Autorize(2), taxesHandler.FindClasses)
+	router.Get("/zones", m.middleware.JwtAuth(), m.middleware.Autorize(2), taxesHandler.FindZones)
+	router.Get("/zones/:zone_id", m.middleware.JwtAuth(), m.middleware.Autorize(2), taxesHandler.FindOneZone)
+
+	router.Patch("/classes/:class_id", m.middleware.JwtAuth(), m.middleware.Autorize(2), taxesHandler.UpdateClass)
+	router.Patch("/zones/:zone_id", m.middleware.JwtAuth(), m.middleware.Autorize(2), taxesHandler.UpdateZone)
+	router.Put("/zones/:zone_id/rates/:class_id", m.middleware.JwtAuth(), m.middleware.Autorize(2), taxesHandler.UpsertRate)
+
+	router.Delete("/classes/:class_id", m.middleware.JwtAuth(), m.middleware.Autorize(2), taxesHandler.DeleteClass)
+	router.Delete("/zones/:zone_id", m.middleware.JwtAuth(), m.middleware.Autorize(2), taxesHandler.DeleteZone)
+	router.Delete("/zones/:zone_id/rates/:class_id", m.middleware.JwtAuth(), m.middleware.Autorize(2), taxesHandler.DeleteRate)
+}
+
+func (m *moduleFactory) ShippingMethodsModule() {
+	cartsUsecase := m.cartsUsecase()
+	shippingMethodsUsecase := m.shippingMethodsUsecase(cartsUsecase)
+	shippingMethodsHandler := shippingMethodsHandlers.ShippingMethodsHandler(m.sever.cfg, shippingMethodsUsecase)
+
+	router := m.router.Group("/shipping")
+
+	router.Post("/methods", m.middleware.JwtAuth(), m.middleware.Autorize(2), shippingMethodsHandler.InsertMethod)
+
+	router.Get("/methods", m.middleware.JwtAuth(), m.middleware.Autorize(2), shippingMethodsHandler.FindMethods)
+	router.Get("/methods/:method_id", m.middleware.JwtAuth(), m.middleware.Autorize(2), shippingMethodsHandler.FindOneMethod)
+	// Rates of the cart, signed in user or guest with X-Cart-Token header
+	router.Get("/rates", m.middleware.ApiKeyAuth(), m.middleware.OptionalJwtAuth(), shippingMethodsHandler.Rates)
+
+	router.Put("/methods/:method_id", m.middleware.JwtAuth(), m.middleware.Autorize(2), shippingMethodsHandler.UpdateMethod)
+
+	router.Delete("/methods/:method_id", m.middleware.JwtAuth(), m.middleware.Autorize(2), shippingMethodsHandler.DeleteMethod)
+}
+
+func (m *moduleFactory) AddressesModule() {
+	addressesRepository := addressesRepositories.AddressesRepository(m.sever.db)
+	addressesUsecase := addressesUsecases.AddressesUsecase(addressesRepository)
+	addressesHandler := addressesHandlers.AddressesHandler(addressesUsecase)
+
+	// Address book of the user of token only
+	router := m.router.Group("/users/:user_id/addresses")
+
+	router.Post("/", m.middleware.JwtAuth(), m.middleware.ParamsCheck(), addressesHandler.InsertAddress)
+
+	router.Get("/", m.middleware.JwtAuth(), m.middleware.ParamsCheck(), addressesHandler.FindAddresses)
+	router.Get("/:address_id", m.middleware.JwtAuth(), m.middleware.ParamsCheck(), addressesHandler.FindOneAddress)
+
+	router.Put("/:address_id", m.middleware.JwtAuth(), m.middleware.ParamsCheck(), addressesHandler.UpdateAddress)
+
+	router.Delete("/:address_id", m.middleware.JwtAuth(), m.middleware.ParamsCheck(), addressesHandler.DeleteAddress)
+}
+
+func (m *moduleFactory) WishlistsModule() {
+	notifier, err := notify.New(m.sever.cfg.Notify().Notifier())
+	if err != nil {
+		log.Fatalf("load notifier failed: %v", err)
+	}
+
+	fileUsecase := filesUsecases.FilesUsecase(m.sever.cfg)
+	productsRepository := productsRepositories.ProductRepository(m.sever.db, m.sever.cfg, fileUsecase)
+	productsUsecase := productsUsecases.ProductsUsecases(m.sever.cfg, productsRepository, fileUsecase)
+
+	wishlistsRepository := wishlistsRepositories.WishlistsRepository(m.sever.db)
+	wishlistsUsecase := wishlistsUsecases.WishlistsUsecase(wishlistsRepository, productsUsecase, notifier)
+	wishlistsHandler := wishlistsHandlers.WishlistsHandler(m.sever.cfg, wishlistsUsecase)
+
+	// Send back in stock alerts of products restocked since last run
+	jobs.Every("notify back in stock", time.Minute, wishlistsUsecase.NotifyRestocked)
+
+	// Wishlist of the user of token
+	router := m.router.Group("/wishlist")
+
+	router.Post("/:product_id", m.middleware.JwtAuth(), wishlistsHandler.AddItem)
+
+	router.Get("/", m.middleware.JwtAuth(), wishlistsHandler.FindWishlist)
+	router.Get("/subscriptions", m.middleware.JwtAuth(), wishlistsHandler.FindSubscriptions)
+
+	router.Delete("/:product_id", m.middleware.JwtAuth(), wishlistsHandler.RemoveItem)
+
+	m.router.Post("/products/:product_id/subscription", m.middleware.JwtAuth(), wishlistsHandler.Subscribe)
+	m.router.Delete("/products/:product_id/subscription", m.middleware.JwtAuth(), wishlistsHandler.Unsubscribe)
+}
+
+func (m *moduleFactory) DocumentsModule() {
+	// documents already issued can still be downloaded, new ones are refused until the font is set
+	if m.sever.cfg.Document().FontPath() == "" {
+		log.Println("DOCUMENT_FONT is not set, invoices and receipts can't be rendered")
+	}
+
+	fileUsecase := filesUsecases.FilesUsecase(m.sever.cfg)
+	documentsRepository := documentsRepositories.DocumentsRepository(m.sever.db)
+	documentsUsecase := documentsUsecases.DocumentsUsecase(m.sever.cfg, documentsRepository, fileUsecase)
+	documentsHandler := documentsHandlers.DocumentsHandler(m.sever.cfg, documentsUsecase, m.ordersUsecase())
+
+	// Invoice or receipt of paid order, for the owner and admin
+	m.router.Get("/orders/:order_id/documents/:type", m.middleware.JwtAuth(), documentsHandler.DownloadDocument)
+}
+
+func (m *moduleFactory) ReturnsModule() {
+	fileUsecase := filesUsecases.FilesUsecase(m.sever.cfg)
+	returnsRepository := returnsRepositories.ReturnsRepository(m.sever.db)
+	returnsUsecase := returnsUsecases.ReturnsUsecase(returnsRepository, fileUsecase, m.paymentsUsecase())
+	returnsHandler := returnsHandlers.ReturnsHandler(m.sever.cfg, returnsUsecase)
+
+	// Customer request return of own delivered order
+	m.router.Post("/orders/:order_id/returns", m.middleware.JwtAuth(), returnsHandler.InsertReturn)
+
+	router := m.router.Group("/returns")
+
+	router.Post("/:return_id/images", m.middleware.JwtAuth(), returnsHandler.InsertImages)
+	router.Post("/:return_id/refund", m.middleware.JwtAuth(), m.middleware.Autorize(2), returnsHandler.RefundReturn)
+	router.Patch("/:return_id/status", m.middleware.JwtAuth(), m.middleware.Autorize(2), returnsHandler.TransitionReturn)
+
+	router.Get("/", m.middleware.JwtAuth(), returnsHandler.FindMyReturns)
+	router.Get("/admin", m.middleware.JwtAuth(), m.middleware.Autorize(2), returnsHandler.FindReturns)
+	router.Get("/:return_id", m.middleware.JwtAuth(), returnsHandler.FindOneReturn)
 }
@@ -53,6 +53,18 @@ func (sever *sever) Start() {
 	modules.AppInfoModule()
 	modules.FilesModule()
 	modules.ProductsModule()
+	modules.ReviewsModule()
+	modules.PriceListsModule()
+	modules.CartsModule()
+	modules.OrdersModule()
+	modules.PaymentsModule()
+	modules.CouponsModule()
+	modules.TaxesModule()
+	modules.ShippingMethodsModule()
+	modules.AddressesModule()
+	modules.WishlistsModule()
+	modules.DocumentsModule()
+	modules.ReturnsModule()
 
 	sever.app.Use(middlewares.RouterCheck())
 
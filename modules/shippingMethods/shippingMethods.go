@@ -0,0 +1,150 @@
+package shippingMethods
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/DrumPatiphon/go-rest-api-service/pkg/money"
+	"github.com/DrumPatiphon/go-rest-api-service/pkg/shipping"
+)
+
+type ShippingMethod struct {
+	Id          string        `json:"id"`
+	Title       string        `json:"title"`
+	Description string        `json:"description"`
+	Type        string        `json:"type"`    // flat_rate, weight_based, free_over
+	Carrier     string        `json:"carrier"` // table when empty
+	Price       *money.Money  `json:"price"`   // flat rate, base price of weight based
+	FreeOver    *money.Money  `json:"free_over,omitempty"`
+	WeightRates []*WeightRate `json:"weight_rates"` // weight based only
+	Countries   []string      `json:"countries"`    // empty is every country
+	SortOrder   int           `json:"sort_order"`
+	IsActive    bool          `json:"is_active"`
+	CreatedAt   string        `json:"created_at"`
+	UpdatedAt   string        `json:"updated_at"`
+}
+
+// WeightRate is price of parcel up to MaxWeight grams
+type WeightRate struct {
+	MaxWeight int          `json:"max_weight"`
+	Price     *money.Money `json:"price"`
+}
+
+// RatesReq is the shipping address
+type RatesReq struct {
+	Country  string `query:"country"`
+	Region   string `query:"region"`
+	Postcode string `query:"postcode"`
+}
+
+// ShippingRate is a method the cart can be shipped with
+type ShippingRate struct {
+	MethodId    string       `json:"method_id"`
+	Title       string       `json:"title"`
+	Description string       `json:"description"`
+	Type        string       `json:"type"`
+	Carrier     string       `json:"carrier"`
+	Price       *money.Money `json:"price"`
+	Reason      string       `json:"-"` // not available when it is set
+}
+
+var countryRegexp = regexp.MustCompile(`^[A-Z]{2}$`)
+
+// Validate check method from admin, money without currency is defaultCurrency
+func (obj *ShippingMethod) Validate(defaultCurrency string) error {
+	obj.Title = strings.TrimSpace(obj.Title)
+	if obj.Title == "" {
+		return fmt.Errorf("title is required")
+	}
+	if obj.Carrier == "" {
+		obj.Carrier = "table"
+	}
+	if obj.Price == nil {
+		obj.Price = money.New(0, defaultCurrency)
+	}
+	if err := obj.Price.Validate(defaultCurrency); err != nil {
+		return err
+	}
+	currency := obj.Price.Currency
+
+	if obj.FreeOver != nil {
+		if err := obj.FreeOver.Validate(currency); err != nil {
+			return err
+		}
+		if obj.FreeOver.Currency != currency {
+			return fmt.Errorf("free over and price must be in the same currency")
+		}
+		if obj.FreeOver.Amount == 0 {
+			obj.FreeOver = nil
+		}
+	}
+
+	switch obj.Type {
+	case shipping.TypeFlatRate:
+		obj.WeightRates = make([]*WeightRate, 0)
+	case shipping.TypeWeightBased:
+		if len(obj.WeightRates) == 0 {
+			return fmt.Errorf("weight rates are required")
+		}
+		weights := make(map[int]bool)
+		for _, r := range obj.WeightRates {
+			if r == nil || r.MaxWeight <= 0 {
+				return fmt.Errorf("max weight must be positive")
+			}
+			if weights[r.MaxWeight] {
+				return fmt.Errorf("max weight %d is duplicated", r.MaxWeight)
+			}
+			weights[r.MaxWeight] = true
+			if r.Price == nil {
+				return fmt.Errorf("price of weight rate is required")
+			}
+			if err := r.Price.Validate(currency); err != nil {
+				return err
+			}
+			if r.Price.Currency != currency {
+				return fmt.Errorf("weight rates and price must be in the same currency")
+			}
+		}
+	case shipping.TypeFreeOver:
+		if obj.FreeOver == nil {
+			return fmt.Errorf("free over is required")
+		}
+		obj.Price = money.New(0, currency)
+		obj.WeightRates = make([]*WeightRate, 0)
+	default:
+		return fmt.Errorf("type must be flat_rate, weight_based or free_over")
+	}
+
+	if obj.Countries == nil {
+		obj.Countries = make([]string, 0)
+	}
+	for i, c := range obj.Countries {
+		obj.Countries[i] = strings.ToUpper(strings.TrimSpace(c))
+		if !countryRegexp.MatchString(obj.Countries[i]) {
+			return fmt.Errorf("country %s must be ISO 3166-1 alpha-2 code", c)
+		}
+	}
+	return nil
+}
+
+// Method return method for carrier
+func (obj *ShippingMethod) Method() *shipping.Method {
+	m := &shipping.Method{
+		Id:          obj.Id,
+		Type:        obj.Type,
+		Currency:    obj.Price.Currency,
+		Amount:      obj.Price.Amount,
+		WeightRates: make([]*shipping.WeightRate, 0),
+	}
+	if obj.FreeOver != nil {
+		m.FreeOver = obj.FreeOver.Amount
+	}
+	for _, r := range obj.WeightRates {
+		m.WeightRates = append(m.WeightRates, &shipping.WeightRate{
+			MaxWeight: r.MaxWeight,
+			Amount:    r.Price.Amount,
+		})
+	}
+	return m
+}
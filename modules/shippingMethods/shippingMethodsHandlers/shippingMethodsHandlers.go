@@ -0,0 +1,192 @@
+package shippingMethodsHandlers
+
+import (
+	"strings"
+
+	"github.com/DrumPatiphon/go-rest-api-service/config"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/carts"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/entities"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/shippingMethods"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/shippingMethods/shippingMethodsUsecases"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/taxes"
+	"github.com/gofiber/fiber/v2"
+)
+
+type shippingMethodsHandlerErrCode string
+
+const (
+	findMethodsErr   shippingMethodsHandlerErrCode = "shippingMethods-001"
+	findOneMethodErr shippingMethodsHandlerErrCode = "shippingMethods-002"
+	insertMethodErr  shippingMethodsHandlerErrCode = "shippingMethods-003"
+	updateMethodErr  shippingMethodsHandlerErrCode = "shippingMethods-004"
+	deleteMethodErr  shippingMethodsHandlerErrCode = "shippingMethods-005"
+	ratesErr         shippingMethodsHandlerErrCode = "shippingMethods-006"
+)
+
+type IShippingMethodsHandler interface {
+	FindMethods(c *fiber.Ctx) error
+	FindOneMethod(c *fiber.Ctx) error
+	InsertMethod(c *fiber.Ctx) error
+	UpdateMethod(c *fiber.Ctx) error
+	DeleteMethod(c *fiber.Ctx) error
+	Rates(c *fiber.Ctx) error
+}
+
+type shippingMethodsHandler struct {
+	cfg                    config.Iconfig
+	shippingMethodsUsecase shippingMethodsUsecases.IShippingMethodsUsecase
+}
+
+func ShippingMethodsHandler(cfg config.Iconfig, shippingMethodsUsecase shippingMethodsUsecases.IShippingMethodsUsecase) IShippingMethodsHandler {
+	return &shippingMethodsHandler{
+		cfg:                    cfg,
+		shippingMethodsUsecase: shippingMethodsUsecase,
+	}
+}
+
+// errStatus return status of known errors of shipping methods usecase
+func errStatus(err error) int {
+	switch {
+	case err.Error() == "shipping method not found":
+		return fiber.ErrNotFound.Code
+	case strings.HasPrefix(err.Error(), "shipping carrier"):
+		return fiber.ErrBadRequest.Code
+	default:
+		return fiber.ErrInternalServerError.Code
+	}
+}
+
+func (h *shippingMethodsHandler) FindMethods(c *fiber.Ctx) error {
+	methods, err := h.shippingMethodsUsecase.FindMethods()
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(findMethodsErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, methods).Res()
+}
+
+func (h *shippingMethodsHandler) FindOneMethod(c *fiber.Ctx) error {
+	methodId := strings.TrimSpace(c.Params("method_id"))
+
+	method, err := h.shippingMethodsUsecase.FindOneMethod(methodId)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			errStatus(err),
+			string(findOneMethodErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, method).Res()
+}
+
+func (h *shippingMethodsHandler) InsertMethod(c *fiber.Ctx) error {
+	req := &shippingMethods.ShippingMethod{
+		IsActive: true,
+	}
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(insertMethodErr),
+			err.Error(),
+		).Res()
+	}
+	if err := req.Validate(h.cfg.App().Currency()); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(insertMethodErr),
+			err.Error(),
+		).Res()
+	}
+
+	method, err := h.shippingMethodsUsecase.InsertMethod(req)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			errStatus(err),
+			string(insertMethodErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Success(fiber.StatusCreated, method).Res()
+}
+
+// UpdateMethod replace the whole method, weight rates too
+func (h *shippingMethodsHandler) UpdateMethod(c *fiber.Ctx) error {
+	req := new(shippingMethods.ShippingMethod)
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(updateMethodErr),
+			err.Error(),
+		).Res()
+	}
+	req.Id = strings.TrimSpace(c.Params("method_id"))
+	if err := req.Validate(h.cfg.App().Currency()); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(updateMethodErr),
+			err.Error(),
+		).Res()
+	}
+
+	method, err := h.shippingMethodsUsecase.UpdateMethod(req)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			errStatus(err),
+			string(updateMethodErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, method).Res()
+}
+
+func (h *shippingMethodsHandler) DeleteMethod(c *fiber.Ctx) error {
+	methodId := strings.TrimSpace(c.Params("method_id"))
+
+	if err := h.shippingMethodsUsecase.DeleteMethod(methodId); err != nil {
+		return entities.NewResponse(c).Error(
+			errStatus(err),
+			string(deleteMethodErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Success(fiber.StatusNoContent, nil).Res()
+}
+
+// Rates return methods for the cart of user or guest, to ?country=&region=&postcode=
+func (h *shippingMethodsHandler) Rates(c *fiber.Ctx) error {
+	req := new(shippingMethods.RatesReq)
+	if err := c.QueryParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(ratesErr),
+			err.Error(),
+		).Res()
+	}
+	address := &taxes.Address{Country: req.Country, Region: req.Region}
+	if err := address.Normalize(); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(ratesErr),
+			err.Error(),
+		).Res()
+	}
+	req.Country = address.Country
+	req.Region = address.Region
+	req.Postcode = strings.TrimSpace(req.Postcode)
+
+	userId, _ := c.Locals("userId").(string)
+	owner := carts.NewOwner(userId, c.Get(carts.CartTokenHeader))
+
+	rates, err := h.shippingMethodsUsecase.Rates(owner, req)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(ratesErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, rates).Res()
+}
@@ -0,0 +1,253 @@
+package shippingMethodsRepositories
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/DrumPatiphon/go-rest-api-service/modules/shippingMethods"
+	"github.com/jmoiron/sqlx"
+)
+
+type IShippingMethodsRepository interface {
+	InsertMethod(req *shippingMethods.ShippingMethod) error
+	UpdateMethod(req *shippingMethods.ShippingMethod) error
+	DeleteMethod(methodId string) error
+	FindOneMethod(methodId string) (*shippingMethods.ShippingMethod, error)
+	FindMethods(activeOnly bool, country string) ([]*shippingMethods.ShippingMethod, error)
+}
+
+type shippingMethodsRepository struct {
+	db *sqlx.DB
+}
+
+func ShippingMethodsRepository(db *sqlx.DB) IShippingMethodsRepository {
+	return &shippingMethodsRepository{
+		db: db,
+	}
+}
+
+// methodColumns is columns of method in json
+const methodColumns = `
+			"m"."id",
+			"m"."title",
+			"m"."description",
+			"m"."type",
+			"m"."carrier",
+			jsonb_build_object('currency', "m"."currency", 'minor_units', "m"."amount") AS "price",
+			CASE WHEN "m"."free_over" > 0 THEN
+				jsonb_build_object('currency', "m"."currency", 'minor_units', "m"."free_over")
+			END AS "free_over",
+			(
+				SELECT
+					COALESCE(array_to_json(array_agg("wt")), '[]'::json)
+				FROM (
+					SELECT
+						"w"."max_weight",
+						jsonb_build_object('currency', "m"."currency", 'minor_units', "w"."amount") AS "price"
+					FROM "shipping_methods_weight_rates" "w"
+					WHERE "w"."method_id" = "m"."id"
+					ORDER BY "w"."max_weight"
+				) AS "wt"
+			) AS "weight_rates",
+			to_jsonb("m"."countries") AS "countries",
+			"m"."sort_order",
+			"m"."is_active",
+			"m"."created_at",
+			"m"."updated_at"`
+
+// methodValues is values of method columns for insert and update
+func methodValues(req *shippingMethods.ShippingMethod) []any {
+	freeOver := int64(0)
+	if req.FreeOver != nil {
+		freeOver = req.FreeOver.Amount
+	}
+	return []any{
+		req.Title,
+		req.Description,
+		req.Type,
+		req.Carrier,
+		req.Price.Currency,
+		req.Price.Amount,
+		freeOver,
+		req.Countries,
+		req.SortOrder,
+		req.IsActive,
+	}
+}
+
+// setWeightRates replace weight rates of method
+func setWeightRates(ctx context.Context, tx *sqlx.Tx, req *shippingMethods.ShippingMethod) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM "shipping_methods_weight_rates" WHERE "method_id" = $1;`, req.Id); err != nil {
+		return fmt.Errorf("delete weight rates failed: %v", err)
+	}
+
+	weights := make([]int64, 0)
+	amounts := make([]int64, 0)
+	for _, r := range req.WeightRates {
+		weights = append(weights, int64(r.MaxWeight))
+		amounts = append(amounts, r.Price.Amount)
+	}
+
+	query := `
+	INSERT INTO "shipping_methods_weight_rates" (
+		"method_id",
+		"max_weight",
+		"amount"
+	)
+	SELECT $1, UNNEST($2::INT[]), UNNEST($3::BIGINT[]);`
+
+	if _, err := tx.ExecContext(ctx, query, req.Id, weights, amounts); err != nil {
+		return fmt.Errorf("insert weight rates failed: %v", err)
+	}
+	return nil
+}
+
+func (r *shippingMethodsRepository) InsertMethod(req *shippingMethods.ShippingMethod) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*15)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	query := `
+	INSERT INTO "shipping_methods" (
+		"title",
+		"description",
+		"type",
+		"carrier",
+		"currency",
+		"amount",
+		"free_over",
+		"countries",
+		"sort_order",
+		"is_active"
+	)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8::VARCHAR[], $9, $10)
+		RETURNING "id";`
+
+	if err := tx.QueryRowContext(ctx, query, methodValues(req)...).Scan(&req.Id); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("insert shipping method failed: %v", err)
+	}
+	if err := setWeightRates(ctx, tx, req); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return nil
+}
+
+func (r *shippingMethodsRepository) UpdateMethod(req *shippingMethods.ShippingMethod) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*15)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	query := `
+	UPDATE "shipping_methods" SET
+		"title" = $1,
+		"description" = $2,
+		"type" = $3,
+		"carrier" = $4,
+		"currency" = $5,
+		"amount" = $6,
+		"free_over" = $7,
+		"countries" = $8::VARCHAR[],
+		"sort_order" = $9,
+		"is_active" = $10
+	WHERE "id"::TEXT = $11;`
+
+	result, err := tx.ExecContext(ctx, query, append(methodValues(req), req.Id)...)
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("update shipping method failed: %v", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		tx.Rollback()
+		return fmt.Errorf("shipping method not found")
+	}
+	if err := setWeightRates(ctx, tx, req); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return nil
+}
+
+// DeleteMethod keep orders of the method, they have the title
+func (r *shippingMethodsRepository) DeleteMethod(methodId string) error {
+	result, err := r.db.ExecContext(context.Background(), `DELETE FROM "shipping_methods" WHERE "id"::TEXT = $1;`, methodId)
+	if err != nil {
+		return fmt.Errorf("delete shipping method failed: %v", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("shipping method not found")
+	}
+	return nil
+}
+
+func (r *shippingMethodsRepository) FindOneMethod(methodId string) (*shippingMethods.ShippingMethod, error) {
+	query := `
+	SELECT
+		to_jsonb("t")
+	FROM (
+		SELECT` + methodColumns + `
+		FROM "shipping_methods" "m"
+		WHERE "m"."id"::TEXT = $1
+	) AS "t";`
+
+	methodBytes := make([]byte, 0)
+	method := new(shippingMethods.ShippingMethod)
+
+	if err := r.db.Get(&methodBytes, query, methodId); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("shipping method not found")
+		}
+		return nil, fmt.Errorf("get shipping method failed: %v", err)
+	}
+	if err := json.Unmarshal(methodBytes, method); err != nil {
+		return nil, fmt.Errorf("unmarshal shipping method failed: %v", err)
+	}
+	return method, nil
+}
+
+// FindMethods return every method for admin, or active methods which ship to country
+func (r *shippingMethodsRepository) FindMethods(activeOnly bool, country string) ([]*shippingMethods.ShippingMethod, error) {
+	query := `
+	SELECT
+		COALESCE(array_to_json(array_agg("t")), '[]'::json)
+	FROM (
+		SELECT` + methodColumns + `
+		FROM "shipping_methods" "m"
+		WHERE (NOT $1 OR "m"."is_active")
+		AND ($2::VARCHAR = '' OR cardinality("m"."countries") = 0 OR $2::VARCHAR = ANY("m"."countries"))
+		ORDER BY "m"."sort_order", "m"."created_at"
+	) AS "t";`
+
+	methodsBytes := make([]byte, 0)
+	methods := make([]*shippingMethods.ShippingMethod, 0)
+
+	if err := r.db.Get(&methodsBytes, query, activeOnly, country); err != nil {
+		return nil, fmt.Errorf("find shipping methods failed: %v", err)
+	}
+	if err := json.Unmarshal(methodsBytes, &methods); err != nil {
+		return nil, fmt.Errorf("unmarshal shipping methods failed: %v", err)
+	}
+	return methods, nil
+}
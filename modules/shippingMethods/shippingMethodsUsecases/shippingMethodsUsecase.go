@@ -0,0 +1,175 @@
+package shippingMethodsUsecases
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/DrumPatiphon/go-rest-api-service/config"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/carts"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/carts/cartsUsecases"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/shippingMethods"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/shippingMethods/shippingMethodsRepositories"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/taxes"
+	"github.com/DrumPatiphon/go-rest-api-service/pkg/money"
+	"github.com/DrumPatiphon/go-rest-api-service/pkg/shipping"
+)
+
+type IShippingMethodsUsecase interface {
+	InsertMethod(req *shippingMethods.ShippingMethod) (*shippingMethods.ShippingMethod, error)
+	UpdateMethod(req *shippingMethods.ShippingMethod) (*shippingMethods.ShippingMethod, error)
+	DeleteMethod(methodId string) error
+	FindOneMethod(methodId string) (*shippingMethods.ShippingMethod, error)
+	FindMethods() ([]*shippingMethods.ShippingMethod, error)
+	Rates(owner *carts.CartOwner, req *shippingMethods.RatesReq) ([]*shippingMethods.ShippingRate, error)
+	Quote(cart *carts.Cart, req *shippingMethods.RatesReq, methodId string) (*shippingMethods.ShippingRate, error)
+}
+
+type shippingMethodsUsecase struct {
+	cfg                       config.Iconfig
+	shippingMethodsRepository shippingMethodsRepositories.IShippingMethodsRepository
+	cartsUsecase              cartsUsecases.ICartsUsecase
+	carriers                  map[string]shipping.Carrier
+}
+
+func ShippingMethodsUsecase(cfg config.Iconfig, shippingMethodsRepository shippingMethodsRepositories.IShippingMethodsRepository, cartsUsecase cartsUsecases.ICartsUsecase, carriers ...shipping.Carrier) IShippingMethodsUsecase {
+	u := &shippingMethodsUsecase{
+		cfg:                       cfg,
+		shippingMethodsRepository: shippingMethodsRepository,
+		cartsUsecase:              cartsUsecase,
+		carriers:                  make(map[string]shipping.Carrier),
+	}
+	for _, c := range carriers {
+		u.carriers[c.Name()] = c
+	}
+	return u
+}
+
+func (u *shippingMethodsUsecase) checkCarrier(req *shippingMethods.ShippingMethod) error {
+	if _, ok := u.carriers[req.Carrier]; !ok {
+		return fmt.Errorf("shipping carrier %s is not supported", req.Carrier)
+	}
+	return nil
+}
+
+func (u *shippingMethodsUsecase) InsertMethod(req *shippingMethods.ShippingMethod) (*shippingMethods.ShippingMethod, error) {
+	if err := u.checkCarrier(req); err != nil {
+		return nil, err
+	}
+	if err := u.shippingMethodsRepository.InsertMethod(req); err != nil {
+		return nil, err
+	}
+	return u.shippingMethodsRepository.FindOneMethod(req.Id)
+}
+
+func (u *shippingMethodsUsecase) UpdateMethod(req *shippingMethods.ShippingMethod) (*shippingMethods.ShippingMethod, error) {
+	if err := u.checkCarrier(req); err != nil {
+		return nil, err
+	}
+	if err := u.shippingMethodsRepository.UpdateMethod(req); err != nil {
+		return nil, err
+	}
+	return u.shippingMethodsRepository.FindOneMethod(req.Id)
+}
+
+func (u *shippingMethodsUsecase) DeleteMethod(methodId string) error {
+	return u.shippingMethodsRepository.DeleteMethod(methodId)
+}
+
+func (u *shippingMethodsUsecase) FindOneMethod(methodId string) (*shippingMethods.ShippingMethod, error) {
+	return u.shippingMethodsRepository.FindOneMethod(methodId)
+}
+
+func (u *shippingMethodsUsecase) FindMethods() ([]*shippingMethods.ShippingMethod, error) {
+	return u.shippingMethodsRepository.FindMethods(false, "")
+}
+
+// Rates return every method the cart of owner can be shipped with to the address, in sort order
+func (u *shippingMethodsUsecase) Rates(owner *carts.CartOwner, req *shippingMethods.RatesReq) ([]*shippingMethods.ShippingRate, error) {
+	cart, err := u.cartsUsecase.FindCart(owner, &taxes.Address{Country: req.Country, Region: req.Region})
+	if err != nil {
+		return nil, err
+	}
+
+	methods, err := u.shippingMethodsRepository.FindMethods(true, u.country(req))
+	if err != nil {
+		return nil, err
+	}
+
+	rates := make([]*shippingMethods.ShippingRate, 0)
+	for _, m := range methods {
+		rate, err := u.quote(cart, req, m)
+		if err != nil {
+			return nil, err
+		}
+		if rate.Reason == "" {
+			rates = append(rates, rate)
+		}
+	}
+	return rates, nil
+}
+
+// Quote price of a method for cart, error when the method can't ship the cart
+func (u *shippingMethodsUsecase) Quote(cart *carts.Cart, req *shippingMethods.RatesReq, methodId string) (*shippingMethods.ShippingRate, error) {
+	methods, err := u.shippingMethodsRepository.FindMethods(true, u.country(req))
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range methods {
+		if m.Id != methodId {
+			continue
+		}
+		rate, err := u.quote(cart, req, m)
+		if err != nil {
+			return nil, err
+		}
+		if rate.Reason != "" {
+			return nil, fmt.Errorf("shipping method is not available: %s", rate.Reason)
+		}
+		return rate, nil
+	}
+	return nil, fmt.Errorf("shipping method is not available: not_found")
+}
+
+// country of address, TAX_COUNTRY is the store country when it is empty
+func (u *shippingMethodsUsecase) country(req *shippingMethods.RatesReq) string {
+	if req.Country == "" {
+		return u.cfg.Tax().Country()
+	}
+	return req.Country
+}
+
+func (u *shippingMethodsUsecase) quote(cart *carts.Cart, req *shippingMethods.RatesReq, m *shippingMethods.ShippingMethod) (*shippingMethods.ShippingRate, error) {
+	carrier, ok := u.carriers[m.Carrier]
+	if !ok {
+		return &shippingMethods.ShippingRate{Reason: shipping.ReasonNotSupport}, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*15)
+	defer cancel()
+
+	quote, err := carrier.Quote(ctx, &shipping.QuoteReq{
+		Method: m.Method(),
+		Parcel: cart.Parcel(),
+		Destination: &shipping.Destination{
+			Country:  u.country(req),
+			Region:   req.Region,
+			Postcode: req.Postcode,
+		},
+		Currency: cart.Currency,
+		Subtotal: cart.Subtotal.Amount - cart.Discount.Amount,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("quote shipping with %s failed: %v", m.Carrier, err)
+	}
+
+	return &shippingMethods.ShippingRate{
+		MethodId:    m.Id,
+		Title:       m.Title,
+		Description: m.Description,
+		Type:        m.Type,
+		Carrier:     m.Carrier,
+		Price:       money.New(quote.Amount, cart.Currency),
+		Reason:      quote.Reason,
+	}, nil
+}
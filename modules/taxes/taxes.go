@@ -0,0 +1,184 @@
+package taxes
+
+import (
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+)
+
+type TaxClass struct {
+	Id        string `db:"id" json:"id"` // like standard, zero, exempt
+	Title     string `db:"title" json:"title"`
+	CreatedAt string `db:"created_at" json:"created_at"`
+	UpdatedAt string `db:"updated_at" json:"updated_at"`
+}
+
+// TaxZone is a country or a region of country, empty region is the whole country
+type TaxZone struct {
+	Id        string     `json:"id"`
+	Title     string     `json:"title"`
+	Country   string     `json:"country"` // ISO 3166-1 alpha-2
+	Region    string     `json:"region"`
+	Rates     []*TaxRate `json:"rates"`
+	CreatedAt string     `json:"created_at"`
+	UpdatedAt string     `json:"updated_at"`
+}
+
+// TaxRate is rate of a class in zone, Rate is percent like "7" or "7.5"
+type TaxRate struct {
+	Id        string `db:"id" json:"id"`
+	ZoneId    string `db:"zone_id" json:"zone_id"`
+	ClassId   string `db:"class_id" json:"class_id"`
+	Title     string `db:"title" json:"title"`
+	Rate      string `db:"rate" json:"rate"`
+	CreatedAt string `db:"created_at" json:"created_at"`
+	UpdatedAt string `db:"updated_at" json:"updated_at"`
+}
+
+// Address pick the tax zone, it is the shipping address
+type Address struct {
+	Country string `json:"country" query:"country" form:"country"`
+	Region  string `json:"region" query:"region" form:"region"`
+}
+
+// Line is a line of cart or order, Amount is line total after discount in minor units
+type Line struct {
+	Id      string
+	ClassId string
+	Amount  int64
+}
+
+type LineTax struct {
+	Rate   string // percent with RateScale digits, 0 when class has no rate in zone
+	Amount int64
+}
+
+// Result of tax calculation, Total is sum of tax of every line
+type Result struct {
+	Inclusive bool
+	Lines     map[string]*LineTax // line id -> tax
+	Total     int64
+}
+
+var (
+	classIdRegexp = regexp.MustCompile(`^[a-z0-9_]+$`)
+	countryRegexp = regexp.MustCompile(`^[A-Z]{2}$`)
+	rateRegexp    = regexp.MustCompile(`^[0-9]+(\.[0-9]+)?$`)
+)
+
+// RateScale is max digits after decimal point of rate
+const RateScale = 4
+
+func (obj *TaxClass) Validate() error {
+	obj.Id = strings.TrimSpace(obj.Id)
+	obj.Title = strings.TrimSpace(obj.Title)
+	if !classIdRegexp.MatchString(obj.Id) {
+		return fmt.Errorf("id must be lowercase letters, digits or _")
+	}
+	if obj.Title == "" {
+		return fmt.Errorf("title is required")
+	}
+	return nil
+}
+
+func (obj *TaxZone) Validate() error {
+	obj.Title = strings.TrimSpace(obj.Title)
+	if obj.Title == "" {
+		return fmt.Errorf("title is required")
+	}
+	addr := &Address{Country: obj.Country, Region: obj.Region}
+	if err := addr.Normalize(); err != nil {
+		return err
+	}
+	if addr.Country == "" {
+		return fmt.Errorf("country is required")
+	}
+	obj.Country, obj.Region = addr.Country, addr.Region
+	return nil
+}
+
+func (obj *TaxRate) Validate() error {
+	obj.Title = strings.TrimSpace(obj.Title)
+	obj.Rate = strings.TrimSpace(obj.Rate)
+	if _, err := ParseRate(obj.Rate); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Normalize trim and uppercase country, empty country is allowed and means the default zone
+func (obj *Address) Normalize() error {
+	obj.Country = strings.ToUpper(strings.TrimSpace(obj.Country))
+	obj.Region = strings.TrimSpace(obj.Region)
+	if obj.Country != "" && !countryRegexp.MatchString(obj.Country) {
+		return fmt.Errorf("country must be ISO 3166-1 alpha-2 code")
+	}
+	if obj.Country == "" && obj.Region != "" {
+		return fmt.Errorf("country is required with region")
+	}
+	return nil
+}
+
+// ParseRate parse percent rate as exact fraction, big.Rat SetString accept 1/3 and 1e2 so format is checked first
+func ParseRate(rate string) (*big.Rat, error) {
+	if !rateRegexp.MatchString(rate) {
+		return nil, fmt.Errorf("rate is invalid")
+	}
+	if _, fraction, ok := strings.Cut(rate, "."); ok && len(strings.TrimRight(fraction, "0")) > RateScale {
+		return nil, fmt.Errorf("rate must have at most %d decimal places", RateScale)
+	}
+
+	r, ok := new(big.Rat).SetString(rate)
+	if !ok {
+		return nil, fmt.Errorf("rate is invalid")
+	}
+	if r.Cmp(big.NewRat(100, 1)) > 0 {
+		return nil, fmt.Errorf("rate must be between 0 and 100")
+	}
+	return r, nil
+}
+
+// Tax of amount at percent rate, rounded half up to minor unit.
+// Inclusive amount already contains the tax, so tax is amount * rate / (100 + rate)
+func Tax(amount int64, rate *big.Rat, inclusive bool) int64 {
+	divisor := big.NewRat(100, 1)
+	if inclusive {
+		divisor.Add(divisor, rate)
+	}
+	tax := new(big.Rat).SetInt64(amount)
+	tax.Mul(tax, rate)
+	tax.Quo(tax, divisor)
+
+	q, r := new(big.Int).QuoRem(tax.Num(), tax.Denom(), new(big.Int))
+	if r.Mul(r, big.NewInt(2)).CmpAbs(tax.Denom()) >= 0 {
+		if tax.Sign() < 0 {
+			q.Sub(q, big.NewInt(1))
+		} else {
+			q.Add(q, big.NewInt(1))
+		}
+	}
+	return q.Int64()
+}
+
+// Calculate tax of every line with rates of the zone by class id
+func Calculate(rates map[string]*TaxRate, lines []*Line, inclusive bool) (*Result, error) {
+	result := &Result{
+		Inclusive: inclusive,
+		Lines:     make(map[string]*LineTax),
+	}
+	for _, line := range lines {
+		lineTax := &LineTax{Rate: new(big.Rat).FloatString(RateScale)}
+		if rate, ok := rates[line.ClassId]; ok {
+			r, err := ParseRate(rate.Rate)
+			if err != nil {
+				return nil, fmt.Errorf("tax rate of %s: %v", line.ClassId, err)
+			}
+			lineTax.Rate = r.FloatString(RateScale)
+			lineTax.Amount = Tax(line.Amount, r, inclusive)
+		}
+		result.Lines[line.Id] = lineTax
+		result.Total += lineTax.Amount
+	}
+	return result, nil
+}
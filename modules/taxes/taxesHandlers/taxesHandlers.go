@@ -0,0 +1,289 @@
+package taxesHandlers
+
+import (
+	"strings"
+
+	"github.com/DrumPatiphon/go-rest-api-service/modules/entities"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/taxes"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/taxes/taxesUsecases"
+	"github.com/gofiber/fiber/v2"
+)
+
+type taxesHandlerErrCode string
+
+const (
+	findClassesErr taxesHandlerErrCode = "taxes-001"
+	insertClassErr taxesHandlerErrCode = "taxes-002"
+	updateClassErr taxesHandlerErrCode = "taxes-003"
+	deleteClassErr taxesHandlerErrCode = "taxes-004"
+	findZonesErr   taxesHandlerErrCode = "taxes-005"
+	findOneZoneErr taxesHandlerErrCode = "taxes-006"
+	insertZoneErr  taxesHandlerErrCode = "taxes-007"
+	updateZoneErr  taxesHandlerErrCode = "taxes-008"
+	deleteZoneErr  taxesHandlerErrCode = "taxes-009"
+	upsertRateErr  taxesHandlerErrCode = "taxes-010"
+	deleteRateErr  taxesHandlerErrCode = "taxes-011"
+)
+
+type ITaxesHandler interface {
+	FindClasses(c *fiber.Ctx) error
+	InsertClass(c *fiber.Ctx) error
+	UpdateClass(c *fiber.Ctx) error
+	DeleteClass(c *fiber.Ctx) error
+	FindZones(c *fiber.Ctx) error
+	FindOneZone(c *fiber.Ctx) error
+	InsertZone(c *fiber.Ctx) error
+	UpdateZone(c *fiber.Ctx) error
+	DeleteZone(c *fiber.Ctx) error
+	UpsertRate(c *fiber.Ctx) error
+	DeleteRate(c *fiber.Ctx) error
+}
+
+type taxesHandler struct {
+	taxesUsecase taxesUsecases.ITaxesUsecase
+}
+
+func TaxesHandler(taxesUsecase taxesUsecases.ITaxesUsecase) ITaxesHandler {
+	return &taxesHandler{
+		taxesUsecase: taxesUsecase,
+	}
+}
+
+// errStatus return status of known errors of taxes usecase
+func errStatus(err error) int {
+	switch err.Error() {
+	case "tax class not found", "tax zone not found", "tax rate not found":
+		return fiber.ErrNotFound.Code
+	case "tax class already exists", "tax zone already exists":
+		return fiber.ErrBadRequest.Code
+	case "tax class is used by products":
+		return fiber.ErrConflict.Code
+	default:
+		return fiber.ErrInternalServerError.Code
+	}
+}
+
+func (h *taxesHandler) FindClasses(c *fiber.Ctx) error {
+	classes, err := h.taxesUsecase.FindClasses()
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(findClassesErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, classes).Res()
+}
+
+func (h *taxesHandler) InsertClass(c *fiber.Ctx) error {
+	req := new(taxes.TaxClass)
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(insertClassErr),
+			err.Error(),
+		).Res()
+	}
+	if err := req.Validate(); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(insertClassErr),
+			err.Error(),
+		).Res()
+	}
+
+	class, err := h.taxesUsecase.InsertClass(req)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			errStatus(err),
+			string(insertClassErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Success(fiber.StatusCreated, class).Res()
+}
+
+func (h *taxesHandler) UpdateClass(c *fiber.Ctx) error {
+	req := new(taxes.TaxClass)
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(updateClassErr),
+			err.Error(),
+		).Res()
+	}
+	req.Id = strings.TrimSpace(c.Params("class_id"))
+	if err := req.Validate(); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(updateClassErr),
+			err.Error(),
+		).Res()
+	}
+
+	class, err := h.taxesUsecase.UpdateClass(req)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			errStatus(err),
+			string(updateClassErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, class).Res()
+}
+
+func (h *taxesHandler) DeleteClass(c *fiber.Ctx) error {
+	classId := strings.TrimSpace(c.Params("class_id"))
+
+	if err := h.taxesUsecase.DeleteClass(classId); err != nil {
+		return entities.NewResponse(c).Error(
+			errStatus(err),
+			string(deleteClassErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Success(fiber.StatusNoContent, nil).Res()
+}
+
+func (h *taxesHandler) FindZones(c *fiber.Ctx) error {
+	zones, err := h.taxesUsecase.FindZones()
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(findZonesErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, zones).Res()
+}
+
+func (h *taxesHandler) FindOneZone(c *fiber.Ctx) error {
+	zoneId := strings.TrimSpace(c.Params("zone_id"))
+
+	zone, err := h.taxesUsecase.FindOneZone(zoneId)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			errStatus(err),
+			string(findOneZoneErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, zone).Res()
+}
+
+func (h *taxesHandler) InsertZone(c *fiber.Ctx) error {
+	req := new(taxes.TaxZone)
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(insertZoneErr),
+			err.Error(),
+		).Res()
+	}
+	if err := req.Validate(); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(insertZoneErr),
+			err.Error(),
+		).Res()
+	}
+
+	zone, err := h.taxesUsecase.InsertZone(req)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			errStatus(err),
+			string(insertZoneErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Success(fiber.StatusCreated, zone).Res()
+}
+
+// UpdateZone replace title, country and region, rates are kept
+func (h *taxesHandler) UpdateZone(c *fiber.Ctx) error {
+	req := new(taxes.TaxZone)
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(updateZoneErr),
+			err.Error(),
+		).Res()
+	}
+	req.Id = strings.TrimSpace(c.Params("zone_id"))
+	if err := req.Validate(); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(updateZoneErr),
+			err.Error(),
+		).Res()
+	}
+
+	zone, err := h.taxesUsecase.UpdateZone(req)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			errStatus(err),
+			string(updateZoneErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, zone).Res()
+}
+
+func (h *taxesHandler) DeleteZone(c *fiber.Ctx) error {
+	zoneId := strings.TrimSpace(c.Params("zone_id"))
+
+	if err := h.taxesUsecase.DeleteZone(zoneId); err != nil {
+		return entities.NewResponse(c).Error(
+			errStatus(err),
+			string(deleteZoneErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Success(fiber.StatusNoContent, nil).Res()
+}
+
+// UpsertRate set rate of the class in zone, like {"rate": "7", "title": "VAT 7%"}
+func (h *taxesHandler) UpsertRate(c *fiber.Ctx) error {
+	req := new(taxes.TaxRate)
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(upsertRateErr),
+			err.Error(),
+		).Res()
+	}
+	req.ZoneId = strings.TrimSpace(c.Params("zone_id"))
+	req.ClassId = strings.TrimSpace(c.Params("class_id"))
+	if err := req.Validate(); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(upsertRateErr),
+			err.Error(),
+		).Res()
+	}
+
+	zone, err := h.taxesUsecase.UpsertRate(req)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			errStatus(err),
+			string(upsertRateErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, zone).Res()
+}
+
+func (h *taxesHandler) DeleteRate(c *fiber.Ctx) error {
+	zoneId := strings.TrimSpace(c.Params("zone_id"))
+	classId := strings.TrimSpace(c.Params("class_id"))
+
+	zone, err := h.taxesUsecase.DeleteRate(zoneId, classId)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			errStatus(err),
+			string(deleteRateErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, zone).Res()
+}
@@ -0,0 +1,305 @@
+package taxesRepositories
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/DrumPatiphon/go-rest-api-service/modules/taxes"
+	"github.com/jmoiron/sqlx"
+)
+
+type ITaxesRepository interface {
+	FindClasses() ([]*taxes.TaxClass, error)
+	InsertClass(req *taxes.TaxClass) error
+	UpdateClass(req *taxes.TaxClass) error
+	DeleteClass(classId string) error
+	FindZones() ([]*taxes.TaxZone, error)
+	FindOneZone(zoneId string) (*taxes.TaxZone, error)
+	InsertZone(req *taxes.TaxZone) error
+	UpdateZone(req *taxes.TaxZone) error
+	DeleteZone(zoneId string) error
+	UpsertRate(req *taxes.TaxRate) error
+	DeleteRate(zoneId, classId string) error
+	FindRates(address *taxes.Address) (map[string]*taxes.TaxRate, error)
+}
+
+type taxesRepository struct {
+	db *sqlx.DB
+}
+
+func TaxesRepository(db *sqlx.DB) ITaxesRepository {
+	return &taxesRepository{
+		db: db,
+	}
+}
+
+const classColumns = `
+		"id",
+		"title",
+		"created_at"::TEXT AS "created_at",
+		"updated_at"::TEXT AS "updated_at"`
+
+const rateColumns = `
+		"r"."id",
+		"r"."zone_id",
+		"r"."class_id",
+		"r"."title",
+		"r"."rate"::TEXT AS "rate",
+		"r"."created_at"::TEXT AS "created_at",
+		"r"."updated_at"::TEXT AS "updated_at"`
+
+func (r *taxesRepository) FindClasses() ([]*taxes.TaxClass, error) {
+	query := fmt.Sprintf(`
+	SELECT %s
+	FROM "tax_classes"
+	ORDER BY "id";`, classColumns)
+
+	classes := make([]*taxes.TaxClass, 0)
+	if err := r.db.Select(&classes, query); err != nil {
+		return nil, fmt.Errorf("get tax classes failed: %v", err)
+	}
+	return classes, nil
+}
+
+func (r *taxesRepository) InsertClass(req *taxes.TaxClass) error {
+	query := fmt.Sprintf(`
+	INSERT INTO "tax_classes" (
+		"id",
+		"title"
+	)
+	VALUES ($1, $2)
+		RETURNING %s;`, classColumns)
+
+	if err := r.db.Get(req, query, req.Id, req.Title); err != nil {
+		if strings.Contains(err.Error(), "tax_classes_pkey") {
+			return fmt.Errorf("tax class already exists")
+		}
+		return fmt.Errorf("insert tax class failed: %v", err)
+	}
+	return nil
+}
+
+func (r *taxesRepository) UpdateClass(req *taxes.TaxClass) error {
+	query := fmt.Sprintf(`
+	UPDATE "tax_classes" SET
+		"title" = $2
+	WHERE "id" = $1
+		RETURNING %s;`, classColumns)
+
+	if err := r.db.Get(req, query, req.Id, req.Title); err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("tax class not found")
+		}
+		return fmt.Errorf("update tax class failed: %v", err)
+	}
+	return nil
+}
+
+// DeleteClass fail when a product still has the class, products in trash too.
+// Rates of the class are removed by ON DELETE CASCADE
+func (r *taxesRepository) DeleteClass(classId string) error {
+	query := `
+	DELETE FROM "tax_classes" "c"
+	WHERE "c"."id" = $1
+	AND NOT EXISTS (SELECT 1 FROM "products" "p" WHERE "p"."tax_class_id" = "c"."id");`
+
+	result, err := r.db.ExecContext(context.Background(), query, classId)
+	if err != nil {
+		// product inserted with the class at the same time
+		if strings.Contains(err.Error(), "products_tax_class_id_fkey") {
+			return fmt.Errorf("tax class is used by products")
+		}
+		return fmt.Errorf("delete tax class failed: %v", err)
+	}
+	if rows, _ := result.RowsAffected(); rows > 0 {
+		return nil
+	}
+
+	var found bool
+	if err := r.db.Get(&found, `SELECT EXISTS (SELECT 1 FROM "tax_classes" WHERE "id" = $1);`, classId); err != nil {
+		return fmt.Errorf("find tax class failed: %v", err)
+	}
+	if found {
+		return fmt.Errorf("tax class is used by products")
+	}
+	return fmt.Errorf("tax class not found")
+}
+
+// zoneQuery return zones with their rates in json
+func zoneQuery(where string) string {
+	return fmt.Sprintf(`
+	SELECT
+		COALESCE(array_to_json(array_agg("t")), '[]'::json)
+	FROM (
+		SELECT
+			"z"."id",
+			"z"."title",
+			"z"."country",
+			COALESCE("z"."region", '') AS "region",
+			(
+				SELECT
+					COALESCE(array_to_json(array_agg("rt")), '[]'::json)
+				FROM (
+					SELECT %s
+					FROM "tax_rates" "r"
+					WHERE "r"."zone_id" = "z"."id"
+					ORDER BY "r"."class_id"
+				) AS "rt"
+			) AS "rates",
+			"z"."created_at",
+			"z"."updated_at"
+		FROM "tax_zones" "z"
+		WHERE %s
+		ORDER BY "z"."country", "z"."region" NULLS FIRST
+	) AS "t";`, rateColumns, where)
+}
+
+func (r *taxesRepository) findZones(where string, args ...any) ([]*taxes.TaxZone, error) {
+	zonesBytes := make([]byte, 0)
+	if err := r.db.Get(&zonesBytes, zoneQuery(where), args...); err != nil {
+		return nil, fmt.Errorf("get tax zones failed: %v", err)
+	}
+
+	zones := make([]*taxes.TaxZone, 0)
+	if err := json.Unmarshal(zonesBytes, &zones); err != nil {
+		return nil, fmt.Errorf("unmarshal tax zones failed: %v", err)
+	}
+	return zones, nil
+}
+
+func (r *taxesRepository) FindZones() ([]*taxes.TaxZone, error) {
+	return r.findZones("1 = 1")
+}
+
+func (r *taxesRepository) FindOneZone(zoneId string) (*taxes.TaxZone, error) {
+	zones, err := r.findZones(`"z"."id"::TEXT = $1`, zoneId)
+	if err != nil {
+		return nil, err
+	}
+	if len(zones) == 0 {
+		return nil, fmt.Errorf("tax zone not found")
+	}
+	return zones[0], nil
+}
+
+func (r *taxesRepository) InsertZone(req *taxes.TaxZone) error {
+	query := `
+	INSERT INTO "tax_zones" (
+		"title",
+		"country",
+		"region"
+	)
+	VALUES ($1, $2, NULLIF($3, ''))
+		RETURNING "id";`
+
+	if err := r.db.QueryRowContext(context.Background(), query, req.Title, req.Country, req.Region).Scan(&req.Id); err != nil {
+		if strings.Contains(err.Error(), "tax_zones_country_region_idx") {
+			return fmt.Errorf("tax zone already exists")
+		}
+		return fmt.Errorf("insert tax zone failed: %v", err)
+	}
+	return nil
+}
+
+func (r *taxesRepository) UpdateZone(req *taxes.TaxZone) error {
+	query := `
+	UPDATE "tax_zones" SET
+		"title" = $2,
+		"country" = $3,
+		"region" = NULLIF($4, '')
+	WHERE "id"::TEXT = $1;`
+
+	result, err := r.db.ExecContext(context.Background(), query, req.Id, req.Title, req.Country, req.Region)
+	if err != nil {
+		if strings.Contains(err.Error(), "tax_zones_country_region_idx") {
+			return fmt.Errorf("tax zone already exists")
+		}
+		return fmt.Errorf("update tax zone failed: %v", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("tax zone not found")
+	}
+	return nil
+}
+
+func (r *taxesRepository) DeleteZone(zoneId string) error {
+	result, err := r.db.ExecContext(context.Background(), `DELETE FROM "tax_zones" WHERE "id"::TEXT = $1;`, zoneId)
+	if err != nil {
+		return fmt.Errorf("delete tax zone failed: %v", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("tax zone not found")
+	}
+	return nil
+}
+
+// UpsertRate set rate of class in zone, a zone has one rate per class
+func (r *taxesRepository) UpsertRate(req *taxes.TaxRate) error {
+	query := fmt.Sprintf(`
+	INSERT INTO "tax_rates" AS "r" (
+		"zone_id",
+		"class_id",
+		"title",
+		"rate"
+	)
+	SELECT "z"."id", $2, $3, $4::NUMERIC
+	FROM "tax_zones" "z"
+	WHERE "z"."id"::TEXT = $1
+	ON CONFLICT ("zone_id", "class_id") DO UPDATE SET
+		"title" = EXCLUDED."title",
+		"rate" = EXCLUDED."rate"
+		RETURNING %s;`, rateColumns)
+
+	if err := r.db.Get(req, query, req.ZoneId, req.ClassId, req.Title, req.Rate); err != nil {
+		switch {
+		case err == sql.ErrNoRows:
+			return fmt.Errorf("tax zone not found")
+		case strings.Contains(err.Error(), "tax_rates_class_id_fkey"):
+			return fmt.Errorf("tax class not found")
+		}
+		return fmt.Errorf("upsert tax rate failed: %v", err)
+	}
+	return nil
+}
+
+func (r *taxesRepository) DeleteRate(zoneId, classId string) error {
+	result, err := r.db.ExecContext(context.Background(), `DELETE FROM "tax_rates" WHERE "zone_id"::TEXT = $1 AND "class_id" = $2;`, zoneId, classId)
+	if err != nil {
+		return fmt.Errorf("delete tax rate failed: %v", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("tax rate not found")
+	}
+	return nil
+}
+
+// FindRates return rates by class id of the zone of address, zone of the region is picked before zone of the country.
+// Address without zone has no rate, so nothing is taxed
+func (r *taxesRepository) FindRates(address *taxes.Address) (map[string]*taxes.TaxRate, error) {
+	query := fmt.Sprintf(`
+	SELECT %s
+	FROM "tax_rates" "r"
+	WHERE "r"."zone_id" = (
+		SELECT
+			"z"."id"
+		FROM "tax_zones" "z"
+		WHERE "z"."country" = $1
+		AND ("z"."region" IS NULL OR LOWER("z"."region") = LOWER($2))
+		ORDER BY "z"."region" NULLS LAST
+		LIMIT 1
+	);`, rateColumns)
+
+	ratesData := make([]*taxes.TaxRate, 0)
+	if err := r.db.Select(&ratesData, query, address.Country, address.Region); err != nil {
+		return nil, fmt.Errorf("get tax rates failed: %v", err)
+	}
+
+	rates := make(map[string]*taxes.TaxRate)
+	for _, rate := range ratesData {
+		rates[rate.ClassId] = rate
+	}
+	return rates, nil
+}
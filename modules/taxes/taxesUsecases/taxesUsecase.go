@@ -0,0 +1,110 @@
+package taxesUsecases
+
+import (
+	"github.com/DrumPatiphon/go-rest-api-service/config"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/taxes"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/taxes/taxesRepositories"
+)
+
+type ITaxesUsecase interface {
+	FindClasses() ([]*taxes.TaxClass, error)
+	InsertClass(req *taxes.TaxClass) (*taxes.TaxClass, error)
+	UpdateClass(req *taxes.TaxClass) (*taxes.TaxClass, error)
+	DeleteClass(classId string) error
+	FindZones() ([]*taxes.TaxZone, error)
+	FindOneZone(zoneId string) (*taxes.TaxZone, error)
+	InsertZone(req *taxes.TaxZone) (*taxes.TaxZone, error)
+	UpdateZone(req *taxes.TaxZone) (*taxes.TaxZone, error)
+	DeleteZone(zoneId string) error
+	UpsertRate(req *taxes.TaxRate) (*taxes.TaxZone, error)
+	DeleteRate(zoneId, classId string) (*taxes.TaxZone, error)
+	Calculate(address *taxes.Address, lines []*taxes.Line) (*taxes.Result, error)
+}
+
+type taxesUsecase struct {
+	cfg             config.Iconfig
+	taxesRepository taxesRepositories.ITaxesRepository
+}
+
+func TaxesUsecase(cfg config.Iconfig, taxesRepository taxesRepositories.ITaxesRepository) ITaxesUsecase {
+	return &taxesUsecase{
+		cfg:             cfg,
+		taxesRepository: taxesRepository,
+	}
+}
+
+func (u *taxesUsecase) FindClasses() ([]*taxes.TaxClass, error) {
+	return u.taxesRepository.FindClasses()
+}
+
+func (u *taxesUsecase) InsertClass(req *taxes.TaxClass) (*taxes.TaxClass, error) {
+	if err := u.taxesRepository.InsertClass(req); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+func (u *taxesUsecase) UpdateClass(req *taxes.TaxClass) (*taxes.TaxClass, error) {
+	if err := u.taxesRepository.UpdateClass(req); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+func (u *taxesUsecase) DeleteClass(classId string) error {
+	return u.taxesRepository.DeleteClass(classId)
+}
+
+func (u *taxesUsecase) FindZones() ([]*taxes.TaxZone, error) {
+	return u.taxesRepository.FindZones()
+}
+
+func (u *taxesUsecase) FindOneZone(zoneId string) (*taxes.TaxZone, error) {
+	return u.taxesRepository.FindOneZone(zoneId)
+}
+
+func (u *taxesUsecase) InsertZone(req *taxes.TaxZone) (*taxes.TaxZone, error) {
+	if err := u.taxesRepository.InsertZone(req); err != nil {
+		return nil, err
+	}
+	return u.taxesRepository.FindOneZone(req.Id)
+}
+
+func (u *taxesUsecase) UpdateZone(req *taxes.TaxZone) (*taxes.TaxZone, error) {
+	if err := u.taxesRepository.UpdateZone(req); err != nil {
+		return nil, err
+	}
+	return u.taxesRepository.FindOneZone(req.Id)
+}
+
+func (u *taxesUsecase) DeleteZone(zoneId string) error {
+	return u.taxesRepository.DeleteZone(zoneId)
+}
+
+// UpsertRate return the zone with every rate
+func (u *taxesUsecase) UpsertRate(req *taxes.TaxRate) (*taxes.TaxZone, error) {
+	if err := u.taxesRepository.UpsertRate(req); err != nil {
+		return nil, err
+	}
+	return u.taxesRepository.FindOneZone(req.ZoneId)
+}
+
+func (u *taxesUsecase) DeleteRate(zoneId, classId string) (*taxes.TaxZone, error) {
+	if err := u.taxesRepository.DeleteRate(zoneId, classId); err != nil {
+		return nil, err
+	}
+	return u.taxesRepository.FindOneZone(zoneId)
+}
+
+// Calculate tax of lines in zone of address, address without country is in the zone of TAX_COUNTRY
+func (u *taxesUsecase) Calculate(address *taxes.Address, lines []*taxes.Line) (*taxes.Result, error) {
+	if address == nil || address.Country == "" {
+		address = &taxes.Address{Country: u.cfg.Tax().Country()}
+	}
+
+	rates, err := u.taxesRepository.FindRates(address)
+	if err != nil {
+		return nil, err
+	}
+	return taxes.Calculate(rates, lines, u.cfg.Tax().PricesIncludeTax())
+}
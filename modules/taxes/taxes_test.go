@@ -0,0 +1,42 @@
+package taxes
+
+import "testing"
+
+func TestTax(t *testing.T) {
+	tests := []struct {
+		name      string
+		amount    int64
+		rate      string
+		inclusive bool
+		want      int64
+	}{
+		{name: "exact", amount: 10000, rate: "7", want: 700},
+		{name: "round down", amount: 107, rate: "7", want: 7},
+		{name: "half up", amount: 150, rate: "7", want: 11},
+		{name: "half up small", amount: 50, rate: "7", want: 4},
+		{name: "below half", amount: 21, rate: "7", want: 1},
+		{name: "half of one", amount: 5, rate: "10", want: 1},
+		{name: "below half of one", amount: 4, rate: "10", want: 0},
+		{name: "decimal rate", amount: 100, rate: "7.5", want: 8},
+		{name: "zero rate", amount: 10000, rate: "0", want: 0},
+		{name: "zero amount", amount: 0, rate: "7", want: 0},
+		{name: "negative half away from zero", amount: -150, rate: "7", want: -11},
+		{name: "inclusive exact", amount: 10700, rate: "7", inclusive: true, want: 700},
+		{name: "inclusive round up", amount: 100, rate: "7", inclusive: true, want: 7},
+		{name: "inclusive round down", amount: 1, rate: "7", inclusive: true, want: 0},
+		{name: "inclusive ten percent", amount: 11, rate: "10", inclusive: true, want: 1},
+		{name: "inclusive zero rate", amount: 10700, rate: "0", inclusive: true, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rate, err := ParseRate(tt.rate)
+			if err != nil {
+				t.Fatalf("ParseRate(%q) error = %v", tt.rate, err)
+			}
+			if got := Tax(tt.amount, rate, tt.inclusive); got != tt.want {
+				t.Errorf("Tax(%d, %s, %v) = %d, want %d", tt.amount, tt.rate, tt.inclusive, got, tt.want)
+			}
+		})
+	}
+}
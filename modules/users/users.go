@@ -21,8 +21,9 @@ type UserRegisterReq struct {
 }
 
 type UserCredential struct {
-	Email    string `db:"email" json:"email" form:"email"`
-	Password string `db:"password" json:"password" form:"password"`
+	Email     string `db:"email" json:"email" form:"email"`
+	Password  string `db:"password" json:"password" form:"password"`
+	CartToken string `db:"-" json:"cart_token" form:"cart_token"` // guest cart merged on sign in
 }
 
 type UserCredentialCheck struct {
@@ -4,6 +4,7 @@ import (
 	"strings"
 
 	"github.com/DrumPatiphon/go-rest-api-service/config"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/carts"
 	"github.com/DrumPatiphon/go-rest-api-service/modules/entities"
 	"github.com/DrumPatiphon/go-rest-api-service/modules/users"
 	"github.com/DrumPatiphon/go-rest-api-service/modules/users/usersUsecases"
@@ -172,6 +173,10 @@ func (h *usersHandler) SignIn(c *fiber.Ctx) error {
 		).Res()
 	}
 
+	if req.CartToken == "" {
+		req.CartToken = strings.TrimSpace(c.Get(carts.CartTokenHeader))
+	}
+
 	passport, err := h.usersUsecase.GetPassport(req)
 	if err != nil {
 		return entities.NewResponse(c).Error(
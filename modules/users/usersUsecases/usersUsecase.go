@@ -2,8 +2,10 @@ package usersUsecases
 
 import (
 	"fmt"
+	"log"
 
 	"github.com/DrumPatiphon/go-rest-api-service/config"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/carts/cartsUsecases"
 	"github.com/DrumPatiphon/go-rest-api-service/modules/users"
 	"github.com/DrumPatiphon/go-rest-api-service/modules/users/usersRepositories"
 	"github.com/DrumPatiphon/go-rest-api-service/pkg/serviceauth"
@@ -22,12 +24,14 @@ type IUserUsecases interface {
 type usersUsecases struct {
 	cfg             config.Iconfig
 	usersRepository usersRepositories.IUserRepository
+	cartsUsecase    cartsUsecases.ICartsUsecase
 }
 
-func UserUsecases(cfg config.Iconfig, usersRepository usersRepositories.IUserRepository) IUserUsecases {
+func UserUsecases(cfg config.Iconfig, usersRepository usersRepositories.IUserRepository, cartsUsecase cartsUsecases.ICartsUsecase) IUserUsecases {
 	return &usersUsecases{
 		cfg:             cfg,
 		usersRepository: usersRepository,
+		cartsUsecase:    cartsUsecase,
 	}
 }
 
@@ -100,6 +104,11 @@ func (u *usersUsecases) GetPassport(req *users.UserCredential) (*users.UserPassp
 	if err := u.usersRepository.InsertOauth(passport); err != nil {
 		return nil, err
 	}
+
+	// sign in should not fail because of the cart
+	if err := u.cartsUsecase.MergeCart(req.CartToken, user.Id); err != nil {
+		log.Printf("merge cart of user %s failed: %v", user.Id, err)
+	}
 	return passport, nil
 }
 
@@ -0,0 +1,20 @@
+package wishlists
+
+// StockSubscription is back in stock alert of user for a product
+type StockSubscription struct {
+	ProductId   string `db:"product_id" json:"product_id"`
+	Title       string `db:"title" json:"title"`
+	Slug        string `db:"slug" json:"slug"`
+	RestockedAt string `db:"restocked_at" json:"restocked_at,omitempty"`
+	NotifiedAt  string `db:"notified_at" json:"notified_at,omitempty"`
+	CreatedAt   string `db:"created_at" json:"created_at"`
+}
+
+// Restocked is an alert waiting to be sent
+type Restocked struct {
+	UserId    string `db:"user_id"`
+	Email     string `db:"email"`
+	ProductId string `db:"product_id"`
+	Title     string `db:"title"`
+	Slug      string `db:"slug"`
+}
@@ -0,0 +1,186 @@
+package wishlistsHandlers
+
+import (
+	"strings"
+
+	"github.com/DrumPatiphon/go-rest-api-service/config"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/entities"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/products"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/wishlists/wishlistsUsecases"
+	"github.com/gofiber/fiber/v2"
+)
+
+type wishlistsHandlerErrCode string
+
+const (
+	findWishlistErr      wishlistsHandlerErrCode = "wishlists-001"
+	addItemErr           wishlistsHandlerErrCode = "wishlists-002"
+	removeItemErr        wishlistsHandlerErrCode = "wishlists-003"
+	subscribeErr         wishlistsHandlerErrCode = "wishlists-004"
+	unsubscribeErr       wishlistsHandlerErrCode = "wishlists-005"
+	findSubscriptionsErr wishlistsHandlerErrCode = "wishlists-006"
+)
+
+type IWishlistsHandler interface {
+	FindWishlist(c *fiber.Ctx) error
+	AddItem(c *fiber.Ctx) error
+	RemoveItem(c *fiber.Ctx) error
+	Subscribe(c *fiber.Ctx) error
+	Unsubscribe(c *fiber.Ctx) error
+	FindSubscriptions(c *fiber.Ctx) error
+}
+
+type wishlistsHandler struct {
+	cfg              config.Iconfig
+	wishlistsUsecase wishlistsUsecases.IWishlistsUsecase
+}
+
+func WishlistsHandler(cfg config.Iconfig, wishlistsUsecase wishlistsUsecases.IWishlistsUsecase) IWishlistsHandler {
+	return &wishlistsHandler{
+		cfg:              cfg,
+		wishlistsUsecase: wishlistsUsecase,
+	}
+}
+
+// errStatus return status of known errors of wishlists usecase
+func errStatus(err error) int {
+	switch err.Error() {
+	case "product not found", "product not found in wishlist", "subscription not found":
+		return fiber.ErrNotFound.Code
+	case "product is in stock":
+		return fiber.ErrBadRequest.Code
+	default:
+		return fiber.ErrInternalServerError.Code
+	}
+}
+
+// parseFilter read page, limit, sort, currency and lang like FindProduct, the wishlist is of the token user
+func (h *wishlistsHandler) parseFilter(c *fiber.Ctx) (*products.ProductFilter, error) {
+	req := &products.ProductFilter{
+		PaginationReq: &entities.PaginationReq{},
+		SortReq:       &entities.SortReq{},
+	}
+	if err := c.QueryParser(req); err != nil {
+		return nil, err
+	}
+
+	userId, _ := c.Locals("userId").(string)
+	view, err := products.NewViewReq(req.Currency, req.Locale, c.Get(fiber.HeaderAcceptLanguage), h.cfg.App().Locale(), userId)
+	if err != nil {
+		return nil, err
+	}
+	req.ProductViewReq = *view
+
+	// only wishlist filter is used
+	req.Id = ""
+	req.Search = ""
+	req.CategoryIds = nil
+	if req.Page < 1 {
+		req.Page = 1
+	}
+	if req.Limit < 5 {
+		req.Limit = 5
+	}
+	return req, nil
+}
+
+func (h *wishlistsHandler) FindWishlist(c *fiber.Ctx) error {
+	req, err := h.parseFilter(c)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(findWishlistErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, h.wishlistsUsecase.FindWishlist(req)).Res()
+}
+
+// AddItem return the wishlist like FindWishlist
+func (h *wishlistsHandler) AddItem(c *fiber.Ctx) error {
+	req, err := h.parseFilter(c)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(addItemErr),
+			err.Error(),
+		).Res()
+	}
+	productId := strings.TrimSpace(c.Params("product_id"))
+
+	wishlist, err := h.wishlistsUsecase.AddItem(productId, req)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			errStatus(err),
+			string(addItemErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Success(fiber.StatusCreated, wishlist).Res()
+}
+
+func (h *wishlistsHandler) RemoveItem(c *fiber.Ctx) error {
+	req, err := h.parseFilter(c)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(removeItemErr),
+			err.Error(),
+		).Res()
+	}
+	productId := strings.TrimSpace(c.Params("product_id"))
+
+	wishlist, err := h.wishlistsUsecase.RemoveItem(productId, req)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			errStatus(err),
+			string(removeItemErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, wishlist).Res()
+}
+
+// Subscribe to back in stock alert of product which is out of stock
+func (h *wishlistsHandler) Subscribe(c *fiber.Ctx) error {
+	userId, _ := c.Locals("userId").(string)
+	productId := strings.TrimSpace(c.Params("product_id"))
+
+	subscriptions, err := h.wishlistsUsecase.Subscribe(userId, productId)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			errStatus(err),
+			string(subscribeErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Success(fiber.StatusCreated, subscriptions).Res()
+}
+
+func (h *wishlistsHandler) Unsubscribe(c *fiber.Ctx) error {
+	userId, _ := c.Locals("userId").(string)
+	productId := strings.TrimSpace(c.Params("product_id"))
+
+	if err := h.wishlistsUsecase.Unsubscribe(userId, productId); err != nil {
+		return entities.NewResponse(c).Error(
+			errStatus(err),
+			string(unsubscribeErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Success(fiber.StatusNoContent, nil).Res()
+}
+
+func (h *wishlistsHandler) FindSubscriptions(c *fiber.Ctx) error {
+	userId, _ := c.Locals("userId").(string)
+
+	subscriptions, err := h.wishlistsUsecase.FindSubscriptions(userId)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(findSubscriptionsErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, subscriptions).Res()
+}
@@ -0,0 +1,173 @@
+package wishlistsRepositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/DrumPatiphon/go-rest-api-service/modules/wishlists"
+	"github.com/jmoiron/sqlx"
+)
+
+type IWishlistsRepository interface {
+	AddItem(userId, productId string) error
+	RemoveItem(userId, productId string) error
+	Subscribe(userId, productId string) error
+	Unsubscribe(userId, productId string) error
+	FindSubscriptions(userId string) ([]*wishlists.StockSubscription, error)
+	FindRestocked(limit int) ([]*wishlists.Restocked, error)
+	MarkNotified(userId, productId string) error
+}
+
+type wishlistsRepository struct {
+	db *sqlx.DB
+}
+
+func WishlistsRepository(db *sqlx.DB) IWishlistsRepository {
+	return &wishlistsRepository{
+		db: db,
+	}
+}
+
+// AddItem only add published product, adding it again keep the first time
+func (r *wishlistsRepository) AddItem(userId, productId string) error {
+	query := `
+	INSERT INTO "wishlists" (
+		"user_id",
+		"product_id"
+	)
+	SELECT $1, "p"."id"
+	FROM "products" "p"
+	WHERE "p"."id" = $2
+	AND "p"."deleted_at" IS NULL
+	AND "p"."status" = 'published'
+	ON CONFLICT ("user_id", "product_id") DO UPDATE SET
+		"created_at" = "wishlists"."created_at";`
+
+	result, err := r.db.ExecContext(context.Background(), query, userId, productId)
+	if err != nil {
+		return fmt.Errorf("add product to wishlist failed: %v", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("product not found")
+	}
+	return nil
+}
+
+func (r *wishlistsRepository) RemoveItem(userId, productId string) error {
+	result, err := r.db.ExecContext(context.Background(), `DELETE FROM "wishlists" WHERE "user_id" = $1 AND "product_id" = $2;`, userId, productId)
+	if err != nil {
+		return fmt.Errorf("remove product from wishlist failed: %v", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("product not found in wishlist")
+	}
+	return nil
+}
+
+// Subscribe only product which is out of stock, subscribing again start a new alert
+func (r *wishlistsRepository) Subscribe(userId, productId string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*15)
+	defer cancel()
+
+	var stock sql.NullInt64
+	if err := r.db.QueryRowContext(
+		ctx,
+		`SELECT "stock" FROM "products" WHERE "id" = $1 AND "deleted_at" IS NULL AND "status" = 'published';`,
+		productId,
+	).Scan(&stock); err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("product not found")
+		}
+		return fmt.Errorf("get stock failed: %v", err)
+	}
+	if !stock.Valid || stock.Int64 > 0 {
+		return fmt.Errorf("product is in stock")
+	}
+
+	query := `
+	INSERT INTO "stock_subscriptions" (
+		"user_id",
+		"product_id"
+	)
+	VALUES ($1, $2)
+	ON CONFLICT ("user_id", "product_id") DO UPDATE SET
+		"restocked_at" = NULL,
+		"notified_at" = NULL,
+		"created_at" = now();`
+
+	if _, err := r.db.ExecContext(ctx, query, userId, productId); err != nil {
+		return fmt.Errorf("subscribe product failed: %v", err)
+	}
+	return nil
+}
+
+func (r *wishlistsRepository) Unsubscribe(userId, productId string) error {
+	result, err := r.db.ExecContext(context.Background(), `DELETE FROM "stock_subscriptions" WHERE "user_id" = $1 AND "product_id" = $2;`, userId, productId)
+	if err != nil {
+		return fmt.Errorf("unsubscribe product failed: %v", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("subscription not found")
+	}
+	return nil
+}
+
+func (r *wishlistsRepository) FindSubscriptions(userId string) ([]*wishlists.StockSubscription, error) {
+	query := `
+	SELECT
+		"s"."product_id",
+		"p"."title",
+		"p"."slug",
+		COALESCE("s"."restocked_at"::TEXT, '') AS "restocked_at",
+		COALESCE("s"."notified_at"::TEXT, '') AS "notified_at",
+		"s"."created_at"::TEXT AS "created_at"
+	FROM "stock_subscriptions" "s"
+		JOIN "products" "p" ON "p"."id" = "s"."product_id"
+	WHERE "s"."user_id" = $1
+	ORDER BY "s"."created_at" DESC;`
+
+	subscriptions := make([]*wishlists.StockSubscription, 0)
+	if err := r.db.Select(&subscriptions, query, userId); err != nil {
+		return nil, fmt.Errorf("get subscriptions failed: %v", err)
+	}
+	return subscriptions, nil
+}
+
+// FindRestocked return alerts waiting to be sent, oldest first
+func (r *wishlistsRepository) FindRestocked(limit int) ([]*wishlists.Restocked, error) {
+	query := `
+	SELECT
+		"s"."user_id",
+		"u"."email",
+		"s"."product_id",
+		"p"."title",
+		"p"."slug"
+	FROM "stock_subscriptions" "s"
+		JOIN "users" "u" ON "u"."id" = "s"."user_id"
+		JOIN "products" "p" ON "p"."id" = "s"."product_id"
+	WHERE "s"."restocked_at" IS NOT NULL
+	AND "s"."notified_at" IS NULL
+	ORDER BY "s"."restocked_at"
+	LIMIT $1;`
+
+	restocked := make([]*wishlists.Restocked, 0)
+	if err := r.db.Select(&restocked, query, limit); err != nil {
+		return nil, fmt.Errorf("get restocked subscriptions failed: %v", err)
+	}
+	return restocked, nil
+}
+
+func (r *wishlistsRepository) MarkNotified(userId, productId string) error {
+	query := `
+	UPDATE "stock_subscriptions" SET
+		"notified_at" = now()
+	WHERE "user_id" = $1
+	AND "product_id" = $2;`
+
+	if _, err := r.db.ExecContext(context.Background(), query, userId, productId); err != nil {
+		return fmt.Errorf("mark subscription notified failed: %v", err)
+	}
+	return nil
+}
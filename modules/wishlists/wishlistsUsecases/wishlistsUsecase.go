@@ -0,0 +1,111 @@
+package wishlistsUsecases
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/DrumPatiphon/go-rest-api-service/modules/entities"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/products"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/products/productsUsecases"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/wishlists"
+	"github.com/DrumPatiphon/go-rest-api-service/modules/wishlists/wishlistsRepositories"
+	"github.com/DrumPatiphon/go-rest-api-service/pkg/notify"
+)
+
+// notifyBatch is max alerts sent in one run of the job
+const notifyBatch = 100
+
+type IWishlistsUsecase interface {
+	FindWishlist(req *products.ProductFilter) *entities.PageRes
+	AddItem(productId string, req *products.ProductFilter) (*entities.PageRes, error)
+	RemoveItem(productId string, req *products.ProductFilter) (*entities.PageRes, error)
+	Subscribe(userId, productId string) ([]*wishlists.StockSubscription, error)
+	Unsubscribe(userId, productId string) error
+	FindSubscriptions(userId string) ([]*wishlists.StockSubscription, error)
+	NotifyRestocked() error
+}
+
+type wishlistsUsecase struct {
+	wishlistsRepository wishlistsRepositories.IWishlistsRepository
+	productsUsecase     productsUsecases.IProductUseCase
+	notifier            notify.Notifier
+}
+
+func WishlistsUsecase(wishlistsRepository wishlistsRepositories.IWishlistsRepository, productsUsecase productsUsecases.IProductUseCase, notifier notify.Notifier) IWishlistsUsecase {
+	return &wishlistsUsecase{
+		wishlistsRepository: wishlistsRepository,
+		productsUsecase:     productsUsecase,
+		notifier:            notifier,
+	}
+}
+
+// FindWishlist return published products in wishlist of the caller, like FindProduct
+func (u *wishlistsUsecase) FindWishlist(req *products.ProductFilter) *entities.PageRes {
+	req.WishlistOf = req.UserId
+	req.Status = "published"
+	return u.productsUsecase.FindProduct(req)
+}
+
+// AddItem return the wishlist after adding
+func (u *wishlistsUsecase) AddItem(productId string, req *products.ProductFilter) (*entities.PageRes, error) {
+	if err := u.wishlistsRepository.AddItem(req.UserId, productId); err != nil {
+		return nil, err
+	}
+	return u.FindWishlist(req), nil
+}
+
+func (u *wishlistsUsecase) RemoveItem(productId string, req *products.ProductFilter) (*entities.PageRes, error) {
+	if err := u.wishlistsRepository.RemoveItem(req.UserId, productId); err != nil {
+		return nil, err
+	}
+	return u.FindWishlist(req), nil
+}
+
+// Subscribe return every subscription of user
+func (u *wishlistsUsecase) Subscribe(userId, productId string) ([]*wishlists.StockSubscription, error) {
+	if err := u.wishlistsRepository.Subscribe(userId, productId); err != nil {
+		return nil, err
+	}
+	return u.wishlistsRepository.FindSubscriptions(userId)
+}
+
+func (u *wishlistsUsecase) Unsubscribe(userId, productId string) error {
+	return u.wishlistsRepository.Unsubscribe(userId, productId)
+}
+
+func (u *wishlistsUsecase) FindSubscriptions(userId string) ([]*wishlists.StockSubscription, error) {
+	return u.wishlistsRepository.FindSubscriptions(userId)
+}
+
+// NotifyRestocked send alerts of products which are back in stock, failed alert is tried again in next run
+func (u *wishlistsUsecase) NotifyRestocked() error {
+	restocked, err := u.wishlistsRepository.FindRestocked(notifyBatch)
+	if err != nil {
+		return err
+	}
+
+	for _, r := range restocked {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second*15)
+		err := u.notifier.Notify(ctx, &notify.Message{
+			UserId:   r.UserId,
+			Email:    r.Email,
+			Template: notify.TemplateBackInStock,
+			Data: map[string]any{
+				"product_id": r.ProductId,
+				"title":      r.Title,
+				"slug":       r.Slug,
+			},
+		})
+		cancel()
+		if err != nil {
+			log.Printf("notify back in stock of %s to %s with %s failed: %v", r.ProductId, r.UserId, u.notifier.Name(), err)
+			continue
+		}
+
+		if err := u.wishlistsRepository.MarkNotified(r.UserId, r.ProductId); err != nil {
+			return err
+		}
+	}
+	return nil
+}
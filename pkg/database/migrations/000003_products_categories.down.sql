@@ -0,0 +1,5 @@
+BEGIN;
+
+ALTER TABLE "products_categories" DROP CONSTRAINT IF EXISTS "products_categories_product_id_category_id_key";
+
+COMMIT;
@@ -0,0 +1,12 @@
+BEGIN;
+
+--Remove duplicated category of the same product before adding unique key
+DELETE FROM "products_categories" "a"
+    USING "products_categories" "b"
+WHERE "a"."product_id" = "b"."product_id"
+AND "a"."category_id" = "b"."category_id"
+AND "a"."id" > "b"."id";
+
+ALTER TABLE "products_categories" ADD CONSTRAINT "products_categories_product_id_category_id_key" UNIQUE ("product_id", "category_id");
+
+COMMIT;
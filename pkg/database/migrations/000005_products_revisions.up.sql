@@ -0,0 +1,17 @@
+BEGIN;
+
+CREATE TABLE "products_revisions" (
+  "id" uuid NOT NULL UNIQUE PRIMARY KEY DEFAULT uuid_generate_v4(),
+  "product_id" VARCHAR NOT NULL,
+  "revision" INT NOT NULL,
+  "user_id" VARCHAR,
+  "snapshot" jsonb NOT NULL,
+  "created_at" TIMESTAMP NOT NULL DEFAULT now(),
+  UNIQUE ("product_id", "revision")
+);
+
+ALTER TABLE "products_revisions" ADD FOREIGN KEY ("product_id") REFERENCES "products" ("id") ON DELETE CASCADE;
+--Revisions stay when the user who made them is deleted
+ALTER TABLE "products_revisions" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE SET NULL;
+
+COMMIT;
@@ -0,0 +1,9 @@
+BEGIN;
+
+DROP TRIGGER IF EXISTS set_updated_at_timestamp_products_import_jobs_table ON "products_import_jobs";
+
+DROP TABLE IF EXISTS "products_import_jobs" CASCADE;
+
+DROP TYPE IF EXISTS "import_status";
+
+COMMIT;
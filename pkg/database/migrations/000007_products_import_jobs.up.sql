@@ -0,0 +1,26 @@
+BEGIN;
+
+CREATE TYPE "import_status" AS ENUM (
+    'running',
+    'completed',
+    'failed'
+);
+
+CREATE TABLE "products_import_jobs" (
+  "id" uuid NOT NULL UNIQUE PRIMARY KEY DEFAULT uuid_generate_v4(),
+  "user_id" VARCHAR NOT NULL,
+  "status" import_status NOT NULL,
+  "dry_run" BOOLEAN NOT NULL DEFAULT FALSE,
+  "total_rows" INT NOT NULL DEFAULT 0,
+  "valid_rows" INT NOT NULL DEFAULT 0,
+  "inserted_rows" INT NOT NULL DEFAULT 0,
+  "row_errors" jsonb NOT NULL DEFAULT '[]'::jsonb,
+  "created_at" TIMESTAMP NOT NULL DEFAULT now(),
+  "updated_at" TIMESTAMP NOT NULL DEFAULT now()
+);
+
+ALTER TABLE "products_import_jobs" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+
+CREATE TRIGGER set_updated_at_timestamp_products_import_jobs_table BEFORE UPDATE ON "products_import_jobs" FOR EACH ROW EXECUTE PROCEDURE set_updated_at_column();
+
+COMMIT;
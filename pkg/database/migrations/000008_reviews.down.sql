@@ -0,0 +1,15 @@
+BEGIN;
+
+DROP TRIGGER IF EXISTS set_products_rating_reviews_table ON "reviews";
+DROP TRIGGER IF EXISTS set_updated_at_timestamp_reviews_table ON "reviews";
+
+DROP FUNCTION IF EXISTS set_products_rating();
+
+ALTER TABLE "products" DROP COLUMN IF EXISTS "rating_average";
+ALTER TABLE "products" DROP COLUMN IF EXISTS "review_count";
+
+DROP TABLE IF EXISTS "reviews" CASCADE;
+
+DROP TYPE IF EXISTS "review_status";
+
+COMMIT;
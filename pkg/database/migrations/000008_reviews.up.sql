@@ -0,0 +1,58 @@
+BEGIN;
+
+CREATE TYPE "review_status" AS ENUM (
+    'pending',
+    'approved',
+    'hidden'
+);
+
+CREATE TABLE "reviews" (
+  "id" uuid NOT NULL UNIQUE PRIMARY KEY DEFAULT uuid_generate_v4(),
+  "product_id" VARCHAR NOT NULL,
+  "user_id" VARCHAR NOT NULL,
+  "rating" INT NOT NULL CHECK ("rating" BETWEEN 1 AND 5),
+  "comment" VARCHAR NOT NULL DEFAULT '',
+  "status" review_status NOT NULL DEFAULT 'pending',
+  "reply" VARCHAR,
+  "replied_at" TIMESTAMP,
+  "created_at" TIMESTAMP NOT NULL DEFAULT now(),
+  "updated_at" TIMESTAMP NOT NULL DEFAULT now(),
+  UNIQUE ("product_id", "user_id")
+);
+
+ALTER TABLE "reviews" ADD FOREIGN KEY ("product_id") REFERENCES "products" ("id") ON DELETE CASCADE;
+ALTER TABLE "reviews" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+
+CREATE TRIGGER set_updated_at_timestamp_reviews_table BEFORE UPDATE ON "reviews" FOR EACH ROW EXECUTE PROCEDURE set_updated_at_column();
+
+--Rating summary of approved reviews, kept by trigger so reading products don't count reviews
+ALTER TABLE "products" ADD COLUMN "rating_average" NUMERIC(3, 2) NOT NULL DEFAULT 0;
+ALTER TABLE "products" ADD COLUMN "review_count" INT NOT NULL DEFAULT 0;
+
+CREATE OR REPLACE FUNCTION set_products_rating()
+RETURNS TRIGGER AS $$
+DECLARE
+    target_product_id VARCHAR;
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        target_product_id = OLD.product_id;
+    ELSE
+        target_product_id = NEW.product_id;
+    END IF;
+
+    UPDATE "products" SET
+        "rating_average" = COALESCE((
+            SELECT ROUND(AVG("rating"), 2) FROM "reviews" WHERE "product_id" = target_product_id AND "status" = 'approved'
+        ), 0),
+        "review_count" = (
+            SELECT COUNT(*) FROM "reviews" WHERE "product_id" = target_product_id AND "status" = 'approved'
+        )
+    WHERE "id" = target_product_id;
+
+    RETURN NULL;
+END;
+$$ language 'plpgsql';
+
+CREATE TRIGGER set_products_rating_reviews_table AFTER INSERT OR UPDATE OF "rating", "status" OR DELETE ON "reviews" FOR EACH ROW EXECUTE PROCEDURE set_products_rating();
+
+COMMIT;
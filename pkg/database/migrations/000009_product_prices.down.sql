@@ -0,0 +1,14 @@
+BEGIN;
+
+ALTER TABLE "products" ADD COLUMN "price" FLOAT NOT NULL DEFAULT 0;
+
+UPDATE "products" "p" SET
+    "price" = "pp"."amount"::FLOAT / 100
+FROM "product_prices" "pp"
+WHERE "pp"."product_id" = "p"."id"
+AND "pp"."currency" = 'THB';
+
+DROP TRIGGER IF EXISTS set_updated_at_timestamp_product_prices_table ON "product_prices";
+DROP TABLE IF EXISTS "product_prices";
+
+COMMIT;
@@ -0,0 +1,27 @@
+BEGIN;
+
+--Price in minor units (satang, cent) per currency, a product can be sold in many currencies
+CREATE TABLE "product_prices" (
+  "product_id" VARCHAR NOT NULL,
+  "currency" VARCHAR(3) NOT NULL,
+  "amount" BIGINT NOT NULL CHECK ("amount" >= 0),
+  "created_at" TIMESTAMP NOT NULL DEFAULT now(),
+  "updated_at" TIMESTAMP NOT NULL DEFAULT now(),
+  PRIMARY KEY ("product_id", "currency")
+);
+
+ALTER TABLE "product_prices" ADD FOREIGN KEY ("product_id") REFERENCES "products" ("id") ON DELETE CASCADE;
+
+CREATE TRIGGER set_updated_at_timestamp_product_prices_table BEFORE UPDATE ON "product_prices" FOR EACH ROW EXECUTE PROCEDURE set_updated_at_column();
+
+--Old prices are baht, keep APP_CURRENCY=THB
+INSERT INTO "product_prices" ("product_id", "currency", "amount")
+SELECT
+    "id",
+    'THB',
+    ROUND("price"::NUMERIC * 100)::BIGINT
+FROM "products";
+
+ALTER TABLE "products" DROP COLUMN "price";
+
+COMMIT;
@@ -0,0 +1,14 @@
+BEGIN;
+
+DROP FUNCTION IF EXISTS product_effective_price(VARCHAR, VARCHAR, VARCHAR);
+
+DROP TRIGGER IF EXISTS set_updated_at_timestamp_product_special_prices_table ON "product_special_prices";
+DROP TRIGGER IF EXISTS set_updated_at_timestamp_price_lists_table ON "price_lists";
+
+DROP TABLE IF EXISTS "product_special_prices";
+
+ALTER TABLE "users" DROP COLUMN IF EXISTS "price_list_id";
+
+DROP TABLE IF EXISTS "price_lists";
+
+COMMIT;
@@ -0,0 +1,63 @@
+BEGIN;
+
+CREATE TABLE "price_lists" (
+  "id" SERIAL PRIMARY KEY,
+  "title" VARCHAR NOT NULL UNIQUE,
+  "created_at" TIMESTAMP NOT NULL DEFAULT now(),
+  "updated_at" TIMESTAMP NOT NULL DEFAULT now()
+);
+
+--User without price list get the regular and sale prices only
+ALTER TABLE "users" ADD COLUMN "price_list_id" INT;
+ALTER TABLE "users" ADD FOREIGN KEY ("price_list_id") REFERENCES "price_lists" ("id") ON DELETE SET NULL;
+
+--Special price is a sale price for everyone when price_list_id is NULL, otherwise for users of the price list.
+--NULL starts_at or ends_at is no limit.
+CREATE TABLE "product_special_prices" (
+  "id" uuid NOT NULL UNIQUE PRIMARY KEY DEFAULT uuid_generate_v4(),
+  "product_id" VARCHAR NOT NULL,
+  "price_list_id" INT,
+  "currency" VARCHAR(3) NOT NULL,
+  "amount" BIGINT NOT NULL CHECK ("amount" >= 0),
+  "starts_at" TIMESTAMP,
+  "ends_at" TIMESTAMP,
+  "created_at" TIMESTAMP NOT NULL DEFAULT now(),
+  "updated_at" TIMESTAMP NOT NULL DEFAULT now(),
+  CHECK ("starts_at" IS NULL OR "ends_at" IS NULL OR "starts_at" < "ends_at")
+);
+
+ALTER TABLE "product_special_prices" ADD FOREIGN KEY ("product_id", "currency") REFERENCES "product_prices" ("product_id", "currency") ON DELETE CASCADE;
+ALTER TABLE "product_special_prices" ADD FOREIGN KEY ("price_list_id") REFERENCES "price_lists" ("id") ON DELETE CASCADE;
+
+CREATE INDEX "product_special_prices_product_id_currency_idx" ON "product_special_prices" ("product_id", "currency");
+
+CREATE TRIGGER set_updated_at_timestamp_price_lists_table BEFORE UPDATE ON "price_lists" FOR EACH ROW EXECUTE PROCEDURE set_updated_at_column();
+CREATE TRIGGER set_updated_at_timestamp_product_special_prices_table BEFORE UPDATE ON "product_special_prices" FOR EACH ROW EXECUTE PROCEDURE set_updated_at_column();
+
+--Lowest of regular price and special prices running now for the user, empty user is anonymous
+CREATE OR REPLACE FUNCTION product_effective_price(target_product_id VARCHAR, target_currency VARCHAR, target_user_id VARCHAR)
+RETURNS BIGINT AS $$
+    SELECT
+        MIN("prices"."amount")
+    FROM (
+        SELECT
+            "pp"."amount"
+        FROM "product_prices" "pp"
+        WHERE "pp"."product_id" = target_product_id
+        AND "pp"."currency" = target_currency
+        UNION ALL
+        SELECT
+            "sp"."amount"
+        FROM "product_special_prices" "sp"
+        WHERE "sp"."product_id" = target_product_id
+        AND "sp"."currency" = target_currency
+        AND ("sp"."starts_at" IS NULL OR "sp"."starts_at" <= now())
+        AND ("sp"."ends_at" IS NULL OR "sp"."ends_at" > now())
+        AND (
+            "sp"."price_list_id" IS NULL
+            OR "sp"."price_list_id" = (SELECT "u"."price_list_id" FROM "users" "u" WHERE "u"."id" = target_user_id)
+        )
+    ) AS "prices";
+$$ LANGUAGE sql STABLE;
+
+COMMIT;
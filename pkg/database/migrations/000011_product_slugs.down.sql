@@ -0,0 +1,8 @@
+BEGIN;
+
+DROP TABLE IF EXISTS "products_slugs";
+
+ALTER TABLE "products" DROP CONSTRAINT IF EXISTS "products_slug_key";
+ALTER TABLE "products" DROP COLUMN IF EXISTS "slug";
+
+COMMIT;
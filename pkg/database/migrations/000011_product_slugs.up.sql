@@ -0,0 +1,32 @@
+BEGIN;
+
+ALTER TABLE "products" ADD COLUMN "slug" VARCHAR;
+
+--Slug of old products from title, Thai titles fall back to product id until renamed
+WITH "s" AS (
+    SELECT
+        "id",
+        COALESCE(NULLIF(TRIM(BOTH '-' FROM REGEXP_REPLACE(LOWER("title"), '[^a-z0-9]+', '-', 'g')), ''), LOWER("id")) AS "base"
+    FROM "products"
+)
+UPDATE "products" "p" SET
+    "slug" = CASE
+        WHEN (SELECT COUNT(*) FROM "s" "o" WHERE "o"."base" = "s"."base" AND "o"."id" < "s"."id") = 0 THEN "s"."base"
+        ELSE "s"."base" || '-' || LOWER("s"."id")
+    END
+FROM "s"
+WHERE "s"."id" = "p"."id";
+
+ALTER TABLE "products" ALTER COLUMN "slug" SET NOT NULL;
+ALTER TABLE "products" ADD CONSTRAINT "products_slug_key" UNIQUE ("slug");
+
+--Old slugs of renamed products, kept for redirect
+CREATE TABLE "products_slugs" (
+  "slug" VARCHAR PRIMARY KEY,
+  "product_id" VARCHAR NOT NULL,
+  "created_at" TIMESTAMP NOT NULL DEFAULT now()
+);
+
+ALTER TABLE "products_slugs" ADD FOREIGN KEY ("product_id") REFERENCES "products" ("id") ON DELETE CASCADE;
+
+COMMIT;
@@ -0,0 +1,6 @@
+BEGIN;
+
+DROP TABLE IF EXISTS "products_translations";
+DROP TABLE IF EXISTS "categories_translations";
+
+COMMIT;
@@ -0,0 +1,34 @@
+BEGIN;
+
+--Title and description of products and categories tables are in APP_LOCALE, other locales are here.
+--dictionary is the text search config of the locale, set by the app
+CREATE TABLE "products_translations" (
+  "product_id" VARCHAR NOT NULL,
+  "locale" VARCHAR(8) NOT NULL,
+  "title" VARCHAR NOT NULL,
+  "description" VARCHAR NOT NULL DEFAULT '',
+  "dictionary" regconfig NOT NULL DEFAULT 'simple',
+  "search" tsvector GENERATED ALWAYS AS (to_tsvector("dictionary", "title" || ' ' || "description")) STORED,
+  "created_at" TIMESTAMP NOT NULL DEFAULT now(),
+  "updated_at" TIMESTAMP NOT NULL DEFAULT now(),
+  PRIMARY KEY ("product_id", "locale")
+);
+
+CREATE TABLE "categories_translations" (
+  "category_id" INT NOT NULL,
+  "locale" VARCHAR(8) NOT NULL,
+  "title" VARCHAR NOT NULL,
+  "created_at" TIMESTAMP NOT NULL DEFAULT now(),
+  "updated_at" TIMESTAMP NOT NULL DEFAULT now(),
+  PRIMARY KEY ("category_id", "locale")
+);
+
+ALTER TABLE "products_translations" ADD FOREIGN KEY ("product_id") REFERENCES "products" ("id") ON DELETE CASCADE;
+ALTER TABLE "categories_translations" ADD FOREIGN KEY ("category_id") REFERENCES "categories" ("id") ON DELETE CASCADE;
+
+CREATE INDEX "products_translations_search_idx" ON "products_translations" USING GIN ("search");
+
+CREATE TRIGGER set_updated_at_timestamp_products_translations_table BEFORE UPDATE ON "products_translations" FOR EACH ROW EXECUTE PROCEDURE set_updated_at_column();
+CREATE TRIGGER set_updated_at_timestamp_categories_translations_table BEFORE UPDATE ON "categories_translations" FOR EACH ROW EXECUTE PROCEDURE set_updated_at_column();
+
+COMMIT;
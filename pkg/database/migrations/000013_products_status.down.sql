@@ -0,0 +1,9 @@
+BEGIN;
+
+DROP INDEX IF EXISTS "products_status_publish_at_idx";
+
+ALTER TABLE "products" DROP COLUMN IF EXISTS "publish_at";
+ALTER TABLE "products" DROP CONSTRAINT IF EXISTS "products_status_check";
+ALTER TABLE "products" DROP COLUMN IF EXISTS "status";
+
+COMMIT;
@@ -0,0 +1,14 @@
+BEGIN;
+
+--draft is hidden from public, draft with publish_at is scheduled and published by the app on time.
+--Existing products are already public, new ones start as draft.
+ALTER TABLE "products" ADD COLUMN "status" VARCHAR NOT NULL DEFAULT 'published';
+ALTER TABLE "products" ADD CONSTRAINT "products_status_check" CHECK ("status" IN ('draft', 'published', 'archived'));
+ALTER TABLE "products" ALTER COLUMN "status" SET DEFAULT 'draft';
+
+ALTER TABLE "products" ADD COLUMN "publish_at" TIMESTAMP;
+UPDATE "products" SET "publish_at" = "created_at";
+
+CREATE INDEX "products_status_publish_at_idx" ON "products" ("status", "publish_at");
+
+COMMIT;
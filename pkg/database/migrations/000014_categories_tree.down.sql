@@ -0,0 +1,7 @@
+BEGIN;
+
+DROP INDEX IF EXISTS "categories_parent_id_idx";
+
+ALTER TABLE "categories" DROP COLUMN IF EXISTS "parent_id";
+
+COMMIT;
@@ -0,0 +1,11 @@
+BEGIN;
+
+--NULL parent_id is a root category, children of a deleted category become roots.
+--Cycles are checked by the app on update.
+ALTER TABLE "categories" ADD COLUMN "parent_id" INT;
+ALTER TABLE "categories" ADD FOREIGN KEY ("parent_id") REFERENCES "categories" ("id") ON DELETE SET NULL;
+ALTER TABLE "categories" ADD CONSTRAINT "categories_parent_id_check" CHECK ("parent_id" <> "id");
+
+CREATE INDEX "categories_parent_id_idx" ON "categories" ("parent_id");
+
+COMMIT;
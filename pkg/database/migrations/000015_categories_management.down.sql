@@ -0,0 +1,8 @@
+BEGIN;
+
+DROP INDEX IF EXISTS "categories_parent_id_sort_order_idx";
+
+ALTER TABLE "categories" DROP COLUMN IF EXISTS "image_url";
+ALTER TABLE "categories" DROP COLUMN IF EXISTS "sort_order";
+
+COMMIT;
@@ -0,0 +1,9 @@
+BEGIN;
+
+--Categories are listed by sort_order then id.
+ALTER TABLE "categories" ADD COLUMN "sort_order" INT NOT NULL DEFAULT 0;
+ALTER TABLE "categories" ADD COLUMN "image_url" VARCHAR NOT NULL DEFAULT '';
+
+CREATE INDEX "categories_parent_id_sort_order_idx" ON "categories" ("parent_id", "sort_order", "id");
+
+COMMIT;
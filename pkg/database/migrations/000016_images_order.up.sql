@@ -0,0 +1,22 @@
+BEGIN;
+
+--Images of a product are listed by position, the primary one is the cover.
+ALTER TABLE "images" ADD COLUMN "position" INT NOT NULL DEFAULT 0;
+ALTER TABLE "images" ADD COLUMN "alt" VARCHAR NOT NULL DEFAULT '';
+ALTER TABLE "images" ADD COLUMN "is_primary" BOOLEAN NOT NULL DEFAULT false;
+
+UPDATE "images" "i" SET
+  "position" = "o"."position",
+  "is_primary" = "o"."position" = 0
+FROM (
+  SELECT
+    "id",
+    row_number() OVER (PARTITION BY "product_id" ORDER BY "created_at", "id") - 1 AS "position"
+  FROM "images"
+) AS "o"
+WHERE "o"."id" = "i"."id";
+
+CREATE INDEX "images_product_id_position_idx" ON "images" ("product_id", "position");
+CREATE UNIQUE INDEX "images_product_id_primary_key" ON "images" ("product_id") WHERE "is_primary";
+
+COMMIT;
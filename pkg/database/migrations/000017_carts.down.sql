@@ -0,0 +1,9 @@
+BEGIN;
+
+DROP TRIGGER IF EXISTS set_updated_at_timestamp_carts_items_table ON "carts_items";
+DROP TRIGGER IF EXISTS set_updated_at_timestamp_carts_table ON "carts";
+
+DROP TABLE IF EXISTS "carts_items";
+DROP TABLE IF EXISTS "carts";
+
+COMMIT;
@@ -0,0 +1,34 @@
+BEGIN;
+
+--A cart belongs to a user or to a guest by token, guest cart is merged into the user cart on sign in.
+CREATE TABLE "carts" (
+  "id" uuid NOT NULL UNIQUE PRIMARY KEY DEFAULT uuid_generate_v4(),
+  "user_id" VARCHAR UNIQUE,
+  "token" VARCHAR UNIQUE,
+  "currency" VARCHAR(3) NOT NULL,
+  "created_at" TIMESTAMP NOT NULL DEFAULT now(),
+  "updated_at" TIMESTAMP NOT NULL DEFAULT now(),
+  CHECK ("user_id" IS NOT NULL OR "token" IS NOT NULL)
+);
+
+--added_amount is the unit price in minor units of cart currency when the item was added or updated,
+--the current price is computed on read and the item is stale when they differ.
+CREATE TABLE "carts_items" (
+  "id" uuid NOT NULL UNIQUE PRIMARY KEY DEFAULT uuid_generate_v4(),
+  "cart_id" uuid NOT NULL,
+  "product_id" VARCHAR NOT NULL,
+  "quantity" INT NOT NULL CHECK ("quantity" > 0),
+  "added_amount" BIGINT,
+  "created_at" TIMESTAMP NOT NULL DEFAULT now(),
+  "updated_at" TIMESTAMP NOT NULL DEFAULT now(),
+  UNIQUE ("cart_id", "product_id")
+);
+
+ALTER TABLE "carts" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+ALTER TABLE "carts_items" ADD FOREIGN KEY ("cart_id") REFERENCES "carts" ("id") ON DELETE CASCADE;
+ALTER TABLE "carts_items" ADD FOREIGN KEY ("product_id") REFERENCES "products" ("id") ON DELETE CASCADE;
+
+CREATE TRIGGER set_updated_at_timestamp_carts_table BEFORE UPDATE ON "carts" FOR EACH ROW EXECUTE PROCEDURE set_updated_at_column();
+CREATE TRIGGER set_updated_at_timestamp_carts_items_table BEFORE UPDATE ON "carts_items" FOR EACH ROW EXECUTE PROCEDURE set_updated_at_column();
+
+COMMIT;
@@ -0,0 +1,36 @@
+BEGIN;
+
+DROP TABLE IF EXISTS "orders_status_history";
+
+ALTER TABLE "products_orders" DROP CONSTRAINT IF EXISTS "products_orders_qty_check";
+ALTER TABLE "products_orders" DROP COLUMN IF EXISTS "unit_amount";
+ALTER TABLE "products_orders" DROP COLUMN IF EXISTS "product_id";
+
+DROP INDEX IF EXISTS "orders_status_idx";
+DROP INDEX IF EXISTS "orders_user_id_created_at_idx";
+
+ALTER TABLE "orders" DROP COLUMN IF EXISTS "total";
+ALTER TABLE "orders" DROP COLUMN IF EXISTS "subtotal";
+ALTER TABLE "orders" DROP COLUMN IF EXISTS "currency";
+
+CREATE TYPE "order_status" AS ENUM (
+    'waiting',
+    'shipping',
+    'completed',
+    'canceled'
+);
+
+ALTER TABLE "orders" DROP CONSTRAINT IF EXISTS "orders_status_check";
+ALTER TABLE "orders" ALTER COLUMN "status" DROP DEFAULT;
+ALTER TABLE "orders" ALTER COLUMN "status" TYPE order_status USING (
+  CASE "status"
+    WHEN 'pending' THEN 'waiting'
+    WHEN 'paid' THEN 'waiting'
+    WHEN 'fulfilled' THEN 'waiting'
+    WHEN 'shipped' THEN 'shipping'
+    WHEN 'delivered' THEN 'completed'
+    ELSE 'canceled'
+  END
+)::order_status;
+
+COMMIT;
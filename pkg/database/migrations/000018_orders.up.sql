@@ -0,0 +1,58 @@
+BEGIN;
+
+--Order status is checked by the app state machine, old statuses are mapped to the new ones.
+ALTER TABLE "orders" ALTER COLUMN "status" TYPE VARCHAR USING (
+  CASE "status"::TEXT
+    WHEN 'waiting' THEN 'pending'
+    WHEN 'shipping' THEN 'shipped'
+    WHEN 'completed' THEN 'delivered'
+    WHEN 'canceled' THEN 'cancelled'
+  END
+);
+ALTER TABLE "orders" ALTER COLUMN "status" SET DEFAULT 'pending';
+ALTER TABLE "orders" ADD CONSTRAINT "orders_status_check" CHECK ("status" IN ('pending', 'paid', 'fulfilled', 'shipped', 'delivered', 'cancelled', 'refunded'));
+DROP TYPE "order_status";
+
+--Totals are in minor units of currency, fixed when the order is placed.
+ALTER TABLE "orders" ADD COLUMN "currency" VARCHAR(3) NOT NULL DEFAULT 'THB';
+ALTER TABLE "orders" ALTER COLUMN "currency" DROP DEFAULT;
+ALTER TABLE "orders" ADD COLUMN "subtotal" BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE "orders" ADD COLUMN "total" BIGINT NOT NULL DEFAULT 0;
+
+CREATE INDEX "orders_user_id_created_at_idx" ON "orders" ("user_id", "created_at");
+CREATE INDEX "orders_status_idx" ON "orders" ("status");
+
+--product is a snapshot, product_id has no foreign key so the line is kept when the product is removed.
+ALTER TABLE "products_orders" ADD COLUMN "product_id" VARCHAR;
+ALTER TABLE "products_orders" ADD COLUMN "unit_amount" BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE "products_orders" ADD CONSTRAINT "products_orders_qty_check" CHECK ("qty" > 0);
+
+--Old lines keep the price in baht inside the snapshot, like product_prices they are THB.
+UPDATE "products_orders" SET
+  "product_id" = "product"->>'id',
+  "unit_amount" = COALESCE(ROUND(("product"->>'price')::NUMERIC * 100)::BIGINT, 0);
+
+UPDATE "orders" "o" SET
+  "subtotal" = "l"."amount",
+  "total" = "l"."amount"
+FROM (
+  SELECT "order_id", SUM("unit_amount" * "qty") AS "amount" FROM "products_orders" GROUP BY "order_id"
+) AS "l"
+WHERE "l"."order_id" = "o"."id";
+
+CREATE TABLE "orders_status_history" (
+  "id" uuid NOT NULL UNIQUE PRIMARY KEY DEFAULT uuid_generate_v4(),
+  "order_id" VARCHAR NOT NULL,
+  "from_status" VARCHAR,
+  "to_status" VARCHAR NOT NULL,
+  "note" VARCHAR NOT NULL DEFAULT '',
+  "user_id" VARCHAR,
+  "created_at" TIMESTAMP NOT NULL DEFAULT now()
+);
+
+ALTER TABLE "orders_status_history" ADD FOREIGN KEY ("order_id") REFERENCES "orders" ("id") ON DELETE CASCADE;
+ALTER TABLE "orders_status_history" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE SET NULL;
+
+CREATE INDEX "orders_status_history_order_id_idx" ON "orders_status_history" ("order_id", "created_at");
+
+COMMIT;
@@ -0,0 +1,13 @@
+BEGIN;
+
+DROP TRIGGER IF EXISTS set_updated_at_timestamp_payments_table ON "payments";
+
+DROP TABLE IF EXISTS "payments_events";
+DROP TABLE IF EXISTS "payments";
+
+ALTER TABLE "orders" DROP COLUMN IF EXISTS "stock_reserved";
+
+ALTER TABLE "products" DROP CONSTRAINT IF EXISTS "products_stock_check";
+ALTER TABLE "products" DROP COLUMN IF EXISTS "stock";
+
+COMMIT;
@@ -0,0 +1,45 @@
+BEGIN;
+
+--NULL stock is not tracked and can always be ordered, placing an order take stock and cancelling it give stock back.
+ALTER TABLE "products" ADD COLUMN "stock" INT;
+ALTER TABLE "products" ADD CONSTRAINT "products_stock_check" CHECK ("stock" >= 0);
+
+ALTER TABLE "orders" ADD COLUMN "stock_reserved" BOOLEAN NOT NULL DEFAULT FALSE;
+
+--A payment is an intent of a provider for an order, amounts are in minor units of currency.
+CREATE TABLE "payments" (
+  "id" uuid NOT NULL UNIQUE PRIMARY KEY DEFAULT uuid_generate_v4(),
+  "order_id" VARCHAR NOT NULL,
+  "provider" VARCHAR NOT NULL,
+  "intent_id" VARCHAR NOT NULL,
+  "status" VARCHAR NOT NULL,
+  "amount" BIGINT NOT NULL CHECK ("amount" >= 0),
+  "refunded_amount" BIGINT NOT NULL DEFAULT 0,
+  "pending_refund_amount" BIGINT NOT NULL DEFAULT 0,
+  "currency" VARCHAR(3) NOT NULL,
+  "created_at" TIMESTAMP NOT NULL DEFAULT now(),
+  "updated_at" TIMESTAMP NOT NULL DEFAULT now(),
+  UNIQUE ("provider", "intent_id"),
+  CHECK ("status" IN ('processing', 'requires_capture', 'succeeded', 'declined', 'refunded')),
+  CHECK ("refunded_amount" BETWEEN 0 AND "amount"),
+  --refund sent to provider but not settled yet, reserved so refunds at the same time can't take more than paid
+  CHECK ("pending_refund_amount" >= 0 AND "refunded_amount" + "pending_refund_amount" <= "amount")
+);
+
+--Webhook events already handled, a provider may send the same event more than once.
+CREATE TABLE "payments_events" (
+  "provider" VARCHAR NOT NULL,
+  "event_id" VARCHAR NOT NULL,
+  "type" VARCHAR NOT NULL,
+  "intent_id" VARCHAR NOT NULL,
+  "created_at" TIMESTAMP NOT NULL DEFAULT now(),
+  PRIMARY KEY ("provider", "event_id")
+);
+
+ALTER TABLE "payments" ADD FOREIGN KEY ("order_id") REFERENCES "orders" ("id") ON DELETE CASCADE;
+
+CREATE INDEX "payments_order_id_idx" ON "payments" ("order_id");
+
+CREATE TRIGGER set_updated_at_timestamp_payments_table BEFORE UPDATE ON "payments" FOR EACH ROW EXECUTE PROCEDURE set_updated_at_column();
+
+COMMIT;
@@ -0,0 +1,15 @@
+BEGIN;
+
+ALTER TABLE "products_orders" DROP COLUMN IF EXISTS "discount_amount";
+ALTER TABLE "orders" DROP COLUMN IF EXISTS "discount";
+ALTER TABLE "orders" DROP COLUMN IF EXISTS "coupon_code";
+ALTER TABLE "carts" DROP COLUMN IF EXISTS "coupon_id";
+
+DROP TRIGGER IF EXISTS set_updated_at_timestamp_coupons_table ON "coupons";
+
+DROP TABLE IF EXISTS "coupons_usages";
+DROP TABLE IF EXISTS "coupons_categories";
+DROP TABLE IF EXISTS "coupons_products";
+DROP TABLE IF EXISTS "coupons";
+
+COMMIT;
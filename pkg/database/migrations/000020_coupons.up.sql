@@ -0,0 +1,73 @@
+BEGIN;
+
+--percentage coupon use percent, fixed coupon use amount in minor units of currency.
+--min_spend is of eligible lines in currency, a coupon without products and categories applies to every product.
+CREATE TABLE "coupons" (
+  "id" uuid NOT NULL UNIQUE PRIMARY KEY DEFAULT uuid_generate_v4(),
+  "code" VARCHAR NOT NULL UNIQUE,
+  "description" VARCHAR NOT NULL DEFAULT '',
+  "type" VARCHAR NOT NULL,
+  "percent" INT,
+  "amount" BIGINT,
+  "currency" VARCHAR(3),
+  "min_spend" BIGINT NOT NULL DEFAULT 0 CHECK ("min_spend" >= 0),
+  "usage_limit" INT CHECK ("usage_limit" > 0),
+  "usage_limit_per_user" INT CHECK ("usage_limit_per_user" > 0),
+  "starts_at" TIMESTAMP,
+  "ends_at" TIMESTAMP,
+  "is_active" BOOLEAN NOT NULL DEFAULT TRUE,
+  "created_at" TIMESTAMP NOT NULL DEFAULT now(),
+  "updated_at" TIMESTAMP NOT NULL DEFAULT now(),
+  CHECK ("code" = UPPER("code")),
+  CHECK (
+    ("type" = 'percentage' AND "percent" BETWEEN 1 AND 100)
+    OR ("type" = 'fixed' AND "amount" > 0 AND "currency" IS NOT NULL)
+  ),
+  CHECK ("min_spend" = 0 OR "currency" IS NOT NULL),
+  CHECK ("ends_at" IS NULL OR "starts_at" IS NULL OR "ends_at" > "starts_at")
+);
+
+CREATE TABLE "coupons_products" (
+  "coupon_id" uuid NOT NULL,
+  "product_id" VARCHAR NOT NULL,
+  PRIMARY KEY ("coupon_id", "product_id")
+);
+
+--A category target include its descendants.
+CREATE TABLE "coupons_categories" (
+  "coupon_id" uuid NOT NULL,
+  "category_id" INT NOT NULL,
+  PRIMARY KEY ("coupon_id", "category_id")
+);
+
+CREATE TABLE "coupons_usages" (
+  "id" uuid NOT NULL UNIQUE PRIMARY KEY DEFAULT uuid_generate_v4(),
+  "coupon_id" uuid NOT NULL,
+  "user_id" VARCHAR NOT NULL,
+  "order_id" VARCHAR NOT NULL UNIQUE,
+  "discount" BIGINT NOT NULL,
+  "created_at" TIMESTAMP NOT NULL DEFAULT now()
+);
+
+ALTER TABLE "coupons_products" ADD FOREIGN KEY ("coupon_id") REFERENCES "coupons" ("id") ON DELETE CASCADE;
+ALTER TABLE "coupons_products" ADD FOREIGN KEY ("product_id") REFERENCES "products" ("id") ON DELETE CASCADE;
+ALTER TABLE "coupons_categories" ADD FOREIGN KEY ("coupon_id") REFERENCES "coupons" ("id") ON DELETE CASCADE;
+ALTER TABLE "coupons_categories" ADD FOREIGN KEY ("category_id") REFERENCES "categories" ("id") ON DELETE CASCADE;
+ALTER TABLE "coupons_usages" ADD FOREIGN KEY ("coupon_id") REFERENCES "coupons" ("id") ON DELETE CASCADE;
+ALTER TABLE "coupons_usages" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+ALTER TABLE "coupons_usages" ADD FOREIGN KEY ("order_id") REFERENCES "orders" ("id") ON DELETE CASCADE;
+
+CREATE INDEX "coupons_usages_coupon_id_user_id_idx" ON "coupons_usages" ("coupon_id", "user_id");
+
+CREATE TRIGGER set_updated_at_timestamp_coupons_table BEFORE UPDATE ON "coupons" FOR EACH ROW EXECUTE PROCEDURE set_updated_at_column();
+
+--Applied coupon of cart, checked again on every read.
+ALTER TABLE "carts" ADD COLUMN "coupon_id" uuid;
+ALTER TABLE "carts" ADD FOREIGN KEY ("coupon_id") REFERENCES "coupons" ("id") ON DELETE SET NULL;
+
+--Code is kept on order as the coupon may be deleted later.
+ALTER TABLE "orders" ADD COLUMN "coupon_code" VARCHAR;
+ALTER TABLE "orders" ADD COLUMN "discount" BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE "products_orders" ADD COLUMN "discount_amount" BIGINT NOT NULL DEFAULT 0;
+
+COMMIT;
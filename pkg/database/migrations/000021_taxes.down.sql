@@ -0,0 +1,19 @@
+BEGIN;
+
+ALTER TABLE "products_orders" DROP COLUMN IF EXISTS "tax_rate";
+ALTER TABLE "products_orders" DROP COLUMN IF EXISTS "tax_amount";
+ALTER TABLE "orders" DROP COLUMN IF EXISTS "tax_inclusive";
+ALTER TABLE "orders" DROP COLUMN IF EXISTS "tax";
+ALTER TABLE "orders" DROP COLUMN IF EXISTS "region";
+ALTER TABLE "orders" DROP COLUMN IF EXISTS "country";
+ALTER TABLE "products" DROP COLUMN IF EXISTS "tax_class_id";
+
+DROP TRIGGER IF EXISTS set_updated_at_timestamp_tax_rates_table ON "tax_rates";
+DROP TRIGGER IF EXISTS set_updated_at_timestamp_tax_zones_table ON "tax_zones";
+DROP TRIGGER IF EXISTS set_updated_at_timestamp_tax_classes_table ON "tax_classes";
+
+DROP TABLE IF EXISTS "tax_rates";
+DROP TABLE IF EXISTS "tax_zones";
+DROP TABLE IF EXISTS "tax_classes";
+
+COMMIT;
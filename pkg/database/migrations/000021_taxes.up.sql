@@ -0,0 +1,68 @@
+BEGIN;
+
+--Tax class of product, rate of a class is set per zone.
+CREATE TABLE "tax_classes" (
+  "id" VARCHAR NOT NULL UNIQUE PRIMARY KEY,
+  "title" VARCHAR NOT NULL,
+  "created_at" TIMESTAMP NOT NULL DEFAULT now(),
+  "updated_at" TIMESTAMP NOT NULL DEFAULT now(),
+  CHECK ("id" ~ '^[a-z0-9_]+$')
+);
+
+--Zone is matched by shipping address, zone with region is picked before the one of whole country.
+CREATE TABLE "tax_zones" (
+  "id" uuid NOT NULL UNIQUE PRIMARY KEY DEFAULT uuid_generate_v4(),
+  "title" VARCHAR NOT NULL,
+  "country" VARCHAR(2) NOT NULL CHECK ("country" = UPPER("country")),
+  "region" VARCHAR,
+  "created_at" TIMESTAMP NOT NULL DEFAULT now(),
+  "updated_at" TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX "tax_zones_country_region_idx" ON "tax_zones" ("country", COALESCE("region", ''));
+
+--Rate is percent with 4 decimal places, class without rate in zone is not taxed.
+CREATE TABLE "tax_rates" (
+  "id" uuid NOT NULL UNIQUE PRIMARY KEY DEFAULT uuid_generate_v4(),
+  "zone_id" uuid NOT NULL,
+  "class_id" VARCHAR NOT NULL,
+  "title" VARCHAR NOT NULL DEFAULT '',
+  "rate" NUMERIC(7, 4) NOT NULL CHECK ("rate" >= 0 AND "rate" <= 100),
+  "created_at" TIMESTAMP NOT NULL DEFAULT now(),
+  "updated_at" TIMESTAMP NOT NULL DEFAULT now(),
+  UNIQUE ("zone_id", "class_id")
+);
+
+ALTER TABLE "tax_rates" ADD FOREIGN KEY ("zone_id") REFERENCES "tax_zones" ("id") ON DELETE CASCADE;
+ALTER TABLE "tax_rates" ADD FOREIGN KEY ("class_id") REFERENCES "tax_classes" ("id") ON DELETE CASCADE;
+
+CREATE TRIGGER set_updated_at_timestamp_tax_classes_table BEFORE UPDATE ON "tax_classes" FOR EACH ROW EXECUTE PROCEDURE set_updated_at_column();
+CREATE TRIGGER set_updated_at_timestamp_tax_zones_table BEFORE UPDATE ON "tax_zones" FOR EACH ROW EXECUTE PROCEDURE set_updated_at_column();
+CREATE TRIGGER set_updated_at_timestamp_tax_rates_table BEFORE UPDATE ON "tax_rates" FOR EACH ROW EXECUTE PROCEDURE set_updated_at_column();
+
+INSERT INTO "tax_classes" ("id", "title") VALUES
+  ('standard', 'Standard'),
+  ('zero', 'Zero rated'),
+  ('exempt', 'Exempt');
+
+--Thai VAT 7%
+WITH "z" AS (
+  INSERT INTO "tax_zones" ("title", "country") VALUES ('Thailand', 'TH') RETURNING "id"
+)
+INSERT INTO "tax_rates" ("zone_id", "class_id", "title", "rate")
+SELECT "z"."id", "r"."class_id", "r"."title", "r"."rate"
+FROM "z", (VALUES ('standard', 'VAT 7%', 7), ('zero', 'VAT 0%', 0)) AS "r" ("class_id", "title", "rate");
+
+ALTER TABLE "products" ADD COLUMN "tax_class_id" VARCHAR NOT NULL DEFAULT 'standard';
+--Tax class used by products can't be deleted, products must be moved to another class first
+ALTER TABLE "products" ADD FOREIGN KEY ("tax_class_id") REFERENCES "tax_classes" ("id") ON DELETE RESTRICT;
+
+--Tax of order and lines are kept as they were when the order was placed.
+ALTER TABLE "orders" ADD COLUMN "country" VARCHAR(2);
+ALTER TABLE "orders" ADD COLUMN "region" VARCHAR;
+ALTER TABLE "orders" ADD COLUMN "tax" BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE "orders" ADD COLUMN "tax_inclusive" BOOLEAN NOT NULL DEFAULT TRUE;
+ALTER TABLE "products_orders" ADD COLUMN "tax_amount" BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE "products_orders" ADD COLUMN "tax_rate" NUMERIC(7, 4) NOT NULL DEFAULT 0;
+
+COMMIT;
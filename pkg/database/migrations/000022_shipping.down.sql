@@ -0,0 +1,17 @@
+BEGIN;
+
+ALTER TABLE "orders" DROP COLUMN IF EXISTS "shipping";
+ALTER TABLE "orders" DROP COLUMN IF EXISTS "shipping_method";
+ALTER TABLE "orders" DROP COLUMN IF EXISTS "shipping_method_id";
+
+DROP TRIGGER IF EXISTS set_updated_at_timestamp_shipping_methods_table ON "shipping_methods";
+
+DROP TABLE IF EXISTS "shipping_methods_weight_rates";
+DROP TABLE IF EXISTS "shipping_methods";
+
+ALTER TABLE "products" DROP COLUMN IF EXISTS "height";
+ALTER TABLE "products" DROP COLUMN IF EXISTS "width";
+ALTER TABLE "products" DROP COLUMN IF EXISTS "length";
+ALTER TABLE "products" DROP COLUMN IF EXISTS "weight";
+
+COMMIT;
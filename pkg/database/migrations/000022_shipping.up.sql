@@ -0,0 +1,46 @@
+BEGIN;
+
+--Weight in grams, size in millimetres, NULL is not set and counted as 0.
+ALTER TABLE "products" ADD COLUMN "weight" INT CHECK ("weight" >= 0);
+ALTER TABLE "products" ADD COLUMN "length" INT CHECK ("length" >= 0);
+ALTER TABLE "products" ADD COLUMN "width" INT CHECK ("width" >= 0);
+ALTER TABLE "products" ADD COLUMN "height" INT CHECK ("height" >= 0);
+
+--flat_rate charge amount, weight_based charge amount + rate of the weight, free_over is free when subtotal reach free_over.
+--flat_rate and weight_based are free too when free_over is set. Empty countries is every country.
+CREATE TABLE "shipping_methods" (
+  "id" uuid NOT NULL UNIQUE PRIMARY KEY DEFAULT uuid_generate_v4(),
+  "title" VARCHAR NOT NULL,
+  "description" VARCHAR NOT NULL DEFAULT '',
+  "type" VARCHAR NOT NULL CHECK ("type" IN ('flat_rate', 'weight_based', 'free_over')),
+  "carrier" VARCHAR NOT NULL DEFAULT 'table',
+  "currency" VARCHAR(3) NOT NULL,
+  "amount" BIGINT NOT NULL DEFAULT 0 CHECK ("amount" >= 0),
+  "free_over" BIGINT NOT NULL DEFAULT 0 CHECK ("free_over" >= 0),
+  "countries" VARCHAR(2)[] NOT NULL DEFAULT '{}',
+  "sort_order" INT NOT NULL DEFAULT 0,
+  "is_active" BOOLEAN NOT NULL DEFAULT TRUE,
+  "created_at" TIMESTAMP NOT NULL DEFAULT now(),
+  "updated_at" TIMESTAMP NOT NULL DEFAULT now(),
+  CHECK ("type" <> 'free_over' OR "free_over" > 0)
+);
+
+--Rate of parcel up to max_weight grams, the smallest one the parcel fit is used.
+CREATE TABLE "shipping_methods_weight_rates" (
+  "method_id" uuid NOT NULL,
+  "max_weight" INT NOT NULL CHECK ("max_weight" > 0),
+  "amount" BIGINT NOT NULL CHECK ("amount" >= 0),
+  PRIMARY KEY ("method_id", "max_weight")
+);
+
+ALTER TABLE "shipping_methods_weight_rates" ADD FOREIGN KEY ("method_id") REFERENCES "shipping_methods" ("id") ON DELETE CASCADE;
+
+CREATE TRIGGER set_updated_at_timestamp_shipping_methods_table BEFORE UPDATE ON "shipping_methods" FOR EACH ROW EXECUTE PROCEDURE set_updated_at_column();
+
+--Method is kept by title as it may be deleted later.
+ALTER TABLE "orders" ADD COLUMN "shipping_method_id" uuid;
+ALTER TABLE "orders" ADD COLUMN "shipping_method" VARCHAR;
+ALTER TABLE "orders" ADD COLUMN "shipping" BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE "orders" ADD FOREIGN KEY ("shipping_method_id") REFERENCES "shipping_methods" ("id") ON DELETE SET NULL;
+
+COMMIT;
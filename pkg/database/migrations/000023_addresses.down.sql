@@ -0,0 +1,10 @@
+BEGIN;
+
+ALTER TABLE "orders" DROP COLUMN IF EXISTS "billing_address";
+ALTER TABLE "orders" DROP COLUMN IF EXISTS "shipping_address";
+
+DROP TRIGGER IF EXISTS set_updated_at_timestamp_addresses_table ON "addresses";
+
+DROP TABLE IF EXISTS "addresses";
+
+COMMIT;
@@ -0,0 +1,36 @@
+BEGIN;
+
+--Address book of user, a user has at most one default shipping and one default billing address.
+CREATE TABLE "addresses" (
+  "id" uuid NOT NULL UNIQUE PRIMARY KEY DEFAULT uuid_generate_v4(),
+  "user_id" VARCHAR NOT NULL,
+  "label" VARCHAR NOT NULL DEFAULT '',
+  "name" VARCHAR NOT NULL,
+  "phone" VARCHAR NOT NULL DEFAULT '',
+  "company" VARCHAR NOT NULL DEFAULT '',
+  "line1" VARCHAR NOT NULL,
+  "line2" VARCHAR NOT NULL DEFAULT '',
+  "district" VARCHAR NOT NULL DEFAULT '',
+  "city" VARCHAR NOT NULL DEFAULT '',
+  "region" VARCHAR NOT NULL DEFAULT '',
+  "postcode" VARCHAR NOT NULL DEFAULT '',
+  "country" VARCHAR(2) NOT NULL,
+  "is_default_shipping" BOOLEAN NOT NULL DEFAULT FALSE,
+  "is_default_billing" BOOLEAN NOT NULL DEFAULT FALSE,
+  "created_at" TIMESTAMP NOT NULL DEFAULT now(),
+  "updated_at" TIMESTAMP NOT NULL DEFAULT now()
+);
+
+ALTER TABLE "addresses" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+
+CREATE INDEX "addresses_user_id_idx" ON "addresses" ("user_id");
+CREATE UNIQUE INDEX "addresses_default_shipping_idx" ON "addresses" ("user_id") WHERE "is_default_shipping";
+CREATE UNIQUE INDEX "addresses_default_billing_idx" ON "addresses" ("user_id") WHERE "is_default_billing";
+
+CREATE TRIGGER set_updated_at_timestamp_addresses_table BEFORE UPDATE ON "addresses" FOR EACH ROW EXECUTE PROCEDURE set_updated_at_column();
+
+--Snapshot of addresses when the order is placed, the address may be changed or deleted later.
+ALTER TABLE "orders" ADD COLUMN "shipping_address" JSONB;
+ALTER TABLE "orders" ADD COLUMN "billing_address" JSONB;
+
+COMMIT;
@@ -0,0 +1,10 @@
+BEGIN;
+
+DROP TRIGGER IF EXISTS set_stock_subscriptions_restocked_products_table ON "products";
+
+DROP FUNCTION IF EXISTS set_stock_subscriptions_restocked();
+
+DROP TABLE IF EXISTS "stock_subscriptions";
+DROP TABLE IF EXISTS "wishlists";
+
+COMMIT;
@@ -0,0 +1,48 @@
+BEGIN;
+
+CREATE TABLE "wishlists" (
+  "user_id" VARCHAR NOT NULL,
+  "product_id" VARCHAR NOT NULL,
+  "created_at" TIMESTAMP NOT NULL DEFAULT now(),
+  PRIMARY KEY ("user_id", "product_id")
+);
+
+ALTER TABLE "wishlists" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+ALTER TABLE "wishlists" ADD FOREIGN KEY ("product_id") REFERENCES "products" ("id") ON DELETE CASCADE;
+
+--Back in stock alert, restocked_at is set by trigger when stock of product go from 0 to positive,
+--then the alert is sent by job and notified_at is set. Subscribing again start a new alert.
+CREATE TABLE "stock_subscriptions" (
+  "user_id" VARCHAR NOT NULL,
+  "product_id" VARCHAR NOT NULL,
+  "restocked_at" TIMESTAMP,
+  "notified_at" TIMESTAMP,
+  "created_at" TIMESTAMP NOT NULL DEFAULT now(),
+  PRIMARY KEY ("user_id", "product_id")
+);
+
+ALTER TABLE "stock_subscriptions" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+ALTER TABLE "stock_subscriptions" ADD FOREIGN KEY ("product_id") REFERENCES "products" ("id") ON DELETE CASCADE;
+
+CREATE INDEX "stock_subscriptions_pending_idx" ON "stock_subscriptions" ("restocked_at") WHERE "notified_at" IS NULL;
+
+--Every way stock change go through here, admin update, cancelled orders and returns.
+--NULL stock is not tracked so it is in stock too.
+CREATE OR REPLACE FUNCTION set_stock_subscriptions_restocked()
+RETURNS TRIGGER AS $$
+BEGIN
+    UPDATE "stock_subscriptions" SET
+        "restocked_at" = now()
+    WHERE "product_id" = NEW.id
+    AND "restocked_at" IS NULL
+    AND "notified_at" IS NULL;
+
+    RETURN NULL;
+END;
+$$ language 'plpgsql';
+
+CREATE TRIGGER set_stock_subscriptions_restocked_products_table AFTER UPDATE OF "stock" ON "products" FOR EACH ROW
+    WHEN (OLD."stock" = 0 AND (NEW."stock" IS NULL OR NEW."stock" > 0))
+    EXECUTE PROCEDURE set_stock_subscriptions_restocked();
+
+COMMIT;
@@ -0,0 +1,10 @@
+BEGIN;
+
+DROP TRIGGER IF EXISTS set_updated_at_timestamp_documents_table ON "documents";
+
+DROP TABLE IF EXISTS "documents";
+
+DROP SEQUENCE IF EXISTS receipts_number_seq;
+DROP SEQUENCE IF EXISTS invoices_number_seq;
+
+COMMIT;
@@ -0,0 +1,24 @@
+BEGIN;
+
+--Numbers of tax invoices and receipts run without gap per type, like INV000001 and RCP000001.
+CREATE SEQUENCE invoices_number_seq START WITH 1 INCREMENT BY 1;
+CREATE SEQUENCE receipts_number_seq START WITH 1 INCREMENT BY 1;
+
+--Invoice and receipt PDF of paid order, one of each type per order.
+--Destination is set when the PDF is uploaded to storage.
+CREATE TABLE "documents" (
+  "id" uuid NOT NULL UNIQUE PRIMARY KEY DEFAULT uuid_generate_v4(),
+  "order_id" VARCHAR NOT NULL,
+  "type" VARCHAR NOT NULL CHECK ("type" IN ('invoice', 'receipt')),
+  "number" VARCHAR NOT NULL UNIQUE,
+  "destination" VARCHAR,
+  "created_at" TIMESTAMP NOT NULL DEFAULT now(),
+  "updated_at" TIMESTAMP NOT NULL DEFAULT now(),
+  UNIQUE ("order_id", "type")
+);
+
+ALTER TABLE "documents" ADD FOREIGN KEY ("order_id") REFERENCES "orders" ("id") ON DELETE CASCADE;
+
+CREATE TRIGGER set_updated_at_timestamp_documents_table BEFORE UPDATE ON "documents" FOR EACH ROW EXECUTE PROCEDURE set_updated_at_column();
+
+COMMIT;
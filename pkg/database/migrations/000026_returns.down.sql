@@ -0,0 +1,9 @@
+BEGIN;
+
+DROP TRIGGER IF EXISTS set_updated_at_timestamp_returns_table ON "returns";
+
+DROP TABLE IF EXISTS "returns_images";
+DROP TABLE IF EXISTS "returns_items";
+DROP TABLE IF EXISTS "returns";
+
+COMMIT;
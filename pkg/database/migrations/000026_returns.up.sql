@@ -0,0 +1,51 @@
+BEGIN;
+
+--Return request of lines of a delivered order. Admin approve or reject it, received items go back to stock,
+--then money of the lines is refunded, refund_amount may be less than the lines like a restocking fee.
+CREATE TABLE "returns" (
+  "id" uuid NOT NULL UNIQUE PRIMARY KEY DEFAULT uuid_generate_v4(),
+  "order_id" VARCHAR NOT NULL,
+  "user_id" VARCHAR NOT NULL,
+  "status" VARCHAR NOT NULL DEFAULT 'requested',
+  "reason" VARCHAR NOT NULL,
+  "note" VARCHAR NOT NULL DEFAULT '',
+  "admin_note" VARCHAR NOT NULL DEFAULT '',
+  "refund_amount" BIGINT NOT NULL DEFAULT 0,
+  "refund_id" VARCHAR,
+  "created_at" TIMESTAMP NOT NULL DEFAULT now(),
+  "updated_at" TIMESTAMP NOT NULL DEFAULT now(),
+  CHECK ("status" IN ('requested', 'approved', 'rejected', 'received', 'refunding', 'refunded')),
+  CHECK ("reason" IN ('damaged', 'defective', 'wrong_item', 'not_as_described', 'no_longer_needed', 'other'))
+);
+
+--amount is what the customer paid for qty of the line, tax included when it was added on top of prices.
+CREATE TABLE "returns_items" (
+  "id" uuid NOT NULL UNIQUE PRIMARY KEY DEFAULT uuid_generate_v4(),
+  "return_id" uuid NOT NULL,
+  "order_item_id" uuid NOT NULL,
+  "qty" INT NOT NULL CHECK ("qty" > 0),
+  "amount" BIGINT NOT NULL DEFAULT 0,
+  UNIQUE ("return_id", "order_item_id")
+);
+
+--Photos of returned items, uploaded to storage by the files module.
+CREATE TABLE "returns_images" (
+  "id" uuid NOT NULL UNIQUE PRIMARY KEY DEFAULT uuid_generate_v4(),
+  "return_id" uuid NOT NULL,
+  "filename" VARCHAR NOT NULL,
+  "url" VARCHAR NOT NULL,
+  "created_at" TIMESTAMP NOT NULL DEFAULT now()
+);
+
+ALTER TABLE "returns" ADD FOREIGN KEY ("order_id") REFERENCES "orders" ("id") ON DELETE CASCADE;
+ALTER TABLE "returns" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+ALTER TABLE "returns_items" ADD FOREIGN KEY ("return_id") REFERENCES "returns" ("id") ON DELETE CASCADE;
+ALTER TABLE "returns_items" ADD FOREIGN KEY ("order_item_id") REFERENCES "products_orders" ("id") ON DELETE CASCADE;
+ALTER TABLE "returns_images" ADD FOREIGN KEY ("return_id") REFERENCES "returns" ("id") ON DELETE CASCADE;
+
+CREATE INDEX "returns_order_id_idx" ON "returns" ("order_id");
+CREATE INDEX "returns_status_created_at_idx" ON "returns" ("status", "created_at");
+
+CREATE TRIGGER set_updated_at_timestamp_returns_table BEFORE UPDATE ON "returns" FOR EACH ROW EXECUTE PROCEDURE set_updated_at_column();
+
+COMMIT;
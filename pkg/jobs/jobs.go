@@ -0,0 +1,20 @@
+package jobs
+
+import (
+	"log"
+	"time"
+)
+
+// Every run job in background every interval until the process exit
+func Every(name string, interval time.Duration, job func() error) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			if err := job(); err != nil {
+				log.Printf("job %s failed: %v", name, err)
+			}
+		}
+	}()
+}
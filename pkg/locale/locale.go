@@ -0,0 +1,67 @@
+package locale
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// dictionaries are text search configs of each locale, Postgres has no Thai dictionary.
+// Text of simple dictionary is searched by LIKE as well, the parser can't split Thai words
+var dictionaries = map[string]string{
+	"th": "simple",
+	"en": "english",
+}
+
+// IsLocale report whether locale is supported
+func IsLocale(locale string) bool {
+	_, ok := dictionaries[locale]
+	return ok
+}
+
+// Dictionary return text search config of locale
+func Dictionary(locale string) string {
+	if d, ok := dictionaries[locale]; ok {
+		return d
+	}
+	return "simple"
+}
+
+// Parse choose locale from lang query, then Accept-Language header, then defaultLocale.
+// en-US is read as en
+func Parse(lang, acceptLanguage, defaultLocale string) string {
+	if l := base(lang); IsLocale(l) {
+		return l
+	}
+
+	// Accept-Language: th-TH,th;q=0.9,en;q=0.8
+	type weighted struct {
+		locale string
+		q      float64
+	}
+	accepts := make([]weighted, 0)
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(part, ";")
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				q = f
+			}
+		}
+		accepts = append(accepts, weighted{locale: base(tag), q: q})
+	}
+	sort.SliceStable(accepts, func(i, j int) bool { return accepts[i].q > accepts[j].q })
+	for _, a := range accepts {
+		if a.q > 0 && IsLocale(a.locale) {
+			return a.locale
+		}
+	}
+	return defaultLocale
+}
+
+func base(tag string) string {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	tag, _, _ = strings.Cut(tag, "-")
+	tag, _, _ = strings.Cut(tag, "_")
+	return tag
+}
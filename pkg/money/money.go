@@ -0,0 +1,203 @@
+package money
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Money is an amount in minor units (satang, cent) of an ISO 4217 currency,
+// so prices never pass through float
+
+type currency struct {
+	exponent int    // digits after decimal point
+	symbol   string // for display
+}
+
+var currencies = map[string]currency{
+	"THB": {exponent: 2, symbol: "฿"},
+	"USD": {exponent: 2, symbol: "$"},
+	"EUR": {exponent: 2, symbol: "€"},
+	"GBP": {exponent: 2, symbol: "£"},
+	"SGD": {exponent: 2, symbol: "S$"},
+	"JPY": {exponent: 0, symbol: "¥"},
+	"KRW": {exponent: 0, symbol: "₩"},
+}
+
+type Money struct {
+	Amount   int64  // minor units
+	Currency string // ISO 4217 code
+	decimal  string // amount from request, parsed by Validate
+}
+
+// IsCurrency report whether code is a supported currency
+func IsCurrency(code string) bool {
+	_, ok := currencies[code]
+	return ok
+}
+
+// New return money of amount minor units
+func New(amount int64, code string) *Money {
+	return &Money{
+		Amount:   amount,
+		Currency: code,
+	}
+}
+
+// Decimal return money from decimal text like "150.50", it must be validated before use
+func Decimal(amount, code string) *Money {
+	return &Money{
+		Currency: strings.ToUpper(strings.TrimSpace(code)),
+		decimal:  strings.TrimSpace(amount),
+	}
+}
+
+// Parse convert decimal text to minor units, more digits than currency has is rejected
+func Parse(amount, code string) (*Money, error) {
+	m := Decimal(amount, code)
+	if err := m.Validate(""); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Validate check currency and amount, empty currency become defaultCurrency
+func (m *Money) Validate(defaultCurrency string) error {
+	if m.Currency == "" {
+		m.Currency = defaultCurrency
+	}
+	c, ok := currencies[m.Currency]
+	if !ok {
+		return fmt.Errorf("currency %s is not supported", m.Currency)
+	}
+
+	if m.decimal != "" {
+		amount, err := parseDecimal(m.decimal, c.exponent)
+		if err != nil {
+			return fmt.Errorf("price %s %s: %v", m.decimal, m.Currency, err)
+		}
+		m.Amount = amount
+		m.decimal = ""
+	}
+	if m.Amount < 0 {
+		return fmt.Errorf("price must not be negative")
+	}
+	return nil
+}
+
+func parseDecimal(value string, exponent int) (int64, error) {
+	if strings.HasPrefix(value, "-") {
+		return 0, fmt.Errorf("amount must not be negative")
+	}
+	whole, fraction, _ := strings.Cut(value, ".")
+	if whole == "" || strings.ContainsAny(whole+fraction, "+-eE") {
+		return 0, fmt.Errorf("amount is invalid")
+	}
+	fraction = strings.TrimRight(fraction, "0")
+	if len(fraction) > exponent {
+		return 0, fmt.Errorf("amount must have at most %d decimal places", exponent)
+	}
+	fraction += strings.Repeat("0", exponent-len(fraction))
+
+	amount, err := strconv.ParseInt(whole+fraction, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("amount is invalid")
+	}
+	return amount, nil
+}
+
+// String return amount as decimal text, like 1500.00 or -1.50
+func (m *Money) String() string {
+	sign, amount := m.abs()
+	exponent := currencies[m.Currency].exponent
+	if exponent == 0 {
+		return sign + strconv.FormatUint(amount, 10)
+	}
+	unit := uint64(math.Pow10(exponent))
+	return fmt.Sprintf("%s%d.%0*d", sign, amount/unit, exponent, amount%unit)
+}
+
+// Display return amount with currency symbol and thousands separator, like ฿1,500.00 or -฿1.50
+func (m *Money) Display() string {
+	number := m.Number()
+	if strings.HasPrefix(number, "-") {
+		return "-" + currencies[m.Currency].symbol + number[1:]
+	}
+	return currencies[m.Currency].symbol + number
+}
+
+// Number return amount with thousands separator without symbol, like 1,500.00
+func (m *Money) Number() string {
+	whole, fraction, _ := strings.Cut(m.String(), ".")
+	sign := ""
+	if strings.HasPrefix(whole, "-") {
+		sign, whole = "-", whole[1:]
+	}
+	for i := len(whole) - 3; i > 0; i -= 3 {
+		whole = whole[:i] + "," + whole[i:]
+	}
+	if fraction != "" {
+		whole += "." + fraction
+	}
+	return sign + whole
+}
+
+// abs return sign and absolute amount, uint64 so the smallest int64 is still positive
+func (m *Money) abs() (string, uint64) {
+	if m.Amount < 0 {
+		return "-", uint64(-(m.Amount + 1)) + 1
+	}
+	return "", uint64(m.Amount)
+}
+
+type moneyJson struct {
+	Currency   string      `json:"currency"`
+	Amount     json.Number `json:"amount,omitempty"`
+	MinorUnits *int64      `json:"minor_units,omitempty"`
+}
+
+func (m *Money) MarshalJSON() ([]byte, error) {
+	// amount is a string, json number would be read as float by most clients
+	return json.Marshal(&struct {
+		Currency   string `json:"currency"`
+		Amount     string `json:"amount"`
+		MinorUnits int64  `json:"minor_units"`
+		Display    string `json:"display"`
+	}{
+		Currency:   m.Currency,
+		Amount:     m.String(),
+		MinorUnits: m.Amount,
+		Display:    m.Display(),
+	})
+}
+
+// UnmarshalJSON accept {"amount": "150.50", "currency": "THB"}, {"minor_units": 15050, "currency": "THB"}
+// or only the amount like 150.5, which is the default currency
+func (m *Money) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] != '{' {
+		var amount json.Number
+		if err := json.Unmarshal(data, &amount); err != nil {
+			return fmt.Errorf("price is invalid")
+		}
+		*m = *Decimal(amount.String(), "")
+		return nil
+	}
+
+	obj := new(moneyJson)
+	if err := json.Unmarshal(data, obj); err != nil {
+		return fmt.Errorf("price is invalid")
+	}
+	if obj.MinorUnits != nil {
+		*m = *New(*obj.MinorUnits, strings.ToUpper(obj.Currency))
+		return nil
+	}
+	if obj.Amount == "" {
+		return fmt.Errorf("price amount is required")
+	}
+	*m = *Decimal(obj.Amount.String(), obj.Currency)
+	return nil
+}
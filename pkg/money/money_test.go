@@ -0,0 +1,109 @@
+package money
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		amount  string
+		code    string
+		want    int64
+		wantErr bool
+	}{
+		{name: "two decimals", amount: "150.50", code: "THB", want: 15050},
+		{name: "one decimal", amount: "150.5", code: "THB", want: 15050},
+		{name: "whole", amount: "150", code: "USD", want: 15000},
+		{name: "trailing zeros", amount: "1.5000", code: "USD", want: 150},
+		{name: "lower case code", amount: "1", code: "thb", want: 100},
+		{name: "zero exponent", amount: "1500", code: "JPY", want: 1500},
+		{name: "zero", amount: "0", code: "THB", want: 0},
+		{name: "spaces", amount: " 2.25 ", code: " EUR ", want: 225},
+		{name: "too many decimals", amount: "1.005", code: "THB", wantErr: true},
+		{name: "decimals on zero exponent", amount: "1.5", code: "JPY", wantErr: true},
+		{name: "negative", amount: "-1.50", code: "THB", wantErr: true},
+		{name: "plus sign", amount: "+1.50", code: "THB", wantErr: true},
+		{name: "exponent", amount: "1e3", code: "THB", wantErr: true},
+		{name: "no whole", amount: ".50", code: "THB", wantErr: true},
+		{name: "text", amount: "abc", code: "THB", wantErr: true},
+		{name: "unsupported currency", amount: "1", code: "XYZ", wantErr: true},
+		{name: "empty currency", amount: "1", code: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := Parse(tt.amount, tt.code)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse(%q, %q) error = %v, wantErr %v", tt.amount, tt.code, err, tt.wantErr)
+			}
+			if err == nil && m.Amount != tt.want {
+				t.Errorf("Parse(%q, %q) = %d, want %d", tt.amount, tt.code, m.Amount, tt.want)
+			}
+		})
+	}
+}
+
+func TestString(t *testing.T) {
+	tests := []struct {
+		name   string
+		amount int64
+		code   string
+		want   string
+	}{
+		{name: "zero", amount: 0, code: "THB", want: "0.00"},
+		{name: "satang", amount: 5, code: "THB", want: "0.05"},
+		{name: "baht", amount: 150000, code: "THB", want: "1500.00"},
+		{name: "zero exponent", amount: 1500, code: "JPY", want: "1500"},
+		{name: "negative", amount: -150, code: "THB", want: "-1.50"},
+		{name: "negative below one", amount: -5, code: "USD", want: "-0.05"},
+		{name: "negative zero exponent", amount: -1500, code: "JPY", want: "-1500"},
+		{name: "smallest", amount: math.MinInt64, code: "THB", want: "-92233720368547758.08"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := New(tt.amount, tt.code).String(); got != tt.want {
+				t.Errorf("New(%d, %q).String() = %q, want %q", tt.amount, tt.code, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDisplay(t *testing.T) {
+	tests := []struct {
+		name   string
+		amount int64
+		code   string
+		want   string
+	}{
+		{name: "small", amount: 150, code: "THB", want: "฿1.50"},
+		{name: "thousands", amount: 150000, code: "THB", want: "฿1,500.00"},
+		{name: "millions", amount: 123456789, code: "USD", want: "$1,234,567.89"},
+		{name: "zero exponent", amount: 1500000, code: "JPY", want: "¥1,500,000"},
+		{name: "negative", amount: -150, code: "THB", want: "-฿1.50"},
+		{name: "negative hundreds", amount: -10000, code: "THB", want: "-฿100.00"},
+		{name: "negative thousands", amount: -150000, code: "THB", want: "-฿1,500.00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := New(tt.amount, tt.code).Display(); got != tt.want {
+				t.Errorf("New(%d, %q).Display() = %q, want %q", tt.amount, tt.code, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseString(t *testing.T) {
+	for _, amount := range []string{"0.00", "0.05", "1.50", "1500.00", "92233720368547758.07"} {
+		m, err := Parse(amount, "THB")
+		if err != nil {
+			t.Fatalf("Parse(%q) error = %v", amount, err)
+		}
+		if got := m.String(); got != amount {
+			t.Errorf("Parse(%q).String() = %q", amount, got)
+		}
+	}
+}
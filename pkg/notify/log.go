@@ -0,0 +1,20 @@
+package notify
+
+import (
+	"context"
+	"log"
+)
+
+// log notifier only write the message to log, for development
+type logNotifier struct{}
+
+func Log() Notifier {
+	return &logNotifier{}
+}
+
+func (n *logNotifier) Name() string { return "log" }
+
+func (n *logNotifier) Notify(ctx context.Context, msg *Message) error {
+	log.Printf("notify %s to %s <%s>: %v", msg.Template, msg.UserId, msg.Email, msg.Data)
+	return nil
+}
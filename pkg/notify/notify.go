@@ -0,0 +1,34 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+)
+
+// Notifier send a message to a user, like email, LINE or push
+type Notifier interface {
+	Name() string
+	Notify(ctx context.Context, msg *Message) error
+}
+
+// Template of message
+const (
+	TemplateBackInStock = "back_in_stock"
+)
+
+type Message struct {
+	UserId   string
+	Email    string
+	Template string
+	Data     map[string]any // values of the template, like product title and url
+}
+
+// New return notifier by name, log is used when name is empty
+func New(name string) (Notifier, error) {
+	switch name {
+	case "", "log":
+		return Log(), nil
+	default:
+		return nil, fmt.Errorf("notifier %s is not supported", name)
+	}
+}
@@ -0,0 +1,139 @@
+package payment
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// fake provider keep nothing in memory, the scenario is in intent id so it still works after restart
+type fake struct {
+	secret []byte
+}
+
+func Fake(webhookSecret string) Provider {
+	return &fake{
+		secret: []byte(webhookSecret),
+	}
+}
+
+func (p *fake) Name() string { return "fake" }
+
+func randomId(prefix string) (string, error) {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate id failed: %v", err)
+	}
+	return prefix + hex.EncodeToString(b), nil
+}
+
+// CreateIntent success is authorized and wait for capture, decline is declined, async is confirmed by webhook later
+func (p *fake) CreateIntent(ctx context.Context, req *IntentReq) (*Intent, error) {
+	scenario := req.Scenario
+	if scenario == "" {
+		scenario = "success"
+	}
+
+	status := ""
+	switch scenario {
+	case "success":
+		status = StatusRequiresCapture
+	case "decline":
+		status = StatusDeclined
+	case "async":
+		status = StatusProcessing
+	default:
+		return nil, fmt.Errorf("scenario must be success, decline or async")
+	}
+
+	id, err := randomId("fake_pi_" + scenario + "_")
+	if err != nil {
+		return nil, err
+	}
+	intent := &Intent{
+		Id:     id,
+		Status: status,
+		Amount: req.Amount,
+		Payload: map[string]any{
+			"provider":  p.Name(),
+			"intent_id": id,
+			"reference": req.Reference,
+			"amount":    req.Amount,
+			"currency":  req.Currency,
+		},
+	}
+
+	// async payment give a signed event, post it to the webhook to confirm the payment
+	if status == StatusProcessing {
+		event, err := randomId("fake_evt_")
+		if err != nil {
+			return nil, err
+		}
+		body, err := json.Marshal(&Event{
+			Id:       event,
+			Type:     EventSucceeded,
+			IntentId: id,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("marshal event failed: %v", err)
+		}
+		intent.Payload["webhook"] = map[string]any{
+			"body":      string(body),
+			"signature": p.sign(body),
+		}
+	}
+	return intent, nil
+}
+
+func (p *fake) Capture(ctx context.Context, intentId string) (*Intent, error) {
+	if !strings.HasPrefix(intentId, "fake_pi_success_") {
+		return nil, fmt.Errorf("intent %s can't be captured", intentId)
+	}
+	return &Intent{
+		Id:     intentId,
+		Status: StatusSucceeded,
+	}, nil
+}
+
+// Refund always succeed, the app check amount against the payment
+func (p *fake) Refund(ctx context.Context, intentId string, amount int64) (*Refund, error) {
+	if !strings.HasPrefix(intentId, "fake_pi_") {
+		return nil, fmt.Errorf("intent %s not found", intentId)
+	}
+	id, err := randomId("fake_re_")
+	if err != nil {
+		return nil, err
+	}
+	return &Refund{
+		Id:     id,
+		Amount: amount,
+		Status: StatusSucceeded,
+	}, nil
+}
+
+func (p *fake) sign(payload []byte) string {
+	mac := hmac.New(sha256.New, p.secret)
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyWebhook check HMAC-SHA256 hex of payload
+func (p *fake) VerifyWebhook(payload []byte, signature string) (*Event, error) {
+	if !hmac.Equal([]byte(p.sign(payload)), []byte(strings.ToLower(signature))) {
+		return nil, fmt.Errorf("signature is invalid")
+	}
+
+	event := new(Event)
+	if err := json.Unmarshal(payload, event); err != nil {
+		return nil, fmt.Errorf("event is invalid: %v", err)
+	}
+	if event.Id == "" || event.IntentId == "" {
+		return nil, fmt.Errorf("event is invalid")
+	}
+	return event, nil
+}
@@ -0,0 +1,68 @@
+package payment
+
+import (
+	"context"
+	"fmt"
+)
+
+// Provider is a payment gateway, amounts are in minor units of currency
+type Provider interface {
+	Name() string
+	CreateIntent(ctx context.Context, req *IntentReq) (*Intent, error)
+	Capture(ctx context.Context, intentId string) (*Intent, error)
+	Refund(ctx context.Context, intentId string, amount int64) (*Refund, error)
+	VerifyWebhook(payload []byte, signature string) (*Event, error)
+}
+
+// Intent status
+const (
+	StatusProcessing      = "processing"       // wait for webhook
+	StatusRequiresCapture = "requires_capture" // authorized, capture to take the money
+	StatusSucceeded       = "succeeded"
+	StatusDeclined        = "declined"
+)
+
+// Event type
+const (
+	EventSucceeded = "payment.succeeded"
+	EventFailed    = "payment.failed"
+)
+
+type IntentReq struct {
+	Reference string // order id
+	Amount    int64
+	Currency  string
+	Scenario  string // fake provider only: success, decline, async
+}
+
+type Intent struct {
+	Id      string         `json:"id"`
+	Status  string         `json:"status"`
+	Amount  int64          `json:"amount"`
+	Payload map[string]any `json:"payload"` // for client to complete the payment
+}
+
+type Refund struct {
+	Id     string `json:"id"`
+	Amount int64  `json:"amount"`
+	Status string `json:"status"`
+}
+
+type Event struct {
+	Id       string `json:"id"`
+	Type     string `json:"type"`
+	IntentId string `json:"intent_id"`
+}
+
+// New return provider by name, webhook of every provider is signed so secret is required
+func New(name string, webhookSecret string) (Provider, error) {
+	if webhookSecret == "" {
+		return nil, fmt.Errorf("webhook secret is required")
+	}
+	switch name {
+	case "fake":
+		return Fake(webhookSecret), nil
+	default:
+		return nil, fmt.Errorf("payment provider %s is not supported", name)
+	}
+}
@@ -0,0 +1,220 @@
+package pdf
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+)
+
+// Font is a TrueType font, the whole file is embedded so every glyph of it can be drawn.
+// Thai marks have no advance in Thai fonts, so they are drawn over the consonant without shaping
+type Font struct {
+	data       []byte
+	unitsPerEm int
+	ascent     int
+	descent    int
+	capHeight  int
+	bbox       [4]int
+	widths     []int           // advance by glyph id
+	glyphs     map[rune]uint16 // glyph id by rune
+}
+
+// fontReader read big endian numbers, out of range read return 0 and keep the error
+type fontReader struct {
+	b   []byte
+	err error
+}
+
+func (r *fontReader) check(off, size int) bool {
+	if r.err != nil {
+		return false
+	}
+	if off < 0 || off+size > len(r.b) {
+		r.err = fmt.Errorf("font is truncated")
+		return false
+	}
+	return true
+}
+
+func (r *fontReader) u16(off int) int {
+	if !r.check(off, 2) {
+		return 0
+	}
+	return int(binary.BigEndian.Uint16(r.b[off:]))
+}
+
+func (r *fontReader) i16(off int) int {
+	return int(int16(r.u16(off)))
+}
+
+func (r *fontReader) u32(off int) int {
+	if !r.check(off, 4) {
+		return 0
+	}
+	return int(binary.BigEndian.Uint32(r.b[off:]))
+}
+
+// LoadFontFile read TrueType font from path
+func LoadFontFile(path string) (*Font, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read font failed: %v", err)
+	}
+	return LoadFont(data)
+}
+
+// LoadFont parse tables of TrueType font which are needed to measure and embed it
+func LoadFont(data []byte) (*Font, error) {
+	r := &fontReader{b: data}
+
+	switch r.u32(0) {
+	case 0x00010000, 0x74727565: // 1.0 and 'true'
+	case 0x4F54544F: // 'OTTO'
+		return nil, fmt.Errorf("font with CFF outlines is not supported, use TrueType")
+	default:
+		if r.err != nil {
+			return nil, r.err
+		}
+		return nil, fmt.Errorf("font is not TrueType")
+	}
+
+	tables := make(map[string]int)
+	numTables := r.u16(4)
+	for i := 0; i < numTables; i++ {
+		rec := 12 + i*16
+		if !r.check(rec, 16) {
+			return nil, r.err
+		}
+		tables[string(data[rec:rec+4])] = r.u32(rec + 8)
+	}
+	for _, tag := range []string{"head", "hhea", "maxp", "hmtx", "cmap"} {
+		if _, ok := tables[tag]; !ok {
+			return nil, fmt.Errorf("font has no %s table", tag)
+		}
+	}
+
+	f := &Font{
+		data:   data,
+		glyphs: make(map[rune]uint16),
+	}
+
+	head := tables["head"]
+	f.unitsPerEm = r.u16(head + 18)
+	f.bbox = [4]int{r.i16(head + 36), r.i16(head + 38), r.i16(head + 40), r.i16(head + 42)}
+	if f.unitsPerEm == 0 {
+		return nil, fmt.Errorf("font units per em is 0")
+	}
+
+	hhea := tables["hhea"]
+	f.ascent = r.i16(hhea + 4)
+	f.descent = r.i16(hhea + 6)
+	f.capHeight = f.ascent
+	numHMetrics := r.u16(hhea + 34)
+
+	// cap height is only in OS/2 version 2 or later
+	if os2, ok := tables["OS/2"]; ok && r.u16(os2) >= 2 && r.i16(os2+88) > 0 {
+		f.capHeight = r.i16(os2 + 88)
+	}
+
+	numGlyphs := r.u16(tables["maxp"] + 4)
+	if numHMetrics == 0 || numHMetrics > numGlyphs {
+		return nil, fmt.Errorf("font metrics are invalid")
+	}
+	f.widths = make([]int, numGlyphs)
+	hmtx := tables["hmtx"]
+	for gid := 0; gid < numGlyphs; gid++ {
+		if gid < numHMetrics {
+			f.widths[gid] = r.u16(hmtx + gid*4)
+		} else {
+			f.widths[gid] = f.widths[numHMetrics-1]
+		}
+	}
+
+	if err := f.parseCmap(r, tables["cmap"]); err != nil {
+		return nil, err
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	return f, nil
+}
+
+// parseCmap read unicode to glyph mapping, full unicode subtable is picked before BMP one
+func (f *Font) parseCmap(r *fontReader, cmap int) error {
+	best, bestRank := -1, 0
+	numTables := r.u16(cmap + 2)
+	for i := 0; i < numTables; i++ {
+		rec := cmap + 4 + i*8
+		platform, encoding, offset := r.u16(rec), r.u16(rec+2), r.u32(rec+4)
+
+		rank := 0
+		switch {
+		case platform == 3 && encoding == 10, platform == 0 && encoding >= 4:
+			rank = 2
+		case platform == 3 && encoding == 1, platform == 0:
+			rank = 1
+		}
+		if rank > bestRank {
+			best, bestRank = cmap+offset, rank
+		}
+	}
+	if best < 0 {
+		return fmt.Errorf("font has no unicode cmap")
+	}
+
+	switch format := r.u16(best); format {
+	case 4:
+		segCount := r.u16(best+6) / 2
+		ends := best + 14
+		starts := ends + segCount*2 + 2
+		deltas := starts + segCount*2
+		rangeOffsets := deltas + segCount*2
+		for i := 0; i < segCount && r.err == nil; i++ {
+			start, end := r.u16(starts+i*2), r.u16(ends+i*2)
+			delta, rangeOffset := r.u16(deltas+i*2), r.u16(rangeOffsets+i*2)
+			for c := start; c <= end && c != 0xFFFF; c++ {
+				gid := 0
+				if rangeOffset == 0 {
+					gid = (c + delta) & 0xFFFF
+				} else if g := r.u16(rangeOffsets + i*2 + rangeOffset + (c-start)*2); g != 0 {
+					gid = (g + delta) & 0xFFFF
+				}
+				if gid != 0 && gid < len(f.widths) {
+					f.glyphs[rune(c)] = uint16(gid)
+				}
+			}
+		}
+	case 12:
+		numGroups := r.u32(best + 12)
+		for i := 0; i < numGroups && r.err == nil; i++ {
+			group := best + 16 + i*12
+			start, end, startGlyph := r.u32(group), r.u32(group+4), r.u32(group+8)
+			if end > 0x10FFFF || end < start {
+				return fmt.Errorf("font cmap is invalid")
+			}
+			for c := start; c <= end; c++ {
+				if gid := startGlyph + c - start; gid != 0 && gid < len(f.widths) {
+					f.glyphs[rune(c)] = uint16(gid)
+				}
+			}
+		}
+	default:
+		return fmt.Errorf("font cmap format %d is not supported", format)
+	}
+	return r.err
+}
+
+// glyph return glyph id of rune, 0 is the missing glyph
+func (f *Font) glyph(c rune) uint16 {
+	return f.glyphs[c]
+}
+
+// width return advance of glyph in 1/1000 of font size
+func (f *Font) width(gid uint16) float64 {
+	return float64(f.widths[gid]) * 1000 / float64(f.unitsPerEm)
+}
+
+// scale convert font units to 1/1000 of font size
+func (f *Font) scale(v int) int {
+	return v * 1000 / f.unitsPerEm
+}
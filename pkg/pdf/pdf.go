@@ -0,0 +1,318 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf16"
+)
+
+// A4 in points
+const (
+	PageWidth  = 595.28
+	PageHeight = 841.89
+)
+
+// helveticaWidths is width of ASCII 32 to 126 of Helvetica, used when there is no font
+var helveticaWidths = [95]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+}
+
+// Document is a PDF of A4 pages, x and y are points from top left of the page
+type Document struct {
+	font  *Font // nil is Helvetica, which only has ASCII
+	pages []*bytes.Buffer
+	used  map[uint16]rune // glyphs drawn, for widths and text copy
+}
+
+// New return document with no page, font is nil to use Helvetica
+func New(font *Font) *Document {
+	return &Document{
+		font: font,
+		used: make(map[uint16]rune),
+	}
+}
+
+func (d *Document) AddPage() {
+	d.pages = append(d.pages, new(bytes.Buffer))
+}
+
+func (d *Document) page() *bytes.Buffer {
+	if len(d.pages) == 0 {
+		d.AddPage()
+	}
+	return d.pages[len(d.pages)-1]
+}
+
+// TextWidth return width of s in points
+func (d *Document) TextWidth(s string, size float64) float64 {
+	width := 0.0
+	for _, c := range s {
+		if d.font == nil {
+			if c < 32 || c > 126 {
+				c = '?'
+			}
+			width += float64(helveticaWidths[c-32])
+			continue
+		}
+		width += d.font.width(d.font.glyph(c))
+	}
+	return width * size / 1000
+}
+
+// Fit cut s with ... so it is not wider than maxWidth
+func (d *Document) Fit(s string, size, maxWidth float64) string {
+	if d.TextWidth(s, size) <= maxWidth {
+		return s
+	}
+	runes := []rune(s)
+	for len(runes) > 0 {
+		runes = runes[:len(runes)-1]
+		if cut := string(runes) + "..."; d.TextWidth(cut, size) <= maxWidth {
+			return cut
+		}
+	}
+	return ""
+}
+
+// Wrap break s into lines not wider than maxWidth, at spaces or anywhere in a word too long like Thai without space
+func (d *Document) Wrap(s string, size, maxWidth float64) []string {
+	lines := make([]string, 0)
+	line := ""
+	for _, word := range strings.Fields(s) {
+		next := word
+		if line != "" {
+			next = line + " " + word
+		}
+		if d.TextWidth(next, size) <= maxWidth {
+			line = next
+			continue
+		}
+		if line != "" {
+			lines = append(lines, line)
+		}
+		line = ""
+		for _, c := range word {
+			if line != "" && d.TextWidth(line+string(c), size) > maxWidth {
+				lines = append(lines, line)
+				line = ""
+			}
+			line += string(c)
+		}
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// encode return s as PDF string of the font
+func (d *Document) encode(s string) string {
+	if d.font == nil {
+		b := new(strings.Builder)
+		b.WriteByte('(')
+		for _, c := range s {
+			switch {
+			case c == '(' || c == ')' || c == '\\':
+				b.WriteByte('\\')
+				b.WriteRune(c)
+			case c < 32 || c > 126:
+				b.WriteByte('?')
+			default:
+				b.WriteRune(c)
+			}
+		}
+		b.WriteByte(')')
+		return b.String()
+	}
+
+	b := new(strings.Builder)
+	b.WriteByte('<')
+	for _, c := range s {
+		gid := d.font.glyph(c)
+		if _, ok := d.used[gid]; !ok {
+			d.used[gid] = c
+		}
+		fmt.Fprintf(b, "%04X", gid)
+	}
+	b.WriteByte('>')
+	return b.String()
+}
+
+// Text draw s with its baseline at y
+func (d *Document) Text(x, y, size float64, s string) {
+	if s == "" {
+		return
+	}
+	fmt.Fprintf(d.page(), "BT /F1 %.2f Tf %.2f %.2f Td %s Tj ET\n", size, x, PageHeight-y, d.encode(s))
+}
+
+// TextRight draw s which end at x
+func (d *Document) TextRight(x, y, size float64, s string) {
+	d.Text(x-d.TextWidth(s, size), y, size, s)
+}
+
+func (d *Document) Line(x1, y1, x2, y2, width float64) {
+	fmt.Fprintf(d.page(), "%.2f w %.2f %.2f m %.2f %.2f l S\n", width, x1, PageHeight-y1, x2, PageHeight-y2)
+}
+
+// FillRect fill rectangle from top left x, y in gray, 0 is black and 1 is white
+func (d *Document) FillRect(x, y, w, h, gray float64) {
+	fmt.Fprintf(d.page(), "q %.2f g %.2f %.2f %.2f %.2f re f Q\n", gray, x, PageHeight-y-h, w, h)
+}
+
+func compress(data []byte) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	zw := zlib.NewWriter(buf)
+	if _, err := zw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// stream return stream object of data in flate
+func stream(data []byte, dict string) ([]byte, error) {
+	compressed, err := compress(data)
+	if err != nil {
+		return nil, fmt.Errorf("compress stream failed: %v", err)
+	}
+	obj := new(bytes.Buffer)
+	fmt.Fprintf(obj, "<< /Length %d /Filter /FlateDecode%s >>\nstream\n", len(compressed), dict)
+	obj.Write(compressed)
+	obj.WriteString("\nendstream")
+	return obj.Bytes(), nil
+}
+
+// fontObjects return objects of the font from id 3, the first one is the font of pages
+func (d *Document) fontObjects() ([][]byte, error) {
+	if d.font == nil {
+		return [][]byte{[]byte("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")}, nil
+	}
+	f := d.font
+
+	gids := make([]int, 0, len(d.used))
+	for gid := range d.used {
+		gids = append(gids, int(gid))
+	}
+	sort.Ints(gids)
+
+	widths := new(strings.Builder)
+	for _, gid := range gids {
+		fmt.Fprintf(widths, "%d [%.0f] ", gid, f.width(uint16(gid)))
+	}
+
+	fontFile, err := stream(f.data, fmt.Sprintf(" /Length1 %d", len(f.data)))
+	if err != nil {
+		return nil, err
+	}
+
+	// ToUnicode let text be copied and searched
+	cmap := new(strings.Builder)
+	cmap.WriteString("/CIDInit /ProcSet findresource begin\n12 dict begin\nbegincmap\n")
+	cmap.WriteString("/CIDSystemInfo << /Registry (Adobe) /Ordering (UCS) /Supplement 0 >> def\n")
+	cmap.WriteString("/CMapName /Adobe-Identity-UCS def\n/CMapType 2 def\n")
+	cmap.WriteString("1 begincodespacerange\n<0000> <FFFF>\nendcodespacerange\n")
+	// .notdef is not any character
+	mapped := make([]int, 0, len(gids))
+	for _, gid := range gids {
+		if gid != 0 {
+			mapped = append(mapped, gid)
+		}
+	}
+	for i := 0; i < len(mapped); i += 100 {
+		chunk := mapped[i:min(i+100, len(mapped))]
+		fmt.Fprintf(cmap, "%d beginbfchar\n", len(chunk))
+		for _, gid := range chunk {
+			fmt.Fprintf(cmap, "<%04X> <", gid)
+			for _, u := range utf16.Encode([]rune{d.used[uint16(gid)]}) {
+				fmt.Fprintf(cmap, "%04X", u)
+			}
+			cmap.WriteString(">\n")
+		}
+		cmap.WriteString("endbfchar\n")
+	}
+	cmap.WriteString("endcmap\nCMapName currentdict /CMap defineresource pop\nend\nend")
+	toUnicode, err := stream([]byte(cmap.String()), "")
+	if err != nil {
+		return nil, err
+	}
+
+	return [][]byte{
+		[]byte("<< /Type /Font /Subtype /Type0 /BaseFont /DocumentFont /Encoding /Identity-H /DescendantFonts [4 0 R] /ToUnicode 7 0 R >>"),
+		[]byte(fmt.Sprintf(
+			"<< /Type /Font /Subtype /CIDFontType2 /BaseFont /DocumentFont /CIDSystemInfo << /Registry (Adobe) /Ordering (Identity) /Supplement 0 >> /FontDescriptor 5 0 R /CIDToGIDMap /Identity /DW %.0f /W [%s] >>",
+			f.width(0), widths.String(),
+		)),
+		[]byte(fmt.Sprintf(
+			"<< /Type /FontDescriptor /FontName /DocumentFont /Flags 32 /FontBBox [%d %d %d %d] /ItalicAngle 0 /Ascent %d /Descent %d /CapHeight %d /StemV 80 /FontFile2 6 0 R >>",
+			f.scale(f.bbox[0]), f.scale(f.bbox[1]), f.scale(f.bbox[2]), f.scale(f.bbox[3]), f.scale(f.ascent), f.scale(f.descent), f.scale(f.capHeight),
+		)),
+		fontFile,
+		toUnicode,
+	}, nil
+}
+
+// Bytes return the PDF file, objects are catalog, pages, font objects then page and content of every page
+func (d *Document) Bytes() ([]byte, error) {
+	if len(d.pages) == 0 {
+		d.AddPage()
+	}
+
+	fontObjects, err := d.fontObjects()
+	if err != nil {
+		return nil, err
+	}
+	firstPage := 3 + len(fontObjects)
+
+	kids := make([]string, 0, len(d.pages))
+	for i := range d.pages {
+		kids = append(kids, fmt.Sprintf("%d 0 R", firstPage+i*2))
+	}
+
+	objects := [][]byte{
+		[]byte("<< /Type /Catalog /Pages 2 0 R >>"),
+		[]byte(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages))),
+	}
+	objects = append(objects, fontObjects...)
+	for i, content := range d.pages {
+		contentObj, err := stream(content.Bytes(), "")
+		if err != nil {
+			return nil, err
+		}
+		objects = append(objects,
+			[]byte(fmt.Sprintf(
+				"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>",
+				PageWidth, PageHeight, firstPage+i*2+1,
+			)),
+			contentObj,
+		)
+	}
+
+	out := new(bytes.Buffer)
+	out.WriteString("%PDF-1.7\n%\xE2\xE3\xCF\xD3\n")
+	offsets := make([]int, 0, len(objects))
+	for i, obj := range objects {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(out, "%d 0 obj\n", i+1)
+		out.Write(obj)
+		out.WriteString("\nendobj\n")
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(out, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return out.Bytes(), nil
+}
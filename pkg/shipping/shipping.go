@@ -0,0 +1,80 @@
+package shipping
+
+import (
+	"context"
+	"fmt"
+)
+
+// Carrier quote a shipping method for a parcel, amounts are in minor units of currency.
+// A real carrier can ask its own API, the table carrier use the rates set by admin
+type Carrier interface {
+	Name() string
+	Quote(ctx context.Context, req *QuoteReq) (*Quote, error)
+}
+
+// Method type
+const (
+	TypeFlatRate    = "flat_rate"
+	TypeWeightBased = "weight_based"
+	TypeFreeOver    = "free_over" // free when subtotal reach FreeOver
+)
+
+// WeightRate is the amount of parcel up to MaxWeight grams
+type WeightRate struct {
+	MaxWeight int
+	Amount    int64
+}
+
+// Method is what admin set for the method
+type Method struct {
+	Id          string
+	Type        string
+	Currency    string
+	Amount      int64
+	FreeOver    int64 // 0 is never free
+	WeightRates []*WeightRate
+}
+
+// Parcel is every item of cart, weight in grams and size in millimetres
+type Parcel struct {
+	Weight int
+	Volume int64 // cubic millimetres
+}
+
+type Destination struct {
+	Country  string
+	Region   string
+	Postcode string
+}
+
+type QuoteReq struct {
+	Method      *Method
+	Parcel      *Parcel
+	Destination *Destination
+	Currency    string
+	Subtotal    int64 // of items after discount
+}
+
+// Quote is not available when Reason is set
+type Quote struct {
+	Amount int64
+	Reason string
+}
+
+// Reasons
+const (
+	ReasonCurrency   = "currency_mismatch"
+	ReasonTooHeavy   = "too_heavy"
+	ReasonBelowFree  = "below_free_threshold"
+	ReasonNotSupport = "not_supported"
+)
+
+// New return carrier by name, table is used when name is empty
+func New(name string) (Carrier, error) {
+	switch name {
+	case "", "table":
+		return Table(), nil
+	default:
+		return nil, fmt.Errorf("shipping carrier %s is not supported", name)
+	}
+}
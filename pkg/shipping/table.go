@@ -0,0 +1,64 @@
+package shipping
+
+import (
+	"context"
+	"sort"
+)
+
+// VolumetricDivisor turn cubic millimetres into grams, like 5000 cm3 per kg of most carriers
+const VolumetricDivisor = 5000
+
+type table struct{}
+
+// Table carrier quote from the type and rates of method, it doesn't call anything
+func Table() Carrier {
+	return &table{}
+}
+
+func (c *table) Name() string { return "table" }
+
+// ChargeableWeight is the bigger of actual and volumetric weight in grams
+func (p *Parcel) ChargeableWeight() int {
+	volumetric := int((p.Volume + VolumetricDivisor - 1) / VolumetricDivisor)
+	if volumetric > p.Weight {
+		return volumetric
+	}
+	return p.Weight
+}
+
+func (c *table) Quote(ctx context.Context, req *QuoteReq) (*Quote, error) {
+	m := req.Method
+	if m.Currency != req.Currency {
+		return &Quote{Reason: ReasonCurrency}, nil
+	}
+
+	switch m.Type {
+	case TypeFlatRate:
+		return &Quote{Amount: freeOver(m, req.Subtotal, m.Amount)}, nil
+	case TypeWeightBased:
+		weight := req.Parcel.ChargeableWeight()
+		rates := append([]*WeightRate{}, m.WeightRates...)
+		sort.Slice(rates, func(i, j int) bool { return rates[i].MaxWeight < rates[j].MaxWeight })
+		for _, r := range rates {
+			if weight <= r.MaxWeight {
+				return &Quote{Amount: freeOver(m, req.Subtotal, m.Amount+r.Amount)}, nil
+			}
+		}
+		return &Quote{Reason: ReasonTooHeavy}, nil
+	case TypeFreeOver:
+		if req.Subtotal < m.FreeOver {
+			return &Quote{Reason: ReasonBelowFree}, nil
+		}
+		return &Quote{Amount: 0}, nil
+	default:
+		return &Quote{Reason: ReasonNotSupport}, nil
+	}
+}
+
+// freeOver return 0 when method has a threshold and subtotal reach it
+func freeOver(m *Method, subtotal, amount int64) int64 {
+	if m.FreeOver > 0 && subtotal >= m.FreeOver {
+		return 0
+	}
+	return amount
+}
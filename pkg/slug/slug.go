@@ -0,0 +1,80 @@
+package slug
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Slug is lower case a-z, 0-9 and -, Thai is transliterated by RTGS (simplified,
+// consonants use their initial sound) so กาแฟ become kafae
+
+const maxLength = 80
+
+var thaiConsonants = map[rune]string{
+	'ก': "k", 'ข': "kh", 'ฃ': "kh", 'ค': "kh", 'ฅ': "kh", 'ฆ': "kh", 'ง': "ng",
+	'จ': "ch", 'ฉ': "ch", 'ช': "ch", 'ซ': "s", 'ฌ': "ch", 'ญ': "y",
+	'ฎ': "d", 'ฏ': "t", 'ฐ': "th", 'ฑ': "th", 'ฒ': "th", 'ณ': "n",
+	'ด': "d", 'ต': "t", 'ถ': "th", 'ท': "th", 'ธ': "th", 'น': "n",
+	'บ': "b", 'ป': "p", 'ผ': "ph", 'ฝ': "f", 'พ': "ph", 'ฟ': "f", 'ภ': "ph", 'ม': "m",
+	'ย': "y", 'ร': "r", 'ฤ': "rue", 'ล': "l", 'ฦ': "lue", 'ว': "w",
+	'ศ': "s", 'ษ': "s", 'ส': "s", 'ห': "h", 'ฬ': "l", 'อ': "o", 'ฮ': "h",
+}
+
+var thaiVowels = map[rune]string{
+	'ะ': "a", 'ั': "a", 'า': "a", 'ำ': "am", 'ิ': "i", 'ี': "i", 'ึ': "ue", 'ื': "ue",
+	'ุ': "u", 'ู': "u", 'เ': "e", 'แ': "ae", 'โ': "o", 'ใ': "ai", 'ไ': "ai",
+}
+
+// leading vowels are written before the consonant but read after it
+var thaiLeadingVowels = map[rune]bool{
+	'เ': true, 'แ': true, 'โ': true, 'ใ': true, 'ไ': true,
+}
+
+// Make return slug of text, empty when text has nothing to keep
+func Make(text string) string {
+	runes := []rune(strings.ToLower(text))
+	b := new(strings.Builder)
+	dash := false
+	write := func(s string) {
+		if s == "" {
+			return
+		}
+		if dash && b.Len() > 0 {
+			b.WriteByte('-')
+		}
+		dash = false
+		b.WriteString(s)
+	}
+
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			write(string(r))
+		case r >= '๐' && r <= '๙':
+			write(string('0' + (r - '๐')))
+		case thaiLeadingVowels[r] && i+1 < len(runes) && thaiConsonants[runes[i+1]] != "":
+			if runes[i+1] != 'อ' {
+				write(thaiConsonants[runes[i+1]])
+			}
+			write(thaiVowels[r])
+			i++
+		case r == 'อ' && i+1 < len(runes) && thaiVowels[runes[i+1]] != "":
+			// อ before vowel is silent
+		case thaiConsonants[r] != "":
+			write(thaiConsonants[r])
+		case thaiVowels[r] != "":
+			write(thaiVowels[r])
+		case unicode.Is(unicode.Thai, r):
+			// tone marks and other signs are not written
+		default:
+			dash = true
+		}
+	}
+
+	slug := b.String()
+	if len(slug) > maxLength {
+		slug = strings.TrimRight(slug[:maxLength], "-")
+	}
+	return slug
+}
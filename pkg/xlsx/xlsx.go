@@ -0,0 +1,118 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// xlsx file is a zip of xml files, sheet rows are written straight to the zip
+// so the memory usage is the same for 10 rows or 1,000,000 rows
+
+const (
+	contentTypesXml = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/><Default Extension="xml" ContentType="application/xml"/><Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/><Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/></Types>`
+	relsXml = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/></Relationships>`
+	workbookXml = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="%s" sheetId="1" r:id="rId1"/></sheets></workbook>`
+	workbookRelsXml = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/></Relationships>`
+	sheetOpenXml = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`
+	sheetCloseXml = `</sheetData></worksheet>`
+)
+
+type IStreamWriter interface {
+	WriteRow(cells []any) error
+	Close() error
+}
+
+type streamWriter struct {
+	zip   *zip.Writer
+	sheet *bufio.Writer
+}
+
+// NewStreamWriter write a workbook with one sheet into w
+func NewStreamWriter(w io.Writer, sheetName string) (IStreamWriter, error) {
+	z := zip.NewWriter(w)
+
+	name := new(strings.Builder)
+	if err := xml.EscapeText(name, []byte(sheetName)); err != nil {
+		return nil, err
+	}
+	parts := []struct {
+		name    string
+		content string
+	}{
+		{"[Content_Types].xml", contentTypesXml},
+		{"_rels/.rels", relsXml},
+		{"xl/workbook.xml", fmt.Sprintf(workbookXml, name.String())},
+		{"xl/_rels/workbook.xml.rels", workbookRelsXml},
+	}
+	for _, p := range parts {
+		f, err := z.Create(p.name)
+		if err != nil {
+			return nil, fmt.Errorf("create %s failed: %v", p.name, err)
+		}
+		if _, err := io.WriteString(f, p.content); err != nil {
+			return nil, fmt.Errorf("write %s failed: %v", p.name, err)
+		}
+	}
+
+	// sheet must be the last file, rows are appended until Close
+	f, err := z.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, fmt.Errorf("create sheet failed: %v", err)
+	}
+	sheet := bufio.NewWriter(f)
+	if _, err := sheet.WriteString(sheetOpenXml); err != nil {
+		return nil, err
+	}
+
+	return &streamWriter{
+		zip:   z,
+		sheet: sheet,
+	}, nil
+}
+
+// WriteRow support string, int, float64 and bool cells, others are written as text
+func (s *streamWriter) WriteRow(cells []any) error {
+	s.sheet.WriteString("<row>")
+	for _, cell := range cells {
+		switch v := cell.(type) {
+		case int:
+			fmt.Fprintf(s.sheet, `<c t="n"><v>%d</v></c>`, v)
+		case float64:
+			fmt.Fprintf(s.sheet, `<c t="n"><v>%s</v></c>`, strconv.FormatFloat(v, 'f', -1, 64))
+		case bool:
+			value := 0
+			if v {
+				value = 1
+			}
+			fmt.Fprintf(s.sheet, `<c t="b"><v>%d</v></c>`, value)
+		default:
+			s.sheet.WriteString(`<c t="inlineStr"><is><t xml:space="preserve">`)
+			if err := xml.EscapeText(s.sheet, []byte(fmt.Sprint(v))); err != nil {
+				return err
+			}
+			s.sheet.WriteString(`</t></is></c>`)
+		}
+	}
+	_, err := s.sheet.WriteString("</row>")
+	return err
+}
+
+func (s *streamWriter) Close() error {
+	if _, err := s.sheet.WriteString(sheetCloseXml); err != nil {
+		return err
+	}
+	if err := s.sheet.Flush(); err != nil {
+		return err
+	}
+	return s.zip.Close()
+}